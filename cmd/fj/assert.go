@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// runAssert implements "fj assert -rule <expr> [-rule <expr>...] [file|url|-]".
+// A rule passes when every value it produces is truthy (neither false nor null).
+func runAssert(cfg config.Config, args []string) int {
+	var rules listFlag
+
+	fs := flag.NewFlagSet("assert", flag.ContinueOnError)
+	fs.Var(&rules, "rule", "Expression that must hold, e.g. '.price >= 0' (repeatable)")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	quietPtr := fs.Bool("quiet", false, "Only report failing rules")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj assert -rule <expr> [-rule <expr>...] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Reads stdin when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if len(rules) == 0 || fs.NArg() > 1 {
+		fs.Usage()
+		return exitCommandError
+	}
+
+	// Compile every rule up front so a typo is reported before any evaluation
+	queries := make([]*query.Query, 0, len(rules))
+	for _, rule := range rules {
+		q, err := query.Parse(rule)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+		queries = append(queries, q)
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return exitCommandError
+	}
+
+	opts := formatOptions(cfg)
+	doc, err := formatter.Decode(data, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	// Values shown in failure reports come from a redacted copy of the document
+	display, _ := formatter.Decode(data, opts)
+	display, err = redact(display, cfg, runOptions{})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	failed := 0
+	for _, q := range queries {
+		if !checkRule(q, doc, display, *quietPtr) {
+			failed++
+		}
+	}
+
+	fmt.Printf("%d of %d rules passed\n", len(queries)-failed, len(queries))
+	if failed > 0 {
+		return exitCheckFailed
+	}
+	return 0
+}
+
+// checkRule evaluates one rule, prints its outcome and reports whether it passed
+func checkRule(q *query.Query, doc, display interface{}, quiet bool) bool {
+	results, err := q.Run(doc)
+	if err != nil {
+		fmt.Printf("FAIL %s\n  error: %v\n", q, err)
+		return false
+	}
+	if len(results) == 0 {
+		fmt.Printf("FAIL %s\n  produced no value\n", q)
+		return false
+	}
+
+	var failures []query.Result
+	for _, r := range results {
+		if !query.Truthy(r.Value) {
+			failures = append(failures, r)
+		}
+	}
+
+	if len(failures) == 0 {
+		if !quiet {
+			fmt.Printf("PASS %s\n", q)
+		}
+		return true
+	}
+
+	fmt.Printf("FAIL %s\n", q)
+	for _, r := range failures {
+		path := jsonpath.String(r.Path)
+		if path == "" {
+			path = "(root)"
+		}
+
+		value, ok := jsonpath.Lookup(display, r.Path)
+		if !ok {
+			fmt.Printf("  at %s: missing\n", path)
+			continue
+		}
+		b, _ := json.Marshal(value)
+		fmt.Printf("  at %s: %s\n", path, b)
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diff"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// runCheck implements -check: each source is formatted in memory and
+// compared with its text, like gofmt -l. Sources that would change are
+// printed with a diff and nothing is written to them. Sources that cannot
+// be read or parsed are reported and the others still checked; the result
+// is the worst outcome: exitCommandError, then exitCheckFailed. With
+// -state, files checked by an interrupted run are skipped; the state file is
+// removed once every source has been checked.
+func runCheck(cfg config.Config, run runOptions, sources []string) int {
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	state, err := loadState(cfg, run.state, os.Args[1:])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	code := 0
+	for _, source := range sources {
+		if entry, ok := state.done(source); ok {
+			code = max(code, entry.Code)
+			continue
+		}
+		name := source
+		if source == "-" {
+			name = "<stdin>"
+		}
+		data, err := readSource(source, cfg.TrustAllURLs)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+			code = exitCommandError
+			continue
+		}
+		formatted, err := checkFormat(data, source, cfg, run)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", name, err)
+			code = exitCommandError
+			continue
+		}
+
+		result := 0
+		if d := diff.Unified(name, name+" (formatted)", data, formatted); d != "" {
+			fmt.Print(d)
+			result = exitCheckFailed
+		}
+		code = max(code, result)
+		if err := state.record(source, result, nil); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+	}
+
+	// Sources that could not be checked are tried again by the next run
+	if code != exitCommandError {
+		if err := state.finish(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return code
+}
+
+// checkFormat returns data as fj would write it with the formatting options
+// of cfg, including the line feed that ends the output
+func checkFormat(data []byte, source string, cfg config.Config, run runOptions) ([]byte, error) {
+	opts := formatOptions(cfg)
+	if run.jsonc || strings.HasSuffix(strings.ToLower(source), ".jsonc") {
+		stripped, comments, err := formatter.StripComments(data)
+		if err != nil {
+			return nil, err
+		}
+		data = stripped
+		if run.keepComments {
+			opts.Comments = comments
+		}
+	}
+
+	value, err := formatter.Decode(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Canonical output is written byte for byte, without a line feed
+	if run.canonical {
+		return formatter.Canonical(value)
+	}
+	out, err := formatter.FormatValue(value, opts)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
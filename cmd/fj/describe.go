@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// runDescribe implements "fj describe [options] [file|url|-]"
+func runDescribe(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("describe", flag.ContinueOnError)
+	depthPtr := fs.Int("depth", 4, "Number of nesting levels to detail")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj describe [options] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Summarizes the structure of a document; stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return 1
+	}
+
+	opts := formatOptions(cfg)
+	value, err := formatter.Decode(data, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+		return 1
+	}
+
+	// Keys are described in document order unless they are sorted
+	order := opts.KeyOrder
+	if cfg.SortKeys {
+		order = nil
+	}
+	fmt.Println(formatter.Describe(value, *depthPtr, order))
+	return 0
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diff"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// runDiff implements "fj diff [options] <old> <new>"
+func runDiff(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	emitPatchPtr := fs.Bool("emit-patch", false, "Print an RFC 6902 JSON Patch instead of a readable diff")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj diff [options] <old> <new>\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Each document can be a file, a URL or - for stdin.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitCommandError
+	}
+
+	oldData, err := readSource(fs.Arg(0), *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", fs.Arg(0), err)
+		return exitCommandError
+	}
+	newData, err := readSource(fs.Arg(1), *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", fs.Arg(1), err)
+		return exitCommandError
+	}
+
+	// Redact both sides so secrets never show up as removed or added values
+	run := runOptions{noRedact: *noRedactPtr}
+	opts := formatOptions(cfg)
+	var values [2]interface{}
+	for i, data := range [][]byte{oldData, newData} {
+		value, err := formatter.Decode(data, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", fs.Arg(i), err)
+			return exitCommandError
+		}
+		if values[i], err = redact(value, cfg, run); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+			return exitCommandError
+		}
+	}
+
+	if *emitPatchPtr {
+		// The patch goes through the formatter so -minify, -escape-html and
+		// the indentation apply to it like to any other output
+		patch := diff.Patch(values[0], values[1])
+		out, err := formatter.FormatValue(patchValue(patch, opts.KeyOrder), opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error formatting patch: %v\n", err)
+			return exitCommandError
+		}
+		fmt.Println(string(out))
+		if len(patch) == 0 {
+			return 0
+		}
+		return exitCheckFailed
+	}
+
+	// Color only helps on a terminal, and is never the sole signal thanks to the markers
+	changes := diff.Compare(values[0], values[1])
+	fmt.Print(diff.Render(changes, diff.RenderOptions{
+		Color:      useColor(cfg) && !cfg.Accessible,
+		EscapeHTML: cfg.EscapeHTML,
+	}))
+	if len(changes) == 0 {
+		return 0
+	}
+	return exitCheckFailed
+}
+
+// patchValue turns the operations of a JSON Patch into plain values for the
+// formatter, recording their members in the order RFC 6902 lists them
+func patchValue(ops []diff.Operation, order *formatter.KeyOrder) []interface{} {
+	out := make([]interface{}, len(ops))
+	for i, op := range ops {
+		obj := map[string]interface{}{"op": op.Op}
+		keys := []string{"op"}
+		if op.From != "" {
+			obj["from"] = op.From
+			keys = append(keys, "from")
+		}
+		obj["path"] = op.Path
+		keys = append(keys, "path")
+		switch op.Op {
+		case "add", "replace", "test":
+			obj["value"] = op.Value
+			keys = append(keys, "value")
+		}
+		order.Record(obj, keys)
+		out[i] = obj
+	}
+	return out
+}
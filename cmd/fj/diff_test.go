@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+func TestDiffEmitPatchExitCode(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.json")
+	changed := filepath.Join(dir, "new.json")
+	files := map[string]string{
+		old:     `{"a": 1}`,
+		changed: `{"a": 2}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-emit-patch", old, changed}, exitCheckFailed},
+		{[]string{"-emit-patch", old, old}, 0},
+		{[]string{old, changed}, exitCheckFailed},
+		{[]string{old, old}, 0},
+	}
+	for _, tt := range tests {
+		var code int
+		captureStdout(t, func() {
+			code = runDiff(config.Config{}, tt.args)
+		})
+		if code != tt.want {
+			t.Errorf("diff %s exit code = %d, want %d", tt.args, code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/transform"
+)
+
+// runSet implements "fj set [options] <doc> <path> <value>"
+func runSet(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	writePtr := fs.Bool("write", false, "Write the result back to the file instead of printing it")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	stringPtr := fs.Bool("string", false, "Set the value as a string instead of parsing it as JSON")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj set [options] <doc> <path> <value>\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Inserts or replaces the value at a path such as user.settings.theme or items[0],\n")
+		_, _ = fmt.Fprintf(os.Stderr, "creating the objects and arrays leading to it. The value is JSON, so strings are quoted: '\"dark\"'.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return exitCommandError
+	}
+	source := fs.Arg(0)
+
+	path, err := jsonpath.ParsePath(fs.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	doc, opts, err := loadForEdit(cfg, source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	value, err := editValue(fs.Arg(2), *stringPtr, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	if doc, err = transform.Set(doc, path, value); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+	return saveEdit(cfg, runOptions{noRedact: *noRedactPtr}, source, doc, opts, *writePtr)
+}
+
+// runDel implements "fj del [options] <doc> <path>..."
+func runDel(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("del", flag.ContinueOnError)
+	writePtr := fs.Bool("write", false, "Write the result back to the file instead of printing it")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj del [options] <doc> <path>...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Removes the entries at paths such as metadata.debug or items[3]. Paths are anchored at the\n")
+		_, _ = fmt.Fprintf(os.Stderr, "root and may use wildcards: items[*].debug, *.tmp or $..debug for a key at any depth.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitCommandError
+	}
+	source := fs.Arg(0)
+
+	var patterns []*jsonpath.Pattern
+	for _, expr := range fs.Args()[1:] {
+		p, err := jsonpath.CompileRooted(expr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+		patterns = append(patterns, p)
+	}
+
+	doc, opts, err := loadForEdit(cfg, source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	doc, removed := transform.Delete(doc, patterns)
+	if removed == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: nothing in %s matches %s\n", source, strings.Join(fs.Args()[1:], ", "))
+		return 1
+	}
+	return saveEdit(cfg, runOptions{noRedact: *noRedactPtr}, source, doc, opts, *writePtr)
+}
+
+// runAppend implements "fj append [options] <doc> <path> <value>..."
+func runAppend(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("append", flag.ContinueOnError)
+	atPtr := fs.Int("at", -1, "Insert before the element at this index instead of appending")
+	writePtr := fs.Bool("write", false, "Write the result back to the file instead of printing it")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	stringPtr := fs.Bool("string", false, "Add the values as strings instead of parsing them as JSON")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj append [options] <doc> <path> <value>...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Adds values to the end of the array at a path such as tags or items[0].labels, or before\n")
+		_, _ = fmt.Fprintf(os.Stderr, "the element at -at. A missing array is created. Values are JSON, so strings are quoted: '\"new-tag\"'.\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Options may follow the arguments; put -- before values that start with -, such as -1.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := parseInterspersed(fs, args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() < 3 {
+		fs.Usage()
+		return exitCommandError
+	}
+	source := fs.Arg(0)
+
+	path, err := jsonpath.ParsePath(fs.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	doc, opts, err := loadForEdit(cfg, source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	var values []interface{}
+	for _, arg := range fs.Args()[2:] {
+		value, err := editValue(arg, *stringPtr, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+		values = append(values, value)
+	}
+
+	if doc, err = transform.Insert(doc, path, *atPtr, values...); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+	return saveEdit(cfg, runOptions{noRedact: *noRedactPtr}, source, doc, opts, *writePtr)
+}
+
+// editValue reads a value given on the command line, as JSON unless asString
+func editValue(arg string, asString bool, opts formatter.Options) (interface{}, error) {
+	if asString {
+		return arg, nil
+	}
+	value, err := formatter.Decode([]byte(arg), opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not JSON, quote strings as '\"text\"' or pass -string: %v", arg, err)
+	}
+	return value, nil
+}
+
+// loadForEdit reads and parses the document an editing command works on
+func loadForEdit(cfg config.Config, source string, trustAllURLs bool) (interface{}, formatter.Options, error) {
+	opts := formatOptions(cfg)
+	data, err := readSource(source, trustAllURLs)
+	if err != nil {
+		return nil, opts, fmt.Errorf("reading %s: %v", source, err)
+	}
+	doc, err := formatter.Decode(data, opts)
+	if err != nil {
+		return nil, opts, fmt.Errorf("parsing %s: %v", source, err)
+	}
+	return doc, opts, nil
+}
+
+// saveEdit prints the edited document, or with write replaces the file it
+// was read from, and returns the exit code. Only the printed document is
+// redacted: the file keeps its values.
+func saveEdit(cfg config.Config, run runOptions, source string, doc interface{}, opts formatter.Options, write bool) int {
+	if !write {
+		var err error
+		if doc, err = redact(doc, cfg, run); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+			return exitCommandError
+		}
+	}
+	out, err := formatter.FormatValue(doc, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	if !write {
+		fmt.Println(string(out))
+		return 0
+	}
+	if source == "-" || isURL(source) {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -write needs a file, not %s\n", source)
+		return exitCommandError
+	}
+	if err := checkSandbox(cfg, source); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", source, err)
+		return exitCommandError
+	}
+	if err := writeFile(source, append(out, '\n'), 0644); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", source, err)
+		return exitCommandError
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+func TestEditRedactsPrintedDocument(t *testing.T) {
+	cfg := config.Config{RedactPaths: []string{"password"}}
+	path := filepath.Join(t.TempDir(), "user.json")
+	input := `{"name":"ada","password":"hunter2","debug":true}`
+
+	tests := []struct {
+		name   string
+		run    func(args []string) int
+		args   []string
+		secret bool
+	}{
+		{"set", func(args []string) int { return runSet(cfg, args) }, []string{path, "name", `"grace"`}, false},
+		{"set -no-redact", func(args []string) int { return runSet(cfg, args) }, []string{"-no-redact", path, "name", `"grace"`}, true},
+		{"del", func(args []string) int { return runDel(cfg, args) }, []string{path, "debug"}, false},
+		{"del -no-redact", func(args []string) int { return runDel(cfg, args) }, []string{"-no-redact", path, "debug"}, true},
+		{"append", func(args []string) int { return runAppend(cfg, args) }, []string{path, "tags", `"new"`}, false},
+		{"append -no-redact", func(args []string) int { return runAppend(cfg, args) }, []string{path, "tags", `"new"`, "-no-redact"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var code int
+			out := captureStdout(t, func() {
+				code = tt.run(tt.args)
+			})
+			if code != 0 {
+				t.Fatalf("exit code = %d", code)
+			}
+			if got := strings.Contains(out, "hunter2"); got != tt.secret {
+				t.Errorf("output shows the password = %v, want %v:\n%s", got, tt.secret, out)
+			}
+		})
+	}
+
+	// The file written with -write keeps the value
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runSet(cfg, []string{"-write", path, "name", `"grace"`}); code != 0 {
+		t.Fatalf("set -write exit code = %d", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "hunter2") {
+		t.Errorf("set -write redacted the file:\n%s", data)
+	}
+}
+
+func TestAppendOptionsAfterArguments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{"offsets":[1,2]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{path, "offsets", "0", "-at", "0"}, "[0,1,2]"},
+		{[]string{"-at", "1", path, "offsets", "--", "-1"}, "[1,-1,2]"},
+	}
+	for _, tt := range tests {
+		var code int
+		out := captureStdout(t, func() {
+			code = runAppend(config.Config{Minify: true}, tt.args)
+		})
+		if code != 0 {
+			t.Fatalf("append %s exit code = %d", tt.args, code)
+		}
+		if want := `{"offsets":` + tt.want + "}\n"; out != want {
+			t.Errorf("append %s = %q, want %q", tt.args, out, want)
+		}
+	}
+}
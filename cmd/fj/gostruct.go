@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/gostruct"
+)
+
+// runGoStruct implements "fj gostruct [options] [file|url|-]"
+func runGoStruct(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("gostruct", flag.ContinueOnError)
+	pkgPtr := fs.String("pkg", "main", "Package the types are declared in")
+	namePtr := fs.String("name", "Document", "Name of the type of the whole document")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj gostruct [options] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Prints Go types that a sample document decodes into; stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := parseInterspersed(fs, args); err != nil {
+		return 1
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return 1
+	}
+
+	opts := formatOptions(cfg)
+	value, err := formatter.Decode(data, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+		return 1
+	}
+
+	// Fields are declared in document order unless keys are sorted
+	keys := opts.KeyOrder.Keys
+	if cfg.SortKeys {
+		keys = nil
+	}
+	code, err := gostruct.Generate(value, gostruct.Options{Package: *pkgPtr, Name: *namePtr, Keys: keys})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Print(string(code))
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// grepContextLen is the longest context line printed under a -grep match
+const grepContextLen = 100
+
+// formatMatches lists -grep matches, each with its path and, on the next
+// line, the object or array that holds it as context. Values are written
+// compactly with the keys, escaping and number notation of opts.
+func formatMatches(matches []query.Match, opts formatter.Options) string {
+	var sb strings.Builder
+	for _, m := range matches {
+		path := jsonpath.String(m.Path)
+		if path == "" {
+			path = "(root)"
+		}
+		if m.Key {
+			fmt.Fprintf(&sb, "%s (key)\n", path)
+		} else {
+			fmt.Fprintf(&sb, "%s: %s\n", path, compactJSON(m.Value, opts))
+		}
+
+		if m.Parent != nil {
+			parent := jsonpath.String(m.Path[:len(m.Path)-1])
+			if parent == "" {
+				parent = "(root)"
+			}
+			fmt.Fprintf(&sb, "  in %s: %s\n", parent, shortenText(compactJSON(m.Parent, opts), grepContextLen))
+		}
+	}
+	return sb.String()
+}
+
+// compactJSON writes a value as one line of JSON, falling back to fmt when
+// it cannot be formatted
+func compactJSON(v interface{}, opts formatter.Options) string {
+	out, err := formatCompact(v, opts)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+// shortenText cuts s to at most n characters, marking the cut with an ellipsis
+func shortenText(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+func TestFormatMatchesKeepsDocument(t *testing.T) {
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	value, err := formatter.Decode([]byte(`{"z": "a<b", "a": 1}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := query.Grep(value, regexp.MustCompile("<"), opts.KeyOrder.Keys)
+	want := "z: \"a<b\"\n  in (root): {\"z\":\"a<b\",\"a\":1}\n"
+	if got := formatMatches(matches, opts); got != want {
+		t.Errorf("formatMatches() = %q, want %q", got, want)
+	}
+
+	opts.EscapeHTML = true
+	opts.SortKeys = true
+	want = "z: \"a\\u003cb\"\n  in (root): {\"a\":1,\"z\":\"a\\u003cb\"}\n"
+	if got := formatMatches(matches, opts); got != want {
+		t.Errorf("formatMatches() with sorted, escaped keys = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// memoryPerInputByte estimates the memory a parsed document takes for each
+// byte of JSON text: maps, interfaces and strings make the value tree several
+// times larger than the input it was read from
+const memoryPerInputByte = 4
+
+// inputLimit, when positive, is the largest input in bytes that can be parsed
+// within the memory limit
+var inputLimit int64
+
+// applyMemoryLimit makes the Go runtime collect garbage more aggressively as
+// the heap nears maxMemoryMB, and bounds the size of the input accordingly.
+// Zero means no limit, lifting any limit applied before, such as the one
+// from the config file when -max-memory 0 overrides it.
+func applyMemoryLimit(maxMemoryMB int) {
+	if maxMemoryMB <= 0 {
+		debug.SetMemoryLimit(math.MaxInt64)
+		inputLimit = 0
+		return
+	}
+
+	limit := int64(maxMemoryMB) << 20
+	debug.SetMemoryLimit(limit)
+	inputLimit = limit / memoryPerInputByte
+}
+
+// applyProcessorLimit caps the number of CPUs that run Go code at the same
+// time, which also bounds any parallel work. Zero means all of them, even
+// when the config file set a limit before -procs 0 overrode it.
+func applyProcessorLimit(maxProcessors int) {
+	if maxProcessors <= 0 {
+		maxProcessors = runtime.NumCPU()
+	}
+	runtime.GOMAXPROCS(maxProcessors)
+}
+
+// binaryInput is set when the input is in a binary format, such as
+// MessagePack, whose bytes must be read as they are
+var binaryInput bool
+
+// readAll reads r to the end, failing early rather than reading an input
+// too large to parse within the memory limit. Input written in UTF-16 or
+// UTF-32, or starting with a byte order mark, is returned as plain UTF-8,
+// unless it is binary.
+func readAll(r io.Reader) ([]byte, error) {
+	var data []byte
+	var err error
+	if inputLimit <= 0 {
+		data, err = io.ReadAll(r)
+	} else {
+		data, err = io.ReadAll(io.LimitReader(r, inputLimit+1))
+		if err == nil && int64(len(data)) > inputLimit {
+			return nil, errInputTooLarge()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if binaryInput {
+		return data, nil
+	}
+
+	data, _, err = formatter.ToUTF8(data)
+	return data, err
+}
+
+// readFile reads a file, checking its size against the memory limit first
+func readFile(path string) ([]byte, error) {
+	if inputLimit > 0 {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Size() > inputLimit {
+			return nil, errInputTooLarge()
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAll(f)
+}
+
+// errInputLimit is returned, wrapped, for inputs too large for the memory limit
+var errInputLimit = errors.New("input too large")
+
+// errInputTooLarge explains which setting keeps the input from being read
+func errInputTooLarge() error {
+	return fmt.Errorf("%w: it is larger than %s and would not fit in max_memory_mb (%d MB) once parsed; raise the limit with -max-memory",
+		errInputLimit, formatSize(int(inputLimit)), (inputLimit*memoryPerInputByte)>>20)
+}
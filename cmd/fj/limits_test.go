@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestApplyMemoryLimitZeroLiftsLimit(t *testing.T) {
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(-1))
+	defer applyMemoryLimit(0)
+
+	applyMemoryLimit(64)
+	if got := debug.SetMemoryLimit(-1); got != 64<<20 {
+		t.Fatalf("memory limit = %d, want %d", got, 64<<20)
+	}
+	applyMemoryLimit(0)
+	if got := debug.SetMemoryLimit(-1); got != math.MaxInt64 {
+		t.Errorf("memory limit after applyMemoryLimit(0) = %d, want no limit", got)
+	}
+	if inputLimit != 0 {
+		t.Errorf("inputLimit = %d, want 0", inputLimit)
+	}
+}
+
+func TestApplyProcessorLimitZeroUsesAllCPUs(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	applyProcessorLimit(1)
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Fatalf("GOMAXPROCS = %d, want 1", got)
+	}
+	applyProcessorLimit(0)
+	if got := runtime.GOMAXPROCS(0); got != runtime.NumCPU() {
+		t.Errorf("GOMAXPROCS after applyProcessorLimit(0) = %d, want %d", got, runtime.NumCPU())
+	}
+}
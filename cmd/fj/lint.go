@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/lint"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// runLint implements "fj lint [options] [file|url|-]..."
+func runLint(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	outputPtr := fs.String("output", "text", "Findings format: text or json")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	statePtr := fs.String("state", "", "Record progress in this file, so an interrupted run resumes where it stopped")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj lint [options] [file|url|-]...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Checks documents for style problems: %s.\n", strings.Join(lint.RuleNames(), ", "))
+		_, _ = fmt.Fprintf(os.Stderr, "Rules are configured with \"lint_rules\" and \"lint_checks\" in the config file.\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Exits with 0 when no errors are found, 1 when some are and 2 when a document cannot be read.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if *outputPtr != "text" && *outputPtr != "json" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: unknown output format %q (use text or json)\n", *outputPtr)
+		return exitCommandError
+	}
+
+	linter, err := newLinter(cfg)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	sources := fs.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	state, err := loadState(cfg, *statePtr, os.Args[1:])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	diagnostics := make([]diag.Diagnostic, 0)
+	for _, source := range sources {
+		// Documents linted by an interrupted run are not printed again
+		if entry, ok := state.done(source); ok {
+			diagnostics = append(diagnostics, entry.Diagnostics...)
+			continue
+		}
+		data, err := readSource(source, *trustPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+			return exitCommandError
+		}
+		value, err := formatter.Decode(data, formatOptions(cfg))
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+			return exitCommandError
+		}
+
+		name := source
+		if source == "-" {
+			name = "<stdin>"
+		}
+		found := linter.Lint(name, data, value)
+		diagnostics = append(diagnostics, lint.Diagnostics(found)...)
+		if *outputPtr == "text" {
+			for _, f := range found {
+				fmt.Println(f)
+			}
+		}
+		if err := state.record(source, 0, lint.Diagnostics(found)); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+	}
+
+	if *outputPtr == "json" {
+		if err := printDiagnostics(diagnostics, cfg); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+	}
+	if err := state.finish(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if diag.HasErrors(diagnostics) {
+		return exitCheckFailed
+	}
+	return 0
+}
+
+// newLinter builds a linter from the lint settings of the config file
+func newLinter(cfg config.Config) (*lint.Linter, error) {
+	settings := make(map[string]lint.Setting, len(cfg.LintRules))
+	for name, rule := range cfg.LintRules {
+		settings[name] = lint.Setting{Severity: diag.Severity(rule.Severity), Max: rule.Max}
+	}
+
+	checks := make([]lint.Check, 0, len(cfg.LintChecks))
+	for _, c := range cfg.LintChecks {
+		if c.Name == "" {
+			return nil, fmt.Errorf("lint check for %q has no name", c.Path)
+		}
+		pattern, err := jsonpath.Compile(c.Path)
+		if err != nil {
+			return nil, fmt.Errorf("lint check %q: %v", c.Name, err)
+		}
+		q, err := query.Parse(c.Check)
+		if err != nil {
+			return nil, fmt.Errorf("lint check %q: %v", c.Name, err)
+		}
+		checks = append(checks, lint.Check{
+			Name:     c.Name,
+			Pattern:  pattern,
+			Query:    q,
+			Severity: diag.Severity(c.Severity),
+			Message:  c.Message,
+		})
+	}
+
+	return lint.New(settings, checks)
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -9,19 +13,69 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/nicolasalberti00/fj/pkg/clipboard"
+	"github.com/nicolasalberti00/fj/pkg/compress"
 	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/convert"
+	"github.com/nicolasalberti00/fj/pkg/diff"
+	"github.com/nicolasalberti00/fj/pkg/filelock"
 	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/query"
+	"github.com/nicolasalberti00/fj/pkg/sandbox"
+	"github.com/nicolasalberti00/fj/pkg/shell"
+	"github.com/nicolasalberti00/fj/pkg/stream"
+	"github.com/nicolasalberti00/fj/pkg/tmpl"
+	"github.com/nicolasalberti00/fj/pkg/transform"
 )
 
 const (
 	version = "0.1.0"
 )
 
+const (
+	// exitCheckFailed is returned by commands whose check did not pass,
+	// such as documents that differ or assertions that fail
+	exitCheckFailed = 1
+	// exitCommandError is returned by commands that could not run their check
+	exitCommandError = 2
+	// exitNotFound is returned when -pointer does not resolve to a value
+	exitNotFound = 3
+	// exitInterrupted is returned when fj is stopped by SIGINT or SIGTERM
+	exitInterrupted = 130
+)
+
+// commands maps subcommand names to their entry points. Each one receives the
+// loaded configuration and the arguments following its name, and returns the
+// process exit code.
+var commands = map[string]func(cfg config.Config, args []string) int{
+	"diff":     runDiff,
+	"assert":   runAssert,
+	"profile":  runProfile,
+	"resolve":  runResolve,
+	"describe": runDescribe,
+	"schema":   runSchema,
+	"mock":     runMock,
+	"template": runTemplate,
+	"validate": runValidate,
+	"lint":     runLint,
+	"merge":    runMerge,
+	"paths":    runPaths,
+	"set":      runSet,
+	"del":      runDel,
+	"append":   runAppend,
+	"gostruct": runGoStruct,
+}
+
 func main() {
+	handleSignals()
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -29,76 +83,607 @@ func main() {
 		_, _ = fmt.Fprintf(os.Stderr, "Using default configuration.\n")
 		cfg = config.DefaultConfig()
 	}
+	applyMemoryLimit(cfg.MaxMemoryMB)
+	applyProcessorLimit(cfg.MaxProcessors)
+
+	// Run a subcommand if the first argument names one
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			os.Exit(run(cfg, os.Args[2:]))
+		}
+	}
 
 	// Parse command line flags
-	cmdConfig := parseFlags(cfg)
+	cmdConfig, run := parseFlags(cfg)
+	applyMemoryLimit(cmdConfig.MaxMemoryMB)
+	applyProcessorLimit(cmdConfig.MaxProcessors)
+	if run.notify {
+		source := "standard input"
+		if flag.NArg() > 0 {
+			source = flag.Arg(0)
+		}
+		enableNotify(source)
+	}
+
+	// Check mode only compares the inputs with their formatted text
+	if run.check {
+		exit(runCheck(cmdConfig, run, flag.Args()))
+	}
 
 	// Process input
+	binaryInput = run.convertFrom.Binary()
 	inputData, err := getInput(cmdConfig.TrustAllURLs)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error while getting input: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	// Format JSON
-	opts := formatter.Options{
-		IndentSpaces: cmdConfig.IndentSpaces,
-		SortKeys:     cmdConfig.SortKeys,
+	if run.invalidUTF8 != "" && !binaryInput {
+		inputData = checkUTF8(inputData, run.invalidUTF8)
 	}
 
-	formattedJSON, err := formatter.Format(inputData, opts)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+	// Other data formats become JSON before anything else reads them
+	if run.convertFrom != "" {
+		inputData, err = convert.ToJSON(inputData, run.convertFrom, run.conversion)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", run.convertFrom, err)
+			exit(1)
+		}
+	}
 
-		// Try auto-correction if formatting fails
-		_, _ = fmt.Fprintf(os.Stderr, "Attempting to auto-correct JSON...\n")
-		correctedJSON, corrErr := formatter.AutoCorrect(inputData)
-		if corrErr != nil {
-			fmt.Fprintf(os.Stderr, "Auto-correction failed: %v\n", corrErr)
-			os.Exit(1)
+	opts := formatOptions(cmdConfig)
+
+	// Input starting with a record separator is a JSON text sequence
+	from := run.from
+	if from == stream.JSON && stream.IsSeq(inputData) {
+		from = stream.JSONSeq
+	}
+
+	// JSONC input has its comments removed before parsing, and kept aside
+	// when they are written back into the output
+	var comments *formatter.Comments
+	if run.jsonc {
+		if from != stream.JSON {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: comments are only supported in single JSON documents, not %s\n", from)
+			exit(1)
+		}
+		inputData, comments, err = formatter.StripComments(inputData)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading JSONC: %v\n", err)
+			exit(1)
+		}
+	}
+
+	if run.fromPython && from != stream.JSON {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -from-python reads a single value and cannot read %s\n", from)
+		exit(1)
+	}
+
+	// Documents written back to back, as some loggers do, are read as a stream
+	// and written back one by one, unless -slurp combines them into an array
+	if from == stream.JSON && !run.fromPython && stream.IsConcat(inputData) {
+		from = stream.Concat
+		if run.to == stream.JSON && !run.slurp && !run.shell && !run.canonical {
+			run.to = stream.Concat
 		}
+	}
 
-		// Try formatting again with corrected JSON
-		formattedJSON, err = formatter.Format(correctedJSON, opts)
+	// Strict mode rejects the input outright instead of auto-correcting it
+	if run.strictIJSON && from != stream.JSON {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -strict-ijson checks single documents and cannot read %s\n", from)
+		exit(1)
+	}
+	if run.strictIJSON {
+		if err := formatter.ValidateIJSON(inputData, run.rejectScalars); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Parse JSON, or a stream of records which is handled as an array
+	var value interface{}
+	if from != stream.JSON {
+		value, err = stream.Decode(inputData, from, opts)
+		if reportSkipped(err, from) {
+			err = nil
+		}
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Error formatting corrected JSON: %v\n", err)
-			os.Exit(1)
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", from, err)
+			exit(1)
 		}
+	} else {
+		repair := repairOptions(cmdConfig)
+		repair.Python = run.fromPython
+		value = decodeJSON(inputData, opts, repair, run)
+	}
 
-		_, _ = fmt.Fprintf(os.Stderr, "Auto-correction successful!\n")
+	// Expand JSON documents stored as strings, so that later steps can reach into them
+	if run.embedded {
+		patterns, err := jsonpath.CompileAll(run.embeddedPaths)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -embedded-paths: %v\n", err)
+			exit(1)
+		}
+		value, err = transform.ParseEmbedded(value, patterns, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -parse-embedded: %v\n", err)
+			exit(1)
+		}
 	}
 
-	// Output formatted JSON
-	fmt.Println(string(formattedJSON))
+	// Overlay a merge patch, as kubectl patch --type merge does
+	if run.mergePatch != "" {
+		value, err = applyMergePatch(value, run.mergePatch, cmdConfig, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -merge-patch: %v\n", err)
+			exit(1)
+		}
+	}
 
-	// Copy to clipboard if requested
-	if cmdConfig.CopyToClipboard {
-		if err := clipboard.Copy(string(formattedJSON)); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Failed to copy to clipboard: %v\n", err)
+	// Replay a JSON Patch, such as a delta stored by fj diff -emit-patch
+	if run.applyPatch != "" {
+		value, err = applyJSONPatch(value, run.applyPatch, cmdConfig, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -apply-patch: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Run the transform pipeline, if one was given
+	if run.pipeline != "" {
+		value, err = applyPipeline(value, run.pipeline, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Reshape the document, or each record of a stream, with a template
+	if run.template != "" {
+		value, err = applyTemplate(value, run.template, run.templateEach || from != stream.JSON, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -transform-template: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Keep only the picked paths, then drop the omitted ones
+	if len(run.pick) > 0 {
+		value = transform.Pick(value, run.pick, opts.KeyOrder)
+	}
+	if len(run.omit) > 0 {
+		value = transform.Omit(value, run.omit, opts.KeyOrder)
+	}
+
+	// Rename the keys listed with -rename and -rename-file
+	if len(run.renames) > 0 {
+		value, err = transform.RenameKeys(value, run.renames, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Convert keys to another naming convention
+	if run.renameKeys != "" {
+		value, err = transform.ConvertKeys(value, run.renameKeys, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Drop null and empty entries
+	if run.prune != (transform.PruneOptions{}) {
+		value = transform.Prune(value, run.prune, opts.KeyOrder)
+	}
+
+	// Drop repeated array elements, keeping the first of each
+	if run.dedupeBy != "" {
+		value = transform.DedupeBy(value, run.dedupeBy, opts.KeyOrder)
+	}
+	if run.dedupe {
+		value = transform.Dedupe(value, opts.KeyOrder)
+	}
+
+	// Order arrays of objects by a field, for reproducible output
+	if cmdConfig.SortArraysBy != "" {
+		value = transform.SortArraysBy(value, cmdConfig.SortArraysBy)
+	}
+
+	// Flatten nested values into one object with path keys, or the reverse
+	if run.flatten {
+		value = transform.Flatten(value, run.separator, opts.KeyOrder)
+	}
+	if run.unflatten {
+		value, err = transform.Unflatten(value, run.separator, opts.KeyOrder)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -unflatten: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Hide sensitive fields before anything is printed, copied or saved
+	value, err = redact(value, cmdConfig, run)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+		exit(1)
+	}
+
+	// Narrow the document down to the value the pointer names
+	if run.pointer != "" {
+		value, err = jsonpath.ResolvePointer(value, run.pointer)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if errors.Is(err, jsonpath.ErrNotFound) {
+				exit(exitNotFound)
+			}
+			exit(1)
+		}
+	}
+	if run.keepComments && run.query == nil {
+		opts.Comments = comments.Under(run.pointer)
+	}
+
+	// Searching prints where the matches are instead of the document
+	if run.grep != nil {
+		keys := opts.KeyOrder.Keys
+		if cmdConfig.SortKeys {
+			keys = nil
+		}
+		matches := query.Grep(value, run.grep, keys)
+		fmt.Print(formatMatches(matches, opts))
+		if len(matches) == 0 {
+			exit(exitCheckFailed)
+		}
+		exit(0)
+	}
+
+	// Keep the elements of the array that match -where
+	if run.where != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -where filters an array, but the document is not one; select an array with -pointer\n")
+			exit(1)
+		}
+		value, err = run.where.Filter(items)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -where: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Summarize the array in groups, like GROUP BY in SQL
+	if run.groupBy != "" {
+		items, ok := value.([]interface{})
+		if !ok {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -group-by groups an array, but the document is not one; select an array with -pointer\n")
+			exit(1)
+		}
+		value = transform.GroupBy(items, run.groupBy, run.aggregates)
+	}
+
+	// Run the -q expression. Like jq, several outputs are written one after
+	// the other rather than as an array.
+	if run.query != nil {
+		results, err := run.query.Run(value)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -q: %v\n", err)
+			exit(1)
+		}
+		if len(results) == 1 {
+			value = results[0].Value
 		} else {
-			fmt.Println("Copied to clipboard!")
+			outputs := make([]interface{}, len(results))
+			for i, r := range results {
+				outputs[i] = r.Value
+			}
+			value = outputs
+			if run.to == stream.JSON {
+				run.to = stream.Concat
+			}
 		}
 	}
 
+	// Format JSON, shell variables, or one record per element when writing a stream
+	var formattedJSON []byte
+	if run.canonical {
+		formattedJSON, err = formatter.Canonical(value)
+	} else if run.shell {
+		formattedJSON, err = shell.Render(value, run.shellExport)
+	} else if run.convertTo != "" && run.to == stream.Concat {
+		formattedJSON, err = convert.EncodeAll(stream.Records(value), run.convertTo, opts, run.conversion)
+	} else if run.convertTo != "" {
+		formattedJSON, err = convert.Encode(value, run.convertTo, opts, run.conversion)
+	} else if run.to != stream.JSON {
+		formattedJSON, err = stream.Encode(stream.Records(value), run.to, opts)
+	} else {
+		formattedJSON, err = formatter.FormatValue(value, opts)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		exit(1)
+	}
+	// Binary output wrapped in base64 is text like any other
+	binaryOutput := run.convertTo.Binary()
+	if binaryOutput && run.base64 {
+		formattedJSON = append([]byte(base64.StdEncoding.EncodeToString(formattedJSON)), '\n')
+		binaryOutput = false
+	}
+
+	// Append mode adds the records to a file instead of printing them
+	if run.appendPath != "" {
+		if err := checkSandbox(cmdConfig, run.appendPath); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to append: %v\n", err)
+			exit(1)
+		}
+		if err := appendRecords(run.appendPath, formattedJSON, cmdConfig.SandboxDir != ""); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to append: %v\n", err)
+			exit(1)
+		}
+		n := len(stream.Records(value))
+		noun := "records"
+		if n == 1 {
+			noun = "record"
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Appended %d %s to %s\n", n, noun, run.appendPath)
+		exit(0)
+	}
+
+	// Raw mode prints a string result as plain text, or one line for each
+	// string of a stream
+	str, rawOutput := value.(string)
+	rawOutput = rawOutput && run.to == stream.JSON
+	if run.to == stream.Concat {
+		str, rawOutput = rawLines(stream.Records(value))
+	}
+	rawOutput = rawOutput && run.raw && !run.shell && !run.canonical
+	if rawOutput {
+		formattedJSON = []byte(str)
+	}
+
+	// A preview collapses deep structures and shortens long strings and arrays
+	// on screen only; the clipboard and saved files still get the whole document
+	shown, shownOpts := formattedJSON, opts
+	preview := run.maxDepth > 0 || run.maxStringLen > 0 || run.maxArrayItems > 0
+	if preview && run.to == stream.JSON && run.convertTo == "" && !run.shell && !run.canonical && !rawOutput {
+		shownOpts.MaxDepth = run.maxDepth
+		shownOpts.MaxStringLen = run.maxStringLen
+		shownOpts.MaxArrayItems = run.maxArrayItems
+		shown, err = formatter.FormatValue(value, shownOpts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Output formatted JSON, or a spoken description of it in accessible mode
+	var printed []byte
+	if rawOutput {
+		printed = append(formattedJSON, '\n')
+	} else if binaryOutput && isTerminal(os.Stdout) {
+		_, _ = fmt.Fprintf(os.Stderr, "Not printing %s output, which is binary, to the terminal; redirect it to a file or save it with -outdir\n", run.convertTo)
+	} else if run.to != stream.JSON || run.shell || run.convertTo != "" {
+		// Records, variables and converted documents already end with a line feed
+		printed = formattedJSON
+	} else if run.canonical {
+		// Canonical output is printed byte for byte, so that it can be piped into a hash
+		printed = formattedJSON
+	} else if cmdConfig.Accessible {
+		narration, err := formatter.Narrate(formattedJSON, opts, cmdConfig.Highlight)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error describing JSON: %v\n", err)
+			exit(1)
+		}
+		printed = append(narration, '\n')
+	} else if useColor(cmdConfig) {
+		printed = append(colorize(value, shown, shownOpts, cmdConfig), '\n')
+	} else {
+		printed = append(shown, '\n')
+	}
+	// The output is written whole lines at a time, and marked as truncated
+	// if fj is interrupted before the end
+	if err := writeInterruptible(os.Stdout, printed, "output"); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		exit(1)
+	}
+
+	// Copy to clipboard if requested; binary output is not text to paste
+	if cmdConfig.CopyToClipboard && !binaryOutput {
+		copyToClipboard(formattedJSON, cmdConfig)
+	}
+
 	// Save to file if requested
 	if cmdConfig.OutputDir != "" {
-		outputPath := generateOutputPath(cmdConfig.OutputDir)
-		if err := saveToFile(formattedJSON, outputPath); err != nil {
+		if err := checkSandbox(cmdConfig, cmdConfig.OutputDir); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to save to file: %v\n", err)
+			exit(1)
+		}
+
+		// The method was checked when parsing flags
+		method, _ := compress.Parse(cmdConfig.Compress)
+		ext := run.to.Extension()
+		if run.shell {
+			ext = ".env"
+		} else if run.convertTo != "" {
+			ext = run.convertTo.Extension()
+		}
+		if run.base64 {
+			ext += ".b64"
+		}
+		outputPath := generateOutputPath(cmdConfig.OutputDir, ext+method.Extension())
+		if err := saveCompressed(formattedJSON, outputPath, method); err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Failed to save to file: %v\n", err)
+		} else if binaryOutput {
+			// Binary output may be piped, and a message would corrupt it
+			_, _ = fmt.Fprintf(os.Stderr, "Saved to %s\n", outputPath)
 		} else {
 			fmt.Printf("Saved to %s\n", outputPath)
 		}
 	}
+
+	exit(0)
 }
 
-// parseFlags parses command line flags and returns a Config
-func parseFlags(defaultCfg config.Config) config.Config {
+// runOptions holds flags that only apply to the current invocation and are
+// never written to the config file
+type runOptions struct {
+	// noRedact drops the redaction paths of the config file, but not the
+	// ones in redactPaths, given with -redact
+	noRedact       bool
+	redactPaths    []string
+	strictIJSON    bool
+	rejectScalars  bool
+	pointer        string
+	query          *query.Query
+	where          *query.Query
+	grep           *regexp.Regexp
+	groupBy        string
+	aggregates     []transform.Aggregate
+	raw            bool
+	pipeline       string
+	template       string
+	templateEach   bool
+	pick           []*jsonpath.Pattern
+	omit           []*jsonpath.Pattern
+	mergePatch     string
+	applyPatch     string
+	from           stream.Format
+	convertFrom    convert.Format
+	to             stream.Format
+	appendPath     string
+	slurp          bool
+	shell          bool
+	convertTo      convert.Format
+	base64         bool
+	conversion     convert.Options
+	shellExport    bool
+	notify         bool
+	jsonc          bool
+	fromPython     bool
+	repairReport   string
+	invalidUTF8    string
+	fixInteractive bool
+	keepComments   bool
+	canonical      bool
+	check          bool
+	state          string
+	maxDepth       int
+	maxStringLen   int
+	maxArrayItems  int
+	embedded       bool
+	embeddedPaths  []string
+	renames        []transform.Rename
+	renameKeys     transform.KeyCase
+	prune          transform.PruneOptions
+	dedupe         bool
+	dedupeBy       string
+	flatten        bool
+	unflatten      bool
+	separator      string
+}
+
+// Limits that -preview applies unless -max-string-len or -max-array-items are given
+const (
+	previewStringLen  = 120
+	previewArrayItems = 20
+)
+
+// parseFlags parses command line flags and returns a Config along with the
+// options that only apply to this run
+func parseFlags(defaultCfg config.Config) (config.Config, runOptions) {
 	// Define flags
 	indentPtr := flag.Int("indent", defaultCfg.IndentSpaces, "Number of spaces for indentation")
+	indentStrPtr := flag.String("indent-str", defaultCfg.IndentString, `Text of one indentation level, replacing -indent; escapes such as \t are understood`)
+	indentTabsPtr := flag.Bool("indent-tabs", false, "Indent with tabs")
 	sortPtr := flag.Bool("sort", defaultCfg.SortKeys, "Sort object keys")
+	sortModePtr := flag.String("sort-mode", defaultCfg.SortMode, "How -sort compares keys: ascii, ignore-case or natural")
+	sortReversePtr := flag.Bool("sort-reverse", defaultCfg.SortReverse, "Sort keys in descending order")
+	keyPriorityPtr := flag.String("key-priority", strings.Join(defaultCfg.KeyPriority, ","), "Comma-separated keys always written first, e.g. id,name")
+	sortArraysPtr := flag.String("sort-arrays-by", defaultCfg.SortArraysBy, "Order arrays of objects by the value of this field")
+	wrapWidthPtr := flag.Int("wrap-width", defaultCfg.WrapWidth, "Keep objects and arrays that fit in this many columns on one line; 0 never does")
+	minifyPtr := flag.Bool("minify", defaultCfg.Minify, "Write output on one line without whitespace")
+	escapeHTMLPtr := flag.Bool("escape-html", defaultCfg.EscapeHTML, `Escape <, > and & in strings as \u003c, \u003e and \u0026`)
 	clipboardPtr := flag.Bool("clipboard", defaultCfg.CopyToClipboard, "Copy result to clipboard")
+	clipboardLimitPtr := flag.Int("clipboard-limit", defaultCfg.ClipboardLimitKB, "Largest output in KB copied as text; 0 means no limit")
+	clipboardTimeoutPtr := flag.Duration("clipboard-timeout", time.Duration(defaultCfg.ClipboardTimeoutMS)*time.Millisecond, "Give up on the clipboard program after this long")
+	clipboardAsyncPtr := flag.Bool("clipboard-async", defaultCfg.ClipboardAsync, "Copy in the background without waiting for the clipboard program")
 	outputDirPtr := flag.String("outdir", defaultCfg.OutputDir, "Output directory for saved files")
+	compressPtr := flag.String("compress", defaultCfg.Compress, "Compress saved files: gzip, zstd or none")
+	sandboxPtr := flag.String("sandbox-dir", defaultCfg.SandboxDir, "Refuse to write files outside this directory")
+	maxMemoryPtr := flag.Int("max-memory", defaultCfg.MaxMemoryMB, "Memory limit in MB, refusing inputs too large to parse within it; 0 means no limit")
+	procsPtr := flag.Int("procs", defaultCfg.MaxProcessors, "Most CPUs to use at once; 0 means all")
 	trustPtr := flag.Bool("trust-all", defaultCfg.TrustAllURLs, "Trust all URLs without prompting")
+	colorPtr := flag.String("color", defaultCfg.Color, "When to color output: auto, always or never")
+	themePtr := flag.String("theme", defaultCfg.Theme, "Color theme for terminal output")
+	highlightPtr := flag.String("highlight", strings.Join(defaultCfg.Highlight, ","), "Comma-separated keys or paths to emphasize")
+	nonFinitePtr := flag.String("non-finite", defaultCfg.NonFinite, "What repair writes for NaN, Infinity and undefined: null or string")
+	duplicatesPtr := flag.String("duplicate-keys", defaultCfg.DuplicateKeys, "How to resolve repeated object keys: last, first, error or array")
+	numbersPtr := flag.String("numbers", defaultCfg.NumberFormat, "Number notation: auto, plain or exponent")
+	expAbovePtr := flag.Float64("exp-above", defaultCfg.ExponentAbove, "With -numbers exponent, use exponent notation from this magnitude")
+	expBelowPtr := flag.Float64("exp-below", defaultCfg.ExponentBelow, "With -numbers exponent, use exponent notation below this magnitude")
+	redactPtr := flag.String("redact", "", "Comma-separated keys or paths to replace with \"***\", in addition to the config file")
+	noRedactPtr := flag.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	strictPtr := flag.Bool("strict-ijson", false, "Reject documents that are not valid I-JSON (RFC 7493)")
+	rejectScalarsPtr := flag.Bool("ijson-reject-scalars", false, "With -strict-ijson, also require a top-level object or array")
+	pointerPtr := flag.String("pointer", "", "Print only the value at this JSON Pointer, e.g. /data/items/0")
+	maxDepthPtr := flag.Int("max-depth", 0, "Show structures nested deeper than this collapsed, e.g. {…3 keys}; 0 shows everything")
+	previewPtr := flag.Bool("preview", false, "Shorten long strings and arrays on screen")
+	maxStringLenPtr := flag.Int("max-string-len", previewStringLen, "With -preview, longest string shown in full; 0 shows everything")
+	maxArrayItemsPtr := flag.Int("max-array-items", previewArrayItems, "With -preview, most array elements shown; 0 shows everything")
+	canonicalPtr := flag.Bool("canonical", false, "Write RFC 8785 canonical JSON, for stable hashes and signatures")
+	checkPtr := flag.Bool("check", false, "Print a diff and exit with 1 for each file that is not already formatted, without changing it")
+	statePtr := flag.String("state", "", "With -check, record progress in this file so an interrupted run resumes where it stopped")
+	invalidUTF8Ptr := flag.String("invalid-utf8", "", "Check the input for invalid UTF-8: error to refuse it, replace to write U+FFFD instead")
+	repairReportPtr := flag.String("repair-report", "text", "How to list the fixes made by auto-correction on stderr: text, json or none")
+	fixInteractivePtr := flag.Bool("fix-interactive", false, "Ask before applying each fix auto-correction proposes")
+	fromPythonPtr := flag.Bool("from-python", false, "Read the output of Python's repr(), such as {'a': (1, 2), 'b': None}")
+	jsoncPtr := flag.Bool("jsonc", false, "Accept // and /* */ comments in the input (automatic for .jsonc files)")
+	keepCommentsPtr := flag.Bool("keep-comments", false, "Accept comments in the input and write them back into the formatted output")
+	grepPtr := flag.String("grep", "", "Print the paths of the keys and string values matching a regular expression, with their context")
+	wherePtr := flag.String("where", "", "Keep only the array elements matching an expression, e.g. 'status == \"active\" && age > 30'")
+	groupByPtr := flag.String("group-by", "", "Bucket the array elements by this field and print statistics for each group")
+	aggPtr := flag.String("agg", "count", "With -group-by, comma-separated statistics: count, sum(f), avg(f), min(f) and max(f)")
+	queryPtr := flag.String("q", "", "Print the outputs of a jq-like expression, e.g. '.items[] | select(.price > 10) | .name'")
+	rawPtr := flag.Bool("r", false, "Print string results without quotes")
+	presetPtr := flag.String("preset", "", "Apply a named set of options: diff, human, ci or one from the config file")
+	parseEmbeddedPtr := flag.Bool("parse-embedded", false, "Expand string values that hold a JSON object or array")
+	embeddedPathsPtr := flag.String("embedded-paths", "", "With -parse-embedded, comma-separated keys or paths to expand; implies -parse-embedded")
+	var renameFlags listFlag
+	flag.Var(&renameFlags, "rename", "Rename the keys matching a path, e.g. user.name=fullName; can be repeated")
+	renameFilePtr := flag.String("rename-file", "", "Rename keys as listed in this JSON file mapping paths to new names")
+	renameKeysPtr := flag.String("rename-keys", "", "Convert every object key to a naming convention: camel, pascal, snake or kebab")
+	dedupePtr := flag.Bool("dedupe", false, "Remove repeated elements from every array")
+	dedupeByPtr := flag.String("dedupe-by", "", "Remove array elements that repeat the value of this field in an earlier one")
+	omitNullPtr := flag.Bool("omit-null", false, "Drop object entries whose value is null")
+	omitEmptyStringsPtr := flag.Bool("omit-empty-strings", false, "Drop object entries whose value is an empty string")
+	omitEmptyContainersPtr := flag.Bool("omit-empty-containers", false, "Drop object entries whose value is an empty object or array")
+	flattenPtr := flag.Bool("flatten", false, "Turn nested values into one object with a.b[0].c keys")
+	unflattenPtr := flag.Bool("unflatten", false, "Rebuild nested values from an object with a.b[0].c keys")
+	separatorPtr := flag.String("separator", ".", "With -flatten or -unflatten, the text joining object keys")
+	templatePtr := flag.String("transform-template", "", "Reshape the document with a Go text/template file whose output is JSON")
+	templateEachPtr := flag.Bool("template-each", false, "With -transform-template, run the template once for each element of the array")
+	pickPtr := flag.String("pick", "", "Comma-separated keys or paths to keep, dropping everything else, e.g. a.b,c")
+	omitPtr := flag.String("omit", "", "Comma-separated keys or paths to drop, e.g. metadata,debug.*")
+	mergePatchPtr := flag.String("merge-patch", "", "Apply this RFC 7386 JSON Merge Patch file, where null deletes a key")
+	applyPatchPtr := flag.String("apply-patch", "", "Apply this RFC 6902 JSON Patch file, such as one written by fj diff -emit-patch")
+	pipelinePtr := flag.String("pipeline", "", "Transform the document with the steps listed in this pipeline file")
+	fromPtr := flag.String("from", "json", "Input format: "+stream.Names()+" or "+convert.Names()+" (automatic for files with those extensions)")
+	toPtr := flag.String("to", "json", "Output format: "+stream.Names()+", shell or "+convert.Names())
+	xmlAttrPrefixPtr := flag.String("xml-attr-prefix", "@", "Prefix of the keys holding XML attributes; empty reads attributes as child elements")
+	xmlTextKeyPtr := flag.String("xml-text-key", "#text", "Key holding the text of XML elements that also have attributes or children")
+	columnsPtr := flag.String("columns", "", "With -to csv or tsv, comma-separated columns to write, in this order, e.g. id,owner.login")
+	delimiterPtr := flag.String("delimiter", "", "Character separating CSV values instead of a comma, or a tab for tsv, e.g. ; or \\t")
+	noHeaderPtr := flag.Bool("no-header", false, "With -from csv or tsv, read the first row as data; rows become arrays, or objects keyed by -columns")
+	inferTypesPtr := flag.Bool("infer-types", false, "With -from csv or tsv, read numbers and booleans as such and empty values as null")
+	protoDescPtr := flag.String("proto-desc", "", "With -from proto, the descriptor set written by protoc --include_imports --descriptor_set_out")
+	protoTypePtr := flag.String("proto-type", "", "With -from proto, the full name of the message type, e.g. my.pkg.Message")
+	base64Ptr := flag.Bool("base64", false, "With -to msgpack, cbor or bson, write the binary output as base64 text")
+	shellExportPtr := flag.Bool("shell-export", false, "With -to shell, prefix every line with export")
+	slurpPtr := flag.Bool("slurp", false, "Combine documents written back to back into one array instead of formatting each")
+	appendPtr := flag.String("append", "", "Append the records to this JSON Lines file instead of printing them")
+	notifyPtr := flag.Bool("notify", false, "Show a desktop notification when fj finishes")
+	accessiblePtr := flag.Bool("accessible", defaultCfg.Accessible, "Describe the structure in words for screen readers")
 	versionPtr := flag.Bool("version", false, "Show version information")
 	helpPtr := flag.Bool("help", false, "Show help information")
 	saveConfigPtr := flag.Bool("save-config", false, "Save current flags as default configuration")
@@ -118,17 +703,269 @@ func parseFlags(defaultCfg config.Config) config.Config {
 		os.Exit(0)
 	}
 
+	// Fill in the options of the preset that were not given explicitly
+	if *presetPtr != "" {
+		if err := applyPreset(flag.CommandLine, *presetPtr, defaultCfg); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Choosing how keys are sorted implies sorting them, and preview limits imply a preview
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "sort-mode" || (f.Name == "sort-reverse" && *sortReversePtr) {
+			*sortPtr = true
+		}
+		if f.Name == "max-string-len" || f.Name == "max-array-items" {
+			*previewPtr = true
+		}
+	})
+	if !*previewPtr {
+		*maxStringLenPtr, *maxArrayItemsPtr = 0, 0
+	}
+
+	// Tabs are a shorthand for -indent-str, which takes escapes like \t
+	if *indentTabsPtr {
+		if *indentStrPtr != "" && *indentStrPtr != `\t` && *indentStrPtr != "\t" {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -indent-tabs cannot be combined with -indent-str\n")
+			os.Exit(1)
+		}
+		*indentStrPtr = "\t"
+	}
+	indentStr, err := unescape(*indentStrPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -indent-str: %v\n", err)
+		os.Exit(1)
+	}
+	*indentStrPtr = indentStr
+
 	// Create config from flags
 	cfg := config.Config{
-		IndentSpaces:    *indentPtr,
-		SortKeys:        *sortPtr,
-		CopyToClipboard: *clipboardPtr,
-		OutputDir:       *outputDirPtr,
-		TrustAllURLs:    *trustPtr,
-		MaxMemoryMB:     defaultCfg.MaxMemoryMB,
-		MaxProcessors:   defaultCfg.MaxProcessors,
-		LogToFile:       defaultCfg.LogToFile,
-		LogFilePath:     defaultCfg.LogFilePath,
+		IndentSpaces:       *indentPtr,
+		IndentString:       *indentStrPtr,
+		SortKeys:           *sortPtr,
+		Minify:             *minifyPtr,
+		SortMode:           *sortModePtr,
+		SortReverse:        *sortReversePtr,
+		KeyPriority:        splitList(*keyPriorityPtr),
+		SortArraysBy:       *sortArraysPtr,
+		EscapeHTML:         *escapeHTMLPtr,
+		WrapWidth:          *wrapWidthPtr,
+		CopyToClipboard:    *clipboardPtr,
+		ClipboardLimitKB:   *clipboardLimitPtr,
+		ClipboardTimeoutMS: int(*clipboardTimeoutPtr / time.Millisecond),
+		ClipboardAsync:     *clipboardAsyncPtr,
+		OutputDir:          *outputDirPtr,
+		TrustAllURLs:       *trustPtr,
+		MaxMemoryMB:        *maxMemoryPtr,
+		MaxProcessors:      *procsPtr,
+		LogToFile:          defaultCfg.LogToFile,
+		LogFilePath:        defaultCfg.LogFilePath,
+		Accessible:         *accessiblePtr,
+		Color:              *colorPtr,
+		Theme:              *themePtr,
+		Themes:             defaultCfg.Themes,
+		Highlight:          splitList(*highlightPtr),
+		RedactPaths:        defaultCfg.RedactPaths,
+		DuplicateKeys:      *duplicatesPtr,
+		NonFinite:          *nonFinitePtr,
+		NumberFormat:       *numbersPtr,
+		ExponentAbove:      *expAbovePtr,
+		ExponentBelow:      *expBelowPtr,
+		Presets:            defaultCfg.Presets,
+		Compress:           *compressPtr,
+		SandboxDir:         *sandboxPtr,
+		LintRules:          defaultCfg.LintRules,
+		LintChecks:         defaultCfg.LintChecks,
+	}
+
+	switch *repairReportPtr {
+	case "text", "json", "none":
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -repair-report: unknown format %q (use text, json or none)\n", *repairReportPtr)
+		os.Exit(1)
+	}
+	switch *invalidUTF8Ptr {
+	case "", "error", "replace":
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -invalid-utf8: unknown mode %q (use error or replace)\n", *invalidUTF8Ptr)
+		os.Exit(1)
+	}
+	if _, err := formatter.ParseNonFinite(cfg.NonFinite); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -non-finite: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := formatter.ParseDuplicateKeys(cfg.DuplicateKeys); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := formatter.ParseKeySort(cfg.SortMode); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -sort-mode: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := formatter.ParseNumberFormat(cfg.NumberFormat); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	switch cfg.Color {
+	case "", "auto", "always", "never":
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "Error: unknown -color %q (use auto, always or never)\n", cfg.Color)
+		os.Exit(1)
+	}
+	if _, err := compress.Parse(cfg.Compress); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -compress: %v\n", err)
+		os.Exit(1)
+	}
+	if *flattenPtr && *unflattenPtr {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -flatten and -unflatten cannot be combined\n")
+		os.Exit(1)
+	}
+	if _, err := jsonpath.CompileAll(splitList(*redactPtr)); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -redact: %v\n", err)
+		os.Exit(1)
+	}
+	pick, err := jsonpath.CompileAll(splitList(*pickPtr))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -pick: %v\n", err)
+		os.Exit(1)
+	}
+	omit, err := jsonpath.CompileAll(splitList(*omitPtr))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -omit: %v\n", err)
+		os.Exit(1)
+	}
+	if *separatorPtr == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -separator cannot be empty\n")
+		os.Exit(1)
+	}
+	renames, err := parseRenames(renameFlags, *renameFilePtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var renameKeys transform.KeyCase
+	if *renameKeysPtr != "" {
+		if renameKeys, err = transform.ParseKeyCase(*renameKeysPtr); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -rename-keys: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var q *query.Query
+	if *queryPtr != "" {
+		if q, err = query.Parse(*queryPtr); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -q: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var grep *regexp.Regexp
+	if *grepPtr != "" {
+		if grep, err = regexp.Compile(*grepPtr); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -grep: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var where *query.Query
+	if *wherePtr != "" {
+		if where, err = query.ParsePredicate(*wherePtr); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -where: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	aggregates, err := transform.ParseAggregates(*aggPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -agg: %v\n", err)
+		os.Exit(1)
+	}
+	// Other data formats are converted to JSON on input, and are picked by
+	// file extension as well
+	convertFrom, _ := convert.ParseFormat(*fromPtr)
+	if *fromPtr == "json" && flag.NArg() > 0 {
+		convertFrom = convert.Detect(flag.Arg(0))
+	}
+	from := stream.JSON
+	if convertFrom == "" {
+		from, err = stream.ParseFormat(*fromPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -from: unknown format %q (use %s or %s)\n", *fromPtr, stream.Names(), convert.Names())
+			os.Exit(1)
+		}
+	}
+	// Shell variables and other data formats are not stream formats, but are
+	// picked with -to as well
+	shellOutput := *toPtr == "shell"
+	convertTo, _ := convert.ParseFormat(*toPtr)
+	to := stream.JSON
+	if !shellOutput && convertTo == "" {
+		to, err = stream.ParseFormat(*toPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -to: unknown format %q (use %s, shell or %s)\n", *toPtr, stream.Names(), convert.Names())
+			os.Exit(1)
+		}
+	}
+	if convertTo == convert.Proto {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -to: proto can only be read, with -from proto\n")
+		os.Exit(1)
+	}
+	if (shellOutput || convertTo != "") && *appendPtr != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -append writes records and cannot be combined with -to %s\n", *toPtr)
+		os.Exit(1)
+	}
+	if *canonicalPtr && (*toPtr != "json" || *appendPtr != "") {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -canonical writes a single JSON document and cannot be combined with -to or -append\n")
+		os.Exit(1)
+	}
+	if *checkPtr && (*fromPtr != "json" || *toPtr != "json" || *appendPtr != "") {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -check compares JSON files with their formatted text and cannot be combined with -from, -to or -append\n")
+		os.Exit(1)
+	}
+	if *statePtr != "" && !*checkPtr {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -state records the progress of -check and needs it\n")
+		os.Exit(1)
+	}
+	conversion := convert.Options{
+		AttrPrefix: *xmlAttrPrefixPtr,
+		TextKey:    *xmlTextKeyPtr,
+		Columns:    splitList(*columnsPtr),
+		NoHeader:   *noHeaderPtr,
+		InferTypes: *inferTypesPtr,
+	}
+	if *delimiterPtr != "" {
+		conversion.Comma, err = parseDelimiter(*delimiterPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -delimiter: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if convertFrom == convert.Proto {
+		if *protoDescPtr == "" || *protoTypePtr == "" {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -from proto needs -proto-desc and -proto-type\n")
+			os.Exit(1)
+		}
+		desc, err := os.ReadFile(*protoDescPtr)
+		if err == nil {
+			conversion.ProtoSchema, err = convert.ParseProtoSchema(desc)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -proto-desc: %v\n", err)
+			os.Exit(1)
+		}
+		if !conversion.ProtoSchema.Has(*protoTypePtr) {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: -proto-type: %s is not a message type in %s\n", *protoTypePtr, *protoDescPtr)
+			os.Exit(1)
+		}
+		conversion.ProtoType = *protoTypePtr
+	} else if *protoDescPtr != "" || *protoTypePtr != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -proto-desc and -proto-type only apply to -from proto\n")
+		os.Exit(1)
+	}
+	if *base64Ptr && !convertTo.Binary() {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -base64 only applies to binary output, such as -to cbor\n")
+		os.Exit(1)
+	}
+	// Appending always writes records, as JSON Lines unless -to says otherwise
+	if *appendPtr != "" && to == stream.JSON {
+		to = stream.NDJSON
 	}
 
 	// Save config if requested
@@ -140,54 +977,454 @@ func parseFlags(defaultCfg config.Config) config.Config {
 		}
 	}
 
-	return cfg
+	return cfg, runOptions{
+		noRedact:       *noRedactPtr,
+		redactPaths:    splitList(*redactPtr),
+		strictIJSON:    *strictPtr || *rejectScalarsPtr,
+		rejectScalars:  *rejectScalarsPtr,
+		pointer:        *pointerPtr,
+		query:          q,
+		where:          where,
+		grep:           grep,
+		groupBy:        *groupByPtr,
+		aggregates:     aggregates,
+		raw:            *rawPtr,
+		pipeline:       *pipelinePtr,
+		template:       *templatePtr,
+		templateEach:   *templateEachPtr,
+		pick:           pick,
+		omit:           omit,
+		mergePatch:     *mergePatchPtr,
+		applyPatch:     *applyPatchPtr,
+		from:           from,
+		convertFrom:    convertFrom,
+		to:             to,
+		appendPath:     *appendPtr,
+		slurp:          *slurpPtr,
+		shell:          shellOutput,
+		convertTo:      convertTo,
+		base64:         *base64Ptr,
+		conversion:     conversion,
+		shellExport:    *shellExportPtr,
+		notify:         *notifyPtr,
+		fromPython:     *fromPythonPtr,
+		repairReport:   *repairReportPtr,
+		invalidUTF8:    *invalidUTF8Ptr,
+		fixInteractive: *fixInteractivePtr,
+		jsonc:          *jsoncPtr || *keepCommentsPtr || strings.HasSuffix(strings.ToLower(flag.Arg(0)), ".jsonc"),
+		keepComments:   *keepCommentsPtr,
+		canonical:      *canonicalPtr,
+		check:          *checkPtr,
+		state:          *statePtr,
+		maxDepth:       *maxDepthPtr,
+		maxStringLen:   *maxStringLenPtr,
+		maxArrayItems:  *maxArrayItemsPtr,
+		embedded:       *parseEmbeddedPtr || *embeddedPathsPtr != "",
+		embeddedPaths:  splitList(*embeddedPathsPtr),
+		renames:        renames,
+		renameKeys:     renameKeys,
+		prune: transform.PruneOptions{
+			Null:            *omitNullPtr,
+			EmptyStrings:    *omitEmptyStringsPtr,
+			EmptyContainers: *omitEmptyContainersPtr,
+		},
+		dedupe:    *dedupePtr,
+		dedupeBy:  *dedupeByPtr,
+		flatten:   *flattenPtr,
+		unflatten: *unflattenPtr,
+		separator: *separatorPtr,
+	}
+}
+
+// indentString returns the text of one indentation level
+func indentString(cfg config.Config) string {
+	if cfg.IndentString != "" {
+		return cfg.IndentString
+	}
+	return strings.Repeat(" ", cfg.IndentSpaces)
+}
+
+// unescape interprets Go escape sequences such as \t in a flag value, so
+// they can be typed in a shell without quoting tricks
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`)
+	if err != nil {
+		return "", fmt.Errorf("invalid escape in %q", s)
+	}
+	return unquoted, nil
+}
+
+// decodeJSON parses a single document, falling back to auto-correction when
+// it is malformed, and reports the fixes or asks about each of them. It exits
+// when the document cannot be repaired.
+func decodeJSON(data []byte, opts formatter.Options, repair formatter.RepairOptions, run runOptions) interface{} {
+	// Python literals are converted up front rather than reported as malformed JSON
+	if repair.Python {
+		converted, err := formatter.AutoCorrect(data, repair)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading Python literal: %v\n", err)
+			exit(1)
+		}
+		data = converted
+	}
+
+	value, err := formatter.Decode(data, opts)
+	if err == nil {
+		return value
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+	var syntaxErr *formatter.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Snippet != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", syntaxErr.Snippet)
+	}
+
+	// A duplicate key rejected by -duplicate-keys error is valid JSON that
+	// auto-correction cannot fix
+	var dupErr *formatter.DuplicateKeyError
+	if errors.As(err, &dupErr) {
+		exit(1)
+	}
+
+	// Try auto-correction if parsing fails
+	_, _ = fmt.Fprintf(os.Stderr, "Attempting to auto-correct JSON...\n")
+	report, corrErr := formatter.Repair(data, repair)
+	if corrErr != nil {
+		fmt.Fprintf(os.Stderr, "Auto-correction failed: %v\n", corrErr)
+		exit(1)
+	}
+
+	// In interactive mode only the fixes the user accepts are applied
+	corrected := report.Output
+	if run.fixInteractive {
+		if corrected, err = confirmFixes(data, report); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Try parsing again with corrected JSON
+	value, err = formatter.Decode(corrected, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting corrected JSON: %v\n", err)
+		exit(1)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Auto-correction successful!\n")
+	if !run.fixInteractive {
+		printRepairReport(report, run.repairReport)
+	}
+	if report.Truncated {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: repaired-truncated: the input ended early and was completed, so values may be missing\n")
+	}
+	return value
+}
+
+// maxReportedOffsets is the most offsets of invalid UTF-8 listed
+const maxReportedOffsets = 10
+
+// checkUTF8 reports the offsets of invalid UTF-8 in the input. In error mode
+// the run stops there; in replace mode each invalid sequence becomes U+FFFD
+// and formatting goes on.
+func checkUTF8(data []byte, mode string) []byte {
+	offsets := formatter.InvalidUTF8(data)
+	if len(offsets) == 0 {
+		return data
+	}
+
+	list := make([]string, 0, maxReportedOffsets)
+	for i, offset := range offsets {
+		if i == maxReportedOffsets {
+			list = append(list, fmt.Sprintf("and %d more", len(offsets)-i))
+			break
+		}
+		list = append(list, strconv.Itoa(offset))
+	}
+	where := "invalid UTF-8 at byte offset " + list[0]
+	if len(offsets) > 1 {
+		where = "invalid UTF-8 at byte offsets " + strings.Join(list, ", ")
+	}
+
+	if mode == "error" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", where)
+		exit(1)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Warning: %s, replaced with U+FFFD\n", where)
+	return formatter.ReplaceInvalidUTF8(data)
+}
+
+// maxReportedFixes is the most fixes listed in a text repair report
+const maxReportedFixes = 20
+
+// printRepairReport lists the fixes auto-correction made on stderr: as text,
+// as one line of JSON for tools, or not at all
+func printRepairReport(report *formatter.RepairReport, format string) {
+	switch format {
+	case "none":
+		return
+	case "json":
+		line, _ := json.Marshal(report)
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", line)
+		return
+	}
+
+	noun := "fixes"
+	if len(report.Fixes) == 1 {
+		noun = "fix"
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Applied %d %s:\n", len(report.Fixes), noun)
+	for i, fix := range report.Fixes {
+		if i == maxReportedFixes {
+			_, _ = fmt.Fprintf(os.Stderr, "  … and %d more (use -repair-report json to see them all)\n", len(report.Fixes)-i)
+			break
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "  %s\n", fix)
+	}
+}
+
+// repairOptions builds the auto-correction options for a configuration
+func repairOptions(cfg config.Config) formatter.RepairOptions {
+	return formatter.RepairOptions{
+		NonFinite: formatter.NonFinite(cfg.NonFinite),
+	}
+}
+
+// formatOptions builds the formatter options for a configuration
+func formatOptions(cfg config.Config) formatter.Options {
+	return formatter.Options{
+		IndentSpaces:  cfg.IndentSpaces,
+		IndentString:  cfg.IndentString,
+		SortKeys:      cfg.SortKeys,
+		KeySort:       formatter.KeySort(cfg.SortMode),
+		ReverseKeys:   cfg.SortReverse,
+		KeyPriority:   cfg.KeyPriority,
+		KeyOrder:      formatter.NewKeyOrder(),
+		Compact:       cfg.Minify,
+		EscapeHTML:    cfg.EscapeHTML,
+		WrapWidth:     cfg.WrapWidth,
+		Highlight:     cfg.Highlight,
+		DuplicateKeys: formatter.DuplicateKeys(cfg.DuplicateKeys),
+		Numbers:       formatter.NumberFormat(cfg.NumberFormat),
+		ExponentAbove: cfg.ExponentAbove,
+		ExponentBelow: cfg.ExponentBelow,
+	}
+}
+
+// formatCompact writes a value as one line of JSON, keeping the key order,
+// sorting, escaping and number notation of opts but none of its layout,
+// previews or colors
+func formatCompact(v interface{}, opts formatter.Options) ([]byte, error) {
+	opts.Compact = true
+	opts.MaxDepth = 0
+	opts.MaxStringLen = 0
+	opts.MaxArrayItems = 0
+	opts.Theme = nil
+	opts.Highlight = nil
+	opts.Comments = nil
+	return formatter.FormatValue(v, opts)
+}
+
+// applyMergePatch applies the merge patch read from source, a file, a URL
+// or - for stdin, to value. The key order of both is recorded in order.
+func applyMergePatch(value interface{}, source string, cfg config.Config, order *formatter.KeyOrder) (interface{}, error) {
+	data, err := readSource(source, cfg.TrustAllURLs)
+	if err != nil {
+		return nil, err
+	}
+	patchOpts := formatOptions(cfg)
+	patchOpts.KeyOrder = order
+	patch, err := formatter.Decode(data, patchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return transform.MergePatch(value, patch, order), nil
+}
+
+// applyJSONPatch applies the JSON Patch read from source, a file, a URL or
+// - for stdin, to value. The key order of both is recorded in order.
+func applyJSONPatch(value interface{}, source string, cfg config.Config, order *formatter.KeyOrder) (interface{}, error) {
+	data, err := readSource(source, cfg.TrustAllURLs)
+	if err != nil {
+		return nil, err
+	}
+	patchOpts := formatOptions(cfg)
+	patchOpts.KeyOrder = order
+	doc, err := formatter.Decode(data, patchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	ops, err := diff.DecodePatch(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return diff.ApplyPatch(value, ops, order)
+}
+
+// applyTemplate runs value, or each element of it when each is set, through
+// the template in path and parses what the template writes as JSON
+func applyTemplate(value interface{}, path string, each bool, opts formatter.Options) (interface{}, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := tmpl.Parse(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	reshape := func(v interface{}) (interface{}, error) {
+		out, err := tmpl.Render(t, v)
+		if err != nil {
+			return nil, err
+		}
+		reshaped, err := formatter.Decode(out, opts)
+		if err != nil {
+			return nil, fmt.Errorf("the template did not write valid JSON (the json function quotes values): %v", err)
+		}
+		return reshaped, nil
+	}
+
+	if !each {
+		return reshape(value)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("-template-each needs an array")
+	}
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		if out[i], err = reshape(item); err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return out, nil
+}
+
+// parseRenames builds the renames given with -rename, followed by those of
+// the -rename-file mapping, so that a flag overrides the file
+func parseRenames(specs []string, file string) ([]transform.Rename, error) {
+	renames := make([]transform.Rename, 0, len(specs))
+	for _, spec := range specs {
+		r, err := transform.ParseRename(spec)
+		if err != nil {
+			return nil, fmt.Errorf("-rename: %v", err)
+		}
+		renames = append(renames, r)
+	}
+	if file == "" {
+		return renames, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("-rename-file: %v", err)
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("-rename-file: %s must be a JSON object mapping paths to new key names: %v", file, err)
+	}
+	fromFile, err := transform.RenamesFromMap(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("-rename-file: %s: %v", file, err)
+	}
+	return append(renames, fromFile...), nil
+}
+
+// applyPipeline runs the steps of a pipeline file over value
+func applyPipeline(value interface{}, path string, order *formatter.KeyOrder) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipeline: %v", err)
+	}
+	// YAML pipelines are read as the JSON they convert to
+	if convert.Detect(path) == convert.YAML {
+		if data, err = convert.ToJSON(data, convert.YAML, convert.DefaultOptions()); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	p, err := transform.ParsePipeline(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p.Apply(value, order)
+}
+
+// reportSkipped lists on stderr the records of a stream that were skipped
+// as malformed, returning false when err is another error
+func reportSkipped(err error, from stream.Format) bool {
+	var skipped *stream.SkippedRecords
+	if !errors.As(err, &skipped) {
+		return false
+	}
+	for _, e := range skipped.Errors {
+		_, _ = fmt.Fprintf(os.Stderr, "Skipped %s %v\n", from, e)
+	}
+	return true
+}
+
+// rawLines joins records that are all strings into lines of text, for -r
+func rawLines(records []interface{}) (string, bool) {
+	lines := make([]string, len(records))
+	for i, record := range records {
+		s, ok := record.(string)
+		if !ok {
+			return "", false
+		}
+		lines[i] = s
+	}
+	return strings.Join(lines, "\n"), len(lines) > 0
+}
+
+// redact replaces the values at the redaction paths of cfg and at the paths
+// given with -redact for this run. When redaction was disabled for this run,
+// only the paths given with -redact are redacted.
+func redact(value interface{}, cfg config.Config, run runOptions) (interface{}, error) {
+	var paths []string
+	if !run.noRedact {
+		paths = append(paths, cfg.RedactPaths...)
+	}
+	paths = append(paths, run.redactPaths...)
+	if len(paths) == 0 {
+		return value, nil
+	}
+
+	patterns, err := jsonpath.CompileAll(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return transform.Redact(value, patterns), nil
 }
 
 // getInput reads JSON input from URL, stdin or file
 func getInput(trustAllURLs bool) ([]byte, error) {
 	args := flag.Args()
 
-	// No args, so we check if it's from terminal or is from a pipe
+	// No args, so the document comes from a pipe or is pasted in the terminal
 	if len(args) <= 0 {
-		// Check type of file from stdin
-		file, err := os.Stdin.Stat()
-		if err != nil {
-			return nil, fmt.Errorf("failed to stat stdin: %v", err)
-		}
-		if (file.Mode() & os.ModeCharDevice) != 0 {
-			return io.ReadAll(os.Stdin)
-		}
-		return nil, errors.New("no input file specified in pipe")
+		return readStdin()
 	}
 
 	// We have args, so we can treat the first one
 	input := strings.TrimSpace(args[0])
 
 	// 1. URL Handling
-	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
-		// Security prompt for URLs unless trust-all is enabled
-		if !trustAllURLs {
-			fmt.Printf("Do you trust the URL: %s? [y/n] ", input)
-			var response string
-			_, err := fmt.Scanln(&response)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read input from URL: %v", err)
-			}
-
-			if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
-				return nil, fmt.Errorf("URL access denied by user")
-			}
-		}
-
-		return readFromURL(input)
+	if isURL(input) {
+		return fetchURL(input, trustAllURLs)
 	}
 
 	// 2. We try to read a file
-	inputFile, err := os.ReadFile(input)
+	inputFile, err := readFile(input)
 	// If no err, we got a file
 	if err == nil {
 		return inputFile, nil
 	}
+	if errors.Is(err, errInputLimit) {
+		return nil, err
+	}
 	// 3. We have an error while reading the file, so we treat it as a raw JSON string
 	if !json.Valid([]byte(input)) {
 		return nil, errors.New("invalid JSON input")
@@ -195,9 +1432,280 @@ func getInput(trustAllURLs bool) ([]byte, error) {
 	return []byte(input), nil
 }
 
+// parseDelimiter reads a CSV delimiter: a single character, or \t or tab
+// for a tab
+func parseDelimiter(value string) (rune, error) {
+	if value == `\t` || value == "tab" {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("%q is not a valid delimiter, which must be a single character other than a quote or line break", value)
+	}
+	return r, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listFlag collects the values of a flag that can be repeated
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseInterspersed parses the flags of a subcommand wherever they appear
+// among its arguments, as in "fj gostruct doc.json -pkg models", rather than
+// stopping at the first argument that is not a flag. Arguments after "--" are
+// never flags. Afterwards fs.Args() holds the other arguments in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		// Collect the arguments up to the next flag, then parse from there
+		i := 0
+		for i < len(rest) && (rest[i] == "-" || !strings.HasPrefix(rest[i], "-")) {
+			i++
+		}
+		positional = append(positional, rest[:i]...)
+		if i == len(rest) {
+			break
+		}
+		args = rest[i:]
+	}
+	// Parsing only a terminator leaves fs.Args() holding the arguments
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// copyToClipboard copies the output, guarding against payloads larger than
+// the configured limit that clipboard tools handle badly. On a terminal the
+// user chooses between copying anyway, copying the path of a file holding the
+// output, or skipping; otherwise the file path is copied.
+func copyToClipboard(output []byte, cfg config.Config) {
+	limit := cfg.ClipboardLimitKB * 1024
+	if limit <= 0 || len(output) <= limit {
+		copyText(string(output), cfg)
+		return
+	}
+
+	choice := "p"
+	if isTerminal(os.Stdin) {
+		_, _ = fmt.Fprintf(os.Stderr, "The output is %s, over the clipboard limit of %s. Copy [t]ext, file [p]ath or [n]othing? [p] ",
+			formatSize(len(output)), formatSize(limit))
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if response = strings.ToLower(strings.TrimSpace(response)); response != "" {
+			choice = response[:1]
+		}
+	}
+
+	switch choice {
+	case "t":
+		copyText(string(output), cfg)
+	case "n":
+		fmt.Println("Skipped copying to clipboard.")
+	default:
+		ctx, cancel := clipboardContext(cfg)
+		defer cancel()
+		path, err := clipboard.CopyAsFileContext(ctx, string(output))
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to copy to clipboard: %v\n", err)
+			return
+		}
+		fmt.Printf("Output is %s; copied the path of %s to clipboard!\n", formatSize(len(output)), path)
+	}
+}
+
+// copyText copies text, in the background or within the configured timeout
+func copyText(text string, cfg config.Config) {
+	if cfg.ClipboardAsync {
+		if err := clipboard.Start(text); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to copy to clipboard: %v\n", err)
+		} else {
+			fmt.Println("Copying to clipboard in the background.")
+		}
+		return
+	}
+
+	ctx, cancel := clipboardContext(cfg)
+	defer cancel()
+
+	if err := clipboard.CopyContext(ctx, text); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to copy to clipboard: %v\n", err)
+	} else {
+		fmt.Println("Copied to clipboard!")
+	}
+}
+
+// clipboardContext bounds a clipboard program by the configured timeout and
+// stops it on an interrupt
+func clipboardContext(cfg config.Config) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(cfg.ClipboardTimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = clipboard.DefaultTimeout
+	}
+	return context.WithTimeout(interruptCtx, timeout)
+}
+
+// formatSize formats a byte count for messages, e.g. "1.5 MB"
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// colorize renders the value again using the configured theme.
+// On any theme error a warning is printed and the plain output is returned.
+func colorize(value interface{}, formattedJSON []byte, opts formatter.Options, cfg config.Config) []byte {
+	theme, err := formatter.LookupTheme(cfg.Theme, cfg.Themes)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return formattedJSON
+	}
+
+	opts.Theme = &theme
+	colored, err := formatter.FormatValue(value, opts)
+	if err != nil {
+		return formattedJSON
+	}
+
+	return colored
+}
+
+// useColor reports whether output should be colored. In auto mode, the
+// default, that is when stdout is a terminal and NO_COLOR is not set
+// (https://no-color.org); always and never override both.
+func useColor(cfg config.Config) bool {
+	switch cfg.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (info.Mode() & os.ModeCharDevice) != 0
+}
+
+// readSource reads a document named on the command line: "-" for stdin, a URL or a file path
+func readSource(source string, trustAllURLs bool) ([]byte, error) {
+	switch {
+	case source == "-":
+		return readStdin()
+	case isURL(source):
+		return fetchURL(source, trustAllURLs)
+	default:
+		return readFile(source)
+	}
+}
+
+// pasteEnd is the line that ends paste mode, as an alternative to Ctrl-D
+const pasteEnd = "."
+
+// readStdin reads a document from stdin. On a terminal it prompts for the
+// document to be pasted, instead of silently waiting for input.
+func readStdin() ([]byte, error) {
+	if !isTerminal(os.Stdin) {
+		return readAll(os.Stdin)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Paste JSON, finish with Ctrl-D or a line containing only %s\n", pasteEnd)
+	return readPaste(os.Stdin)
+}
+
+// readPaste reads lines until end of input or a line containing only pasteEnd
+func readPaste(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == pasteEnd {
+			break
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pasted input: %v", err)
+	}
+	if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+		return nil, errors.New("no input was pasted")
+	}
+	return buf.Bytes(), nil
+}
+
+// isURL reports whether input looks like an HTTP(S) URL
+func isURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// fetchURL asks the user to confirm the URL unless trustAllURLs is set, then downloads it
+func fetchURL(url string, trustAllURLs bool) ([]byte, error) {
+	if err := confirmURL(url, trustAllURLs); err != nil {
+		return nil, err
+	}
+	return readFromURL(url)
+}
+
+// confirmURL asks whether to trust a URL, unless trust-all is enabled
+func confirmURL(url string, trustAllURLs bool) error {
+	if trustAllURLs {
+		return nil
+	}
+	// The prompt goes to stderr, so it does not end up in redirected output
+	_, _ = fmt.Fprintf(os.Stderr, "Do you trust the URL: %s? [y/n] ", url)
+	var response string
+	_, err := fmt.Scanln(&response)
+	if err != nil {
+		return fmt.Errorf("failed to read input from URL: %v", err)
+	}
+
+	if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
+		return fmt.Errorf("URL access denied by user")
+	}
+	return nil
+}
+
 // readFromURL fetches JSON from a URL
 func readFromURL(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(interruptCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -207,11 +1715,11 @@ func readFromURL(url string) ([]byte, error) {
 		return nil, fmt.Errorf("HTTP request failed with status code: %d", resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	return readAll(resp.Body)
 }
 
-// generateOutputPath generates a file path for saving output
-func generateOutputPath(outputDir string) string {
+// generateOutputPath generates a file path with the given extension for saving output
+func generateOutputPath(outputDir, ext string) string {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to create output directory: %v\n", err)
@@ -220,14 +1728,76 @@ func generateOutputPath(outputDir string) string {
 
 	// Generate filename based on current time
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("json_%s.json", timestamp)
+	filename := fmt.Sprintf("json_%s%s", timestamp, ext)
 
 	return filepath.Join(outputDir, filename)
 }
 
+// checkSandbox rejects a path outside the configured sandbox directory
+func checkSandbox(cfg config.Config, path string) error {
+	if cfg.SandboxDir == "" {
+		return nil
+	}
+	return sandbox.Check(cfg.SandboxDir, path)
+}
+
 // saveToFile saves data to a file
 func saveToFile(data []byte, path string) error {
-	return os.WriteFile(path, data, 0644)
+	return writeFile(path, data, 0644)
+}
+
+// appendRecords adds stream records to the end of a file, creating it if
+// needed. The file stays locked while writing, so records from concurrent
+// invocations never interleave. With noFollow, a symbolic link is refused
+// rather than followed, so that a link swapped in after the sandbox check
+// cannot lead the records out of the sandbox.
+func appendRecords(path string, data []byte, noFollow bool) error {
+	flags := os.O_RDWR | os.O_APPEND | os.O_CREATE
+	if noFollow {
+		flags |= oNoFollow
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if noFollow {
+			if info, lerr := os.Lstat(path); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("%s is a symbolic link, which is not followed with a sandbox directory", path)
+			}
+		}
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	unlock, err := filelock.Lock(f)
+	if err != nil {
+		return fmt.Errorf("locking %s: %v", path, err)
+	}
+	defer func() { _ = unlock() }()
+
+	// Start on a new line when the last record was not terminated
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	return writeInterruptible(f, data, path)
+}
+
+// saveCompressed saves data to a file, compressing it first
+func saveCompressed(data []byte, path string, method compress.Method) error {
+	compressed, err := compress.Compress(data, method)
+	if err != nil {
+		return fmt.Errorf("compressing output: %v", err)
+	}
+	return saveToFile(compressed, path)
 }
 
 // showHelp displays help information
@@ -236,13 +1806,157 @@ func showHelp() {
 
 Usage:
   fj [options] [file|url]
+  fj <command> [options] [arguments]
+
+Commands:
+  diff old new      Show structural differences between two documents
+  assert -rule expr Check that rules hold for a document, e.g. '.price >= 0'
+  profile           Summarize numeric fields of an array of records or NDJSON
+  resolve file      Merge the JSON on both sides of git conflict markers
+  describe          Summarize the structure of a document in prose
+  schema sample s   Generate example documents from a JSON Schema
+  schema infer f... Write a JSON Schema that example documents conform to
+  mock file         Serve a JSON file over HTTP, e.g. -route /users=.users
+  template -tpl t   Render a document through a Go text/template
+  validate          Check documents for syntax errors, or with -schema s against a JSON Schema
+  lint              Report style problems such as inconsistent key casing or deep nesting
+  merge a b...      Deep-merge documents from left to right, e.g. config overlays
+  set doc p v       Insert or replace the value at a path, e.g. set f.json user.theme '"dark"'
+  del doc path...   Remove the entries at paths, e.g. del f.json items[3] metadata.debug
+  append doc p v... Add values to the array at a path, or insert them with -at index
+  paths             List the path and type of every leaf value, e.g. user.addresses[2].zip
+  gostruct          Print Go types a sample document decodes into, e.g. -pkg models -name Response
 
 Options:
   -indent int       Number of spaces for indentation (default 2)
+  -indent-str text  Text of one indentation level, replacing -indent, e.g. '\t'
+  -indent-tabs      Indent with tabs
   -sort             Sort object keys
+  -sort-mode mode   How -sort compares keys: ascii, ignore-case or natural (default ascii)
+  -sort-reverse     Sort keys in descending order
+  -key-priority list
+                    Comma-separated keys always written first, e.g. id,name
+  -sort-arrays-by key
+                    Order arrays of objects by the value of this field
+  -wrap-width n     Keep objects and arrays that fit in n columns on one line, e.g. [1, 2, 3]
+  -minify           Write output on one line without whitespace
+  -escape-html      Escape <, > and & in strings as \u003c, \u003e and \u0026
   -clipboard        Copy result to clipboard (default true)
+  -clipboard-limit n
+                    Largest output in KB copied as text; 0 means no limit (default 1024)
+  -clipboard-timeout d
+                    Give up on the clipboard program after this long (default 5s)
+  -clipboard-async  Copy in the background without waiting for the clipboard program
   -outdir string    Output directory for saved files
+  -compress method  Compress saved files: gzip, zstd or none
+  -sandbox-dir dir  Refuse to write files outside this directory
+  -max-memory MB    Memory limit, refusing inputs too large to parse within it (0: no limit)
+  -procs n          Most CPUs to use at once (0: all)
   -trust-all        Trust all URLs without prompting
+  -color when       When to color output: auto, always or never (default auto)
+  -theme string     Color theme for terminal output (dark, light, solarized, monochrome)
+  -highlight list   Comma-separated keys or paths to emphasize
+  -duplicate-keys s How to resolve repeated object keys: last, first, error or array (default last)
+  -non-finite s     What repair writes for NaN, Infinity and undefined: null or string
+                    (default null)
+  -numbers string   Number notation: auto, plain or exponent (default auto)
+  -exp-above float  With -numbers exponent, use exponent notation from this magnitude (default 1e21)
+  -exp-below float  With -numbers exponent, use exponent notation below this magnitude (default 1e-6)
+  -redact list      Comma-separated keys or paths to replace with "***", such as
+                    "password,token,*secret*"; adds to redact_paths in the config file
+  -no-redact        Do not redact the paths listed in the config file
+  -strict-ijson     Reject documents that are not valid I-JSON (RFC 7493)
+  -ijson-reject-scalars
+                    With -strict-ijson, also require a top-level object or array
+  -max-depth n      Show structures nested deeper than n collapsed, e.g. {…3 keys}
+  -preview          Shorten long strings and arrays on screen
+  -max-string-len n With -preview, longest string shown in full (default 120)
+  -max-array-items n
+                    With -preview, most array elements shown (default 20)
+  -canonical        Write RFC 8785 canonical JSON, for stable hashes and signatures
+  -check            Print a diff for each file that is not already formatted and exit
+                    with 1, without changing it; for CI and pre-commit hooks
+  -state file       With -check, record progress in file so an interrupted run resumes
+                    where it stopped
+  -invalid-utf8 mode
+                    Check the input for invalid UTF-8: error to refuse it, replace to
+                    write U+FFFD instead
+  -repair-report f  How to list the fixes made by auto-correction on stderr: text, json
+                    or none (default text)
+  -fix-interactive  Show each fix auto-correction proposes as a diff and ask before
+                    applying it
+  -from-python      Read the output of Python's repr(): True, False and None, tuples and
+                    single-quoted strings become JSON
+  -jsonc            Accept // and /* */ comments in the input (automatic for .jsonc files)
+  -keep-comments    Accept comments in the input and write them back into the output
+  -pointer string   Print only the value at this JSON Pointer, e.g. /data/items/0
+  -grep regex       Print the paths of the keys and string values matching a regular expression,
+                    with their context
+  -where expr       Keep only the array elements matching an expression, e.g.
+                    'status == "active" && age > 30'
+  -group-by field   Bucket the array elements by this field and print statistics for each group
+  -agg list         With -group-by, comma-separated statistics: count, sum(f), avg(f), min(f)
+                    and max(f) (default count)
+  -q expr           Print the outputs of a jq-like expression, e.g.
+                    '.items[] | select(.price > 10) | .name'
+  -r                Print string results without quotes
+  -preset name      Apply a named set of options: diff, human, ci or one from the config file
+  -parse-embedded   Expand string values that hold a JSON object or array
+  -embedded-paths list
+                    With -parse-embedded, comma-separated keys or paths to expand
+  -rename path=name Rename the keys matching a path, e.g. user.name=fullName or *_id=id;
+                    can be repeated
+  -rename-file file Rename keys as listed in a JSON file mapping paths to new names
+  -rename-keys case
+                    Convert every object key to camel, pascal, snake or kebab case
+  -dedupe           Remove repeated elements from every array
+  -dedupe-by key    Remove array elements that repeat the value of this field in an earlier one
+  -omit-null        Drop object entries whose value is null
+  -omit-empty-strings
+                    Drop object entries whose value is an empty string
+  -omit-empty-containers
+                    Drop object entries whose value is an empty object or array
+  -flatten          Turn nested values into one object with a.b[0].c keys
+  -unflatten        Rebuild nested values from an object with a.b[0].c keys
+  -separator text   With -flatten or -unflatten, the text joining object keys (default .)
+  -transform-template file
+                    Reshape the document with a Go text/template file whose output is JSON
+  -template-each    With -transform-template, run the template once for each element of the array
+  -pick list        Comma-separated keys or paths to keep, dropping everything else,
+                    e.g. a.b,c
+  -omit list        Comma-separated keys or paths to drop, e.g. metadata,debug.*
+  -merge-patch file Apply an RFC 7386 JSON Merge Patch, where null deletes a key
+  -apply-patch file Apply an RFC 6902 JSON Patch, such as one written by fj diff -emit-patch
+  -pipeline file    Transform the document with the steps listed in a pipeline file
+  -from format      Input format: json, ndjson, json-seq, concat, yaml, toml, xml, csv, tsv,
+                    msgpack, cbor, bson or proto (default json, or the format named by the
+                    file extension)
+  -to format        Output format: json, ndjson, json-seq, concat, shell, yaml, toml, xml,
+                    csv, tsv, msgpack, cbor or bson (default json)
+  -xml-attr-prefix prefix
+                    Prefix of the keys holding XML attributes (default @); empty reads
+                    attributes as child elements
+  -xml-text-key key Key holding the text of XML elements that also have attributes or
+                    children (default #text)
+  -columns list     With -to csv or tsv, comma-separated columns to write, in this order,
+                    e.g. id,owner.login
+  -delimiter char   Character separating CSV values instead of a comma, or a tab for tsv,
+                    e.g. ; or \t
+  -no-header        With -from csv or tsv, read the first row as data; rows become arrays,
+                    or objects keyed by -columns
+  -infer-types      With -from csv or tsv, read numbers and booleans as such and empty
+                    values as null
+  -proto-desc file  With -from proto, the descriptor set written by
+                    protoc --include_imports --descriptor_set_out
+  -proto-type name  With -from proto, the full name of the message type, e.g.
+                    my.pkg.Message
+  -base64           With -to msgpack, cbor or bson, write the binary output as base64 text
+  -slurp            Combine documents written back to back into one array instead of
+                    formatting each
+  -shell-export     With -to shell, prefix every line with export
+  -append file      Append the records to this JSON Lines file instead of printing them
+  -notify           Show a desktop notification when fj finishes, e.g. after a long download
+  -accessible       Describe the structure in words for screen readers
   -save-config      Save current flags as default configuration
   -version          Show version information
   -help             Show this help information
@@ -251,8 +1965,52 @@ Examples:
   fj file.json                  Format JSON from file
   fj https://example.com/data   Format JSON from URL
   cat file.json | fj            Format JSON from stdin
+  fj                            Paste JSON in the terminal, end with Ctrl-D or "."
   fj -indent 4 file.json        Format with 4-space indentation
+  fj -indent-tabs file.json     Format with tab indentation
   fj -sort file.json            Format with sorted keys
+  fj -sort-mode natural -key-priority id file.json
+                                Sort item2 before item10, with id first
+  fj -sort-arrays-by name users.json
+                                Order the users by name, e.g. for fixtures
+  fj -wrap-width 80 file.json   Keep short arrays and objects on one line
+  fj -minify file.json          Shrink a payload to one line
+  fj -pointer /data/0/name -r f.json
+                                Print one value as plain text
+  fj -keep-comments .vscode/settings.json
+                                Reformat a settings file without losing its comments
+  fj -max-depth 2 https://example.com/api/large
+                                Skim the top levels of a huge response
+  fj -preview -max-array-items 5 dump.json
+                                Skim a multi-MB document with giant blobs and lists
+  fj -canonical doc.json | sha256sum
+                                Hash a document independently of its layout
+  fj -check -sort config/*.json
+                                Fail a CI job when a file is not sorted and formatted
+  fj diff old.json new.json     Compare two documents
+  fj diff -emit-patch old.json new.json
+                                Print the differences as a JSON Patch
+  fj -from json-seq -to ndjson events.log
+                                Convert an RFC 7464 log stream to JSON Lines
+  fj -pointer /items -append items.ndjson https://example.com/orders
+                                Collect records from several runs in one file
+  eval "$(fj -pointer /db -to shell config.json)"
+                                Load fields into shell variables such as DB_HOST
+  fj -parse-embedded app.log.json
+                                Show "{\"a\":1}" payload strings as real objects
+  fj -rename-keys camel go-payload.json
+                                Turn Go-style UserID keys into JavaScript-style userId
+  fj -omit-null -omit-empty-containers generated.json
+                                Clean up a generated payload before committing it
+  fj -flatten -separator / config.json
+                                List every value with a path key such as db/hosts[0]
+  fj -pipeline clean.fj.json data.json
+                                Apply a reusable cleanup recipe
+  fj assert -rule '.items | length > 0' file.json
+  fj validate -output json *.json
+                                Report problems as JSON for CI and editors
+  fj validate -schema user.schema.json users/*.json
+                                Check documents against a JSON Schema
 
 Configuration:
   fj uses a configuration file stored in:
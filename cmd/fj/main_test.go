@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+// TestMain runs fj itself when a test starts the test binary with
+// FJ_TEST_MAIN set, so that the command line can be tested end to end
+func TestMain(m *testing.M) {
+	if os.Getenv("FJ_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs fj with args in a home directory of its own, and returns the
+// document it prints and its exit code
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	home := t.TempDir()
+	args = append([]string{"-clipboard=false", "-outdir", filepath.Join(home, "out")}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "FJ_TEST_MAIN=1", "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("running fj: %v", err)
+	}
+	if code != 0 {
+		t.Logf("fj %s: %s", strings.Join(args, " "), stderr.String())
+	}
+	// The document is followed by where it was saved
+	doc, _, _ := strings.Cut(stdout.String(), "\n")
+	return doc, code
+}
+
+func TestMainSortArraysBy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	input := `{"users":[{"name":"zoe","id":2},"guest",{"id":5},{"name":"ada","id":1},{"name":3,"id":4},{"name":null,"id":6}],"count":6}`
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			// null sorts before numbers and numbers before strings; elements
+			// without the field, and elements that are not objects, come last
+			name: "Missing and mixed-type keys",
+			args: []string{"-sort-arrays-by", "name"},
+			want: `{"users":[{"name":null,"id":6},{"name":3,"id":4},{"name":"ada","id":1},{"name":"zoe","id":2},"guest",{"id":5}],"count":6}`,
+		},
+		{
+			// -sort orders the keys of each element, not the elements
+			name: "With -sort",
+			args: []string{"-sort", "-sort-arrays-by", "name"},
+			want: `{"count":6,"users":[{"id":6,"name":null},{"id":4,"name":3},{"id":1,"name":"ada"},{"id":2,"name":"zoe"},"guest",{"id":5}]}`,
+		},
+		{
+			name: "By a field no element has",
+			args: []string{"-sort-arrays-by", "email"},
+			want: input,
+		},
+		{
+			name: "Without the flag",
+			args: []string{"-sort"},
+			want: `{"count":6,"users":[{"id":2,"name":"zoe"},"guest",{"id":5},{"id":1,"name":"ada"},{"id":4,"name":3},{"id":6,"name":null}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, code := runMain(t, append(tt.args, "-minify", path)...)
+			if code != 0 {
+				t.Fatalf("fj exited with %d", code)
+			}
+			if got != tt.want {
+				t.Errorf("fj %s =\n%s\nwant\n%s", strings.Join(tt.args, " "), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPkg  string
+		wantName string
+		wantArgs []string
+	}{
+		{"Flags after the document", []string{"doc.json", "-pkg", "models", "-name", "Response"}, "models", "Response", []string{"doc.json"}},
+		{"Flags first", []string{"-pkg", "models", "doc.json"}, "models", "Document", []string{"doc.json"}},
+		{"Flags around arguments", []string{"a.json", "-pkg=models", "b.json", "-name", "R", "c.json"}, "models", "R", []string{"a.json", "b.json", "c.json"}},
+		{"Stdin", []string{"-", "-pkg", "models"}, "models", "Document", []string{"-"}},
+		{"Terminator", []string{"-pkg", "models", "--", "-name", "x"}, "models", "Document", []string{"-name", "x"}},
+		{"No arguments", nil, "main", "Document", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("gostruct", flag.ContinueOnError)
+			pkg := fs.String("pkg", "main", "")
+			name := fs.String("name", "Document", "")
+			if err := parseInterspersed(fs, tt.args); err != nil {
+				t.Fatalf("parseInterspersed() error = %v", err)
+			}
+			if *pkg != tt.wantPkg || *name != tt.wantName {
+				t.Errorf("-pkg %q -name %q, want -pkg %q -name %q", *pkg, *name, tt.wantPkg, tt.wantName)
+			}
+			if got := fs.Args(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("gostruct", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := parseInterspersed(fs, []string{"doc.json", "-unknown"}); err == nil {
+		t.Error("parseInterspersed() accepted an unknown flag after an argument")
+	}
+}
+
+func TestParseInterspersedRepeatedFlags(t *testing.T) {
+	// The argument order of fj mock data.json --listen :8081 --route ...
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	listen := fs.String("listen", "localhost:8081", "")
+	var routes listFlag
+	fs.Var(&routes, "route", "")
+	args := []string{"data.json", "--listen", ":8081", "--route", "/users=.users", "--route", "/first=.users[0]"}
+	if err := parseInterspersed(fs, args); err != nil {
+		t.Fatalf("parseInterspersed() error = %v", err)
+	}
+	if *listen != ":8081" {
+		t.Errorf("-listen = %q, want :8081", *listen)
+	}
+	if want := (listFlag{"/users=.users", "/first=.users[0]"}); !reflect.DeepEqual(routes, want) {
+		t.Errorf("-route = %q, want %q", routes, want)
+	}
+	if got := fs.Args(); !reflect.DeepEqual(got, []string{"data.json"}) {
+		t.Errorf("Args() = %q, want [data.json]", got)
+	}
+}
+
+func TestRedactRunPaths(t *testing.T) {
+	cfg := config.Config{RedactPaths: []string{"password"}}
+	tests := []struct {
+		name string
+		run  runOptions
+		want map[string]interface{}
+	}{
+		{"Config paths", runOptions{}, map[string]interface{}{"password": "***", "token": "t", "name": "n"}},
+		{"Config and run paths", runOptions{redactPaths: []string{"token"}}, map[string]interface{}{"password": "***", "token": "***", "name": "n"}},
+		{"Run paths only", runOptions{noRedact: true, redactPaths: []string{"token"}}, map[string]interface{}{"password": "p", "token": "***", "name": "n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]interface{}{"password": "p", "token": "t", "name": "n"}
+			got, err := redact(doc, cfg, tt.run)
+			if err != nil {
+				t.Fatalf("redact() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !reflect.DeepEqual(cfg.RedactPaths, []string{"password"}) {
+		t.Errorf("redact() changed the config paths to %q", cfg.RedactPaths)
+	}
+}
+
+func TestAppendRecordsNoFollow(t *testing.T) {
+	if oNoFollow == 0 {
+		t.Skip("opening a file cannot refuse symbolic links here")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "outside.ndjson")
+	link := filepath.Join(dir, "link.ndjson")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := appendRecords(link, []byte("{}\n"), true); err == nil {
+		t.Error("appendRecords() through a link error = nil")
+	}
+	if _, err := os.Lstat(target); !os.IsNotExist(err) {
+		t.Errorf("appendRecords() created the link target: %v", err)
+	}
+
+	// Without a sandbox the link is followed
+	if err := appendRecords(link, []byte("{}\n"), false); err != nil {
+		t.Fatalf("appendRecords() error = %v", err)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "{}\n" {
+		t.Errorf("link target = %q, %v", data, err)
+	}
+}
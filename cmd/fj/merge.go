@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/transform"
+)
+
+// runMerge implements "fj merge [options] <doc> <doc>..."
+func runMerge(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	arraysPtr := fs.String("arrays", string(transform.ArrayReplace), "How to combine arrays: replace, concat or merge-by-key")
+	keyPtr := fs.String("key", "id", "With -arrays merge-by-key, the field that identifies an object")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj merge [options] <doc> <doc>...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Deep-merges documents from left to right, so later documents override earlier ones.\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Each document can be a file, a URL or - for stdin.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitCommandError
+	}
+
+	arrays, err := transform.ParseArrayMerge(*arraysPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -arrays: %v\n", err)
+		return exitCommandError
+	}
+	opts := formatOptions(cfg)
+	mergeOpts := transform.MergeOptions{Arrays: arrays, Key: *keyPtr, Order: opts.KeyOrder}
+	var merged interface{}
+	for i, source := range fs.Args() {
+		data, err := readSource(source, *trustPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+			return exitCommandError
+		}
+		value, err := formatter.Decode(data, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+			return exitCommandError
+		}
+
+		if i == 0 {
+			merged = value
+		} else {
+			merged = transform.DeepMerge(merged, value, mergeOpts)
+		}
+	}
+
+	merged, err = redact(merged, cfg, runOptions{noRedact: *noRedactPtr})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+		return exitCommandError
+	}
+	out, err := formatter.FormatValue(merged, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		return exitCommandError
+	}
+	fmt.Println(string(out))
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+func TestMergeRedactsOutput(t *testing.T) {
+	cfg := config.Config{RedactPaths: []string{"db.password"}}
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	local := filepath.Join(dir, "local.json")
+	files := map[string]string{
+		base:  `{"db":{"host":"localhost","password":"hunter2"}}`,
+		local: `{"db":{"host":"db.internal"}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{{base, local}, {"-no-redact", base, local}} {
+		var code int
+		out := captureStdout(t, func() {
+			code = runMerge(cfg, args)
+		})
+		if code != 0 {
+			t.Fatalf("merge %s exit code = %d", args, code)
+		}
+		if !strings.Contains(out, "db.internal") {
+			t.Errorf("merge %s did not merge:\n%s", args, out)
+		}
+		if shown, want := strings.Contains(out, "hunter2"), args[0] == "-no-redact"; shown != want {
+			t.Errorf("merge %s shows the password = %v, want %v:\n%s", args, shown, want, out)
+		}
+	}
+}
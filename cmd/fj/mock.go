@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/mock"
+)
+
+// runMock implements "fj mock [options] file"
+func runMock(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	listenPtr := fs.String("listen", "localhost:8081", "Address to listen on")
+	latencyPtr := fs.Duration("latency", 0, "Delay every response, e.g. 300ms")
+	statusPtr := fs.Int("status", http.StatusOK, "Status code of successful responses")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	var routes listFlag
+	fs.Var(&routes, "route", "Serve a part of the document as PATH=EXPR, e.g. /users=.users (repeatable)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj mock [options] file\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Serves a JSON file over HTTP. Without -route, / serves the whole document.\n")
+		_, _ = fmt.Fprintf(os.Stderr, "The file is read again on every request, so edits show up immediately.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := parseInterspersed(fs, args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitCommandError
+	}
+	if *statusPtr < 100 || *statusPtr > 999 {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: invalid -status %d\n", *statusPtr)
+		return exitCommandError
+	}
+
+	path := fs.Arg(0)
+	opts := formatOptions(cfg)
+	run := runOptions{noRedact: *noRedactPtr}
+	server := &mock.Server{
+		// Every load records the key order of the file in a fresh KeyOrder,
+		// so that responses follow the file as it is now
+		Load: func() (interface{}, *formatter.KeyOrder, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error reading %s: %v", path, err)
+			}
+			loadOpts := opts
+			loadOpts.KeyOrder = formatter.NewKeyOrder()
+			value, err := formatter.Decode(data, loadOpts)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error parsing %s: %v", path, err)
+			}
+			value, err = redact(value, cfg, run)
+			return value, loadOpts.KeyOrder, err
+		},
+		Format: func(v interface{}, order *formatter.KeyOrder) ([]byte, error) {
+			formatOpts := opts
+			formatOpts.KeyOrder = order
+			return formatter.FormatValue(v, formatOpts)
+		},
+		Latency: *latencyPtr,
+		Status:  *statusPtr,
+	}
+	for _, spec := range routes {
+		route, err := mock.ParseRoute(spec)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+		server.Routes = append(server.Routes, route)
+	}
+
+	// Fail early on a file that cannot be served, rather than on the first request
+	if _, _, err := server.Load(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Serving %s on http://%s\n", path, *listenPtr)
+	for _, route := range server.Routes {
+		_, _ = fmt.Fprintf(os.Stderr, "  %s -> %s\n", route.Path, route.Query)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		server.ServeHTTP(rec, r)
+		_, _ = fmt.Fprintf(os.Stderr, "%s %s %d %v\n", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+	if err := http.ListenAndServe(*listenPtr, handler); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+	return 0
+}
+
+// statusRecorder remembers the status code written to a response for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+// oNoFollow is 0 where opening a file cannot refuse a symbolic link; the
+// sandbox check, which resolves links, is the only guard there
+const oNoFollow = 0
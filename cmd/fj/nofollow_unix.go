@@ -0,0 +1,8 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// oNoFollow makes opening a file fail when it is a symbolic link
+const oNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/notify"
+)
+
+// notifyOnExit, when set by -notify, reports the outcome of the run before
+// fj exits
+var notifyOnExit func(code int)
+
+// exit ends the run with a status code, sending the -notify notification first
+func exit(code int) {
+	if notifyOnExit != nil {
+		notifyOnExit(code)
+	}
+	os.Exit(code)
+}
+
+// enableNotify makes exit send a desktop notification naming the source and
+// how long the run took
+func enableNotify(source string) {
+	start := time.Now()
+	notifyOnExit = func(code int) {
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		title, message := "fj finished", fmt.Sprintf("Formatted %s in %s", source, elapsed)
+		if code != 0 {
+			title, message = "fj failed", fmt.Sprintf("%s failed with exit status %d after %s", source, code, elapsed)
+		}
+
+		if err := notify.Send(title, message); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// runPaths implements "fj paths [options] [file|url|-]"
+func runPaths(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("paths", flag.ContinueOnError)
+	valuesPtr := fs.Bool("values", false, "Print the value of each leaf after its type")
+	pointersPtr := fs.Bool("pointers", false, "Write paths as JSON Pointers, e.g. /user/addresses/2/zip")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj paths [options] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Lists the path and type of every leaf value; stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return 1
+	}
+
+	opts := formatOptions(cfg)
+	value, err := formatter.Decode(data, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+		return 1
+	}
+	value, err = redact(value, cfg, runOptions{noRedact: *noRedactPtr})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+		return 1
+	}
+
+	// Leaves are listed in document order unless keys are sorted
+	keys := opts.KeyOrder.Keys
+	if cfg.SortKeys {
+		keys = nil
+	}
+	leaves := jsonpath.Leaves(value, keys)
+
+	paths := make([]string, len(leaves))
+	width := 0
+	for i, leaf := range leaves {
+		if *pointersPtr {
+			paths[i] = jsonpath.Pointer(leaf.Path)
+		} else {
+			paths[i] = jsonpath.String(leaf.Path)
+		}
+		if paths[i] == "" {
+			paths[i] = "(root)"
+		}
+		width = max(width, len(paths[i]))
+	}
+
+	var sb strings.Builder
+	for i, leaf := range leaves {
+		line := fmt.Sprintf("%-*s  %s", width, paths[i], leaf.Type())
+		if *valuesPtr {
+			text, err := formatCompact(leaf.Value, opts)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error formatting %s: %v\n", paths[i], err)
+				return 1
+			}
+			line = fmt.Sprintf("%-*s  %-7s  %s", width, paths[i], leaf.Type(), text)
+		}
+		sb.WriteString(line + "\n")
+	}
+	fmt.Print(sb.String())
+	return 0
+}
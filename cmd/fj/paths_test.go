@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+func TestPathsValuesUseFormatter(t *testing.T) {
+	doc := filepath.Join(t.TempDir(), "doc.json")
+	if err := os.WriteFile(doc, []byte(`{"tag": "<b>", "price": 1.10, "tags": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var code int
+	out := captureStdout(t, func() {
+		code = runPaths(config.Config{}, []string{"-values", doc})
+	})
+	if code != 0 {
+		t.Fatalf("runPaths() = %d, want 0", code)
+	}
+	want := "" +
+		"tag    string   \"<b>\"\n" +
+		"price  number   1.10\n" +
+		"tags   object   {}\n"
+	if out != want {
+		t.Errorf("runPaths() printed\n%s\nwant\n%s", out, want)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+// builtinPresets returns the presets available without any configuration.
+// Each maps option names to values, exactly as they would be given on the
+// command line.
+func builtinPresets() map[string]map[string]string {
+	return map[string]map[string]string{
+		// diff produces stable output that compares well line by line
+		"diff": {
+			"sort":       "true",
+			"indent":     "2",
+			"indent-str": "",
+			"color":      "never",
+			"numbers":    "auto",
+		},
+		// human favors reading in a terminal: containers that fit its width stay
+		// on one line and long strings and arrays are shortened
+		"human": {
+			"color":      "auto",
+			"theme":      "dark",
+			"wrap-width": strconv.Itoa(outputWidth()),
+			"preview":    "true",
+		},
+		// ci prints to stdout only, without colors or side effects
+		"ci": {
+			"color":     "never",
+			"clipboard": "false",
+			"outdir":    "",
+		},
+	}
+}
+
+// presetExcluded lists the options a preset cannot set
+var presetExcluded = map[string]bool{
+	"preset":      true,
+	"save-config": true,
+	"help":        true,
+	"version":     true,
+}
+
+// applyPreset sets the options of the named preset on fs, except for the
+// ones given explicitly on the command line. Presets from the config file
+// take precedence over built-in presets of the same name.
+func applyPreset(fs *flag.FlagSet, name string, cfg config.Config) error {
+	preset, ok := cfg.Presets[name]
+	if !ok {
+		preset, ok = builtinPresets()[name]
+	}
+	if !ok {
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(presetNames(cfg), ", "))
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	// Apply options in a stable order so that errors are reported consistently
+	options := make([]string, 0, len(preset))
+	for option := range preset {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+
+	for _, option := range options {
+		if presetExcluded[option] || fs.Lookup(option) == nil {
+			return fmt.Errorf("preset %q: unknown option %q", name, option)
+		}
+		if explicit[option] {
+			continue
+		}
+		if err := fs.Set(option, preset[option]); err != nil {
+			return fmt.Errorf("preset %q: invalid value %q for -%s: %v", name, preset[option], option, err)
+		}
+	}
+	return nil
+}
+
+// presetNames lists the built-in and configured presets
+func presetNames(cfg config.Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, presets := range []map[string]map[string]string{builtinPresets(), cfg.Presets} {
+		for name := range presets {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultOutputWidth is the width assumed when stdout is not a terminal
+const defaultOutputWidth = 80
+
+// outputWidth returns the width of the terminal stdout is attached to, as
+// the terminal reports it or, failing that, as COLUMNS gives it. Output
+// that is not going to a terminal gets defaultOutputWidth.
+func outputWidth() int {
+	if !isTerminal(os.Stdout) {
+		return defaultOutputWidth
+	}
+	if width := windowColumns(os.Stdout); width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultOutputWidth
+}
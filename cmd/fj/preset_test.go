@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestHumanPresetWidthOffTerminal(t *testing.T) {
+	// COLUMNS only stands in for a terminal that does not report its width
+	t.Setenv("COLUMNS", "132")
+	var width string
+	captureStdout(t, func() {
+		width = builtinPresets()["human"]["wrap-width"]
+	})
+	if width != "80" {
+		t.Errorf("human preset wrap-width with stdout piped = %s, want 80", width)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/profile"
+	"github.com/nicolasalberti00/fj/pkg/stream"
+)
+
+// runProfile implements "fj profile [options] [file|url|-]"
+func runProfile(cfg config.Config, args []string) int {
+	defaultPercentiles := make([]string, len(profile.DefaultPercentiles))
+	for i, p := range profile.DefaultPercentiles {
+		defaultPercentiles[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	percentilesPtr := fs.String("percentiles", strings.Join(defaultPercentiles, ","), "Comma-separated percentiles to compute")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj profile [options] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Input is a JSON array of records, NDJSON or a JSON text sequence; stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	var percentiles []float64
+	for _, item := range splitList(*percentilesPtr) {
+		p, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: invalid percentile %q\n", item)
+			return 1
+		}
+		percentiles = append(percentiles, p)
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return 1
+	}
+
+	var records []interface{}
+	if stream.IsSeq(data) {
+		records, err = stream.Decode(data, stream.JSONSeq, formatter.Options{})
+		if reportSkipped(err, stream.JSONSeq) {
+			err = nil
+		}
+	} else {
+		records, err = profile.DecodeRecords(data)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing records: %v\n", err)
+		return 1
+	}
+
+	report, err := profile.Profile(records, percentiles)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	out, err := json.MarshalIndent(report, "", indentString(cfg))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting report: %v\n", err)
+		return 1
+	}
+
+	fmt.Println(string(out))
+	return 0
+}
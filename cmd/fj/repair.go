@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// openTerminal opens the terminal for questions, which stdin cannot be used
+// for when the document was piped in
+func openTerminal() (*os.File, error) {
+	if isTerminal(os.Stdin) {
+		return os.Stdin, nil
+	}
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("-fix-interactive needs a terminal to ask on: %v", err)
+	}
+	return f, nil
+}
+
+// confirmFixes shows each fix auto-correction proposes as a diff hunk and
+// applies the ones the user accepts
+func confirmFixes(data []byte, report *formatter.RepairReport) ([]byte, error) {
+	tty, err := openTerminal()
+	if err != nil {
+		return nil, err
+	}
+	if tty != os.Stdin {
+		defer tty.Close()
+	}
+	return askFixes(data, report.Fixes, tty, os.Stderr), nil
+}
+
+// askFixes asks about each fix on in, writing the questions to out, and
+// returns data with the accepted fixes applied. End of input rejects the
+// remaining fixes.
+func askFixes(data []byte, fixes []formatter.RepairFix, in io.Reader, out io.Writer) []byte {
+	scanner := bufio.NewScanner(in)
+	var accepted []formatter.RepairFix
+	all := false
+	for i, fix := range fixes {
+		if all {
+			accepted = append(accepted, fix)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "Fix %d of %d: %s at line %d, column %d\n", i+1, len(fixes), fix.Rule, fix.Line, fix.Column)
+		_, _ = fmt.Fprint(out, fixHunk(data, fix))
+		_, _ = fmt.Fprint(out, "Apply this fix? [y]es, [n]o, [a]ll remaining, [q]uit: ")
+
+		answer := ""
+		if scanner.Scan() {
+			answer = strings.ToLower(strings.TrimSpace(scanner.Text()))
+		} else {
+			answer = "q"
+			_, _ = fmt.Fprintln(out)
+		}
+		if answer == "" {
+			answer = "y"
+		}
+
+		switch answer[:1] {
+		case "y":
+			accepted = append(accepted, fix)
+		case "a":
+			accepted = append(accepted, fix)
+			all = true
+		case "q":
+			return formatter.ApplyFixes(data, accepted)
+		}
+	}
+	return formatter.ApplyFixes(data, accepted)
+}
+
+// fixHunk renders the lines a fix touches before and after it, in the style
+// of a unified diff
+func fixHunk(data []byte, fix formatter.RepairFix) string {
+	start := strings.LastIndexByte(string(data[:fix.Offset]), '\n') + 1
+	end := fix.Offset + len(fix.Before)
+	if i := strings.IndexByte(string(data[end:]), '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(data)
+	}
+
+	before := string(data[start:end])
+	after := string(data[start:fix.Offset]) + fix.After + string(data[fix.Offset+len(fix.Before):end])
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "@@ line %d @@\n", fix.Line)
+	for _, line := range strings.Split(before, "\n") {
+		fmt.Fprintf(&sb, "-%s\n", strings.TrimRight(line, "\r"))
+	}
+	for _, line := range strings.Split(after, "\n") {
+		fmt.Fprintf(&sb, "+%s\n", strings.TrimRight(line, "\r"))
+	}
+	return sb.String()
+}
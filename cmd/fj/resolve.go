@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/conflict"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// runResolve implements "fj resolve [options] <file>"
+func runResolve(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	writePtr := fs.Bool("write", false, "Write the result back to the file instead of printing it")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj resolve [options] <file>\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Merges the JSON on both sides of git conflict markers.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitCommandError
+	}
+	file := fs.Arg(0)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, err)
+		return exitCommandError
+	}
+
+	sides, err := conflict.Split(data)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading conflict markers in %s: %v\n", file, err)
+		return exitCommandError
+	}
+
+	opts := formatOptions(cfg)
+	var values [3]interface{}
+	for i, side := range []struct {
+		name string
+		data []byte
+		skip bool
+	}{
+		{"ours", sides.Ours, false},
+		{"theirs", sides.Theirs, false},
+		{"base", sides.Base, !sides.HasBase},
+	} {
+		if side.skip {
+			continue
+		}
+		if values[i], err = formatter.Decode(side.data, opts); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing the %s version: %v\n", side.name, err)
+			return exitCommandError
+		}
+	}
+
+	merged, conflicts := conflict.Merge(values[2], values[0], values[1], sides.HasBase, opts.KeyOrder)
+
+	// Only the printed result is redacted: the file keeps its values
+	if !*writePtr {
+		if merged, err = redact(merged, cfg, runOptions{noRedact: *noRedactPtr}); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+			return exitCommandError
+		}
+	}
+	out, err := formatter.FormatValue(merged, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	if *writePtr {
+		if err := checkSandbox(cfg, file); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", file, err)
+			return exitCommandError
+		}
+		if err := writeFile(file, append(out, '\n'), 0644); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", file, err)
+			return exitCommandError
+		}
+	} else {
+		fmt.Println(string(out))
+	}
+
+	for _, path := range conflicts {
+		name := jsonpath.String(path)
+		if name == "" {
+			name = "(root)"
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "conflict at %s\n", name)
+	}
+	if len(conflicts) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "%d conflicts left as %q objects\n", len(conflicts), conflict.Key)
+		return exitCheckFailed
+	}
+
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+func TestResolveRedactsPrintedDocument(t *testing.T) {
+	cfg := config.Config{RedactPaths: []string{"password"}}
+	path := filepath.Join(t.TempDir(), "user.json")
+	input := "{\n<<<<<<< ours\n  \"password\": \"hunter2\"\n=======\n  \"password\": \"letmein\"\n>>>>>>> theirs\n}\n"
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{path}, {"-no-redact", path}} {
+		var code int
+		out := captureStdout(t, func() {
+			code = runResolve(cfg, args)
+		})
+		if code != exitCheckFailed {
+			t.Fatalf("resolve %s exit code = %d, want %d", args, code, exitCheckFailed)
+		}
+		shown := strings.Contains(out, "hunter2") || strings.Contains(out, "letmein")
+		if want := args[0] == "-no-redact"; shown != want {
+			t.Errorf("resolve %s shows the passwords = %v, want %v:\n%s", args, shown, want, out)
+		}
+	}
+}
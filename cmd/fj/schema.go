@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/schema"
+)
+
+// schemaCommands maps "fj schema" subcommands to their entry points
+var schemaCommands = map[string]func(cfg config.Config, args []string) int{
+	"infer":  runSchemaInfer,
+	"sample": runSchemaSample,
+}
+
+// runSchema implements "fj schema <subcommand> [options] [arguments]"
+func runSchema(cfg config.Config, args []string) int {
+	if len(args) > 0 {
+		if run, ok := schemaCommands[args[0]]; ok {
+			return run(cfg, args[1:])
+		}
+	}
+
+	names := make([]string, 0, len(schemaCommands))
+	for name := range schemaCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	_, _ = fmt.Fprintf(os.Stderr, "Usage: fj schema <%s> [options] [arguments]\n", strings.Join(names, "|"))
+	return exitCommandError
+}
+
+// runSchemaSample implements "fj schema sample [options] <schema>"
+func runSchemaSample(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("schema sample", flag.ContinueOnError)
+	countPtr := fs.Int("count", 1, "Number of documents to generate; more than one prints an array")
+	seedPtr := fs.Int64("seed", 0, "Random seed for reproducible output (default: a new seed each run)")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj schema sample [options] <schema>\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Generates example documents that conform to a JSON Schema.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if fs.NArg() != 1 || *countPtr < 1 {
+		fs.Usage()
+		return exitCommandError
+	}
+
+	data, err := readSource(fs.Arg(0), *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", fs.Arg(0), err)
+		return exitCommandError
+	}
+	opts := formatOptions(cfg)
+	root, err := formatter.Decode(data, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing schema: %v\n", err)
+		return exitCommandError
+	}
+
+	seed := *seedPtr
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	sampler := schema.NewSampler(root, seed, opts.KeyOrder)
+
+	samples := make([]interface{}, 0, *countPtr)
+	for i := 0; i < *countPtr; i++ {
+		doc, err := sampler.Sample()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error generating sample: %v\n", err)
+			return exitCommandError
+		}
+		samples = append(samples, doc)
+	}
+
+	var out interface{} = samples
+	if *countPtr == 1 {
+		out = samples[0]
+	}
+	formatted, err := formatter.FormatValue(out, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	fmt.Println(string(formatted))
+	return 0
+}
+
+// runSchemaInfer implements "fj schema infer [options] [file|url|-]..."
+func runSchemaInfer(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("schema infer", flag.ContinueOnError)
+	maxEnumPtr := fs.Int("max-enum", 10, "Most distinct values a repeated string may take to be written as an enum; 0 writes none")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj schema infer [options] [file|url|-]...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Writes a JSON Schema (draft 2020-12) that every example document conforms to;\n")
+		_, _ = fmt.Fprintf(os.Stderr, "stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitCommandError
+	}
+	if *maxEnumPtr < 0 {
+		fs.Usage()
+		return exitCommandError
+	}
+
+	sources := fs.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+	opts := formatOptions(cfg)
+	docs := make([]interface{}, 0, len(sources))
+	for _, source := range sources {
+		data, err := readSource(source, *trustPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+			return exitCommandError
+		}
+		doc, err := formatter.Decode(data, opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+			return exitCommandError
+		}
+		docs = append(docs, doc)
+	}
+
+	// Properties are listed in document order unless keys are sorted
+	keys := opts.KeyOrder.Keys
+	if cfg.SortKeys {
+		keys = nil
+	}
+	inferred := schema.Infer(docs, schema.InferOptions{Keys: keys, Order: opts.KeyOrder, MaxEnum: *maxEnumPtr})
+	formatted, err := formatter.FormatValue(inferred, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+		return exitCommandError
+	}
+
+	fmt.Println(string(formatted))
+	return 0
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// interruptCtx is cancelled when fj receives SIGINT or SIGTERM, aborting the
+// network requests in flight
+var interruptCtx, cancelInterrupt = context.WithCancel(context.Background())
+
+// cleanupMu guards the registered cleanups. Steps that must not be cut in
+// half by an interruption, such as renaming a file into place or writing a
+// chunk of output, hold it while they run.
+var (
+	cleanupMu   sync.Mutex
+	cleanups    = make(map[int]func())
+	nextCleanup int
+)
+
+// interruptOutput receives the messages written when fj is interrupted
+var interruptOutput io.Writer = os.Stderr
+
+// cleanupWait is how long an interruption waits for a write in progress to
+// finish before exiting without cleaning up
+const cleanupWait = time.Second
+
+// outputChunkSize is roughly how much output is written at once by
+// writeInterruptible; chunks end at line breaks
+const outputChunkSize = 64 * 1024
+
+// handleSignals stops fj on the first SIGINT or SIGTERM: requests in flight
+// are cancelled, the registered cleanups run, and the process exits with
+// exitInterrupted, sending the -notify notification. A second signal exits
+// at once, and so does the first when a write in progress does not finish
+// within cleanupWait.
+func handleSignals() {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-sigs
+		go func() {
+			<-sigs
+			os.Exit(exitInterrupted)
+		}()
+
+		cancelInterrupt()
+		cleanUp(cleanupWait)
+		_, _ = fmt.Fprintf(interruptOutput, "\nInterrupted\n")
+		exit(exitInterrupted)
+	}()
+}
+
+// cleanUp runs the registered cleanups once it holds cleanupMu, and reports
+// whether they ran. The lock is kept until the process exits, so nothing is
+// renamed or written after the cleanups have run. Output blocked on a reader
+// that stopped reading holds it indefinitely, so after waiting for wait the
+// cleanups are skipped: running them alongside the write in progress would
+// race with it.
+func cleanUp(wait time.Duration) bool {
+	// Polling leaves nothing waiting on the lock once the wait is over
+	deadline := time.Now().Add(wait)
+	for !cleanupMu.TryLock() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	runCleanups()
+	return true
+}
+
+// onInterrupt registers fn to run if fj is interrupted, such as removing a
+// temporary file or marking truncated output. The returned function
+// unregisters fn once it is no longer needed.
+func onInterrupt(fn func()) func() {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+
+	id := nextCleanup
+	nextCleanup++
+	cleanups[id] = fn
+	return func() {
+		cleanupMu.Lock()
+		defer cleanupMu.Unlock()
+		delete(cleanups, id)
+	}
+}
+
+// runCleanups runs the registered cleanups, most recent first. The caller
+// holds cleanupMu, so no file is renamed and no output written meanwhile.
+func runCleanups() {
+	for id := nextCleanup - 1; id >= 0; id-- {
+		if fn, ok := cleanups[id]; ok {
+			fn()
+		}
+	}
+}
+
+// writeFile writes data to path through a temporary file in the same
+// directory, so that an interrupted write never leaves a partial file behind.
+// Like os.WriteFile, perm only applies when the file does not exist yet.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	done := onInterrupt(func() {
+		_ = os.Remove(tmpPath)
+	})
+	defer done()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	// Renaming under the lock means an interruption either removes the
+	// temporary file or finds it already in place
+	cleanupMu.Lock()
+	err = os.Rename(tmpPath, path)
+	cleanupMu.Unlock()
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeInterruptible writes data to w in chunks that end at line breaks, so
+// that records of a stream such as NDJSON are never cut in half. If fj is
+// interrupted meanwhile, the output stops after a whole chunk and a marker
+// saying that name is truncated, and how much of it was written, is printed.
+func writeInterruptible(w io.Writer, data []byte, name string) error {
+	total := bytes.Count(data, []byte{'\n'})
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		total++
+	}
+	lines := 0
+	done := onInterrupt(func() {
+		_, _ = fmt.Fprintf(interruptOutput, "\n[fj: %s truncated after %d of %d lines]\n", name, lines, total)
+	})
+	defer done()
+
+	for len(data) > 0 {
+		n := min(outputChunkSize, len(data))
+		if i := bytes.IndexByte(data[n:], '\n'); i >= 0 {
+			n += i + 1
+		} else {
+			n = len(data)
+		}
+		chunk := data[:n]
+
+		cleanupMu.Lock()
+		_, err := w.Write(chunk)
+		if err == nil {
+			lines += bytes.Count(chunk, []byte{'\n'})
+			if chunk[len(chunk)-1] != '\n' {
+				lines++
+			}
+		}
+		cleanupMu.Unlock()
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// interruptingWriter simulates an interruption just before the nth chunk is
+// written, running the cleanups as the signal handler would once it holds the
+// lock between two chunks
+type interruptingWriter struct {
+	bytes.Buffer
+	n      int
+	writes int
+}
+
+func (w *interruptingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == w.n {
+		// cleanupMu is held here, as the handler holds it
+		runCleanups()
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteInterruptible(t *testing.T) {
+	var marker bytes.Buffer
+	interruptOutput = &marker
+	defer func() { interruptOutput = os.Stderr }()
+
+	line := strings.Repeat("x", 1000) + "\n"
+	data := []byte(strings.Repeat(line, 200))
+
+	w := &interruptingWriter{n: 3}
+	if err := writeInterruptible(w, data, "output"); err != nil {
+		t.Fatalf("writeInterruptible() error = %v", err)
+	}
+	if w.String() != string(data) {
+		t.Errorf("writeInterruptible() wrote %d bytes, want %d", w.Len(), len(data))
+	}
+	if w.writes < 3 {
+		t.Fatalf("writeInterruptible() wrote in %d chunks, want several", w.writes)
+	}
+
+	// Every chunk, and so the output written when the interruption came,
+	// ends at a line break: a chunk holds the lines that start within
+	// outputChunkSize bytes
+	written := 2 * (outputChunkSize/len(line) + 1)
+	want := "\n[fj: output truncated after " + strconv.Itoa(written) + " of 200 lines]\n"
+	if got := marker.String(); got != want {
+		t.Errorf("marker = %q, want %q", got, want)
+	}
+
+	// Once the output is complete, an interruption no longer marks it
+	marker.Reset()
+	cleanupMu.Lock()
+	runCleanups()
+	cleanupMu.Unlock()
+	if marker.Len() != 0 {
+		t.Errorf("marker after the output was written = %q", marker.String())
+	}
+}
+
+func TestWriteFileRenamesUnderLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+
+	cleanupMu.Lock()
+	done := make(chan error)
+	go func() { done <- writeFile(path, []byte("{}\n"), 0644) }()
+
+	// While the lock is held, as during an interruption, the file is not
+	// renamed into place
+	select {
+	case err := <-done:
+		cleanupMu.Unlock()
+		t.Fatalf("writeFile() returned %v while the lock was held", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists before the lock was released", path)
+	}
+
+	cleanupMu.Unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "{}\n" {
+		t.Errorf("ReadFile() = %q, %v", data, err)
+	}
+}
+
+func TestCleanUpSkippedWhileWriteBlocks(t *testing.T) {
+	ran := false
+	done := onInterrupt(func() { ran = true })
+	defer done()
+
+	// A write blocked on its reader holds the lock past the wait
+	cleanupMu.Lock()
+	if cleanUp(50 * time.Millisecond) {
+		t.Errorf("cleanUp() = true while the lock was held")
+	}
+	if ran {
+		t.Errorf("cleanUp() ran the cleanups without the lock")
+	}
+	cleanupMu.Unlock()
+
+	// Once the write is done, the cleanups run and the lock stays held
+	if !cleanUp(50*time.Millisecond) || !ran {
+		t.Errorf("cleanUp() did not run the cleanups once the lock was free")
+	}
+	cleanupMu.Unlock()
+}
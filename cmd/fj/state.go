@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diag"
+)
+
+// batchState is the progress of a run over many documents, kept in the file
+// given with -state so that an interrupted run of the same command resumes
+// where it stopped. Only files are recorded: stdin and URLs are read again.
+// A nil *batchState records nothing.
+//
+// The state file is a journal: a header line holding the command line, then
+// one line per document processed, appended as each one is done, so that a
+// checkpoint costs the same however many documents came before it. Resuming
+// compacts the journal, keeping the last line of each document.
+type batchState struct {
+	path      string
+	args      []string
+	documents map[string]stateEntry
+	journal   *os.File
+}
+
+// stateHeader is the first line of a state file
+type stateHeader struct {
+	// Args is the command line of the run, which a resumed run must repeat
+	Args []string `json:"args"`
+}
+
+// stateEntry is the result of a document that was completely processed. The
+// size and modification time tell whether the file changed since.
+type stateEntry struct {
+	Source      string            `json:"source"`
+	Size        int64             `json:"size"`
+	ModTime     time.Time         `json:"mod_time"`
+	Code        int               `json:"code"`
+	Diagnostics []diag.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// loadState reads the state file at path, or starts an empty one when it
+// does not exist yet. An empty path disables checkpoints and returns nil.
+// The state file is written like any other output, so it must lie within
+// the sandbox directory, if any.
+func loadState(cfg config.Config, path string, args []string) (*batchState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if err := checkSandbox(cfg, path); err != nil {
+		return nil, fmt.Errorf("state file %s: %v", path, err)
+	}
+	s := &batchState{path: path, args: args, documents: make(map[string]stateEntry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := s.replay(data); err != nil {
+			return nil, err
+		}
+	}
+	if len(s.documents) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Resuming from %s: %d documents already done\n", path, len(s.documents))
+	}
+
+	// Start the journal afresh with the documents still valid, then append
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	// compact replaced any link with a file; one swapped in since is refused
+	flags := os.O_WRONLY | os.O_APPEND
+	if cfg.SandboxDir != "" {
+		flags |= oNoFollow
+	}
+	if s.journal, err = os.OpenFile(path, flags, 0644); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay reads the documents recorded in a state file. A last line cut short
+// by an interruption is ignored.
+func (s *batchState) replay(data []byte) error {
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+
+	var header stateHeader
+	if err := json.Unmarshal(lines[0], &header); err != nil {
+		return fmt.Errorf("state file %s: %v", s.path, err)
+	}
+	if !slices.Equal(header.Args, s.args) {
+		return fmt.Errorf("state file %s was written by another command; remove it to start over", s.path)
+	}
+
+	for i, line := range lines[1:] {
+		var entry stateEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-2 {
+				break
+			}
+			return fmt.Errorf("state file %s, line %d: %v", s.path, i+2, err)
+		}
+		s.documents[entry.Source] = entry
+	}
+	return nil
+}
+
+// compact replaces the state file with the header and one line per document
+// recorded. The file is replaced atomically, so an interruption leaves the
+// previous journal in place.
+func (s *batchState) compact() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(stateHeader{Args: s.args}); err != nil {
+		return err
+	}
+	sources := make([]string, 0, len(s.documents))
+	for source := range s.documents {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+	for _, source := range sources {
+		if err := enc.Encode(s.documents[source]); err != nil {
+			return err
+		}
+	}
+	return writeFile(s.path, buf.Bytes(), 0644)
+}
+
+// done returns the recorded result of source, if the file was processed by
+// the earlier run and has not changed since
+func (s *batchState) done(source string) (stateEntry, bool) {
+	if s == nil {
+		return stateEntry{}, false
+	}
+	entry, ok := s.documents[source]
+	if !ok {
+		return stateEntry{}, false
+	}
+	info, err := os.Stat(source)
+	if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		return stateEntry{}, false
+	}
+	return entry, true
+}
+
+// record saves the result of source, which must have been processed
+// completely, by appending one line to the journal
+func (s *batchState) record(source string, code int, diagnostics []diag.Diagnostic) error {
+	if s == nil || source == "-" || isURL(source) {
+		return nil
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	entry := stateEntry{
+		Source:      source,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		Code:        code,
+		Diagnostics: diagnostics,
+	}
+	s.documents[source] = entry
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// A single write keeps the line whole unless the process dies mid-write
+	_, err = s.journal.Write(append(line, '\n'))
+	return err
+}
+
+// finish removes the state file once every document has been processed
+func (s *batchState) finish() error {
+	if s == nil {
+		return nil
+	}
+	_ = s.journal.Close()
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
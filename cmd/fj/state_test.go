@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diag"
+)
+
+func TestBatchStateResume(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := []string{"validate", "-state", statePath, a, b}
+	found := []diag.Diagnostic{{File: a, Line: 1, Column: 2, Code: "duplicate-key", Severity: diag.SeverityWarning}}
+
+	state, err := loadState(config.Config{}, statePath, args)
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	if err := state.record(a, exitCheckFailed, found); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	if err := state.record("-", 0, nil); err != nil {
+		t.Fatalf("record(stdin) error = %v", err)
+	}
+
+	// A second run, as after an interruption, finds a.json done
+	resumed, err := loadState(config.Config{}, statePath, args)
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	entry, ok := resumed.done(a)
+	if !ok || entry.Code != exitCheckFailed || len(entry.Diagnostics) != 1 || entry.Diagnostics[0] != found[0] {
+		t.Errorf("done(a) = %+v, %v", entry, ok)
+	}
+	for _, source := range []string{b, "-"} {
+		if _, ok := resumed.done(source); ok {
+			t.Errorf("done(%s) = true for a document not processed", source)
+		}
+	}
+
+	// A file changed since it was processed is processed again
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(a, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resumed.done(a); ok {
+		t.Errorf("done(a) = true after a.json changed")
+	}
+
+	if _, err := loadState(config.Config{}, statePath, []string{"lint", "-state", statePath, a, b}); err == nil {
+		t.Errorf("loadState() with other arguments error = nil")
+	}
+
+	if err := resumed.finish(); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("state file still exists after finish(): %v", err)
+	}
+}
+
+func TestBatchStateDisabled(t *testing.T) {
+	state, err := loadState(config.Config{}, "", nil)
+	if err != nil || state != nil {
+		t.Fatalf("loadState(\"\") = %v, %v", state, err)
+	}
+	if _, ok := state.done("a.json"); ok {
+		t.Errorf("done() = true without a state file")
+	}
+	if err := state.record("a.json", 0, nil); err != nil {
+		t.Errorf("record() error = %v", err)
+	}
+	if err := state.finish(); err != nil {
+		t.Errorf("finish() error = %v", err)
+	}
+}
+
+func TestBatchStateJournal(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	var files []string
+	for _, name := range []string{"a.json", "b.json", "c.json"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	args := append([]string{"lint", "-state", statePath}, files...)
+
+	state, err := loadState(config.Config{}, statePath, args)
+	if err != nil {
+		t.Fatalf("loadState() error = %v", err)
+	}
+	for _, path := range files[:2] {
+		if err := state.record(path, 0, nil); err != nil {
+			t.Fatalf("record() error = %v", err)
+		}
+	}
+	// Each document appends one line after the header
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("state file has %d lines, want 3:\n%s", lines, data)
+	}
+
+	// An interruption in the middle of a line loses only that document
+	if err := os.WriteFile(statePath, append(data, `{"source":"`+files[2]+`","si`...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := loadState(config.Config{}, statePath, args)
+	if err != nil {
+		t.Fatalf("loadState() with a truncated line error = %v", err)
+	}
+	for i, path := range files {
+		if _, ok := resumed.done(path); ok != (i < 2) {
+			t.Errorf("done(%s) = %v, want %v", path, ok, i < 2)
+		}
+	}
+	// Resuming compacts the journal, dropping the truncated line
+	if data, err = os.ReadFile(statePath); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 || strings.Contains(string(data), `"source":"`+files[2]) {
+		t.Errorf("compacted state file:\n%s", data)
+	}
+
+	if err := resumed.finish(); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+}
+
+func TestBatchStateSandbox(t *testing.T) {
+	sandboxDir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "state.json")
+	cfg := config.Config{SandboxDir: sandboxDir}
+
+	if _, err := loadState(cfg, outside, nil); err == nil {
+		t.Errorf("loadState() outside the sandbox error = nil")
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("state file written outside the sandbox: %v", err)
+	}
+
+	state, err := loadState(cfg, filepath.Join(sandboxDir, "state.json"), nil)
+	if err != nil {
+		t.Fatalf("loadState() inside the sandbox error = %v", err)
+	}
+	if err := state.finish(); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/tmpl"
+)
+
+// runTemplate implements "fj template -tpl file [options] [file|url|-]"
+func runTemplate(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("template", flag.ContinueOnError)
+	tplPtr := fs.String("tpl", "", "Go text/template file to execute (required)")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	noRedactPtr := fs.Bool("no-redact", false, "Do not redact the paths listed in the config file")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj template -tpl file [options] [file|url|-]\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Executes a Go template with the document as its context; stdin is read when no document is given.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *tplPtr == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return 1
+	}
+
+	text, err := os.ReadFile(*tplPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading template: %v\n", err)
+		return 1
+	}
+	t, err := tmpl.Parse(filepath.Base(*tplPtr), string(text))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing template: %v\n", err)
+		return 1
+	}
+
+	source := "-"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	data, err := readSource(source, *trustPtr)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", source, err)
+		return 1
+	}
+
+	value, err := formatter.Decode(data, formatOptions(cfg))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", source, err)
+		return 1
+	}
+	value, err = redact(value, cfg, runOptions{noRedact: *noRedactPtr})
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error redacting JSON: %v\n", err)
+		return 1
+	}
+
+	out, err := tmpl.Render(t, value)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error executing template: %v\n", err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(out)
+	return 0
+}
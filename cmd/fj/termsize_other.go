@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import "os"
+
+// windowColumns returns 0 where the terminal cannot be asked for its width,
+// leaving COLUMNS as the only source
+func windowColumns(f *os.File) int {
+	return 0
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/schema"
+	"github.com/nicolasalberti00/fj/pkg/validate"
+)
+
+// runValidate implements "fj validate [options] [file|url|-]..."
+func runValidate(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	outputPtr := fs.String("output", "text", "Diagnostics format: text or json")
+	strictPtr := fs.Bool("strict-ijson", false, "Report documents that are not valid I-JSON (RFC 7493)")
+	rejectScalarsPtr := fs.Bool("ijson-reject-scalars", false, "With -strict-ijson, also require a top-level object or array")
+	schemaPtr := fs.String("schema", "", "JSON Schema (draft 7 or 2020-12) the documents must conform to")
+	fetchPtr := fs.Bool("fetch", false, "Download remote schemas named by $ref, and without -schema by a document's $schema")
+	trustPtr := fs.Bool("trust-all", cfg.TrustAllURLs, "Trust all URLs without prompting")
+	statePtr := fs.String("state", "", "Record progress in this file, so an interrupted run resumes where it stopped")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: fj validate [options] [file|url|-]...\n\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Checks that documents are valid JSON, and with -schema that they conform to a JSON Schema;\n")
+		_, _ = fmt.Fprintf(os.Stderr, "stdin is read when no document is given.\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Exits with 0 when no errors are found, 1 when some are and 2 when a document cannot be read.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := parseInterspersed(fs, args); err != nil {
+		return exitCommandError
+	}
+	if *outputPtr != "text" && *outputPtr != "json" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: unknown output format %q (use text or json)\n", *outputPtr)
+		return exitCommandError
+	}
+
+	sources := fs.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	opts := validate.Options{
+		DuplicateKeys: formatter.DuplicateKeys(cfg.DuplicateKeys),
+		StrictIJSON:   *strictPtr || *rejectScalarsPtr,
+		RejectScalars: *rejectScalarsPtr,
+	}
+
+	loader := &schemaLoader{cfg: cfg, fetch: *fetchPtr, trust: *trustPtr, validators: map[string]*schema.Validator{}}
+	if *schemaPtr != "" {
+		validator, err := loader.fromSource(*schemaPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+		opts.Schema = validator
+	}
+
+	state, err := loadState(cfg, *statePtr, os.Args[1:])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCommandError
+	}
+
+	// Documents that cannot be read are reported and the others still
+	// validated; the result is the worst outcome, as for -check
+	code := 0
+	diagnostics := make([]diag.Diagnostic, 0)
+	for _, source := range sources {
+		// Documents checked by an interrupted run are not printed again
+		if entry, ok := state.done(source); ok {
+			diagnostics = append(diagnostics, entry.Diagnostics...)
+			continue
+		}
+		name := source
+		if source == "-" {
+			name = "<stdin>"
+		}
+		data, err := readSource(source, *trustPtr)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+			code = exitCommandError
+			continue
+		}
+
+		docOpts := opts
+		if docOpts.Schema == nil && *fetchPtr {
+			validator, err := loader.declared(source, data)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+				code = exitCommandError
+				continue
+			}
+			docOpts.Schema = validator
+		}
+		found := validate.Validate(name, data, docOpts)
+		diagnostics = append(diagnostics, found...)
+		if *outputPtr == "text" {
+			for _, d := range found {
+				fmt.Println(d)
+			}
+			if len(found) == 0 {
+				fmt.Printf("%s: valid\n", name)
+			}
+		}
+		if err := state.record(source, 0, found); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+	}
+
+	if *outputPtr == "json" {
+		if err := printDiagnostics(diagnostics, cfg); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitCommandError
+		}
+	}
+	// Documents that could not be read are tried again by the next run
+	if code != exitCommandError {
+		if err := state.finish(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if code == 0 && diag.HasErrors(diagnostics) {
+		return exitCheckFailed
+	}
+	return code
+}
+
+// printDiagnostics writes diagnostics to stdout as an indented JSON array.
+// Messages quote schema keywords such as ">=", which are kept as they are
+// rather than escaped as HTML.
+func printDiagnostics(diagnostics []diag.Diagnostic, cfg config.Config) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indentString(cfg))
+	return enc.Encode(diagnostics)
+}
+
+// schemaCacheTTL is how long a downloaded schema is used before it is
+// downloaded again
+const schemaCacheTTL = 7 * 24 * time.Hour
+
+// schemaLoader reads the schemas documents are validated against, and the
+// schemas they refer to. Local files are always read; remote schemas only
+// with -fetch, after the usual trust prompt, and are then kept in the user
+// cache directory.
+type schemaLoader struct {
+	cfg   config.Config
+	fetch bool
+	trust bool
+	// validators holds a validator per schema URI, as documents often
+	// declare the same $schema
+	validators map[string]*schema.Validator
+}
+
+// fromSource returns a validator for the schema named by -schema
+func (l *schemaLoader) fromSource(source string) (*schema.Validator, error) {
+	uri := sourceURI(source)
+	if isURL(source) && l.fetch {
+		return l.validator(uri)
+	}
+	data, err := readSource(source, l.trust)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", source, err)
+	}
+	root, err := formatter.Decode(data, formatOptions(l.cfg))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema %s: %v", source, err)
+	}
+	return l.newValidator(uri, root)
+}
+
+// declared returns a validator for the "$schema" a document declares, or
+// nil when it declares none. Relative URIs are resolved against the
+// location of the document.
+func (l *schemaLoader) declared(source string, data []byte) (*schema.Validator, error) {
+	var doc struct {
+		Schema string `json:"$schema"`
+	}
+	// Syntax errors are reported by the validation itself
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Schema == "" {
+		return nil, nil
+	}
+	base, err := url.Parse(sourceURI(source))
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(doc.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $schema %q: %v", doc.Schema, err)
+	}
+	uri := base.ResolveReference(ref)
+	uri.Fragment = ""
+	// A remote document cannot have a local file read as its schema
+	if isURL(source) && !isURL(uri.String()) {
+		return nil, fmt.Errorf("a remote document cannot declare the local schema %s", uri)
+	}
+	return l.validator(uri.String())
+}
+
+// validator returns the validator for the schema at uri, reading it the first time
+func (l *schemaLoader) validator(uri string) (*schema.Validator, error) {
+	if v, ok := l.validators[uri]; ok {
+		return v, nil
+	}
+	root, err := l.load(uri)
+	if err != nil {
+		return nil, err
+	}
+	return l.newValidator(uri, root)
+}
+
+// newValidator returns a validator for a schema read from uri
+func (l *schemaLoader) newValidator(uri string, root interface{}) (*schema.Validator, error) {
+	v, err := schema.NewValidator(root, schema.ValidatorOptions{Base: uri, Load: l.load})
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %v", uri, err)
+	}
+	l.validators[uri] = v
+	return v, nil
+}
+
+// load reads and parses the schema at an absolute URI
+func (l *schemaLoader) load(uri string) (interface{}, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch u.Scheme {
+	case "file":
+		data, err = readFile(filepath.FromSlash(u.Path))
+	case "http", "https":
+		if !l.fetch {
+			return nil, fmt.Errorf("%s is remote; use -fetch to download it", uri)
+		}
+		data, err = l.download(uri)
+	default:
+		return nil, fmt.Errorf("cannot read %s", uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", uri, err)
+	}
+	root, err := formatter.Decode(data, formatOptions(l.cfg))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema %s: %v", uri, err)
+	}
+	return root, nil
+}
+
+// download fetches a remote schema, using the cached copy while it is
+// recent. The URL must be trusted either way, as for any other download:
+// the cache is keyed by the full URL, and saves time, not the prompt.
+func (l *schemaLoader) download(uri string) ([]byte, error) {
+	if err := confirmURL(uri, l.trust); err != nil {
+		return nil, err
+	}
+
+	path := schemaCachePath(uri)
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			// A modification time in the future does not make a copy recent
+			if age := time.Since(info.ModTime()); age >= 0 && age < schemaCacheTTL {
+				if data, err := os.ReadFile(path); err == nil {
+					return data, nil
+				}
+			}
+		}
+	}
+
+	data, err := readFromURL(uri)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		// The cache only saves downloads, so failing to write it is not an error
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			_ = os.WriteFile(path, data, 0644)
+		}
+	}
+	return data, nil
+}
+
+// schemaCachePath returns the file a remote schema is cached in, or "" when
+// there is no user cache directory
+func schemaCachePath(uri string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(uri))
+	return filepath.Join(dir, "fj", "schemas", hex.EncodeToString(sum[:16])+".json")
+}
+
+// sourceURI returns the URI of a document source, which the relative
+// references in it resolve against: a URL as it is, a file as a file: URI,
+// and "" for stdin
+func sourceURI(source string) string {
+	switch {
+	case source == "-":
+		return ""
+	case isURL(source):
+		return source
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return ""
+	}
+	path := filepath.ToSlash(abs)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/config"
+)
+
+// captureStdout returns what f writes to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	defer func() {
+		os.Stdout = stdout
+	}()
+	f()
+	_ = w.Close()
+	return <-out
+}
+
+func TestValidateSources(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "age.schema.json")
+	doc := filepath.Join(dir, "doc.json")
+	missing := filepath.Join(dir, "missing.json")
+	files := map[string]string{
+		schemaPath: `{"properties": {"age": {"minimum": 0}}}`,
+		doc:        `{"age": -1}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Options follow the documents, and the missing one does not stop the other
+	var code int
+	out := captureStdout(t, func() {
+		code = runValidate(config.Config{}, []string{missing, doc, "-schema", schemaPath, "-output", "json"})
+	})
+	if code != exitCommandError {
+		t.Errorf("runValidate() = %d, want %d", code, exitCommandError)
+	}
+	if !strings.Contains(out, `"/age"`) {
+		t.Errorf("runValidate() did not validate %s after %s:\n%s", doc, missing, out)
+	}
+	if !strings.Contains(out, "must be >= 0") {
+		t.Errorf("runValidate() output escapes the message:\n%s", out)
+	}
+}
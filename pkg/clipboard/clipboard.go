@@ -1,39 +1,189 @@
 package clipboard
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 )
 
+// DefaultTimeout bounds how long Copy waits for the clipboard program
+const DefaultTimeout = 5 * time.Second
+
 // Copy copies text to the system clipboard by using utilities that are present on each platform:
 // - pbcopy for MacOS
 // - clip for Windows
-// - xclip for Linux
+// - xclip, xsel or wl-copy for Linux
 // This part could be adjusted in the config in a next release to let the user choose which program to use.
 func Copy(text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return CopyContext(ctx, text)
+}
 
-	var copyProgram string
-
+// CopyContext is like Copy, but stops the clipboard program when ctx is done,
+// so that a wedged program (e.g. xclip over X forwarding) cannot hang the caller
+func CopyContext(ctx context.Context, text string) error {
 	switch runtime.GOOS {
 	case "darwin":
-		copyProgram = "pbcopy"
+		return copyOSX(ctx, text)
 	case "windows":
-		copyProgram = "clip"
+		return copyWindows(ctx, text)
 	case "linux":
-		copyProgram = "xclip"
+		return copyLinux(ctx, text)
 	default:
 		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
+}
 
-	cmd := exec.Command(copyProgram, text)
+// copyOSX copies text using pbcopy
+func copyOSX(ctx context.Context, text string) error {
+	return run(ctx, text, "pbcopy")
+}
+
+// copyWindows copies text using clip
+func copyWindows(ctx context.Context, text string) error {
+	return run(ctx, text, "clip")
+}
+
+// copyLinux copies text using the first clipboard utility found in PATH
+func copyLinux(ctx context.Context, text string) error {
+	name, args, err := linuxCommand()
+	if err != nil {
+		return err
+	}
+	return run(ctx, text, name, args...)
+}
+
+// run executes the clipboard program, feeding text through stdin
+func run(ctx context.Context, text string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Stdin = strings.NewReader(text)
+	// Stop waiting for output pipes held open by children of a killed program
+	cmd.WaitDelay = time.Second
 
 	err := cmd.Run()
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("could not copy to clipboard: %s did not finish in time", name)
+		}
 		return fmt.Errorf("could not copy to clipboard: %w", err)
 	}
 
 	return nil
 }
+
+// Start copies text in the background: the clipboard program is started and
+// left running, so the caller can exit without waiting for it. Failures of the
+// program itself are not reported.
+func Start(text string) error {
+	name, args, err := command()
+	if err != nil {
+		return err
+	}
+
+	// The program reads from a file rather than a pipe, which would need this
+	// process to stay alive to feed it
+	f, err := os.CreateTemp("", "fj-clipboard-*")
+	if err != nil {
+		return fmt.Errorf("could not copy to clipboard: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("could not copy to clipboard: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("could not copy to clipboard: %v", err)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = f
+	if err := cmd.Start(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("could not copy to clipboard: %w", err)
+	}
+
+	// The program keeps its own descriptor, so the file can go away now, except
+	// on Windows where open files cannot be removed and the file stays in the
+	// temporary directory
+	if runtime.GOOS != "windows" {
+		_ = os.Remove(f.Name())
+	}
+	return cmd.Process.Release()
+}
+
+// command returns the clipboard program of the current platform and its arguments
+func command() (string, []string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return "pbcopy", nil, nil
+	case "windows":
+		return "clip", nil, nil
+	case "linux":
+		return linuxCommand()
+	default:
+		return "", nil, fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+}
+
+// linuxCommand returns the first clipboard utility found in PATH
+func linuxCommand() (string, []string, error) {
+	switch {
+	case hasCommand("xclip"):
+		return "xclip", []string{"-selection", "clipboard"}, nil
+	case hasCommand("xsel"):
+		return "xsel", []string{"--clipboard", "--input"}, nil
+	case hasCommand("wl-copy"):
+		return "wl-copy", nil, nil
+	default:
+		// Report the preferred utility so the user knows what to install
+		return "", nil, fmt.Errorf("could not copy to clipboard: %w", &exec.Error{Name: "xclip", Err: exec.ErrNotFound})
+	}
+}
+
+// hasCommand reports whether the named program can be found in PATH
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// CopyAsFile writes text to a new file in the temporary directory and copies
+// the file's path instead of the text, for payloads too large for the clipboard.
+// The file is left in place for the user to open, unless the copy fails.
+func CopyAsFile(text string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	return CopyAsFileContext(ctx, text)
+}
+
+// CopyAsFileContext is like CopyAsFile, but stops the clipboard program when
+// ctx is done
+func CopyAsFileContext(ctx context.Context, text string) (string, error) {
+	f, err := os.CreateTemp("", "fj-*.json")
+	if err != nil {
+		return "", fmt.Errorf("could not create clipboard file: %v", err)
+	}
+	path := f.Name()
+
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", fmt.Errorf("could not write clipboard file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("could not write clipboard file: %v", err)
+	}
+
+	if err := CopyContext(ctx, path); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
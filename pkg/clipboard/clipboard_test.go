@@ -1,11 +1,15 @@
 package clipboard
 
 import (
+	"context"
 	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCopy(t *testing.T) {
@@ -38,7 +42,7 @@ func TestCopy(t *testing.T) {
 func TestPlatformSpecificFunctions(t *testing.T) {
 	// Test copyOSX
 	if runtime.GOOS == "darwin" {
-		err := copyOSX("Test macOS clipboard")
+		err := copyOSX(context.Background(), "Test macOS clipboard")
 		if err != nil && !isCommandNotFoundError(err) {
 			t.Errorf("copyOSX() error = %v", err)
 		}
@@ -46,7 +50,7 @@ func TestPlatformSpecificFunctions(t *testing.T) {
 
 	// Test copyWindows
 	if runtime.GOOS == "windows" {
-		err := copyWindows("Test Windows clipboard")
+		err := copyWindows(context.Background(), "Test Windows clipboard")
 		if err != nil && !isCommandNotFoundError(err) {
 			t.Errorf("copyWindows() error = %v", err)
 		}
@@ -54,13 +58,78 @@ func TestPlatformSpecificFunctions(t *testing.T) {
 
 	// Test copyLinux
 	if runtime.GOOS == "linux" {
-		err := copyLinux("Test Linux clipboard")
+		err := copyLinux(context.Background(), "Test Linux clipboard")
 		if err != nil && !isCommandNotFoundError(err) {
 			t.Errorf("copyLinux() error = %v", err)
 		}
 	}
 }
 
+// TestCopyAsFile checks that the text is written to the file whose path is copied
+func TestCopyAsFile(t *testing.T) {
+	path, err := CopyAsFile(`{"large": true}`)
+	if err != nil {
+		if isCommandNotFoundError(err) {
+			t.Skip("Clipboard command not available, skipping test")
+		}
+		t.Fatalf("CopyAsFile() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	data, readErr := os.ReadFile(path)
+	if readErr != nil || string(data) != `{"large": true}` {
+		t.Errorf("clipboard file contains %q (%v)", data, readErr)
+	}
+}
+
+// TestCopyTimeout checks that a clipboard program that never finishes is stopped
+func TestCopyTimeout(t *testing.T) {
+	if !hasCommand("sleep") {
+		t.Skip("sleep not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := run(ctx, "", "sleep", "10")
+	if err == nil || !strings.Contains(err.Error(), "did not finish in time") {
+		t.Errorf("run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("run() returned after %v", elapsed)
+	}
+}
+
+// TestCopyAsFileTimeout checks that the clipboard program copying the path
+// is stopped at the caller's deadline
+func TestCopyAsFileTimeout(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("a stand-in xclip needs Linux")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nexec " + sleep + " 10\n"
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	path, err := CopyAsFileContext(ctx, `{"large": true}`)
+	if err == nil || !strings.Contains(err.Error(), "did not finish in time") {
+		t.Errorf("CopyAsFileContext() = %q, %v, want a timeout", path, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CopyAsFileContext() returned after %v", elapsed)
+	}
+}
+
 // TestHasCommand tests the hasCommand function
 func TestHasCommand(t *testing.T) {
 	// Test with a command that should exist on all platforms
@@ -0,0 +1,64 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+// Method names a compression format for saved files
+type Method string
+
+const (
+	// None writes files as they are
+	None Method = ""
+	// Gzip writes gzip files with the .gz extension
+	Gzip Method = "gzip"
+	// Zstd writes zstd files with the .zst extension. Only runs of a
+	// repeated byte are compressed, as fj depends on the standard library
+	// alone, which has no zstd encoder.
+	Zstd Method = "zstd"
+)
+
+// Parse validates a compression method name; empty and "none" mean None
+func Parse(name string) (Method, error) {
+	switch m := Method(name); m {
+	case None, "none":
+		return None, nil
+	case Gzip, Zstd:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown compression %q (use gzip, zstd or none)", name)
+}
+
+// Extension returns the suffix appended to compressed file names
+func (m Method) Extension() string {
+	switch m {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// Compress returns data compressed with the method
+func Compress(data []byte, m Method) ([]byte, error) {
+	switch m {
+	case None:
+		return data, nil
+	case Gzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		return zstdFrame(data), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", m)
+}
@@ -0,0 +1,141 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]Method{"": None, "none": None, "gzip": Gzip, "zstd": Zstd} {
+		if got, err := Parse(name); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"brotli", "zst"} {
+		if _, err := Parse(name); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	data := []byte("{\"a\":1}\n{\"a\":2}\n")
+
+	got, err := Compress(data, None)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Compress(None) = %q, %v", got, err)
+	}
+
+	got, err = Compress(data, Gzip)
+	if err != nil {
+		t.Fatalf("Compress(Gzip) error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(got))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	back, err := io.ReadAll(zr)
+	if err != nil || !bytes.Equal(back, data) {
+		t.Errorf("decompressed = %q, %v, want %q", back, err, data)
+	}
+
+	if Gzip.Extension() != ".gz" || Zstd.Extension() != ".zst" || None.Extension() != "" {
+		t.Error("Extension() returned the wrong suffix")
+	}
+}
+
+func TestCompressZstd(t *testing.T) {
+	tests := map[string][]byte{
+		"Empty":          {},
+		"Small":          []byte("{\"a\":1}\n"),
+		"Repeated bytes": bytes.Repeat([]byte(" "), 300),
+		"Several blocks": append(bytes.Repeat([]byte("{\"a\":1}\n"), 40000), bytes.Repeat([]byte(" "), zstdMaxBlock)...),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Compress(data, Zstd)
+			if err != nil {
+				t.Fatalf("Compress(Zstd) error = %v", err)
+			}
+			back, err := readZstd(got)
+			if err != nil {
+				t.Fatalf("reading the frame: %v", err)
+			}
+			if !bytes.Equal(back, data) {
+				t.Errorf("decompressed %d bytes, want %d", len(back), len(data))
+			}
+		})
+	}
+}
+
+func TestXXHash64(t *testing.T) {
+	tests := map[string]uint64{
+		"":                                     0xef46db3751d8e999,
+		"a":                                    0xd24ec4f1a98c6e5b,
+		"abc":                                  0x44bc2cf5ad770999,
+		"abcdefghijklmnopqrstuvwxyz0123456789": 0x64f23ecf1609b766,
+	}
+	for input, want := range tests {
+		if got := xxhash64([]byte(input)); got != want {
+			t.Errorf("xxhash64(%q) = %#x, want %#x", input, got, want)
+		}
+	}
+}
+
+// readZstd decodes a frame of raw and RLE blocks, checking its content size
+// and checksum
+func readZstd(frame []byte) ([]byte, error) {
+	if len(frame) < 6 || binary.LittleEndian.Uint32(frame) != zstdMagic {
+		return nil, fmt.Errorf("no zstd magic number")
+	}
+	descriptor := frame[4]
+	if descriptor&(1<<5) == 0 || descriptor&(1<<2) == 0 {
+		return nil, fmt.Errorf("descriptor %#x is not a single segment with a checksum", descriptor)
+	}
+	rest := frame[5:]
+	var size uint64
+	switch descriptor >> 6 {
+	case 0:
+		size, rest = uint64(rest[0]), rest[1:]
+	case 1:
+		size, rest = uint64(binary.LittleEndian.Uint16(rest))+256, rest[2:]
+	case 2:
+		size, rest = uint64(binary.LittleEndian.Uint32(rest)), rest[4:]
+	case 3:
+		size, rest = binary.LittleEndian.Uint64(rest), rest[8:]
+	}
+
+	var out []byte
+	for last := false; !last; {
+		if len(rest) < 3 {
+			return nil, fmt.Errorf("truncated block header")
+		}
+		header := uint32(rest[0]) | uint32(rest[1])<<8 | uint32(rest[2])<<16
+		rest = rest[3:]
+		last = header&1 == 1
+		n := int(header >> 3)
+		if n > zstdMaxBlock {
+			return nil, fmt.Errorf("block of %d bytes", n)
+		}
+		switch header >> 1 & 3 {
+		case zstdBlockRaw:
+			out, rest = append(out, rest[:n]...), rest[n:]
+		case zstdBlockRLE:
+			out, rest = append(out, bytes.Repeat(rest[:1], n)...), rest[1:]
+		default:
+			return nil, fmt.Errorf("unexpected block type %d", header>>1&3)
+		}
+	}
+
+	if uint64(len(out)) != size {
+		return nil, fmt.Errorf("content size %d, frame says %d", len(out), size)
+	}
+	if len(rest) != 4 || binary.LittleEndian.Uint32(rest) != uint32(xxhash64(out)) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package compress
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The standard library has no zstd encoder, so zstd output is written as
+// raw and RLE blocks (RFC 8878): every zstd decoder reads it, but only runs
+// of one repeated byte get smaller.
+
+const (
+	zstdMagic = 0xFD2FB528
+	// zstdMaxBlock is the largest block a frame may hold
+	zstdMaxBlock = 128 << 10
+
+	zstdBlockRaw = 0
+	zstdBlockRLE = 1
+)
+
+// zstdFrame returns data as a single zstd frame with its content size and
+// checksum
+func zstdFrame(data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, zstdMagic)
+
+	// Single segment, content checksum, and the smallest content size field
+	// that holds len(data)
+	size := uint64(len(data))
+	descriptor := byte(1<<5 | 1<<2)
+	switch {
+	case size < 256:
+		out = append(out, descriptor, byte(size))
+	case size < 65536+256:
+		out = append(out, descriptor|1<<6)
+		out = binary.LittleEndian.AppendUint16(out, uint16(size-256))
+	case size <= 0xFFFFFFFF:
+		out = append(out, descriptor|2<<6)
+		out = binary.LittleEndian.AppendUint32(out, uint32(size))
+	default:
+		out = append(out, descriptor|3<<6)
+		out = binary.LittleEndian.AppendUint64(out, size)
+	}
+
+	// An empty frame still holds one, empty, last block
+	rest := data
+	for first := true; first || len(rest) > 0; first = false {
+		n := min(len(rest), zstdMaxBlock)
+		block := rest[:n]
+		rest = rest[n:]
+
+		header := uint32(n) << 3
+		if len(rest) == 0 {
+			header |= 1
+		}
+		if n > 1 && isRun(block) {
+			out = appendUint24(out, header|zstdBlockRLE<<1)
+			out = append(out, block[0])
+		} else {
+			out = appendUint24(out, header|zstdBlockRaw<<1)
+			out = append(out, block...)
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(out, uint32(xxhash64(data)))
+}
+
+// isRun reports whether every byte of b is the same
+func isRun(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendUint24 appends the low three bytes of v, little-endian
+func appendUint24(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16))
+}
+
+// XXH64 primes
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 returns the XXH64 hash of data with seed 0, which zstd uses for
+// its content checksum
+func xxhash64(data []byte) uint64 {
+	n := len(data)
+	var h uint64
+	if n >= 32 {
+		// The lanes start from seed 0; a variable keeps 0 - xxPrime1 from
+		// being a constant that overflows
+		var seed uint64
+		v1 := seed + xxPrime1 + xxPrime2
+		v2 := seed + xxPrime2
+		v3 := seed
+		v4 := seed - xxPrime1
+		for ; len(data) >= 32; data = data[32:] {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(data[0:]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(data[8:]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(data[16:]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(data[24:]))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		for _, v := range []uint64{v1, v2, v3, v4} {
+			h ^= xxRound(0, v)
+			h = h*xxPrime1 + xxPrime4
+		}
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+
+	for ; len(data) >= 8; data = data[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(data))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+// xxRound mixes one 8-byte lane into an accumulator
+func xxRound(acc, lane uint64) uint64 {
+	acc += lane * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
@@ -11,15 +11,86 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	IndentSpaces    int    `json:"indent_spaces"`
-	SortKeys        bool   `json:"sort_keys"`
-	CopyToClipboard bool   `json:"copy_to_clipboard"`
-	OutputDir       string `json:"output_dir"`
-	TrustAllURLs    bool   `json:"trust_all_urls"`
-	MaxMemoryMB     int    `json:"max_memory_mb"`
-	MaxProcessors   int    `json:"max_processors"`
-	LogToFile       bool   `json:"log_to_file"`
-	LogFilePath     string `json:"log_file_path"`
+	IndentSpaces    int  `json:"indent_spaces"`
+	SortKeys        bool `json:"sort_keys"`
+	CopyToClipboard bool `json:"copy_to_clipboard"`
+	// ClipboardLimitKB is the largest output copied as text; larger output
+	// asks for confirmation or copies the path of a file instead. Zero means no limit.
+	ClipboardLimitKB int `json:"clipboard_limit_kb"`
+	// ClipboardTimeoutMS bounds how long the clipboard program may run; zero means the default
+	ClipboardTimeoutMS int `json:"clipboard_timeout_ms"`
+	// ClipboardAsync leaves the clipboard program running in the background instead of waiting for it
+	ClipboardAsync bool   `json:"clipboard_async"`
+	OutputDir      string `json:"output_dir"`
+	TrustAllURLs   bool   `json:"trust_all_urls"`
+	MaxMemoryMB    int    `json:"max_memory_mb"`
+	MaxProcessors  int    `json:"max_processors"`
+	LogToFile      bool   `json:"log_to_file"`
+	LogFilePath    string `json:"log_file_path"`
+	Accessible     bool   `json:"accessible"`
+	// Color selects when output is colored: auto (on a terminal, unless NO_COLOR is set), always or never
+	Color string `json:"color"`
+	Theme string `json:"theme"`
+	// Themes holds user-defined themes, keyed by name, mapping token types to styles
+	Themes map[string]map[string]string `json:"themes,omitempty"`
+	// Highlight lists keys or paths that are emphasized in terminal output
+	Highlight []string `json:"highlight,omitempty"`
+	// RedactPaths lists keys or paths whose values are always replaced with "***"
+	RedactPaths []string `json:"redact_paths,omitempty"`
+	// DuplicateKeys selects how repeated object keys are resolved: last, first, error or array
+	DuplicateKeys string `json:"duplicate_keys"`
+	// NonFinite selects what auto-correction writes for NaN, Infinity and undefined: null or string
+	NonFinite string `json:"non_finite,omitempty"`
+	// NumberFormat selects number notation: auto, plain or exponent
+	NumberFormat string `json:"number_format"`
+	// ExponentAbove and ExponentBelow are the magnitudes outside which the
+	// exponent number format switches to exponent notation
+	ExponentAbove float64 `json:"exponent_above"`
+	ExponentBelow float64 `json:"exponent_below"`
+	// IndentString, when set, replaces IndentSpaces as the text of one indentation level
+	IndentString string `json:"indent_string,omitempty"`
+	// Minify writes output on one line without whitespace instead of indenting it
+	Minify bool `json:"minify,omitempty"`
+	// SortMode selects how SortKeys compares keys: ascii, ignore-case or natural
+	SortMode string `json:"sort_mode,omitempty"`
+	// SortReverse sorts keys in descending order
+	SortReverse bool `json:"sort_reverse,omitempty"`
+	// KeyPriority lists keys that are always written first, in this order
+	KeyPriority []string `json:"key_priority,omitempty"`
+	// SortArraysBy, when set, orders arrays of objects by the value of this field
+	SortArraysBy string `json:"sort_arrays_by,omitempty"`
+	// EscapeHTML writes <, > and & in strings as \u003c, \u003e and \u0026
+	EscapeHTML bool `json:"escape_html,omitempty"`
+	// WrapWidth, when positive, keeps objects and arrays that fit in this many columns on one line
+	WrapWidth int `json:"wrap_width,omitempty"`
+	// Presets holds user-defined presets, keyed by name, mapping option names
+	// to the values the preset gives them
+	Presets map[string]map[string]string `json:"presets,omitempty"`
+	// Compress compresses files saved to OutputDir: "gzip", "zstd", or empty for none
+	Compress string `json:"compress,omitempty"`
+	// SandboxDir, when set, is the only directory fj writes files into
+	SandboxDir string `json:"sandbox_dir,omitempty"`
+	// LintRules adjusts the built-in lint rules, keyed by rule name
+	LintRules map[string]LintRule `json:"lint_rules,omitempty"`
+	// LintChecks adds lint rules that check the values at a path with a query
+	LintChecks []LintCheck `json:"lint_checks,omitempty"`
+}
+
+// LintRule adjusts a built-in lint rule
+type LintRule struct {
+	// Severity is "error", "warning" or "off"; empty keeps the rule's default
+	Severity string `json:"severity,omitempty"`
+	// Max is the limit of rules that have one, such as max-depth; zero keeps the default
+	Max int `json:"max,omitempty"`
+}
+
+// LintCheck is a user-defined lint rule: every value at Path must satisfy Check
+type LintCheck struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Check    string `json:"check"`
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -30,15 +101,24 @@ func DefaultConfig() Config {
 	}
 
 	return Config{
-		IndentSpaces:    2,
-		SortKeys:        false,
-		CopyToClipboard: false,
-		OutputDir:       filepath.Join(homeDir, "fj_output"),
-		TrustAllURLs:    false,
-		MaxMemoryMB:     0, // 0 means no limit
-		MaxProcessors:   0, // 0 means use all available
-		LogToFile:       false,
-		LogFilePath:     filepath.Join(homeDir, ".fj", "fj.log"),
+		IndentSpaces:       2,
+		SortKeys:           false,
+		CopyToClipboard:    true,
+		ClipboardLimitKB:   1024,
+		ClipboardTimeoutMS: 5000,
+		OutputDir:          filepath.Join(homeDir, "fj_output"),
+		TrustAllURLs:       false,
+		MaxMemoryMB:        0, // 0 means no limit
+		MaxProcessors:      0, // 0 means use all available
+		LogToFile:          false,
+		LogFilePath:        filepath.Join(homeDir, ".fj", "fj.log"),
+		Accessible:         false,
+		Color:              "auto",
+		Theme:              "",
+		DuplicateKeys:      "last",
+		NumberFormat:       "auto",
+		ExponentAbove:      1e21,
+		ExponentBelow:      1e-6,
 	}
 }
 
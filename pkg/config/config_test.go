@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -38,15 +39,40 @@ func TestSaveAndLoadConfig(t *testing.T) {
 
 	// Create a test config
 	testCfg := Config{
-		IndentSpaces:    4,
-		SortKeys:        true,
-		CopyToClipboard: false,
-		OutputDir:       "/test/output",
-		TrustAllURLs:    true,
-		MaxMemoryMB:     1024,
-		MaxProcessors:   2,
-		LogToFile:       true,
-		LogFilePath:     "/test/log.txt",
+		IndentSpaces:       4,
+		SortKeys:           true,
+		CopyToClipboard:    false,
+		ClipboardLimitKB:   64,
+		ClipboardTimeoutMS: 1500,
+		ClipboardAsync:     true,
+		OutputDir:          "/test/output",
+		TrustAllURLs:       true,
+		MaxMemoryMB:        1024,
+		MaxProcessors:      2,
+		LogToFile:          true,
+		LogFilePath:        "/test/log.txt",
+		Accessible:         true,
+		Theme:              "ocean",
+		Themes: map[string]map[string]string{
+			"ocean": {"key": "bold #005f87", "string": "cyan"},
+		},
+		Highlight:     []string{"error", "data.items[*].id"},
+		RedactPaths:   []string{"password", "*secret*", "auth.token"},
+		DuplicateKeys: "error",
+		NumberFormat:  "exponent",
+		ExponentAbove: 1e9,
+		ExponentBelow: 1e-4,
+		Presets: map[string]map[string]string{
+			"ci": {"clipboard": "false", "theme": ""},
+		},
+		SandboxDir: "/test/sandbox",
+		LintRules: map[string]LintRule{
+			"max-depth":  {Max: 6},
+			"key-casing": {Severity: "off"},
+		},
+		LintChecks: []LintCheck{
+			{Name: "positive-price", Path: "items[*].price", Check: ". > 0", Severity: "error"},
+		},
 	}
 
 	// Override getConfigPath for testing
@@ -81,6 +107,18 @@ func TestSaveAndLoadConfig(t *testing.T) {
 		t.Errorf("LoadConfig().CopyToClipboard = %v, want %v", loadedCfg.CopyToClipboard, testCfg.CopyToClipboard)
 	}
 
+	if loadedCfg.ClipboardLimitKB != testCfg.ClipboardLimitKB {
+		t.Errorf("LoadConfig().ClipboardLimitKB = %v, want %v", loadedCfg.ClipboardLimitKB, testCfg.ClipboardLimitKB)
+	}
+
+	if loadedCfg.ClipboardTimeoutMS != testCfg.ClipboardTimeoutMS {
+		t.Errorf("LoadConfig().ClipboardTimeoutMS = %v, want %v", loadedCfg.ClipboardTimeoutMS, testCfg.ClipboardTimeoutMS)
+	}
+
+	if loadedCfg.ClipboardAsync != testCfg.ClipboardAsync {
+		t.Errorf("LoadConfig().ClipboardAsync = %v, want %v", loadedCfg.ClipboardAsync, testCfg.ClipboardAsync)
+	}
+
 	if loadedCfg.OutputDir != testCfg.OutputDir {
 		t.Errorf("LoadConfig().OutputDir = %v, want %v", loadedCfg.OutputDir, testCfg.OutputDir)
 	}
@@ -104,4 +142,56 @@ func TestSaveAndLoadConfig(t *testing.T) {
 	if loadedCfg.LogFilePath != testCfg.LogFilePath {
 		t.Errorf("LoadConfig().LogFilePath = %v, want %v", loadedCfg.LogFilePath, testCfg.LogFilePath)
 	}
+
+	if loadedCfg.Accessible != testCfg.Accessible {
+		t.Errorf("LoadConfig().Accessible = %v, want %v", loadedCfg.Accessible, testCfg.Accessible)
+	}
+
+	if loadedCfg.Theme != testCfg.Theme {
+		t.Errorf("LoadConfig().Theme = %v, want %v", loadedCfg.Theme, testCfg.Theme)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.Themes, testCfg.Themes) {
+		t.Errorf("LoadConfig().Themes = %v, want %v", loadedCfg.Themes, testCfg.Themes)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.Highlight, testCfg.Highlight) {
+		t.Errorf("LoadConfig().Highlight = %v, want %v", loadedCfg.Highlight, testCfg.Highlight)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.RedactPaths, testCfg.RedactPaths) {
+		t.Errorf("LoadConfig().RedactPaths = %v, want %v", loadedCfg.RedactPaths, testCfg.RedactPaths)
+	}
+
+	if loadedCfg.DuplicateKeys != testCfg.DuplicateKeys {
+		t.Errorf("LoadConfig().DuplicateKeys = %v, want %v", loadedCfg.DuplicateKeys, testCfg.DuplicateKeys)
+	}
+
+	if loadedCfg.NumberFormat != testCfg.NumberFormat {
+		t.Errorf("LoadConfig().NumberFormat = %v, want %v", loadedCfg.NumberFormat, testCfg.NumberFormat)
+	}
+
+	if loadedCfg.ExponentAbove != testCfg.ExponentAbove {
+		t.Errorf("LoadConfig().ExponentAbove = %v, want %v", loadedCfg.ExponentAbove, testCfg.ExponentAbove)
+	}
+
+	if loadedCfg.ExponentBelow != testCfg.ExponentBelow {
+		t.Errorf("LoadConfig().ExponentBelow = %v, want %v", loadedCfg.ExponentBelow, testCfg.ExponentBelow)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.Presets, testCfg.Presets) {
+		t.Errorf("LoadConfig().Presets = %v, want %v", loadedCfg.Presets, testCfg.Presets)
+	}
+
+	if loadedCfg.SandboxDir != testCfg.SandboxDir {
+		t.Errorf("LoadConfig().SandboxDir = %v, want %v", loadedCfg.SandboxDir, testCfg.SandboxDir)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.LintRules, testCfg.LintRules) {
+		t.Errorf("LoadConfig().LintRules = %v, want %v", loadedCfg.LintRules, testCfg.LintRules)
+	}
+
+	if !reflect.DeepEqual(loadedCfg.LintChecks, testCfg.LintChecks) {
+		t.Errorf("LoadConfig().LintChecks = %v, want %v", loadedCfg.LintChecks, testCfg.LintChecks)
+	}
 }
@@ -0,0 +1,204 @@
+package conflict
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Key marks an object that stands in for a value both sides changed differently.
+// Its value is an object holding the "ours", "theirs" and, when known, "base"
+// versions; a side that deleted the value is left out.
+const Key = "$conflict"
+
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSep    = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// Sides holds the versions of a file reconstructed from its conflict markers
+type Sides struct {
+	Ours   []byte
+	Theirs []byte
+	// Base is only set when every hunk carries a base section (diff3 style)
+	Base    []byte
+	HasBase bool
+	// Hunks is the number of conflict hunks found
+	Hunks int
+}
+
+// Split rebuilds the ours, theirs and base versions of a file containing git
+// conflict markers. Text outside the hunks is shared by every version.
+func Split(data []byte) (Sides, error) {
+	var ours, theirs, base strings.Builder
+	var sides Sides
+
+	const (
+		stateCommon = iota
+		stateOurs
+		stateBase
+		stateTheirs
+	)
+	state := stateCommon
+	baseHunks := 0
+
+	for n, line := range strings.SplitAfter(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, markerOurs):
+			if state != stateCommon {
+				return Sides{}, fmt.Errorf("line %d: nested conflict marker", n+1)
+			}
+			state = stateOurs
+			sides.Hunks++
+			continue
+		case strings.HasPrefix(line, markerBase) && state == stateOurs:
+			state = stateBase
+			baseHunks++
+			continue
+		case strings.HasPrefix(line, markerSep) && (state == stateOurs || state == stateBase):
+			state = stateTheirs
+			continue
+		case strings.HasPrefix(line, markerTheirs):
+			if state != stateTheirs {
+				return Sides{}, fmt.Errorf("line %d: unexpected %s", n+1, markerTheirs)
+			}
+			state = stateCommon
+			continue
+		}
+
+		switch state {
+		case stateCommon:
+			ours.WriteString(line)
+			theirs.WriteString(line)
+			base.WriteString(line)
+		case stateOurs:
+			ours.WriteString(line)
+		case stateBase:
+			base.WriteString(line)
+		case stateTheirs:
+			theirs.WriteString(line)
+		}
+	}
+
+	if state != stateCommon {
+		return Sides{}, fmt.Errorf("unterminated conflict hunk")
+	}
+
+	sides.Ours = []byte(ours.String())
+	sides.Theirs = []byte(theirs.String())
+	sides.HasBase = sides.Hunks > 0 && baseHunks == sides.Hunks
+	if sides.HasBase {
+		sides.Base = []byte(base.String())
+	}
+	return sides, nil
+}
+
+// slot is a value that may be absent, such as a key one side deleted
+type slot struct {
+	v  interface{}
+	ok bool
+}
+
+// merger carries the state of one merge
+type merger struct {
+	hasBase   bool
+	order     *formatter.KeyOrder
+	conflicts [][]jsonpath.Segment
+}
+
+// Merge combines two decoded versions of a document. With a base version the
+// merge is three-way: a side that left a value untouched accepts the other
+// side's edit, including deletions. Without one, keys present on only one side
+// are kept. Objects are merged key by key; any other values that differ are
+// replaced by a Key annotation. Merged objects keep the keys of ours in the
+// order order recorded for them, followed by the keys only theirs has, so that
+// the result differs from ours only where the merge changed it. Merge returns
+// the result and the paths of the annotations, in that order.
+func Merge(base, ours, theirs interface{}, hasBase bool, order *formatter.KeyOrder) (interface{}, [][]jsonpath.Segment) {
+	m := &merger{hasBase: hasBase, order: order}
+	result := m.merge(nil, slot{base, hasBase}, slot{ours, true}, slot{theirs, true})
+	return result.v, m.conflicts
+}
+
+// merge merges one value found at path
+func (m *merger) merge(path []jsonpath.Segment, base, ours, theirs slot) slot {
+	if equal(ours, theirs) {
+		return ours
+	}
+	if m.hasBase {
+		if equal(base, ours) {
+			return theirs
+		}
+		if equal(base, theirs) {
+			return ours
+		}
+	} else {
+		if !ours.ok {
+			return theirs
+		}
+		if !theirs.ok {
+			return ours
+		}
+	}
+
+	o, oIsObj := ours.v.(map[string]interface{})
+	t, tIsObj := theirs.v.(map[string]interface{})
+	if ours.ok && theirs.ok && oIsObj && tIsObj {
+		b, _ := base.v.(map[string]interface{})
+		return slot{m.mergeObjects(path, b, o, t), true}
+	}
+
+	m.conflicts = append(m.conflicts, append([]jsonpath.Segment(nil), path...))
+	annotation := make(map[string]interface{})
+	var keys []string
+	if ours.ok {
+		annotation["ours"] = ours.v
+		keys = append(keys, "ours")
+	}
+	if theirs.ok {
+		annotation["theirs"] = theirs.v
+		keys = append(keys, "theirs")
+	}
+	if m.hasBase && base.ok {
+		annotation["base"] = base.v
+		keys = append(keys, "base")
+	}
+	m.order.Record(annotation, keys)
+	return slot{map[string]interface{}{Key: annotation}, true}
+}
+
+// mergeObjects merges two objects key by key; base may be nil
+func (m *merger) mergeObjects(path []jsonpath.Segment, base, ours, theirs map[string]interface{}) map[string]interface{} {
+	keys := m.order.Keys(ours)
+	for _, k := range m.order.Keys(theirs) {
+		if _, ok := ours[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	result := make(map[string]interface{}, len(keys))
+	kept := make([]string, 0, len(keys))
+	for _, k := range keys {
+		b, bOK := base[k]
+		o, oOK := ours[k]
+		t, tOK := theirs[k]
+
+		merged := m.merge(append(path, jsonpath.Key(k)), slot{b, bOK}, slot{o, oOK}, slot{t, tOK})
+		if merged.ok {
+			result[k] = merged.v
+			kept = append(kept, k)
+		}
+	}
+	m.order.Record(result, kept)
+	return result
+}
+
+// equal reports whether two slots hold the same value, or are both absent
+func equal(a, b slot) bool {
+	return a.ok == b.ok && reflect.DeepEqual(a.v, b.v)
+}
@@ -0,0 +1,179 @@
+package conflict
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+const twoWay = `{
+  "name": "fj",
+<<<<<<< HEAD
+  "version": "1.1.0",
+  "tags": ["cli"]
+=======
+  "version": "1.2.0",
+  "license": "MIT"
+>>>>>>> feature
+}
+`
+
+const threeWay = `{
+<<<<<<< HEAD
+  "a": 2,
+  "b": 1,
+  "c": 1
+||||||| base
+  "a": 1,
+  "b": 1,
+  "c": 1
+=======
+  "a": 1,
+  "b": 3,
+  "c": 4
+>>>>>>> feature
+}
+`
+
+func TestSplit(t *testing.T) {
+	sides, err := Split([]byte(twoWay))
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if sides.Hunks != 1 || sides.HasBase {
+		t.Errorf("Split() hunks = %d, hasBase = %v, want 1, false", sides.Hunks, sides.HasBase)
+	}
+
+	wantOurs := "{\n  \"name\": \"fj\",\n  \"version\": \"1.1.0\",\n  \"tags\": [\"cli\"]\n}\n"
+	if string(sides.Ours) != wantOurs {
+		t.Errorf("Split() ours = %q, want %q", sides.Ours, wantOurs)
+	}
+
+	sides, err = Split([]byte(threeWay))
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if !sides.HasBase {
+		t.Errorf("Split() did not find the base section")
+	}
+	if !json.Valid(sides.Base) || !json.Valid(sides.Ours) || !json.Valid(sides.Theirs) {
+		t.Errorf("Split() produced invalid JSON: %s | %s | %s", sides.Ours, sides.Base, sides.Theirs)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	for _, input := range []string{
+		"<<<<<<< a\n1\n",
+		"<<<<<<< a\n<<<<<<< b\n",
+		">>>>>>> b\n",
+		"<<<<<<< a\n1\n>>>>>>> b\n",
+	} {
+		if _, err := Split([]byte(input)); err == nil {
+			t.Errorf("Split(%q) expected an error", input)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		conflicts []string
+	}{
+		{
+			name:      "Two-way keeps keys from both sides",
+			input:     twoWay,
+			want:      `{"license":"MIT","name":"fj","tags":["cli"],"version":{"$conflict":{"ours":"1.1.0","theirs":"1.2.0"}}}`,
+			conflicts: []string{"version"},
+		},
+		{
+			name:  "Three-way takes the side that changed",
+			input: threeWay,
+			want:  `{"a":2,"b":3,"c":4}`,
+		},
+		{
+			name:      "Three-way conflict",
+			input:     "{\n<<<<<<< a\n\"x\": 2\n||||||| b\n\"x\": 1\n=======\n\"x\": 3\n>>>>>>> c\n}\n",
+			want:      `{"x":{"$conflict":{"base":1,"ours":2,"theirs":3}}}`,
+			conflicts: []string{"x"},
+		},
+		{
+			name:  "Three-way deletion",
+			input: "{\"keep\": 1\n<<<<<<< a\n, \"gone\": 1\n||||||| b\n, \"gone\": 1\n=======\n>>>>>>> c\n}\n",
+			want:  `{"keep":1}`,
+		},
+		{
+			name:      "Delete and edit conflict",
+			input:     "{\"keep\": 1\n<<<<<<< a\n, \"x\": 2\n||||||| b\n, \"x\": 1\n=======\n>>>>>>> c\n}\n",
+			want:      `{"keep":1,"x":{"$conflict":{"base":1,"ours":2}}}`,
+			conflicts: []string{"x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sides, err := Split([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Split() error = %v", err)
+			}
+
+			var base, ours, theirs interface{}
+			if sides.HasBase {
+				if err := json.Unmarshal(sides.Base, &base); err != nil {
+					t.Fatalf("base: %v", err)
+				}
+			}
+			if err := json.Unmarshal(sides.Ours, &ours); err != nil {
+				t.Fatalf("ours: %v", err)
+			}
+			if err := json.Unmarshal(sides.Theirs, &theirs); err != nil {
+				t.Fatalf("theirs: %v", err)
+			}
+
+			merged, conflicts := Merge(base, ours, theirs, sides.HasBase, nil)
+			got, _ := json.Marshal(merged)
+			if string(got) != tt.want {
+				t.Errorf("Merge() = %s, want %s", got, tt.want)
+			}
+
+			if len(conflicts) != len(tt.conflicts) {
+				t.Fatalf("Merge() conflicts = %v, want %v", conflicts, tt.conflicts)
+			}
+			for i, path := range conflicts {
+				if jsonpath.String(path) != tt.conflicts[i] {
+					t.Errorf("conflict %d = %s, want %s", i, jsonpath.String(path), tt.conflicts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeKeepsKeyOrder(t *testing.T) {
+	sides, err := Split([]byte(twoWay))
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder(), Compact: true}
+	ours, err := formatter.Decode(sides.Ours, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := formatter.Decode(sides.Theirs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, _ := Merge(nil, ours, theirs, false, opts.KeyOrder)
+	got, err := formatter.FormatValue(merged, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"fj","version":{"$conflict":{"ours":"1.1.0","theirs":"1.2.0"}},"tags":["cli"],"license":"MIT"}`
+	if string(got) != want {
+		t.Errorf("Merge() = %s, want %s", got, want)
+	}
+}
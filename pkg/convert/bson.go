@@ -0,0 +1,382 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bsonEncoder writes documents in BSON, reading the Extended JSON wrappers
+// that bsonToJSON writes, such as {"$oid": "..."}, back into their types
+type bsonEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+}
+
+// encodeBSON writes v as a BSON document, or an array of objects as
+// documents back to back, the way mongodump writes a collection
+func encodeBSON(v interface{}, keys func(map[string]interface{}) []string) ([]byte, error) {
+	e := &bsonEncoder{keys: keys}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if err := e.document(val, ""); err != nil {
+			return nil, err
+		}
+	case []interface{}:
+		for i, item := range val {
+			doc, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("item %d is %s, not an object; BSON holds documents", i, typeName(item))
+			}
+			if err := e.document(doc, ""); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("BSON holds documents, so it is written from an object or an array of objects, not %s", typeName(v))
+	}
+	return e.buf.Bytes(), nil
+}
+
+// document writes an object as a document: its size, its elements and a
+// NUL byte
+func (e *bsonEncoder) document(m map[string]interface{}, path string) error {
+	start := e.buf.Len()
+	e.buf.Write(make([]byte, 4))
+	for _, k := range e.keys(m) {
+		if err := e.element(k, m[k], joinPath(path, k)); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(0)
+	binary.LittleEndian.PutUint32(e.buf.Bytes()[start:], uint32(e.buf.Len()-start))
+	return nil
+}
+
+// array writes an array as a document keyed "0", "1" and so on
+func (e *bsonEncoder) array(items []interface{}, path string) error {
+	start := e.buf.Len()
+	e.buf.Write(make([]byte, 4))
+	for i, item := range items {
+		if err := e.element(strconv.Itoa(i), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(0)
+	binary.LittleEndian.PutUint32(e.buf.Bytes()[start:], uint32(e.buf.Len()-start))
+	return nil
+}
+
+// element writes a name and value, after the type byte of the value
+func (e *bsonEncoder) element(name string, v interface{}, path string) error {
+	if strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("key %q holds a NUL byte, which BSON names cannot", name)
+	}
+	typePos := e.buf.Len()
+	e.buf.WriteByte(0)
+	e.cstring(name)
+	t, err := e.value(v, path)
+	if err != nil {
+		return err
+	}
+	e.buf.Bytes()[typePos] = t
+	return nil
+}
+
+// value writes a value and returns its type
+func (e *bsonEncoder) value(v interface{}, path string) (byte, error) {
+	switch val := v.(type) {
+	case nil:
+		return bsonNull, nil
+	case bool:
+		if val {
+			e.buf.WriteByte(1)
+		} else {
+			e.buf.WriteByte(0)
+		}
+		return bsonBool, nil
+	case string:
+		e.string(val)
+		return bsonString, nil
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			return e.int(int64(val)), nil
+		}
+		e.double(val)
+		return bsonDouble, nil
+	case json.Number:
+		// Numbers too large for float64 to hold exactly
+		if n, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return e.int(n), nil
+		}
+		if strings.ContainsAny(string(val), ".eE") {
+			if f, err := val.Float64(); err == nil {
+				e.double(f)
+				return bsonDouble, nil
+			}
+		}
+		return 0, fmt.Errorf("%s: %s is out of the range of BSON integers; write it as {\"$numberDecimal\": \"%s\"}", path, val, val)
+	case []interface{}:
+		return bsonArray, e.array(val, path)
+	case map[string]interface{}:
+		if t, ok, err := e.extended(val, path); ok || err != nil {
+			return t, err
+		}
+		return bsonDocument, e.document(val, path)
+	}
+	return 0, fmt.Errorf("cannot write %T as BSON", v)
+}
+
+// extended writes an Extended JSON wrapper as the type it stands for,
+// reporting false when m is an ordinary object
+func (e *bsonEncoder) extended(m map[string]interface{}, path string) (byte, bool, error) {
+	if code, ok := m["$code"].(string); ok && len(m) == 2 {
+		scope, ok := m["$scope"].(map[string]interface{})
+		if !ok {
+			return 0, false, nil
+		}
+		start := e.buf.Len()
+		e.buf.Write(make([]byte, 4))
+		e.string(code)
+		if err := e.document(scope, path+".$scope"); err != nil {
+			return 0, true, err
+		}
+		binary.LittleEndian.PutUint32(e.buf.Bytes()[start:], uint32(e.buf.Len()-start))
+		return bsonCodeScope, true, nil
+	}
+	if len(m) != 1 {
+		return 0, false, nil
+	}
+
+	key := e.keys(m)[0]
+	v := m[key]
+	bad := func(want string) (byte, bool, error) {
+		return 0, true, fmt.Errorf("%s: %s must hold %s", path, key, want)
+	}
+	s, isString := v.(string)
+	fields, _ := v.(map[string]interface{})
+
+	switch key {
+	case "$oid":
+		raw, err := hex.DecodeString(s)
+		if !isString || err != nil || len(raw) != 12 {
+			return bad("24 hexadecimal digits")
+		}
+		e.buf.Write(raw)
+		return bsonObjectID, true, nil
+	case "$date":
+		ms, ok := dateMillis(v)
+		if !ok {
+			return bad(`an RFC 3339 string, milliseconds since the epoch or {"$numberLong": "..."}`)
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(ms)))
+		return bsonDateTime, true, nil
+	case "$numberInt":
+		n, err := strconv.ParseInt(s, 10, 32)
+		if !isString || err != nil {
+			return bad("a 32-bit integer as a string")
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(n)))
+		return bsonInt32, true, nil
+	case "$numberLong":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if !isString || err != nil {
+			return bad("a 64-bit integer as a string")
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(n)))
+		return bsonInt64, true, nil
+	case "$numberDouble":
+		f, err := strconv.ParseFloat(s, 64)
+		if !isString || err != nil {
+			return bad(`a number as a string, such as "1.5" or "Infinity"`)
+		}
+		e.double(f)
+		return bsonDouble, true, nil
+	case "$numberDecimal":
+		high, low, err := parseDecimal128(s)
+		if !isString || err != nil {
+			return bad("a decimal number of up to 34 digits as a string")
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint64(nil, low))
+		e.buf.Write(binary.LittleEndian.AppendUint64(nil, high))
+		return bsonDecimal128, true, nil
+	case "$binary":
+		data, ok1 := fields["base64"].(string)
+		subType, ok2 := fields["subType"].(string)
+		raw, err := base64.StdEncoding.DecodeString(data)
+		st, err2 := hex.DecodeString(subType)
+		if !ok1 || !ok2 || len(fields) != 2 || err != nil || err2 != nil || len(st) != 1 {
+			return bad(`{"base64": "...", "subType": "00"}`)
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(raw))))
+		e.buf.WriteByte(st[0])
+		e.buf.Write(raw)
+		return bsonBinary, true, nil
+	case "$regularExpression":
+		pattern, ok1 := fields["pattern"].(string)
+		options, ok2 := fields["options"].(string)
+		if !ok1 || !ok2 || len(fields) != 2 || strings.IndexByte(pattern+options, 0) >= 0 {
+			return bad(`{"pattern": "...", "options": "..."}`)
+		}
+		e.cstring(pattern)
+		e.cstring(options)
+		return bsonRegex, true, nil
+	case "$timestamp":
+		t, ok1 := uint32Field(fields["t"])
+		i, ok2 := uint32Field(fields["i"])
+		if !ok1 || !ok2 || len(fields) != 2 {
+			return bad(`{"t": seconds, "i": increment}`)
+		}
+		e.buf.Write(binary.LittleEndian.AppendUint32(nil, i))
+		e.buf.Write(binary.LittleEndian.AppendUint32(nil, t))
+		return bsonTimestamp, true, nil
+	case "$dbPointer":
+		ref, ok1 := fields["$ref"].(string)
+		id, ok2 := fields["$id"].(map[string]interface{})
+		oid, _ := id["$oid"].(string)
+		raw, err := hex.DecodeString(oid)
+		if !ok1 || !ok2 || len(fields) != 2 || len(id) != 1 || err != nil || len(raw) != 12 {
+			return bad(`{"$ref": "...", "$id": {"$oid": "..."}}`)
+		}
+		e.string(ref)
+		e.buf.Write(raw)
+		return bsonDBPointer, true, nil
+	case "$code", "$symbol":
+		if !isString {
+			return bad("a string")
+		}
+		e.string(s)
+		if key == "$symbol" {
+			return bsonSymbol, true, nil
+		}
+		return bsonCode, true, nil
+	case "$minKey", "$maxKey":
+		if n, ok := v.(float64); !ok || n != 1 {
+			return bad("1")
+		}
+		if key == "$minKey" {
+			return bsonMinKey, true, nil
+		}
+		return bsonMaxKey, true, nil
+	case "$undefined":
+		if v != true {
+			return bad("true")
+		}
+		return bsonUndefined, true, nil
+	}
+	return 0, false, nil
+}
+
+// dateMillis reads the value of a $date wrapper as milliseconds since the
+// epoch
+func dateMillis(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		return t.UnixMilli(), err == nil
+	case float64:
+		return int64(val), val == math.Trunc(val)
+	case map[string]interface{}:
+		s, ok := val["$numberLong"].(string)
+		if !ok || len(val) != 1 {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// uint32Field reads a number that must fit in 32 unsigned bits
+func uint32Field(v interface{}) (uint32, bool) {
+	n, ok := v.(float64)
+	if !ok || n != math.Trunc(n) || n < 0 || n > math.MaxUint32 {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+// int writes an integer as an int32 when it fits, and otherwise an int64
+func (e *bsonEncoder) int(n int64) byte {
+	if n >= math.MinInt32 && n <= math.MaxInt32 {
+		e.buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(n)))
+		return bsonInt32
+	}
+	e.buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(n)))
+	return bsonInt64
+}
+
+// double writes a float64
+func (e *bsonEncoder) double(f float64) {
+	e.buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// string writes a string: its length including a NUL byte, then its bytes
+func (e *bsonEncoder) string(s string) {
+	e.buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(s)+1)))
+	e.cstring(s)
+}
+
+// cstring writes a NUL-terminated string
+func (e *bsonEncoder) cstring(s string) {
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+}
+
+// joinPath names key within the object at path, for error messages
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// parseDecimal128 reads a decimal number, such as "-1.50" or "2E+10", into
+// the high and low 64 bits of an IEEE 754 decimal128
+func parseDecimal128(s string) (high, low uint64, err error) {
+	text := s
+	var sign uint64
+	if strings.HasPrefix(text, "-") {
+		sign, text = 1<<63, text[1:]
+	} else {
+		text = strings.TrimPrefix(text, "+")
+	}
+	switch strings.ToLower(text) {
+	case "nan":
+		return 0x1f << 58, 0, nil
+	case "inf", "infinity":
+		return sign | 0x1e<<58, 0, nil
+	}
+
+	mant, exp := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		if exp, err = strconv.Atoi(text[i+1:]); err != nil {
+			return 0, 0, fmt.Errorf("%q is not a decimal number", s)
+		}
+		mant = text[:i]
+	}
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		exp -= len(mant) - i - 1
+		mant = mant[:i] + mant[i+1:]
+	}
+	if mant == "" || strings.Trim(mant, "0123456789") != "" {
+		return 0, 0, fmt.Errorf("%q is not a decimal number", s)
+	}
+	mant = strings.TrimLeft(mant, "0")
+	if len(mant) > 34 || exp < -6176 || exp > 6111 {
+		return 0, 0, fmt.Errorf("%q is out of the range of decimal128", s)
+	}
+
+	coef, _ := new(big.Int).SetString("0"+mant, 10)
+	low = coef.Uint64()
+	high = sign | uint64(exp+6176)<<49 | coef.Rsh(coef, 64).Uint64()
+	return high, low, nil
+}
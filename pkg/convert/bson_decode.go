@@ -0,0 +1,379 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BSON element types
+const (
+	bsonDouble     = 0x01
+	bsonString     = 0x02
+	bsonDocument   = 0x03
+	bsonArray      = 0x04
+	bsonBinary     = 0x05
+	bsonUndefined  = 0x06
+	bsonObjectID   = 0x07
+	bsonBool       = 0x08
+	bsonDateTime   = 0x09
+	bsonNull       = 0x0a
+	bsonRegex      = 0x0b
+	bsonDBPointer  = 0x0c
+	bsonCode       = 0x0d
+	bsonSymbol     = 0x0e
+	bsonCodeScope  = 0x0f
+	bsonInt32      = 0x10
+	bsonTimestamp  = 0x11
+	bsonInt64      = 0x12
+	bsonDecimal128 = 0x13
+	bsonMinKey     = 0xff
+	bsonMaxKey     = 0x7f
+)
+
+// bsonDecoder reads BSON documents from data
+type bsonDecoder struct {
+	data []byte
+	pos  int
+}
+
+// bsonToJSON converts BSON documents to JSON text, writing the types JSON
+// lacks in MongoDB Extended JSON, relaxed mode: {"$oid": "..."} for an
+// ObjectId, {"$date": "2024-05-01T12:30:00Z"} for a date, and so on.
+// Several documents back to back, as mongodump writes them, become an
+// array of them.
+func bsonToJSON(data []byte) ([]byte, error) {
+	d := &bsonDecoder{data: data}
+	var docs []interface{}
+	for d.pos < len(d.data) {
+		doc, err := d.document()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return writeValues(docs)
+}
+
+// document reads a document: its size, its elements and a NUL byte
+func (d *bsonDecoder) document() (*orderedMap, error) {
+	start := d.pos
+	size, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+	if size < 5 || int64(size) > int64(len(d.data)-start) {
+		return nil, fmt.Errorf("offset %d: document size %d does not fit the data", start, size)
+	}
+	end := start + int(size)
+
+	m := &orderedMap{}
+	for {
+		b, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] == 0 {
+			break
+		}
+		name, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.element(b[0], name)
+		if err != nil {
+			return nil, err
+		}
+		m.add(name, v)
+	}
+	if d.pos != end {
+		return nil, fmt.Errorf("offset %d: document ends at offset %d, not at %d as its size says", start, d.pos, end)
+	}
+	return m, nil
+}
+
+// element reads the value of an element of type t
+func (d *bsonDecoder) element(t byte, name string) (interface{}, error) {
+	start := d.pos
+	switch t {
+	case bsonDouble:
+		raw, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(raw))
+		switch {
+		case math.IsNaN(f):
+			return wrapped("$numberDouble", "NaN"), nil
+		case math.IsInf(f, 1):
+			return wrapped("$numberDouble", "Infinity"), nil
+		case math.IsInf(f, -1):
+			return wrapped("$numberDouble", "-Infinity"), nil
+		}
+		return floatLiteral(f, 64)
+	case bsonString:
+		return d.string()
+	case bsonDocument:
+		return d.document()
+	case bsonArray:
+		doc, err := d.document()
+		if err != nil {
+			return nil, err
+		}
+		// Arrays are documents keyed "0", "1" and so on
+		return doc.values, nil
+	case bsonBinary:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("offset %d: negative binary length", start)
+		}
+		raw, err := d.read(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		bin := &orderedMap{}
+		bin.add("base64", base64.StdEncoding.EncodeToString(raw[1:]))
+		bin.add("subType", fmt.Sprintf("%02x", raw[0]))
+		return wrapped("$binary", bin), nil
+	case bsonUndefined:
+		return wrapped("$undefined", literal("true")), nil
+	case bsonObjectID:
+		raw, err := d.read(12)
+		if err != nil {
+			return nil, err
+		}
+		return wrapped("$oid", hex.EncodeToString(raw)), nil
+	case bsonBool:
+		raw, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return literal(strconv.FormatBool(raw[0] != 0)), nil
+	case bsonDateTime:
+		ms, err := d.int64()
+		if err != nil {
+			return nil, err
+		}
+		return bsonDate(ms), nil
+	case bsonNull:
+		return nil, nil
+	case bsonRegex:
+		pattern, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		regex := &orderedMap{}
+		regex.add("pattern", pattern)
+		regex.add("options", options)
+		return wrapped("$regularExpression", regex), nil
+	case bsonDBPointer:
+		ref, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.read(12)
+		if err != nil {
+			return nil, err
+		}
+		pointer := &orderedMap{}
+		pointer.add("$ref", ref)
+		pointer.add("$id", wrapped("$oid", hex.EncodeToString(raw)))
+		return wrapped("$dbPointer", pointer), nil
+	case bsonCode:
+		code, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		return wrapped("$code", code), nil
+	case bsonSymbol:
+		symbol, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		return wrapped("$symbol", symbol), nil
+	case bsonCodeScope:
+		if _, err := d.int32(); err != nil {
+			return nil, err
+		}
+		code, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		scope, err := d.document()
+		if err != nil {
+			return nil, err
+		}
+		m := wrapped("$code", code)
+		m.add("$scope", scope)
+		return m, nil
+	case bsonInt32:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		return literal(strconv.FormatInt(int64(n), 10)), nil
+	case bsonTimestamp:
+		raw, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		ts := &orderedMap{}
+		ts.add("t", literal(strconv.FormatUint(uint64(binary.LittleEndian.Uint32(raw[4:])), 10)))
+		ts.add("i", literal(strconv.FormatUint(uint64(binary.LittleEndian.Uint32(raw)), 10)))
+		return wrapped("$timestamp", ts), nil
+	case bsonInt64:
+		n, err := d.int64()
+		if err != nil {
+			return nil, err
+		}
+		return literal(strconv.FormatInt(n, 10)), nil
+	case bsonDecimal128:
+		raw, err := d.read(16)
+		if err != nil {
+			return nil, err
+		}
+		return wrapped("$numberDecimal", decimal128String(binary.LittleEndian.Uint64(raw[8:]), binary.LittleEndian.Uint64(raw))), nil
+	case bsonMinKey:
+		return wrapped("$minKey", literal("1")), nil
+	case bsonMaxKey:
+		return wrapped("$maxKey", literal("1")), nil
+	}
+	return nil, fmt.Errorf("offset %d: %s has unknown type 0x%02x", start-len(name)-2, name, t)
+}
+
+// wrapped returns an Extended JSON wrapper such as {"$oid": "..."}
+func wrapped(key string, v interface{}) *orderedMap {
+	m := &orderedMap{}
+	m.add(key, v)
+	return m
+}
+
+// bsonDate writes a date, given in milliseconds since the epoch, as an RFC
+// 3339 string for years 1970 to 9999, and as {"$numberLong": "..."} beyond
+func bsonDate(ms int64) *orderedMap {
+	t := time.UnixMilli(ms).UTC()
+	if t.Year() < 1970 || t.Year() > 9999 {
+		return wrapped("$date", wrapped("$numberLong", strconv.FormatInt(ms, 10)))
+	}
+	return wrapped("$date", t.Format("2006-01-02T15:04:05.999Z07:00"))
+}
+
+// decimal128String writes an IEEE 754 decimal128 number, given as its high
+// and low 64 bits, the way the BSON specification does
+func decimal128String(high, low uint64) string {
+	sign := ""
+	if high>>63 == 1 {
+		sign = "-"
+	}
+	switch high >> 58 & 0x1f {
+	case 0x1f:
+		return "NaN"
+	case 0x1e:
+		return sign + "Infinity"
+	}
+
+	var exp int
+	coef := new(big.Int)
+	if high>>61&3 == 3 {
+		// This form only holds coefficients beyond the 34 digits allowed,
+		// which count as zero
+		exp = int(high >> 47 & 0x3fff)
+	} else {
+		exp = int(high >> 49 & 0x3fff)
+		coef.SetUint64(high & (1<<49 - 1))
+		coef.Lsh(coef, 64).Or(coef, new(big.Int).SetUint64(low))
+		if len(coef.String()) > 34 {
+			coef.SetUint64(0)
+		}
+	}
+	exp -= 6176
+
+	digits := coef.String()
+	adjusted := exp + len(digits) - 1
+	if exp > 0 || adjusted < -6 {
+		text := digits[:1]
+		if len(digits) > 1 {
+			text += "." + digits[1:]
+		}
+		return sign + text + "E" + fmt.Sprintf("%+d", adjusted)
+	}
+	if exp == 0 {
+		return sign + digits
+	}
+	if point := len(digits) + exp; point > 0 {
+		return sign + digits[:point] + "." + digits[point:]
+	}
+	return sign + "0." + strings.Repeat("0", -exp-len(digits)) + digits
+}
+
+// string reads a string: its length including a NUL byte, then its bytes
+func (d *bsonDecoder) string() (string, error) {
+	start := d.pos
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", fmt.Errorf("offset %d: string length %d is too short", start, n)
+	}
+	raw, err := d.read(int(n))
+	if err != nil {
+		return "", err
+	}
+	if raw[n-1] != 0 {
+		return "", fmt.Errorf("offset %d: string does not end with a NUL byte", start)
+	}
+	return string(raw[:n-1]), nil
+}
+
+// cstring reads a NUL-terminated string, as used for element names
+func (d *bsonDecoder) cstring() (string, error) {
+	for i := d.pos; i < len(d.data); i++ {
+		if d.data[i] == 0 {
+			s := string(d.data[d.pos:i])
+			d.pos = i + 1
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("offset %d: unexpected end of data", d.pos)
+}
+
+// int32 reads a little-endian 32-bit integer
+func (d *bsonDecoder) int32() (int32, error) {
+	raw, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(raw)), nil
+}
+
+// int64 reads a little-endian 64-bit integer
+func (d *bsonDecoder) int64() (int64, error) {
+	raw, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(raw)), nil
+}
+
+// read returns the next n bytes
+func (d *bsonDecoder) read(n int) ([]byte, error) {
+	if n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("offset %d: unexpected end of data", d.pos)
+	}
+	d.pos += n
+	return d.data[d.pos-n : d.pos], nil
+}
@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestBSONToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+		want string
+	}{
+		{"Strings", "160000000268656c6c6f0006000000776f726c640000", `{"hello":"world"}`},
+		{"Integers", "1700000010610001000000126200000000000100000000", `{"a":1,"b":4294967296}`},
+		{"Arrays", "140000000461000c000000083000010a31000000", `{"a":[true,null]}`},
+		{"ObjectId", "14000000076100507f1f77bcf86cd79943901100", `{"a":{"$oid":"507f1f77bcf86cd799439011"}}`},
+		{"Dates", "10000000096100e80300000000000000", `{"a":{"$date":"1970-01-01T00:00:01Z"}}`},
+		{"Dates before 1970", "10000000096100807be1ffffffffff00", `{"a":{"$date":{"$numberLong":"-2000000"}}}`},
+		{"Binary", "10000000056100030000000001020300", `{"a":{"$binary":{"base64":"AQID","subType":"00"}}}`},
+		{"Regular expressions", "0d0000000b61005e6100690000", `{"a":{"$regularExpression":{"pattern":"^a","options":"i"}}}`},
+		{"Timestamps", "1000000011610001000000c858326600", `{"a":{"$timestamp":{"t":1714575560,"i":1}}}`},
+		{"Decimals", "180000001361007b000000000000000000000000003c3000", `{"a":{"$numberDecimal":"1.23"}}`},
+		{"Infinity", "10000000016100000000000000f07f00", `{"a":{"$numberDouble":"Infinity"}}`},
+		{"Min and max keys", "0b000000ff61007f620000", `{"a":{"$minKey":1},"b":{"$maxKey":1}}`},
+		{"Documents back to back", "0500000000" + "0c0000001061000100000000", `[{},{"a":1}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ToJSON(data, BSON, Options{})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBSONToJSONErrors(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{"050000000005", "offset 5: unexpected end of data"},
+		{"0600000000", "offset 0: document size 6 does not fit the data"},
+		{"060000000000", "offset 0: document ends at offset 5, not at 6 as its size says"},
+		{"0800000020610000", "offset 4: a has unknown type 0x20"},
+		{"0e00000002610002000000616200", "string does not end with a NUL byte"},
+		{"0800000001610000", "offset 7: unexpected end of data"},
+	}
+
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ToJSON(data, BSON, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%s) error = %v, want it to contain %q", tt.hex, err, tt.want)
+		}
+	}
+}
+
+func TestBSONRoundTrip(t *testing.T) {
+	doc := `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"name":"fj","n":-70000,"big":9007199254740993,"pi":3.14,` +
+		`"at":{"$date":"2024-05-01T12:30:00.5Z"},"old":{"$date":{"$numberLong":"-86400000"}},"price":{"$numberDecimal":"19.99"},` +
+		`"raw":{"$binary":{"base64":"AQID","subType":"04"}},"re":{"$regularExpression":{"pattern":"^a","options":"i"}},` +
+		`"ts":{"$timestamp":{"t":1714566600,"i":1}},"inf":{"$numberDouble":"-Infinity"},"code":{"$code":"x","$scope":{"x":1}},` +
+		`"tags":["cli","json"],"nested":{"empty":{},"list":[],"none":null,"ok":true}}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	data, err := Encode(v, BSON, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(data, BSON, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for %x", err, data)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
@@ -0,0 +1,88 @@
+package convert
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeBSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		// The example of bsonspec.org
+		{`{"hello": "world"}`, "160000000268656c6c6f0006000000776f726c640000"},
+		{`{"a": 1}`, "0c0000001061000100000000"},
+		{`{"a": 3000000000}`, "10000000126100005ed0b20000000000"},
+		{`{"a": 1.5}`, "10000000016100000000000000f83f00"},
+		{`{"a": [true, null]}`, "140000000461000c000000083000010a31000000"},
+		{`{"a": {"$oid": "507f1f77bcf86cd799439011"}}`, "14000000076100507f1f77bcf86cd79943901100"},
+		{`{"a": {"$date": "1970-01-01T00:00:01Z"}}`, "10000000096100e80300000000000000"},
+		{`{"a": {"$numberDecimal": "1"}}`, "180000001361000100000000000000000000000000403000"},
+		{`{"a": {"$numberLong": "1"}}`, "10000000126100010000000000000000"},
+		{`[{}, {}]`, "05000000000500000000"},
+	}
+
+	for _, tt := range tests {
+		opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+		v, err := formatter.Decode([]byte(tt.json), opts)
+		if err != nil {
+			t.Fatalf("Decode(%s) error = %v", tt.json, err)
+		}
+		got, err := Encode(v, BSON, opts, Options{})
+		if err != nil {
+			t.Fatalf("Encode(%s) error = %v", tt.json, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("Encode(%s) = %x, want %s", tt.json, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeBSONErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`"x"`, "written from an object or an array of objects, not a string"},
+		{`[{}, 1]`, "item 1 is a number, not an object"},
+		{`{"a": {"$oid": "xyz"}}`, "a: $oid must hold 24 hexadecimal digits"},
+		{`{"a": [{"$date": "soon"}]}`, "a[0]: $date must hold an RFC 3339 string"},
+		{`{"a": {"b": {"$numberDecimal": "1.2.3"}}}`, "a.b: $numberDecimal must hold"},
+		{`{"n": 18446744073709551615}`, "n: 18446744073709551615 is out of the range of BSON integers"},
+		{`{"a\u0000b": 1}`, "holds a NUL byte"},
+	}
+
+	for _, tt := range tests {
+		opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+		v, err := formatter.Decode([]byte(tt.json), opts)
+		if err != nil {
+			t.Fatalf("Decode(%s) error = %v", tt.json, err)
+		}
+		_, err = Encode(v, BSON, opts, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Encode(%s) error = %v, want it to contain %q", tt.json, err, tt.want)
+		}
+	}
+}
+
+func TestDecimal128(t *testing.T) {
+	for _, s := range []string{"1", "0.1", "-0", "123.45", "0.000001", "1E-7", "1E+1", "-1.000", "9999999999999999999999999999999999", "Infinity", "-Infinity", "NaN"} {
+		high, low, err := parseDecimal128(s)
+		if err != nil {
+			t.Fatalf("parseDecimal128(%q) error = %v", s, err)
+		}
+		if got := decimal128String(high, low); got != s {
+			t.Errorf("decimal128String(parseDecimal128(%q)) = %q", s, got)
+		}
+	}
+
+	for _, s := range []string{"", "1.2.3", "abc", "1e", "10000000000000000000000000000000000", "1E+6112"} {
+		if _, _, err := parseDecimal128(s); err == nil {
+			t.Errorf("parseDecimal128(%q) error = nil, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// CBOR major types, in the top three bits of the first byte of an item
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// cborEncoder writes values in CBOR, choosing the shortest encoding of
+// every length and number, as RFC 8949 preferred serialization does
+type cborEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+}
+
+// encodeCBOR writes v as a single CBOR item
+func encodeCBOR(v interface{}, keys func(map[string]interface{}) []string) ([]byte, error) {
+	e := &cborEncoder{keys: keys}
+	if err := e.value(v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// value writes one item and everything it holds
+func (e *cborEncoder) value(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		e.buf.WriteByte(0xf6)
+	case bool:
+		if val {
+			e.buf.WriteByte(0xf5)
+		} else {
+			e.buf.WriteByte(0xf4)
+		}
+	case string:
+		e.head(cborText, uint64(len(val)))
+		e.buf.WriteString(val)
+	case float64:
+		if val == math.Trunc(val) && val >= -(1<<63) && val < 1<<63 {
+			e.int(int64(val))
+		} else {
+			e.float(val)
+		}
+	case json.Number:
+		// Numbers too large for float64 to hold exactly
+		n, ok := new(big.Int).SetString(string(val), 10)
+		if !ok {
+			f, err := val.Float64()
+			if err != nil {
+				return fmt.Errorf("%s is out of the range of CBOR numbers", val)
+			}
+			e.float(f)
+			return nil
+		}
+		e.bigInt(n)
+	case []interface{}:
+		e.head(cborArray, uint64(len(val)))
+		for _, item := range val {
+			if err := e.value(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		e.head(cborMap, uint64(len(val)))
+		for _, k := range e.keys(val) {
+			e.head(cborText, uint64(len(k)))
+			e.buf.WriteString(k)
+			if err := e.value(val[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot write %T as CBOR", v)
+	}
+	return nil
+}
+
+// head writes the first bytes of an item: its major type and an argument,
+// such as a length, in as few bytes as it fits
+func (e *cborEncoder) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		e.buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(major | 25)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(major | 26)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(major | 27)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// int writes an integer; negative ones are stored as -1 - n
+func (e *cborEncoder) int(n int64) {
+	if n >= 0 {
+		e.head(cborUint, uint64(n))
+	} else {
+		e.head(cborNegInt, uint64(-1-n))
+	}
+}
+
+// bigInt writes an integer of any size: as a plain integer when it fits in
+// 64 bits, and otherwise as a bignum, tag 2 or 3 on its bytes
+func (e *cborEncoder) bigInt(n *big.Int) {
+	major, tag := byte(cborUint), uint64(2)
+	if n.Sign() < 0 {
+		// Negative numbers are stored as -1 - n
+		major, tag = cborNegInt, 3
+		n = new(big.Int).Sub(big.NewInt(-1), n)
+	}
+	if n.IsUint64() {
+		e.head(major, n.Uint64())
+		return
+	}
+	e.head(cborTag, tag)
+	b := n.Bytes()
+	e.head(cborBytes, uint64(len(b)))
+	e.buf.Write(b)
+}
+
+// float writes a float in the shortest of the half, single and double
+// precision forms that holds it exactly
+func (e *cborEncoder) float(f float64) {
+	if h, ok := toHalf(f); ok {
+		e.buf.WriteByte(0xf9)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, h))
+		return
+	}
+	if float64(float32(f)) == f {
+		e.buf.WriteByte(0xfa)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))))
+		return
+	}
+	e.buf.WriteByte(0xfb)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// toHalf returns the half-precision bits of f, when a half-precision float
+// holds it exactly
+func toHalf(f float64) (uint16, bool) {
+	if float64(float32(f)) != f {
+		return 0, false
+	}
+	bits := math.Float32bits(float32(f))
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127
+	mant := bits & 0x7fffff
+	switch {
+	case f == 0:
+		return sign, true
+	case exp >= -14 && exp <= 15 && mant&0x1fff == 0:
+		// Normal numbers keep 10 of the 23 bits of the mantissa
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		// Subnormal numbers shift the mantissa, with its implicit 1, right
+		shift := uint(-14 - exp + 13)
+		full := mant | 0x800000
+		if full&(1<<shift-1) == 0 {
+			return sign | uint16(full>>shift), true
+		}
+	}
+	return 0, false
+}
+
+// fromHalf returns the value of half-precision bits
+func fromHalf(h uint16) float64 {
+	exp := int(h >> 10 & 0x1f)
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,243 @@
+package convert
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// cborBreak ends an item of indefinite length
+const cborBreak = 0xff
+
+// cborDecoder reads CBOR items from data
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// cborToJSON converts CBOR to JSON text. Byte strings become base64
+// strings, epoch times become RFC 3339 strings, bignums become integers,
+// and other tags are dropped, leaving the item they wrap. Several items
+// back to back become an array of them.
+func cborToJSON(data []byte) ([]byte, error) {
+	d := &cborDecoder{data: data}
+	var values []interface{}
+	for d.pos < len(d.data) {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return writeValues(values)
+}
+
+// value reads one item and everything it holds
+func (d *cborDecoder) value() (interface{}, error) {
+	start := d.pos
+	major, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return literal(strconv.FormatUint(arg, 10)), nil
+	case cborNegInt:
+		// The item holds -1 - n, which may not fit in an int64
+		n := new(big.Int).SetUint64(arg)
+		return literal(n.Sub(big.NewInt(-1), n).String()), nil
+	case cborBytes:
+		raw, err := d.bytes(cborBytes, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	case cborText:
+		raw, err := d.bytes(cborText, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(raw) {
+			return nil, fmt.Errorf("offset %d: text string is not valid UTF-8", start)
+		}
+		return string(raw), nil
+	case cborArray:
+		items := []interface{}{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			item, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		m := &orderedMap{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite && d.atBreak() {
+				break
+			}
+			keyStart := d.pos
+			k, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			key, err := mapKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("offset %d: %v", keyStart, err)
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			if m.has(key) {
+				return nil, fmt.Errorf("offset %d: key %q is repeated", keyStart, key)
+			}
+			m.add(key, v)
+		}
+		return m, nil
+	case cborTag:
+		return d.tagged(arg)
+	}
+
+	// Major type 7: simple values and floats, told apart by the size of
+	// the argument
+	switch b := d.data[start] & 0x1f; {
+	case b == 20:
+		return literal("false"), nil
+	case b == 21:
+		return literal("true"), nil
+	case b == 22, b == 23:
+		// null and undefined
+		return nil, nil
+	case b == 25:
+		return floatLiteral(fromHalf(uint16(arg)), 32)
+	case b == 26:
+		return floatLiteral(float64(math.Float32frombits(uint32(arg))), 32)
+	case b == 27:
+		return floatLiteral(math.Float64frombits(arg), 64)
+	case b == 31:
+		return nil, fmt.Errorf("offset %d: unexpected break", start)
+	}
+	return nil, fmt.Errorf("offset %d: simple value %d has no JSON equivalent", start, arg)
+}
+
+// head reads the first bytes of an item: its major type and argument, or
+// whether its length is indefinite
+func (d *cborDecoder) head() (major byte, arg uint64, indefinite bool, err error) {
+	start := d.pos
+	b, err := d.read(1)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		raw, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, false, err
+		}
+		return major, bigEndian(raw), false, nil
+	case info == 31 && (major >= cborBytes && major <= cborMap || major == cborSimple):
+		return major, 0, true, nil
+	}
+	return 0, 0, false, fmt.Errorf("offset %d: 0x%02x is not a valid CBOR item", start, b[0])
+}
+
+// bytes reads the content of a byte or text string of n bytes, or the
+// chunks of one of indefinite length
+func (d *cborDecoder) bytes(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, fmt.Errorf("offset %d: length %d is past the end of the data", d.pos, n)
+		}
+		return d.read(int(n))
+	}
+
+	var out []byte
+	for !d.atBreak() {
+		start := d.pos
+		chunkMajor, size, chunkIndefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, fmt.Errorf("offset %d: a string of indefinite length can only hold definite strings of its type", start)
+		}
+		chunk, err := d.bytes(major, size, false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+	}
+	return out, nil
+}
+
+// tagged reads the item that follows a tag, converting the ones JSON has
+// no type for
+func (d *cborDecoder) tagged(tag uint64) (interface{}, error) {
+	start := d.pos
+	if (tag == 2 || tag == 3) && (start >= len(d.data) || d.data[start]>>5 != cborBytes) {
+		return nil, fmt.Errorf("offset %d: a bignum must be a byte string", start)
+	}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case 1:
+		// Seconds since the epoch, as an integer or a float
+		n, ok := v.(literal)
+		if !ok {
+			return nil, fmt.Errorf("offset %d: an epoch time must be a number", start)
+		}
+		secs, err := strconv.ParseFloat(string(n), 64)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %v", start, err)
+		}
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(frac*1e9)).UTC().Format(time.RFC3339Nano), nil
+	case 2, 3:
+		// Bignums: a byte string, read here from its base64 form
+		raw, err := base64.StdEncoding.DecodeString(v.(string))
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).SetBytes(raw)
+		if tag == 3 {
+			n.Sub(big.NewInt(-1), n)
+		}
+		return literal(n.String()), nil
+	}
+	return v, nil
+}
+
+// atBreak reports whether the next byte ends an item of indefinite length,
+// and consumes it if so
+func (d *cborDecoder) atBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == cborBreak {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// read returns the next n bytes
+func (d *cborDecoder) read(n int) ([]byte, error) {
+	if n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("offset %d: unexpected end of data", d.pos)
+	}
+	d.pos += n
+	return d.data[d.pos-n : d.pos], nil
+}
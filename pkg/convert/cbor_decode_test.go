@@ -0,0 +1,108 @@
+package convert
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestCBORToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+		want string
+	}{
+		{"Integers", "8300203bffffffffffffffff", `[0,-1,-18446744073709551616]`},
+		{"Bignums", "82c249010000000000000000c349010000000000000000", `[18446744073709551616,-18446744073709551617]`},
+		{"Floats", "83f93c00fa47c35040fbc010666666666666", `[1,100000.5,-4.1]`},
+		{"Simple values", "84f4f5f6f7", `[false,true,null,null]`},
+		{"Byte strings become base64", "4401020304", `"AQIDBA=="`},
+		{"Epoch times", "82c11a514b67b0c1fb41d452d9ec200000", `["2013-03-21T20:04:00Z","2013-03-21T20:04:00.5Z"]`},
+		{"Other tags are dropped", "82c074323031332d30332d32315432303a30343a30305ad82076687474703a2f2f7777772e6578616d706c652e636f6d", `["2013-03-21T20:04:00Z","http://www.example.com"]`},
+		{"Indefinite strings", "825f42010243030405ff7f657374726561646d696e67ff", `["AQIDBAU=","streaming"]`},
+		{"Indefinite containers", "bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"Integer keys, as in COSE keys", "a301020326200a", `{"1":2,"3":-7,"-1":10}`},
+		{"Items back to back", "01616180", `[1,"a",[]]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ToJSON(data, CBOR, Options{})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCBORBase64Input(t *testing.T) {
+	// {"fmt": "none"} as base64, base64url without padding, and wrapped
+	for _, input := range []string{"oWNmbXRkbm9uZQ==", "oWNmbXRkbm9uZQ", "oWNmbXRk\nbm9uZQ==\n"} {
+		got, err := ToJSON([]byte(input), CBOR, Options{})
+		if err != nil {
+			t.Fatalf("ToJSON(%q) error = %v", input, err)
+		}
+		if want := `{"fmt":"none"}`; string(got) != want {
+			t.Errorf("ToJSON(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestCBORToJSONErrors(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{"1c", "offset 0: 0x1c is not a valid CBOR item"},
+		{"8201", "offset 2: unexpected end of data"},
+		{"5a00000100", "length 256 is past the end of the data"},
+		{"f97c00", "+Inf has no JSON equivalent"},
+		{"f0", "simple value 16 has no JSON equivalent"},
+		{"ff", "offset 0: unexpected break"},
+		{"62c328", "text string is not valid UTF-8"},
+		{"a1810102", "offset 1: an array cannot be an object key"},
+		{"a2616101616102", `offset 4: key "a" is repeated`},
+		{"c26161", "a bignum must be a byte string"},
+		{"5f6161ff", "a string of indefinite length can only hold definite strings of its type"},
+	}
+
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ToJSON(data, CBOR, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%s) error = %v, want it to contain %q", tt.hex, err, tt.want)
+		}
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	doc := `{"name":"fj","n":-70000,"big":123456789012345678901234567890,"pi":3.14,"half":0.5,"tags":["cli","json"],"nested":{"empty":{},"list":[],"none":null,"ok":true}}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	data, err := Encode(v, CBOR, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(data, CBOR, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for %x", err, data)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
@@ -0,0 +1,53 @@
+package convert
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// The examples of RFC 8949, appendix A
+func TestEncodeCBOR(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`0`, "00"},
+		{`23`, "17"},
+		{`24`, "1818"},
+		{`1000`, "1903e8"},
+		{`1000000`, "1a000f4240"},
+		{`18446744073709551615`, "1bffffffffffffffff"},
+		{`18446744073709551616`, "c249010000000000000000"},
+		{`-18446744073709551616`, "3bffffffffffffffff"},
+		{`-18446744073709551617`, "c349010000000000000000"},
+		{`-1`, "20"},
+		{`-1000`, "3903e7"},
+		{`1.5`, "f93e00"},
+		{`5.960464477539063e-8`, "f90001"},
+		{`0.00006103515625`, "f90400"},
+		{`100000.5`, "fa47c35040"},
+		{`-4.1`, "fbc010666666666666"},
+		{`1.0e+300`, "fb7e37e43c8800759c"},
+		{`[false, true, null]`, "83f4f5f6"},
+		{`"ü"`, "62c3bc"},
+		{`[1, [2, 3], [4, 5]]`, "8301820203820405"},
+		{`{"a": 1, "b": [2, 3]}`, "a26161016162820203"},
+	}
+
+	for _, tt := range tests {
+		opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+		v, err := formatter.Decode([]byte(tt.json), opts)
+		if err != nil {
+			t.Fatalf("Decode(%s) error = %v", tt.json, err)
+		}
+		got, err := Encode(v, CBOR, opts, Options{})
+		if err != nil {
+			t.Fatalf("Encode(%s) error = %v", tt.json, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("Encode(%s) = %x, want %s", tt.json, got, tt.want)
+		}
+	}
+}
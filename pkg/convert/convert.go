@@ -0,0 +1,383 @@
+// Package convert translates documents between JSON and other data formats
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// Format names a data format other than JSON
+type Format string
+
+const (
+	// YAML is YAML 1.2, written in block style
+	YAML Format = "yaml"
+	// TOML is TOML 1.0, as used by Cargo.toml and pyproject.toml
+	TOML Format = "toml"
+	// XML is XML 1.0, its attributes and text held under prefixed keys
+	XML Format = "xml"
+	// CSV is comma-separated values as described by RFC 4180, one row per
+	// object of an array
+	CSV Format = "csv"
+	// TSV is CSV with tabs between the values
+	TSV Format = "tsv"
+	// MessagePack is the binary format of msgpack.org
+	MessagePack Format = "msgpack"
+	// CBOR is the Concise Binary Object Representation of RFC 8949
+	CBOR Format = "cbor"
+	// BSON is the binary document format of MongoDB, its own types written
+	// in Extended JSON
+	BSON Format = "bson"
+	// Proto is a protocol buffer message, read with the types of a compiled
+	// descriptor set; it cannot be written
+	Proto Format = "proto"
+)
+
+// formats lists every format, in the order they are named in messages
+var formats = []Format{YAML, TOML, XML, CSV, TSV, MessagePack, CBOR, BSON, Proto}
+
+// Options holds the settings of formats whose data does not map directly
+// onto JSON
+type Options struct {
+	// AttrPrefix starts the keys that hold XML attributes, such as "@id".
+	// When empty, attributes are read as child elements and never written.
+	AttrPrefix string
+	// TextKey holds the text of XML elements that also have attributes or
+	// child elements
+	TextKey string
+	// Columns lists the CSV columns to write, in order; when empty, every
+	// column is written in the order it first appears. With NoHeader, it
+	// names the columns read.
+	Columns []string
+	// Comma separates CSV values instead of the format's own separator
+	Comma rune
+	// NoHeader reads the first CSV row as data rather than column names
+	NoHeader bool
+	// InferTypes reads CSV values that look like numbers or booleans as
+	// such, and empty values as null, rather than as strings
+	InferTypes bool
+	// ProtoSchema holds the types protocol buffer messages are read with
+	ProtoSchema *ProtoSchema
+	// ProtoType is the full name of the message type read, such as
+	// my.pkg.Message
+	ProtoType string
+}
+
+// DefaultOptions returns the settings used unless a flag changes them
+func DefaultOptions() Options {
+	return Options{AttrPrefix: "@", TextKey: "#text"}
+}
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (use %s)", name, Names())
+}
+
+// Names lists the format names for help text
+func Names() string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Extension returns the file extension for output in this format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Binary reports whether the format is binary rather than text, so that it
+// is not checked as UTF-8 on input or printed to a terminal on output
+func (f Format) Binary() bool {
+	return f == MessagePack || f == CBOR || f == BSON || f == Proto
+}
+
+// Encode writes v in the format. Objects keep the key order FormatValue
+// would give them with the same options.
+func Encode(v interface{}, format Format, opts formatter.Options, conv Options) ([]byte, error) {
+	switch format {
+	case YAML:
+		return encodeYAML(v, opts.Keys)
+	case TOML:
+		return encodeTOML(v, opts.Keys)
+	case XML:
+		return encodeXML(v, opts.Keys, conv)
+	case CSV:
+		return encodeCSV(v, opts.Keys, conv, ',')
+	case TSV:
+		return encodeCSV(v, opts.Keys, conv, '\t')
+	case MessagePack:
+		return encodeMsgpack(v, opts.Keys)
+	case CBOR:
+		return encodeCBOR(v, opts.Keys)
+	case BSON:
+		return encodeBSON(v, opts.Keys)
+	}
+	return nil, fmt.Errorf("cannot write %s", format)
+}
+
+// EncodeAll writes several documents as one stream: for YAML, documents
+// that each start with "---", and for binary formats, values back to back.
+// Other formats hold a single document.
+func EncodeAll(docs []interface{}, format Format, opts formatter.Options, conv Options) ([]byte, error) {
+	if format != YAML && !format.Binary() {
+		return nil, fmt.Errorf("%s holds a single document, not %d", format, len(docs))
+	}
+	var out []byte
+	for _, doc := range docs {
+		data, err := Encode(doc, format, opts, conv)
+		if err != nil {
+			return nil, err
+		}
+		if format == YAML {
+			out = append(out, "---\n"...)
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+// ToJSON converts a document in the format to JSON text, keeping the order
+// of object keys
+func ToJSON(data []byte, format Format, conv Options) ([]byte, error) {
+	switch format {
+	case YAML:
+		return yamlToJSON(data)
+	case TOML:
+		return tomlToJSON(data)
+	case XML:
+		return xmlToJSON(data, conv)
+	case CSV:
+		return csvToJSON(data, conv, ',')
+	case TSV:
+		return csvToJSON(data, conv, '\t')
+	case MessagePack:
+		return binaryToJSON(data, msgpackToJSON)
+	case CBOR:
+		return binaryToJSON(data, cborToJSON)
+	case BSON:
+		return binaryToJSON(data, bsonToJSON)
+	case Proto:
+		return binaryToJSON(data, func(raw []byte) ([]byte, error) {
+			return protoToJSON(raw, conv)
+		})
+	}
+	return nil, fmt.Errorf("cannot read %s", format)
+}
+
+// Detect picks the format of a file from its extension, returning "" for
+// JSON and unknown extensions
+func Detect(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAML
+	case ".toml":
+		return TOML
+	case ".xml":
+		return XML
+	case ".csv":
+		return CSV
+	case ".tsv":
+		return TSV
+	case ".msgpack":
+		return MessagePack
+	case ".cbor":
+		return CBOR
+	case ".bson":
+		return BSON
+	}
+	return ""
+}
+
+// orderedMap is an object read from another format, keeping its keys in
+// document order so that they survive the conversion to JSON text
+type orderedMap struct {
+	keys   []string
+	values []interface{}
+}
+
+// index returns the position of key in the map, or -1
+func (m *orderedMap) index(key string) int {
+	for i, k := range m.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// has reports whether the map has key
+func (m *orderedMap) has(key string) bool {
+	return m.index(key) >= 0
+}
+
+// get returns the value of key
+func (m *orderedMap) get(key string) (interface{}, bool) {
+	if i := m.index(key); i >= 0 {
+		return m.values[i], true
+	}
+	return nil, false
+}
+
+// add appends an entry
+func (m *orderedMap) add(key string, value interface{}) {
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+// literal is a null, boolean or number read from another format, held as
+// its JSON text
+type literal string
+
+// numberText writes a number the way encoding/json does, in exponent form
+// only when it is very large or very small
+func numberText(f float64) string {
+	text, err := json.Marshal(f)
+	if err != nil {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return string(text)
+}
+
+// writeJSON writes a parsed document as JSON text
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case literal:
+		buf.WriteString(string(val))
+	case string:
+		// Unlike Marshal, an Encoder can leave <, > and & unescaped; the
+		// newline it ends with is dropped
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(val); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *orderedMap:
+		buf.WriteByte('{')
+		for i, k := range val.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSON(buf, val.values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected %T", v)
+	}
+	return nil
+}
+
+// binaryToJSON converts a binary format with decode, accepting it wrapped
+// in base64 as well, as binary payloads often are inside JSON or text
+// protocols. Input that reads as base64 but does not decode to valid data
+// is read as it is.
+func binaryToJSON(data []byte, decode func([]byte) ([]byte, error)) ([]byte, error) {
+	if raw, ok := unwrapBase64(data); ok {
+		if out, err := decode(raw); err == nil {
+			return out, nil
+		}
+	}
+	return decode(data)
+}
+
+// unwrapBase64 decodes data when it is nothing but standard or URL-safe
+// base64, with or without padding, ignoring line breaks and spaces
+func unwrapBase64(data []byte) ([]byte, bool) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	if text == "" {
+		return nil, false
+	}
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(text, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	raw, err := enc.DecodeString(strings.TrimRight(text, "="))
+	return raw, err == nil
+}
+
+// writeValues writes the values read from a binary format as JSON text: a
+// single value as itself, and several as an array
+func writeValues(values []interface{}) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no data")
+	}
+	var doc interface{} = values
+	if len(values) == 1 {
+		doc = values[0]
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bigEndian reads an unsigned integer of 1 to 8 bytes
+func bigEndian(raw []byte) uint64 {
+	var n uint64
+	for _, b := range raw {
+		n = n<<8 | uint64(b)
+	}
+	return n
+}
+
+// floatLiteral writes a float read from a binary format, as briefly as its
+// precision allows; infinities and NaN have no JSON equivalent
+func floatLiteral(f float64, bits int) (interface{}, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("%v has no JSON equivalent", f)
+	}
+	text := strconv.FormatFloat(f, 'g', -1, bits)
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		// Keep integral floats short, as 3 rather than 3e+00
+		text = strconv.FormatFloat(f, 'f', -1, bits)
+	}
+	return literal(text), nil
+}
+
+// mapKey turns a map key read from a binary format into an object key
+func mapKey(k interface{}) (string, error) {
+	switch key := k.(type) {
+	case string:
+		return key, nil
+	case literal:
+		return string(key), nil
+	case nil:
+		return "null", nil
+	}
+	return "", fmt.Errorf("%s cannot be an object key", typeName(k))
+}
@@ -0,0 +1,119 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// csvRow is a record flattened to its cells, keyed by column name
+type csvRow map[string]string
+
+// encodeCSV writes an array of objects, or a single object, as rows with
+// values separated by comma, or conv.Comma when set. Nested values are
+// flattened to columns named by their path, such as owner.login or tags[0].
+// The header lists conv.Columns when set, and otherwise every column of
+// every row in the order they first appear.
+func encodeCSV(v interface{}, keys func(map[string]interface{}) []string, conv Options, comma rune) ([]byte, error) {
+	records, ok := v.([]interface{})
+	if !ok {
+		records = []interface{}{v}
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]csvRow, len(records))
+	for i, record := range records {
+		obj, ok := record.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("row %d is %s, not an object; CSV is written from an array of objects", i+1, typeName(record))
+		}
+		rows[i] = make(csvRow)
+		flattenRow(rows[i], "", obj, keys, func(column string) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		})
+	}
+	if len(conv.Columns) > 0 {
+		columns = conv.Columns
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	if conv.Comma != 0 {
+		comma = conv.Comma
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	cells := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			cells[i] = row[column]
+		}
+		if err := w.Write(cells); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// flattenRow adds the scalars of v, found at prefix, to row, calling
+// column for each column in key order
+func flattenRow(row csvRow, prefix string, v interface{}, keys func(map[string]interface{}) []string, column func(string)) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && prefix != "" {
+			break
+		}
+		for _, k := range keys(val) {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenRow(row, key, val[k], keys, column)
+		}
+		return
+	case []interface{}:
+		if len(val) == 0 {
+			break
+		}
+		for i, item := range val {
+			flattenRow(row, prefix+"["+strconv.Itoa(i)+"]", item, keys, column)
+		}
+		return
+	}
+	row[prefix] = csvCell(v)
+	column(prefix)
+}
+
+// csvCell writes a scalar, or an empty object or array, as cell text; null
+// is an empty cell
+func csvCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case json.Number:
+		return string(val)
+	case float64:
+		return numberText(val)
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,100 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// csvToJSON converts CSV text to a JSON array with one element per row:
+// an object keyed by the header row, or by conv.Columns when conv.NoHeader
+// is set, and otherwise an array of the row's values
+func csvToJSON(data []byte, conv Options, comma rune) ([]byte, error) {
+	if conv.Comma != 0 {
+		comma = conv.Comma
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	r.Comma = comma
+	r.ReuseRecord = true
+
+	var header []string
+	if !conv.NoHeader {
+		record, err := r.Read()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, csvError(err)
+		}
+		header = append(header, record...)
+	} else if len(conv.Columns) > 0 {
+		header = conv.Columns
+		r.FieldsPerRecord = len(header)
+	}
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("column %q appears twice in the header", name)
+		}
+		seen[name] = true
+	}
+
+	rows := []interface{}{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		values := make([]interface{}, len(record))
+		for i, cell := range record {
+			values[i] = csvValue(cell, conv.InferTypes)
+		}
+		if header == nil {
+			rows = append(rows, values)
+			continue
+		}
+		row := &orderedMap{}
+		for i, name := range header {
+			row.add(name, values[i])
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvValue reads a cell as a string or, when infer is set, as the number,
+// boolean or null it spells: JSON number syntax, true or false, or an
+// empty cell. Text such as 007 or 1e5x stays a string.
+func csvValue(cell string, infer bool) interface{} {
+	if !infer {
+		return cell
+	}
+	switch cell {
+	case "":
+		return nil
+	case "true", "false":
+		return literal(cell)
+	}
+	if (cell[0] == '-' || isDigit(cell[0])) && isDigit(cell[len(cell)-1]) && json.Valid([]byte(cell)) {
+		return literal(cell)
+	}
+	return cell
+}
+
+// csvError reports a CSV syntax error by line
+func csvError(err error) error {
+	var parse *csv.ParseError
+	if errors.As(err, &parse) {
+		return fmt.Errorf("line %d: %v", parse.Line, parse.Err)
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestCSVToJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		csv    string
+		format Format
+		conv   Options
+		want   string
+	}{
+		{
+			name:   "Header row names the keys, after a byte order mark",
+			csv:    "\uFEFFid,name,note\n1,\"fj, tool\",\"two\nlines\"\n2,jq,\n",
+			format: CSV,
+			want:   `[{"id":"1","name":"fj, tool","note":"two\nlines"},{"id":"2","name":"jq","note":""}]`,
+		},
+		{
+			name:   "Type inference",
+			csv:    "a,b,c,d,e,f,g\n-2.5e3,007,true,FALSE,,1 ,12345678901234567890\n",
+			format: CSV,
+			conv:   Options{InferTypes: true},
+			want:   `[{"a":-2.5e3,"b":"007","c":true,"d":"FALSE","e":null,"f":"1 ","g":12345678901234567890}]`,
+		},
+		{
+			name:   "No header gives arrays",
+			csv:    "1,2\n3,4\n",
+			format: CSV,
+			conv:   Options{NoHeader: true},
+			want:   `[["1","2"],["3","4"]]`,
+		},
+		{
+			name:   "No header with column names",
+			csv:    "1,2\n",
+			format: CSV,
+			conv:   Options{NoHeader: true, Columns: []string{"x", "y"}},
+			want:   `[{"x":"1","y":"2"}]`,
+		},
+		{
+			name:   "Custom delimiter",
+			csv:    "a;b\n\"x;y\";2\n",
+			format: CSV,
+			conv:   Options{Comma: ';'},
+			want:   `[{"a":"x;y","b":"2"}]`,
+		},
+		{
+			name:   "TSV",
+			csv:    "a\tb\nx, y\t2\n",
+			format: TSV,
+			want:   `[{"a":"x, y","b":"2"}]`,
+		},
+		{
+			name:   "Header only",
+			csv:    "a,b\n",
+			format: CSV,
+			want:   `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.csv), tt.format, tt.conv)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVToJSONErrors(t *testing.T) {
+	tests := []struct {
+		csv  string
+		conv Options
+		want string
+	}{
+		{"a,b\n1,2\n3\n", Options{}, "line 3: wrong number of fields"},
+		{"a,b\n\"open\n", Options{}, "extraneous or missing \" in quoted-field"},
+		{"a,a\n1,2\n", Options{}, `column "a" appears twice in the header`},
+		{"1,2,3\n", Options{NoHeader: true, Columns: []string{"x", "y"}}, "line 1: wrong number of fields"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON([]byte(tt.csv), CSV, tt.conv)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%q) error = %v, want it to contain %q", tt.csv, err, tt.want)
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	doc := `[{"id":1,"name":"fj, \"the\" tool","active":true,"note":null},{"id":2,"name":"jq","active":false,"note":"x"}]`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	csv, err := Encode(v, CSV, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(csv, CSV, Options{InferTypes: true})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for\n%s", err, csv)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
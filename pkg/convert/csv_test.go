@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		format Format
+		conv   Options
+		want   string
+	}{
+		{
+			name:   "Union of keys in order of appearance",
+			json:   `[{"id": 1, "name": "fj"}, {"id": 2, "stars": 40, "name": "jq"}]`,
+			format: CSV,
+			want:   "id,name,stars\n1,fj,\n2,jq,40\n",
+		},
+		{
+			name:   "Nested values are flattened",
+			json:   `[{"owner": {"login": "nico", "id": 7}, "tags": ["cli", "json"], "meta": {}, "x": null, "ok": true}]`,
+			format: CSV,
+			want:   "owner.login,owner.id,tags[0],tags[1],meta,x,ok\nnico,7,cli,json,{},,true\n",
+		},
+		{
+			name:   "RFC 4180 quoting",
+			json:   `[{"a": "x, y", "b": "say \"hi\"", "c": "two\nlines", "d": " plain "}]`,
+			format: CSV,
+			want:   "a,b,c,d\n\"x, y\",\"say \"\"hi\"\"\",\"two\nlines\",\" plain \"\n",
+		},
+		{
+			name:   "Columns select and order",
+			json:   `[{"id": 1, "name": "fj", "owner": {"login": "nico"}}]`,
+			format: CSV,
+			conv:   Options{Columns: []string{"owner.login", "id", "missing"}},
+			want:   "owner.login,id,missing\nnico,1,\n",
+		},
+		{
+			name:   "A single object is one row",
+			json:   `{"id": 1, "big": 12345678, "small": 0.5}`,
+			format: CSV,
+			want:   "id,big,small\n1,12345678,0.5\n",
+		},
+		{
+			name:   "TSV",
+			json:   `[{"a": "x, y", "b": "tab\there"}]`,
+			format: TSV,
+			want:   "a\tb\nx, y\t\"tab\there\"\n",
+		},
+		{
+			name:   "Empty array",
+			json:   `[]`,
+			format: CSV,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+			v, err := formatter.Decode([]byte(tt.json), opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := Encode(v, tt.format, opts, tt.conv)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeCSVErrors(t *testing.T) {
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(`[{"a": 1}, [2]]`), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	_, err = Encode(v, CSV, opts, Options{})
+	if want := "row 2 is an array, not an object"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Encode() error = %v, want it to contain %q", err, want)
+	}
+}
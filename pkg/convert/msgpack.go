@@ -0,0 +1,157 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// msgpackEncoder writes values in MessagePack, always choosing the
+// shortest encoding
+type msgpackEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+}
+
+// encodeMsgpack writes v as a single MessagePack value
+func encodeMsgpack(v interface{}, keys func(map[string]interface{}) []string) ([]byte, error) {
+	e := &msgpackEncoder{keys: keys}
+	if err := e.value(v); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// value writes one value and everything it holds
+func (e *msgpackEncoder) value(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		e.buf.WriteByte(0xc0)
+	case bool:
+		if val {
+			e.buf.WriteByte(0xc3)
+		} else {
+			e.buf.WriteByte(0xc2)
+		}
+	case string:
+		e.str(val)
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			e.int(int64(val))
+		} else {
+			e.float(val)
+		}
+	case json.Number:
+		// Numbers too large for float64 to hold exactly
+		if n, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			e.int(n)
+		} else if u, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			e.uint(u)
+		} else if f, err := val.Float64(); err == nil {
+			e.float(f)
+		} else {
+			return fmt.Errorf("%s is out of the range of MessagePack numbers", val)
+		}
+	case []interface{}:
+		e.header(len(val), 0x90, 15, 0xdc)
+		for _, item := range val {
+			if err := e.value(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		e.header(len(val), 0x80, 15, 0xde)
+		for _, k := range e.keys(val) {
+			e.str(k)
+			if err := e.value(val[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot write %T as MessagePack", v)
+	}
+	return nil
+}
+
+// str writes a string
+func (e *msgpackEncoder) str(s string) {
+	switch n := len(s); {
+	case n <= 31:
+		e.buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xd9, byte(n)})
+	default:
+		e.header(n, 0, 0, 0xda)
+	}
+	e.buf.WriteString(s)
+}
+
+// header writes the length of an array, map or long string: in the low bits
+// of fix when it is at most fixMax, and otherwise after long or the 32-bit
+// form that follows it
+func (e *msgpackEncoder) header(n int, fix byte, fixMax int, long byte) {
+	switch {
+	case n <= fixMax:
+		e.buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(long)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		e.buf.WriteByte(long + 1)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// int writes a signed integer
+func (e *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		e.buf.Write([]byte{0xd0, byte(n)})
+	case n >= math.MinInt16:
+		e.buf.WriteByte(0xd1)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n >= math.MinInt32:
+		e.buf.WriteByte(0xd2)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(0xd3)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	}
+}
+
+// uint writes an unsigned integer
+func (e *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xcc, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(0xcd)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(0xce)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(0xcf)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// float writes a float64, or a float32 when that holds it exactly
+func (e *msgpackEncoder) float(f float64) {
+	if float64(float32(f)) == f {
+		e.buf.WriteByte(0xca)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))))
+		return
+	}
+	e.buf.WriteByte(0xcb)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
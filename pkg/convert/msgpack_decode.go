@@ -0,0 +1,229 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// msgpackDecoder reads MessagePack values from data
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// msgpackToJSON converts MessagePack to JSON text. Binary values become
+// base64 strings, timestamps become RFC 3339 strings, and other extension
+// types become {"type": n, "data": base64}. Several values back to back
+// become an array of them.
+func msgpackToJSON(data []byte) ([]byte, error) {
+	d := &msgpackDecoder{data: data}
+	var values []interface{}
+	for d.pos < len(d.data) {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return writeValues(values)
+}
+
+// value reads one value and everything it holds
+func (d *msgpackDecoder) value() (interface{}, error) {
+	start := d.pos
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return literal(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return literal(strconv.Itoa(int(int8(c)))), nil
+	case c&0xf0 == 0x80:
+		return d.mapping(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c := b[0]; c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return literal("false"), nil
+	case 0xc3:
+		return literal("true"), nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(c - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(raw), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(c - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		raw, err := d.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return floatLiteral(float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), 32)
+	case 0xcb:
+		raw, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return floatLiteral(math.Float64frombits(binary.BigEndian.Uint64(raw)), 64)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		raw, err := d.read(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return literal(strconv.FormatUint(bigEndian(raw), 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		raw, err := d.read(size)
+		if err != nil {
+			return nil, err
+		}
+		// Shift the sign bit to the top, then back down with sign extension
+		shift := 64 - 8*size
+		return literal(strconv.FormatInt(int64(bigEndian(raw)<<shift)>>shift, 10)), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(c - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(c - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n)
+	case 0xde, 0xdf:
+		n, err := d.length(c - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapping(n)
+	}
+	return nil, fmt.Errorf("offset %d: 0x%02x is not a MessagePack type", start, b[0])
+}
+
+// length reads a length of 1, 2 or 4 bytes, for size 0, 1 or 2
+func (d *msgpackDecoder) length(size byte) (int, error) {
+	raw, err := d.read(1 << size)
+	if err != nil {
+		return 0, err
+	}
+	n := bigEndian(raw)
+	if n > uint64(len(d.data)) {
+		return 0, fmt.Errorf("offset %d: length %d is past the end of the data", d.pos, n)
+	}
+	return int(n), nil
+}
+
+// str reads a string of n bytes
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	raw, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// array reads n values
+func (d *msgpackDecoder) array(n int) (interface{}, error) {
+	items := make([]interface{}, 0, min(n, len(d.data)-d.pos))
+	for i := 0; i < n; i++ {
+		item, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// mapping reads n key and value pairs. Keys that are not strings are
+// written as their JSON text, so that the integer key 1 becomes "1".
+func (d *msgpackDecoder) mapping(n int) (interface{}, error) {
+	m := &orderedMap{}
+	for i := 0; i < n; i++ {
+		start := d.pos
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		key, err := mapKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %v", start, err)
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if m.has(key) {
+			return nil, fmt.Errorf("offset %d: key %q is repeated", start, key)
+		}
+		m.add(key, v)
+	}
+	return m, nil
+}
+
+// ext reads an extension value of n bytes after its type: a timestamp
+// (type -1) as an RFC 3339 string, and anything else as its type and data
+func (d *msgpackDecoder) ext(n int) (interface{}, error) {
+	start := d.pos
+	raw, err := d.read(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	typ, data := int8(raw[0]), raw[1:]
+	if typ != -1 {
+		m := &orderedMap{}
+		m.add("type", literal(strconv.Itoa(int(typ))))
+		m.add("data", base64.StdEncoding.EncodeToString(data))
+		return m, nil
+	}
+
+	var sec int64
+	var nsec uint32
+	switch len(data) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		nsec, sec = uint32(v>>34), int64(v&(1<<34-1))
+	case 12:
+		nsec, sec = binary.BigEndian.Uint32(data), int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return nil, fmt.Errorf("offset %d: a timestamp cannot be %d bytes long", start, len(data))
+	}
+	return time.Unix(sec, int64(nsec)).UTC().Format(time.RFC3339Nano), nil
+}
+
+// read returns the next n bytes
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("offset %d: unexpected end of data", d.pos)
+	}
+	d.pos += n
+	return d.data[d.pos-n : d.pos], nil
+}
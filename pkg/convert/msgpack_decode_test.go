@@ -0,0 +1,82 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestMsgpackToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"Map", "\x82\xa2id\x01\xa4tags\x92\xa1a\xc0", `{"id":1,"tags":["a",null]}`},
+		{"Integers", "\x96\xff\xd0\x80\xd1\x80\x00\xcd\xff\xff\xd3\x80\x00\x00\x00\x00\x00\x00\x00\xcf\xff\xff\xff\xff\xff\xff\xff\xff",
+			`[-1,-128,-32768,65535,-9223372036854775808,18446744073709551615]`},
+		{"Floats", "\x92\xca\x3f\xc0\x00\x00\xcb\x40\x09\x21\xfb\x54\x44\x2d\x18", `[1.5,3.141592653589793]`},
+		{"Binary becomes base64", "\xc4\x03abc", `"YWJj"`},
+		{"Timestamps", "\x92\xd6\xff\x00\x00\x00\x01\xd7\xff\x00\x00\x00\x04\x00\x00\x00\x02", `["1970-01-01T00:00:01Z","1970-01-01T00:00:02.000000001Z"]`},
+		{"Other extensions", "\xd4\x05\x01", `{"type":5,"data":"AQ=="}`},
+		{"Keys that are not strings", "\x83\x01\xa1a\xc3\xa1b\xc0\xa1c", `{"1":"a","true":"b","null":"c"}`},
+		{"Values back to back", "\x01\xa1a\x90", `[1,"a",[]]`},
+		{"Long string", "\xda\x00\x03abc", `"abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.data), MessagePack, Options{})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackToJSONErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"", "no data"},
+		{"\x92\x01", "offset 2: unexpected end of data"},
+		{"\xc1", "offset 0: 0xc1 is not a MessagePack type"},
+		{"\xdd\xff\xff\xff\xff", "length 4294967295 is past the end of the data"},
+		{"\x81\x90\x01", "offset 1: an array cannot be an object key"},
+		{"\x82\xa1a\x01\xa1a\x02", `offset 4: key "a" is repeated`},
+		{"\xcb\x7f\xf0\x00\x00\x00\x00\x00\x00", "+Inf has no JSON equivalent"},
+		{"\xd5\xff\x00\x00", "a timestamp cannot be 2 bytes long"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON([]byte(tt.data), MessagePack, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%q) error = %v, want it to contain %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	doc := `{"name":"fj","n":-70000,"big":12345678901234567890,"pi":3.14,"half":0.5,"tags":["cli","json"],"nested":{"empty":{},"list":[],"none":null,"ok":true}}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	data, err := Encode(v, MessagePack, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(data, MessagePack, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for % x", err, data)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
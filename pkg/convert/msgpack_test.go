@@ -0,0 +1,51 @@
+package convert
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeMsgpack(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []byte
+	}{
+		{"Small integers", `[0, 127, -1, -32]`, []byte{0x94, 0x00, 0x7f, 0xff, 0xe0}},
+		{"Sized integers", `[128, -33, 65536, -40000]`, []byte{0x94, 0xcc, 0x80, 0xd0, 0xdf, 0xce, 0x00, 0x01, 0x00, 0x00, 0xd2, 0xff, 0xff, 0x63, 0xc0}},
+		{"Integers beyond float64", `18446744073709551615`, []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"Floats", `[0.5, 0.1]`, []byte{0x92, 0xca, 0x3f, 0x00, 0x00, 0x00, 0xcb, 0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{"Scalars", `[null, true, false, "hi"]`, []byte{0x94, 0xc0, 0xc3, 0xc2, 0xa2, 'h', 'i'}},
+		{"Objects keep key order", `{"b": 1, "a": {}}`, []byte{0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x80}},
+		{"Long string", `"` + string(bytes.Repeat([]byte("x"), 40)) + `"`, append([]byte{0xd9, 40}, bytes.Repeat([]byte("x"), 40)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+			v, err := formatter.Decode([]byte(tt.json), opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := Encode(v, MessagePack, opts, Options{})
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Encode() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeAllMsgpack(t *testing.T) {
+	got, err := EncodeAll([]interface{}{1.0, "a"}, MessagePack, formatter.Options{}, Options{})
+	if err != nil {
+		t.Fatalf("EncodeAll() error = %v", err)
+	}
+	if want := []byte{0x01, 0xa1, 'a'}; !bytes.Equal(got, want) {
+		t.Errorf("EncodeAll() = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,425 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// protoMaxDepth limits how deeply messages may nest, as protobuf-go does
+const protoMaxDepth = 10000
+
+// protoSpecialJSON lists the well-known types whose JSON is not an object
+// of their fields
+var protoSpecialJSON = map[string]bool{
+	"google.protobuf.Any":         true,
+	"google.protobuf.Timestamp":   true,
+	"google.protobuf.Duration":    true,
+	"google.protobuf.FieldMask":   true,
+	"google.protobuf.Struct":      true,
+	"google.protobuf.Value":       true,
+	"google.protobuf.ListValue":   true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// protoDecoder reads protocol buffer messages of the types in schema
+type protoDecoder struct {
+	schema *ProtoSchema
+	depth  int
+}
+
+// protoToJSON converts a protocol buffer message of type conv.ProtoType to
+// JSON text, following the protobuf JSON mapping: fields are named in
+// lowerCamelCase, 64-bit integers become strings, bytes become base64,
+// enums become their names, and well-known types such as Timestamp take
+// their JSON forms. Fields the schema does not know are dropped.
+func protoToJSON(data []byte, conv Options) ([]byte, error) {
+	if conv.ProtoSchema == nil || conv.ProtoType == "" {
+		return nil, fmt.Errorf("reading protobuf needs a descriptor set and a message type")
+	}
+	msg := conv.ProtoSchema.messages[strings.TrimPrefix(conv.ProtoType, ".")]
+	if msg == nil {
+		return nil, fmt.Errorf("message type %q is not in the descriptor set", conv.ProtoType)
+	}
+	d := &protoDecoder{schema: conv.ProtoSchema}
+	v, err := d.message(msg, data, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return writeValues([]interface{}{v})
+}
+
+// message reads a message that starts at offset base of the input, or the
+// contents of a group when group is its field number
+func (d *protoDecoder) message(msg *protoDescriptor, data []byte, base int, group int32) (interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > protoMaxDepth {
+		return nil, fmt.Errorf("offset %d: messages are nested more than %d deep", base, protoMaxDepth)
+	}
+
+	records, _, err := protoGroupRecords(data, 0, base, group)
+	if err != nil {
+		return nil, err
+	}
+	if msg.name == "google.protobuf.Any" {
+		return d.any(records)
+	}
+
+	values := map[int32]interface{}{}
+	for _, rec := range records {
+		f := msg.byNumber[rec.num]
+		if f == nil {
+			continue
+		}
+		if err := d.field(msg, f, rec, values); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fields are written in the order the schema declares them
+	m := &orderedMap{}
+	for _, f := range msg.fields {
+		if v, ok := values[f.number]; ok {
+			m.add(f.jsonName, v)
+		}
+	}
+	return d.wellKnown(msg, m)
+}
+
+// field reads a record of field f into values, appending to repeated
+// fields and replacing others, as a later value on the wire wins
+func (d *protoDecoder) field(msg *protoDescriptor, f *protoField, rec protoRecord, values map[int32]interface{}) error {
+	kind := d.kind(f)
+	want := protoWireType(kind)
+	if f.repeated && rec.wire == wireBytes && want != wireBytes && want != wireStartGroup {
+		// Packed repeated numbers: one length-delimited record holding
+		// them all
+		items, _ := values[f.number].([]interface{})
+		for pos := 0; pos < len(rec.raw); {
+			var n uint64
+			switch want {
+			case wireVarint:
+				var err error
+				if n, err = protoVarint(rec.raw, &pos, rec.rawOffset); err != nil {
+					return err
+				}
+			case wireFixed64, wireFixed32:
+				size := 8
+				if want == wireFixed32 {
+					size = 4
+				}
+				if size > len(rec.raw)-pos {
+					return fmt.Errorf("offset %d: packed %s.%s ends in the middle of a value", rec.rawOffset+pos, msg.name, f.name)
+				}
+				if size == 8 {
+					n = binary.LittleEndian.Uint64(rec.raw[pos:])
+				} else {
+					n = uint64(binary.LittleEndian.Uint32(rec.raw[pos:]))
+				}
+				pos += size
+			}
+			items = append(items, d.scalar(f, kind, n))
+		}
+		values[f.number] = items
+		return nil
+	}
+	if rec.wire != want {
+		return fmt.Errorf("offset %d: %s.%s should have wire type %d, not %d; is the message type right?", rec.offset, msg.name, f.name, want, rec.wire)
+	}
+
+	var v interface{}
+	switch kind {
+	case protoMessage, protoGroup:
+		sub := d.schema.messages[f.typeName]
+		if sub == nil {
+			return fmt.Errorf("type %s is not in the descriptor set; build it with protoc --include_imports", f.typeName)
+		}
+		var group int32
+		if kind == protoGroup {
+			group = f.number
+		}
+		var err error
+		if v, err = d.message(sub, rec.raw, rec.rawOffset, group); err != nil {
+			return err
+		}
+		if sub.mapEntry {
+			return d.mapEntry(sub, v.(*orderedMap), f, values)
+		}
+	case protoString:
+		if !utf8.Valid(rec.raw) {
+			return fmt.Errorf("offset %d: %s.%s is not valid UTF-8", rec.rawOffset, msg.name, f.name)
+		}
+		v = string(rec.raw)
+	case protoBytes:
+		v = base64.StdEncoding.EncodeToString(rec.raw)
+	default:
+		v = d.scalar(f, kind, rec.n)
+	}
+
+	if f.repeated {
+		items, _ := values[f.number].([]interface{})
+		values[f.number] = append(items, v)
+	} else {
+		values[f.number] = v
+	}
+	return nil
+}
+
+// mapEntry adds a map entry, which protoc stores as a repeated message of
+// a key and a value, to the object holding the map
+func (d *protoDecoder) mapEntry(entry *protoDescriptor, m *orderedMap, f *protoField, values map[int32]interface{}) error {
+	k, ok := m.get("key")
+	if !ok {
+		k = d.zero(entry.byNumber[1])
+	}
+	v, ok := m.get("value")
+	if !ok {
+		var err error
+		if v, err = d.zeroValue(entry.byNumber[2]); err != nil {
+			return err
+		}
+	}
+	key, err := mapKey(k)
+	if err != nil {
+		return err
+	}
+
+	object, _ := values[f.number].(*orderedMap)
+	if object == nil {
+		object = &orderedMap{}
+		values[f.number] = object
+	}
+	if i := object.index(key); i >= 0 {
+		object.values[i] = v
+	} else {
+		object.add(key, v)
+	}
+	return nil
+}
+
+// kind returns the type of a field, telling enums from messages when the
+// descriptor only names the type
+func (d *protoDecoder) kind(f *protoField) int {
+	if f.kind != 0 {
+		return f.kind
+	}
+	if _, ok := d.schema.enums[f.typeName]; ok {
+		return protoEnum
+	}
+	return protoMessage
+}
+
+// protoWireType returns the wire type values of a kind are written with
+func protoWireType(kind int) int {
+	switch kind {
+	case protoDouble, protoFixed64, protoSfixed64:
+		return wireFixed64
+	case protoFloat, protoFixed32, protoSfixed32:
+		return wireFixed32
+	case protoString, protoBytes, protoMessage:
+		return wireBytes
+	case protoGroup:
+		return wireStartGroup
+	}
+	return wireVarint
+}
+
+// scalar converts the bits of a number, bool or enum read from the wire
+func (d *protoDecoder) scalar(f *protoField, kind int, n uint64) interface{} {
+	switch kind {
+	case protoInt32, protoSfixed32:
+		return literal(strconv.FormatInt(int64(int32(n)), 10))
+	case protoSint32:
+		return literal(strconv.FormatInt(int64(int32(uint32(n)>>1)^-int32(n&1)), 10))
+	case protoUint32, protoFixed32:
+		return literal(strconv.FormatUint(uint64(uint32(n)), 10))
+	case protoInt64, protoSfixed64:
+		// 64-bit integers are strings, as JavaScript numbers cannot hold
+		// them all
+		return strconv.FormatInt(int64(n), 10)
+	case protoSint64:
+		return strconv.FormatInt(int64(n>>1)^-int64(n&1), 10)
+	case protoUint64, protoFixed64:
+		return strconv.FormatUint(n, 10)
+	case protoBool:
+		return literal(strconv.FormatBool(n != 0))
+	case protoEnum:
+		if name, ok := d.schema.enums[f.typeName][int32(n)]; ok {
+			return name
+		}
+		return literal(strconv.FormatInt(int64(int32(n)), 10))
+	case protoFloat:
+		return protoFloatValue(float64(math.Float32frombits(uint32(n))), 32)
+	}
+	return protoFloatValue(math.Float64frombits(n), 64)
+}
+
+// protoFloatValue writes a float, or a string for infinities and NaN
+func protoFloatValue(f float64, bits int) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	v, _ := floatLiteral(f, bits)
+	return v
+}
+
+// zero returns the default value of a field that is not a message
+func (d *protoDecoder) zero(f *protoField) interface{} {
+	if f == nil {
+		return nil
+	}
+	switch kind := d.kind(f); kind {
+	case protoString, protoBytes:
+		return ""
+	case protoBool:
+		return literal("false")
+	case protoMessage, protoGroup:
+		return nil
+	default:
+		return d.scalar(f, kind, 0)
+	}
+}
+
+// zeroValue returns the default value of any field, reading an empty
+// message for message fields
+func (d *protoDecoder) zeroValue(f *protoField) (interface{}, error) {
+	if f == nil {
+		return nil, nil
+	}
+	if kind := d.kind(f); kind == protoMessage || kind == protoGroup {
+		sub := d.schema.messages[f.typeName]
+		if sub == nil {
+			return nil, fmt.Errorf("type %s is not in the descriptor set; build it with protoc --include_imports", f.typeName)
+		}
+		return d.message(sub, nil, 0, 0)
+	}
+	return d.zero(f), nil
+}
+
+// wellKnown converts the well-known types to their JSON forms, returning
+// other messages as they are
+func (d *protoDecoder) wellKnown(msg *protoDescriptor, m *orderedMap) (interface{}, error) {
+	if !protoSpecialJSON[msg.name] {
+		return m, nil
+	}
+	switch strings.TrimPrefix(msg.name, "google.protobuf.") {
+	case "Timestamp":
+		secs, nanos := protoInt(m, "seconds"), protoInt(m, "nanos")
+		return time.Unix(secs, nanos).UTC().Format(time.RFC3339Nano), nil
+	case "Duration":
+		secs, nanos := protoInt(m, "seconds"), protoInt(m, "nanos")
+		sign := ""
+		if secs < 0 || nanos < 0 {
+			sign, secs, nanos = "-", -secs, -nanos
+		}
+		text := strconv.FormatInt(secs, 10)
+		if nanos != 0 {
+			text += "." + strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+		}
+		return sign + text + "s", nil
+	case "FieldMask":
+		paths, _ := m.get("paths")
+		items, _ := paths.([]interface{})
+		names := make([]string, len(items))
+		for i, p := range items {
+			names[i] = lowerCamel(p.(string))
+		}
+		return strings.Join(names, ","), nil
+	case "Struct":
+		if fields, ok := m.get("fields"); ok {
+			return fields, nil
+		}
+		return &orderedMap{}, nil
+	case "ListValue":
+		if values, ok := m.get("values"); ok {
+			return values, nil
+		}
+		return []interface{}{}, nil
+	case "Value":
+		// A oneof, so a single field is set; nullValue and an unset Value
+		// are both null
+		if len(m.values) == 0 || m.keys[len(m.keys)-1] == "nullValue" {
+			return nil, nil
+		}
+		return m.values[len(m.values)-1], nil
+	}
+
+	// The wrapper types, such as Int32Value, hold a single field
+	if v, ok := m.get("value"); ok {
+		return v, nil
+	}
+	return d.zero(msg.byNumber[1]), nil
+}
+
+// any reads a google.protobuf.Any, decoding the message it holds by the
+// type named in its URL
+func (d *protoDecoder) any(records []protoRecord) (interface{}, error) {
+	var url string
+	var value protoRecord
+	for _, rec := range records {
+		switch {
+		case rec.num == 1 && rec.wire == wireBytes:
+			url = string(rec.raw)
+		case rec.num == 2 && rec.wire == wireBytes:
+			value = rec
+		}
+	}
+	if url == "" {
+		return &orderedMap{}, nil
+	}
+	name := url[strings.LastIndex(url, "/")+1:]
+	sub := d.schema.messages[name]
+	if sub == nil {
+		return nil, fmt.Errorf("an Any holds %s, which is not in the descriptor set", name)
+	}
+	v, err := d.message(sub, value.raw, value.rawOffset, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &orderedMap{}
+	m.add("@type", url)
+	if fields, ok := v.(*orderedMap); ok && !protoSpecialJSON[sub.name] {
+		m.keys = append(m.keys, fields.keys...)
+		m.values = append(m.values, fields.values...)
+	} else {
+		m.add("value", v)
+	}
+	return m, nil
+}
+
+// protoInt reads an integer field of a decoded message, 0 when unset
+func protoInt(m *orderedMap, key string) int64 {
+	v, ok := m.get(key)
+	if !ok {
+		return 0
+	}
+	var text string
+	switch val := v.(type) {
+	case string:
+		text = val
+	case literal:
+		text = string(val)
+	}
+	n, _ := strconv.ParseInt(text, 10, 64)
+	return n
+}
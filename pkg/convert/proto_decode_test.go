@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestProtoToJSON(t *testing.T) {
+	s := testProtoSchema(t)
+	bits := binary.LittleEndian.AppendUint64(nil, math.Float64bits(9.5))
+	big := binary.LittleEndian.AppendUint64(nil, 1<<63)
+
+	tests := []struct {
+		name string
+		typ  string
+		data [][]byte
+		want string
+	}{
+		{"Scalars", "shop.Order", [][]byte{pbVarint(1, 42), pbString(2, "Ada"), pbVarint(9, 1), {0x51}, big},
+			`{"id":"42","customerName":"Ada","gift":true,"big":"9223372036854775808"}`},
+		{"Fields in schema order", "shop.Order", [][]byte{pbVarint(9, 0), pbVarint(1, 1)}, `{"id":"1","gift":false}`},
+		{"Packed and unpacked repeated numbers", "shop.Order", [][]byte{pbLen(3, []byte{1, 2, 0xac, 0x02}), pbVarint(3, 4)}, `{"quantities":[1,2,300,4]}`},
+		{"Enums", "shop.Order", [][]byte{pbVarint(4, 1)}, `{"status":"PAID"}`},
+		{"Unknown enum numbers", "shop.Order", [][]byte{pbVarint(4, 7)}, `{"status":7}`},
+		{"Maps", "shop.Order", [][]byte{pbLen(5, pbString(1, "apple"), pbVarint(2, 3)), pbLen(5, pbString(1, "pear"))}, `{"stock":{"apple":3,"pear":0}}`},
+		{"Nested messages", "shop.Order", [][]byte{pbLen(6, pbString(1, "A1"), []byte{0x11}, bits, pbVarint(3, 3))}, `{"items":[{"sku":"A1","price":9.5,"delta":-2}]}`},
+		{"Bytes", "shop.Order", [][]byte{pbLen(7, []byte{1, 2})}, `{"token":"AQI="}`},
+		{"Timestamps", "shop.Order", [][]byte{pbLen(8, pbVarint(1, 1714566600), pbVarint(2, 500000000))}, `{"createdAt":"2024-05-01T12:30:00.5Z"}`},
+		{"Unknown fields are dropped", "shop.Order", [][]byte{pbVarint(99, 1), pbVarint(1, 5)}, `{"id":"5"}`},
+		{"Later values win", "shop.Order", [][]byte{pbVarint(1, 1), pbVarint(1, 2)}, `{"id":"2"}`},
+		{"Empty message", "shop.Order", nil, `{}`},
+		{"Durations and wrappers", "shop.Envelope", [][]byte{pbLen(1, pbVarint(1, 90), pbVarint(2, 250000000)), pbLen(2, pbVarint(1, 3))}, `{"ttl":"90.25s","retries":3}`},
+		{"Any", "shop.Envelope", [][]byte{pbLen(3, pbString(1, "type.googleapis.com/shop.Order.Item"), pbLen(2, pbString(1, "A1")))},
+			`{"payload":{"@type":"type.googleapis.com/shop.Order.Item","sku":"A1"}}`},
+		{"Any holding a well-known type", "shop.Envelope", [][]byte{pbLen(3, pbString(1, "type.googleapis.com/google.protobuf.Int32Value"), pbLen(2, pbVarint(1, 7)))},
+			`{"payload":{"@type":"type.googleapis.com/google.protobuf.Int32Value","value":7}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data []byte
+			for _, d := range tt.data {
+				data = append(data, d...)
+			}
+			got, err := ToJSON(data, Proto, Options{ProtoSchema: s, ProtoType: tt.typ})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoToJSONErrors(t *testing.T) {
+	s := testProtoSchema(t)
+	tests := []struct {
+		name string
+		typ  string
+		data []byte
+		want string
+	}{
+		{"Wrong wire type", "shop.Order", pbString(4, "PAID"), "offset 0: shop.Order.status should have wire type 0, not 2"},
+		{"Truncated", "shop.Order", pbString(2, "Ada")[:3], "offset 2: length 3 is past the end of the data"},
+		{"Invalid UTF-8", "shop.Order", pbString(2, "\xff"), "shop.Order.customer_name is not valid UTF-8"},
+		{"Unknown type", "shop.Missing", nil, `message type "shop.Missing" is not in the descriptor set`},
+		{"Unknown Any type", "shop.Envelope", pbLen(3, pbString(1, "type.googleapis.com/other.Thing")), "an Any holds other.Thing, which is not in the descriptor set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToJSON(tt.data, Proto, Options{ProtoSchema: s, ProtoType: tt.typ})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ToJSON() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
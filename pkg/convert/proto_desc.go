@@ -0,0 +1,370 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Protocol buffer field types, as numbered in descriptor.proto
+const (
+	protoDouble   = 1
+	protoFloat    = 2
+	protoInt64    = 3
+	protoUint64   = 4
+	protoInt32    = 5
+	protoFixed64  = 6
+	protoFixed32  = 7
+	protoBool     = 8
+	protoString   = 9
+	protoGroup    = 10
+	protoMessage  = 11
+	protoBytes    = 12
+	protoUint32   = 13
+	protoEnum     = 14
+	protoSfixed32 = 15
+	protoSfixed64 = 16
+	protoSint32   = 17
+	protoSint64   = 18
+)
+
+// Protocol buffer wire types, in the low three bits of every tag
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+// ProtoSchema holds the message and enum types of a compiled descriptor
+// set, as written by protoc --descriptor_set_out, by their full names
+type ProtoSchema struct {
+	messages map[string]*protoDescriptor
+	enums    map[string]map[int32]string
+}
+
+// protoDescriptor describes a message type
+type protoDescriptor struct {
+	name     string
+	fields   []*protoField
+	byNumber map[int32]*protoField
+	// mapEntry marks the key and value messages protoc generates for map
+	// fields
+	mapEntry bool
+}
+
+// protoField describes a field of a message
+type protoField struct {
+	name     string
+	jsonName string
+	number   int32
+	kind     int
+	repeated bool
+	// typeName is the full name of the message or enum type, without the
+	// leading dot
+	typeName string
+}
+
+// protoRecord is a field as read from the wire, before its type is known
+type protoRecord struct {
+	num  int32
+	wire int
+	// n holds varint and fixed values
+	n uint64
+	// raw holds length-delimited values and the contents of groups, which
+	// start at rawOffset
+	raw       []byte
+	rawOffset int
+	offset    int
+}
+
+// ParseProtoSchema reads a FileDescriptorSet. Build one with
+// protoc --include_imports --descriptor_set_out=schema.pb, so that it also
+// holds the types the messages use from other files.
+func ParseProtoSchema(data []byte) (*ProtoSchema, error) {
+	s := &ProtoSchema{messages: map[string]*protoDescriptor{}, enums: map[string]map[int32]string{}}
+	records, err := protoRecords(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		if rec.num == 1 && rec.wire == wireBytes {
+			if err := s.addFile(rec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(s.messages) == 0 {
+		return nil, fmt.Errorf("no message types found; is this a descriptor set written by protoc --descriptor_set_out?")
+	}
+	return s, nil
+}
+
+// Has reports whether the schema holds the message type, named with its
+// package, such as my.pkg.Message
+func (s *ProtoSchema) Has(name string) bool {
+	return s.messages[strings.TrimPrefix(name, ".")] != nil
+}
+
+// addFile reads the types of a FileDescriptorProto
+func (s *ProtoSchema) addFile(file protoRecord) error {
+	records, err := protoRecords(file.raw, file.rawOffset)
+	if err != nil {
+		return err
+	}
+	var pkg string
+	for _, rec := range records {
+		if rec.num == 2 && rec.wire == wireBytes {
+			pkg = string(rec.raw)
+		}
+	}
+	for _, rec := range records {
+		var err error
+		switch {
+		case rec.num == 4 && rec.wire == wireBytes:
+			err = s.addMessage(pkg, rec)
+		case rec.num == 5 && rec.wire == wireBytes:
+			err = s.addEnum(pkg, rec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMessage reads a DescriptorProto and the types nested in it
+func (s *ProtoSchema) addMessage(scope string, desc protoRecord) error {
+	records, err := protoRecords(desc.raw, desc.rawOffset)
+	if err != nil {
+		return err
+	}
+	msg := &protoDescriptor{byNumber: map[int32]*protoField{}}
+	for _, rec := range records {
+		if rec.num == 1 && rec.wire == wireBytes {
+			msg.name = qualify(scope, string(rec.raw))
+		}
+	}
+
+	for _, rec := range records {
+		if rec.wire != wireBytes {
+			continue
+		}
+		switch rec.num {
+		case 2:
+			f, err := parseProtoField(rec)
+			if err != nil {
+				return err
+			}
+			msg.fields = append(msg.fields, f)
+			msg.byNumber[f.number] = f
+		case 3:
+			err = s.addMessage(msg.name, rec)
+		case 4:
+			err = s.addEnum(msg.name, rec)
+		case 7:
+			// MessageOptions, where map_entry is field 7
+			var options []protoRecord
+			options, err = protoRecords(rec.raw, rec.rawOffset)
+			for _, opt := range options {
+				if opt.num == 7 && opt.wire == wireVarint {
+					msg.mapEntry = opt.n != 0
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	s.messages[msg.name] = msg
+	return nil
+}
+
+// parseProtoField reads a FieldDescriptorProto
+func parseProtoField(desc protoRecord) (*protoField, error) {
+	records, err := protoRecords(desc.raw, desc.rawOffset)
+	if err != nil {
+		return nil, err
+	}
+	f := &protoField{}
+	for _, rec := range records {
+		switch {
+		case rec.num == 1 && rec.wire == wireBytes:
+			f.name = string(rec.raw)
+		case rec.num == 3 && rec.wire == wireVarint:
+			f.number = int32(rec.n)
+		case rec.num == 4 && rec.wire == wireVarint:
+			f.repeated = rec.n == 3
+		case rec.num == 5 && rec.wire == wireVarint:
+			f.kind = int(rec.n)
+		case rec.num == 6 && rec.wire == wireBytes:
+			f.typeName = strings.TrimPrefix(string(rec.raw), ".")
+		case rec.num == 10 && rec.wire == wireBytes:
+			f.jsonName = string(rec.raw)
+		}
+	}
+	if f.jsonName == "" {
+		f.jsonName = lowerCamel(f.name)
+	}
+	return f, nil
+}
+
+// addEnum reads an EnumDescriptorProto
+func (s *ProtoSchema) addEnum(scope string, desc protoRecord) error {
+	records, err := protoRecords(desc.raw, desc.rawOffset)
+	if err != nil {
+		return err
+	}
+	var name string
+	names := map[int32]string{}
+	for _, rec := range records {
+		switch {
+		case rec.num == 1 && rec.wire == wireBytes:
+			name = qualify(scope, string(rec.raw))
+		case rec.num == 2 && rec.wire == wireBytes:
+			values, err := protoRecords(rec.raw, rec.rawOffset)
+			if err != nil {
+				return err
+			}
+			var valueName string
+			var number int32
+			for _, v := range values {
+				switch {
+				case v.num == 1 && v.wire == wireBytes:
+					valueName = string(v.raw)
+				case v.num == 2 && v.wire == wireVarint:
+					number = int32(v.n)
+				}
+			}
+			// With allow_alias, the first name of a number is used
+			if _, ok := names[number]; !ok {
+				names[number] = valueName
+			}
+		}
+	}
+	s.enums[name] = names
+	return nil
+}
+
+// qualify names a type within a package or message
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// lowerCamel turns a field name such as user_id into userId, the way
+// protoc names fields in JSON
+func lowerCamel(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && r >= 'a' && r <= 'z':
+			b.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
+
+// protoRecords reads the fields of a message that starts at offset base of
+// the input, for error messages
+func protoRecords(data []byte, base int) ([]protoRecord, error) {
+	records, _, err := protoGroupRecords(data, 0, base, 0)
+	return records, err
+}
+
+// protoGroupRecords reads fields from pos to the end of data or, when group
+// is not 0, to the tag that ends that group. It returns the position after
+// the last field read.
+func protoGroupRecords(data []byte, pos, base int, group int32) ([]protoRecord, int, error) {
+	var records []protoRecord
+	for pos < len(data) {
+		start := pos
+		tag, err := protoVarint(data, &pos, base)
+		if err != nil {
+			return nil, 0, err
+		}
+		if tag>>3 == 0 || tag>>3 > 1<<29-1 {
+			return nil, 0, fmt.Errorf("offset %d: field number %d is out of range", base+start, tag>>3)
+		}
+		rec := protoRecord{num: int32(tag >> 3), wire: int(tag & 7), offset: base + start}
+
+		switch rec.wire {
+		case wireVarint:
+			rec.n, err = protoVarint(data, &pos, base)
+		case wireFixed64, wireFixed32:
+			size := 8
+			if rec.wire == wireFixed32 {
+				size = 4
+			}
+			if size > len(data)-pos {
+				return nil, 0, fmt.Errorf("offset %d: unexpected end of data", base+pos)
+			}
+			if size == 8 {
+				rec.n = binary.LittleEndian.Uint64(data[pos:])
+			} else {
+				rec.n = uint64(binary.LittleEndian.Uint32(data[pos:]))
+			}
+			pos += size
+		case wireBytes:
+			var n uint64
+			if n, err = protoVarint(data, &pos, base); err == nil && n > uint64(len(data)-pos) {
+				err = fmt.Errorf("offset %d: length %d is past the end of the data", base+pos, n)
+			}
+			if err == nil {
+				rec.raw, rec.rawOffset = data[pos:pos+int(n)], base+pos
+				pos += int(n)
+			}
+		case wireStartGroup:
+			// Keep the contents and the end tag, and read them again when
+			// the group is decoded
+			var end int
+			if _, end, err = protoGroupRecords(data, pos, base, rec.num); err == nil {
+				rec.raw, rec.rawOffset = data[pos:end], base+pos
+				pos = end
+			}
+		case wireEndGroup:
+			if rec.num != group {
+				return nil, 0, fmt.Errorf("offset %d: end of group %d was never started", base+start, rec.num)
+			}
+			return records, pos, nil
+		default:
+			return nil, 0, fmt.Errorf("offset %d: wire type %d is not valid", base+start, rec.wire)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, rec)
+	}
+	if group != 0 {
+		return nil, 0, fmt.Errorf("offset %d: group %d is not closed", base+pos, group)
+	}
+	return records, pos, nil
+}
+
+// protoVarint reads a base 128 varint at *pos
+func protoVarint(data []byte, pos *int, base int) (uint64, error) {
+	var n uint64
+	for i := 0; i < 10; i++ {
+		if *pos >= len(data) {
+			return 0, fmt.Errorf("offset %d: unexpected end of data", base+*pos)
+		}
+		b := data[*pos]
+		*pos++
+		n |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("offset %d: varint is longer than 10 bytes", base+*pos-10)
+}
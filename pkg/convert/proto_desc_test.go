@@ -0,0 +1,145 @@
+package convert
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// pbVarint writes a varint field
+func pbVarint(num int, n uint64) []byte {
+	b := binary.AppendUvarint(nil, uint64(num<<3|wireVarint))
+	return binary.AppendUvarint(b, n)
+}
+
+// pbLen writes a length-delimited field holding parts
+func pbLen(num int, parts ...[]byte) []byte {
+	var payload []byte
+	for _, p := range parts {
+		payload = append(payload, p...)
+	}
+	b := binary.AppendUvarint(nil, uint64(num<<3|wireBytes))
+	b = binary.AppendUvarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+// pbString writes a string field
+func pbString(num int, s string) []byte {
+	return pbLen(num, []byte(s))
+}
+
+// pbFieldDesc writes a FieldDescriptorProto, as field 2 of a message
+func pbFieldDesc(name string, number, label, kind int, typeName string) []byte {
+	desc := [][]byte{pbString(1, name), pbVarint(3, uint64(number)), pbVarint(4, uint64(label)), pbVarint(5, uint64(kind))}
+	if typeName != "" {
+		desc = append(desc, pbString(6, typeName))
+	}
+	return pbLen(2, desc...)
+}
+
+// testProtoSchema returns a descriptor set for shop.proto and the
+// well-known types it uses:
+//
+//	message Order {
+//	  enum Status { UNKNOWN = 0; PAID = 1; }
+//	  message Item { string sku = 1; double price = 2; sint32 delta = 3; }
+//	  int64 id = 1;
+//	  string customer_name = 2;
+//	  repeated int32 quantities = 3;
+//	  Status status = 4;
+//	  map<string, int32> stock = 5;
+//	  repeated Item items = 6;
+//	  bytes token = 7;
+//	  google.protobuf.Timestamp created_at = 8;
+//	  bool gift = 9;
+//	  fixed64 big = 10;
+//	}
+//
+//	message Envelope {
+//	  google.protobuf.Duration ttl = 1;
+//	  google.protobuf.Int32Value retries = 2;
+//	  google.protobuf.Any payload = 3;
+//	}
+func testProtoSchema(t *testing.T) *ProtoSchema {
+	t.Helper()
+	wellKnown := pbLen(1,
+		pbString(1, "google/protobuf/well_known.proto"),
+		pbString(2, "google.protobuf"),
+		pbLen(4, pbString(1, "Timestamp"), pbFieldDesc("seconds", 1, 1, protoInt64, ""), pbFieldDesc("nanos", 2, 1, protoInt32, "")),
+		pbLen(4, pbString(1, "Duration"), pbFieldDesc("seconds", 1, 1, protoInt64, ""), pbFieldDesc("nanos", 2, 1, protoInt32, "")),
+		pbLen(4, pbString(1, "Int32Value"), pbFieldDesc("value", 1, 1, protoInt32, "")),
+		pbLen(4, pbString(1, "Any"), pbFieldDesc("type_url", 1, 1, protoString, ""), pbFieldDesc("value", 2, 1, protoBytes, "")),
+	)
+	shop := pbLen(1,
+		pbString(1, "shop.proto"),
+		pbString(2, "shop"),
+		pbLen(4,
+			pbString(1, "Order"),
+			pbFieldDesc("id", 1, 1, protoInt64, ""),
+			pbFieldDesc("customer_name", 2, 1, protoString, ""),
+			pbFieldDesc("quantities", 3, 3, protoInt32, ""),
+			pbFieldDesc("status", 4, 1, protoEnum, ".shop.Order.Status"),
+			pbFieldDesc("stock", 5, 3, protoMessage, ".shop.Order.StockEntry"),
+			pbFieldDesc("items", 6, 3, protoMessage, ".shop.Order.Item"),
+			pbFieldDesc("token", 7, 1, protoBytes, ""),
+			pbFieldDesc("created_at", 8, 1, protoMessage, ".google.protobuf.Timestamp"),
+			pbFieldDesc("gift", 9, 1, protoBool, ""),
+			pbFieldDesc("big", 10, 1, protoFixed64, ""),
+			pbLen(3, pbString(1, "StockEntry"), pbFieldDesc("key", 1, 1, protoString, ""), pbFieldDesc("value", 2, 1, protoInt32, ""), pbLen(7, pbVarint(7, 1))),
+			pbLen(3, pbString(1, "Item"), pbFieldDesc("sku", 1, 1, protoString, ""), pbFieldDesc("price", 2, 1, protoDouble, ""), pbFieldDesc("delta", 3, 1, protoSint32, "")),
+			pbLen(4, pbString(1, "Status"), pbLen(2, pbString(1, "UNKNOWN"), pbVarint(2, 0)), pbLen(2, pbString(1, "PAID"), pbVarint(2, 1))),
+		),
+		pbLen(4,
+			pbString(1, "Envelope"),
+			pbFieldDesc("ttl", 1, 1, protoMessage, ".google.protobuf.Duration"),
+			pbFieldDesc("retries", 2, 1, protoMessage, ".google.protobuf.Int32Value"),
+			pbFieldDesc("payload", 3, 1, protoMessage, ".google.protobuf.Any"),
+		),
+	)
+
+	s, err := ParseProtoSchema(append(wellKnown, shop...))
+	if err != nil {
+		t.Fatalf("ParseProtoSchema() error = %v", err)
+	}
+	return s
+}
+
+func TestParseProtoSchema(t *testing.T) {
+	s := testProtoSchema(t)
+	for _, name := range []string{"shop.Order", ".shop.Order", "shop.Order.Item", "google.protobuf.Timestamp"} {
+		if !s.Has(name) {
+			t.Errorf("Has(%q) = false, want true", name)
+		}
+	}
+	if s.Has("shop.Missing") {
+		t.Errorf("Has(%q) = true, want false", "shop.Missing")
+	}
+	if got := s.messages["shop.Order"].byNumber[2].jsonName; got != "customerName" {
+		t.Errorf("json name of customer_name = %q, want customerName", got)
+	}
+	if !s.messages["shop.Order.StockEntry"].mapEntry {
+		t.Errorf("shop.Order.StockEntry is not a map entry")
+	}
+}
+
+func TestParseProtoSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"Empty", nil, "no message types found"},
+		{"Text", []byte("syntax = \"proto3\";"), "wire type 7 is not valid"},
+		{"Truncated", pbLen(1, pbString(1, "a.proto"))[:5], "length 9 is past the end of the data"},
+		{"Unclosed group", []byte{0x0b, 0x08, 0x01}, "group 1 is not closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProtoSchema(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseProtoSchema() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
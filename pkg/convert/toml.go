@@ -0,0 +1,258 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tomlEncoder writes a document as TOML, nested objects as [tables] and
+// arrays of objects as [[arrays of tables]]
+type tomlEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+}
+
+// encodeTOML writes v, which must be an object, as a TOML document
+func encodeTOML(v interface{}, keys func(map[string]interface{}) []string) ([]byte, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a TOML document is a table, so the value must be an object, not %s", typeName(v))
+	}
+	e := &tomlEncoder{keys: keys}
+	if err := e.table(obj, nil); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// table writes the entries of obj, located at path: its values first, then
+// its tables and arrays of tables, which TOML requires to come last
+func (e *tomlEncoder) table(obj map[string]interface{}, path []string) error {
+	var tables, arrays []string
+	for _, k := range e.keys(obj) {
+		switch v := obj[k]; {
+		case isTable(v):
+			tables = append(tables, k)
+		case isTableArray(v):
+			arrays = append(arrays, k)
+		default:
+			text, err := e.inline(v, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return err
+			}
+			e.buf.WriteString(tomlKey(k) + " = " + text + "\n")
+		}
+	}
+
+	for _, k := range tables {
+		child := obj[k].(map[string]interface{})
+		childPath := append(path[:len(path):len(path)], k)
+		// A table holding only other tables needs no header of its own
+		if hasValues(child) {
+			e.header("[" + tomlPath(childPath) + "]")
+		}
+		if err := e.table(child, childPath); err != nil {
+			return err
+		}
+	}
+	for _, k := range arrays {
+		childPath := append(path[:len(path):len(path)], k)
+		for _, item := range obj[k].([]interface{}) {
+			e.header("[[" + tomlPath(childPath) + "]]")
+			if err := e.table(item.(map[string]interface{}), childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// header writes a table header, after a blank line unless it comes first
+func (e *tomlEncoder) header(text string) {
+	if e.buf.Len() > 0 {
+		e.buf.WriteByte('\n')
+	}
+	e.buf.WriteString(text + "\n")
+}
+
+// isTable reports whether v is written as a [table]: a non-empty object
+func isTable(v interface{}) bool {
+	obj, ok := v.(map[string]interface{})
+	return ok && len(obj) > 0
+}
+
+// isTableArray reports whether v is written as an [[array of tables]]: a
+// non-empty array of objects only
+func isTableArray(v interface{}) bool {
+	items, ok := v.([]interface{})
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasValues reports whether obj has an entry written as key = value, or
+// is empty, so that its table needs a header
+func hasValues(obj map[string]interface{}) bool {
+	if len(obj) == 0 {
+		return true
+	}
+	for _, v := range obj {
+		if !isTable(v) && !isTableArray(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// inline writes a value on one line: objects become inline tables
+func (e *tomlEncoder) inline(v interface{}, path []string) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", fmt.Errorf("%s is null, which TOML cannot hold; drop nulls with -omit-null", tomlPath(path))
+	case bool:
+		return strconv.FormatBool(val), nil
+	case string:
+		if tomlDatetime.MatchString(val) && validDatetime(val) {
+			return val, nil
+		}
+		return tomlString(val), nil
+	case json.Number:
+		// Numbers too precise for float64; integers must still fit in 64 bits
+		if _, err := strconv.ParseInt(string(val), 10, 64); err != nil && !strings.ContainsAny(string(val), ".eE") {
+			return "", fmt.Errorf("%s is %s, out of the range of TOML integers", tomlPath(path), val)
+		}
+		return string(val), nil
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return strconv.FormatInt(int64(val), 10), nil
+		}
+		return numberText(val), nil
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			text, err := e.inline(item, path)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = text
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return "{}", nil
+		}
+		keys := e.keys(val)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			text, err := e.inline(val[k], append(path[:len(path):len(path)], k))
+			if err != nil {
+				return "", err
+			}
+			parts[i] = tomlKey(k) + " = " + text
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("cannot write %T as TOML", v)
+}
+
+// tomlKey writes a key bare when it can be, and quoted otherwise
+func tomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for i := 0; i < len(k); i++ {
+		if !isBareKeyChar(k[i]) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// tomlPath writes a dotted key such as servers."eu west".ip
+func tomlPath(path []string) string {
+	parts := make([]string, len(path))
+	for i, k := range path {
+		parts[i] = tomlKey(k)
+	}
+	return strings.Join(parts, ".")
+}
+
+// tomlString writes s as a basic string
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// validDatetime checks the fields of text, which matches tomlDatetime, so
+// that a string such as "2024-13-45" is not taken for a date
+func validDatetime(text string) bool {
+	date, clock := text, ""
+	if len(text) > 10 {
+		date, clock = text[:10], text[11:]
+	}
+	if strings.Contains(text[:min(len(text), 8)], ":") {
+		date, clock = "", text
+	}
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return false
+		}
+	}
+	if clock != "" {
+		// The fraction and offset are checked by the pattern
+		if _, err := time.Parse("15:04:05", clock[:8]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// typeName names the JSON type of v for messages
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}, *orderedMap:
+		return "an object"
+	}
+	return "a number"
+}
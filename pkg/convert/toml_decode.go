@@ -0,0 +1,594 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser reads a TOML 1.0 document into orderedMap, []interface{},
+// string and literal values. Dates and times become RFC 3339 strings.
+type tomlParser struct {
+	src     string
+	pos     int
+	root    *orderedMap
+	current *orderedMap
+	// defined holds the tables given a [header] or filled through dotted
+	// keys, which cannot be defined again
+	defined map[*orderedMap]bool
+	// arrays holds the paths of arrays of tables, which [[headers]] extend
+	arrays map[string]bool
+}
+
+// tomlToJSON converts a TOML document to JSON text
+func tomlToJSON(data []byte) ([]byte, error) {
+	root := &orderedMap{}
+	p := &tomlParser{
+		src:     strings.ReplaceAll(string(data), "\r\n", "\n"),
+		root:    root,
+		current: root,
+		defined: map[*orderedMap]bool{root: true},
+		arrays:  make(map[string]bool),
+	}
+	if err := p.document(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// document reads every line of the document
+func (p *tomlParser) document() error {
+	for {
+		p.skipSpace()
+		switch {
+		case p.eof():
+			return nil
+		case p.peek() == '\n':
+			p.pos++
+			continue
+		case p.peek() == '#':
+			p.skipComment()
+			continue
+		case strings.HasPrefix(p.src[p.pos:], "[["):
+			if err := p.tableArray(); err != nil {
+				return err
+			}
+		case p.peek() == '[':
+			if err := p.table(); err != nil {
+				return err
+			}
+		default:
+			if err := p.keyValue(p.current); err != nil {
+				return err
+			}
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// table reads a [table] header and makes that table current
+func (p *tomlParser) table() error {
+	p.pos++
+	path, err := p.headerKey("]")
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.walk(p.root, path[:len(path)-1], path)
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	var t *orderedMap
+	if i := parent.index(last); i >= 0 {
+		existing, ok := parent.values[i].(*orderedMap)
+		if !ok || p.defined[existing] {
+			return p.errorf("table [%s] is defined more than once", strings.Join(path, "."))
+		}
+		t = existing
+	} else {
+		t = &orderedMap{}
+		parent.add(last, t)
+	}
+	p.defined[t] = true
+	p.current = t
+	return nil
+}
+
+// tableArray reads an [[array]] header, appending a table to the array and
+// making it current
+func (p *tomlParser) tableArray() error {
+	p.pos += 2
+	path, err := p.headerKey("]]")
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.walk(p.root, path[:len(path)-1], path)
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	t := &orderedMap{}
+	p.defined[t] = true
+	key := strings.Join(path, "\x00")
+	if i := parent.index(last); i >= 0 {
+		items, ok := parent.values[i].([]interface{})
+		if !ok || !p.arrays[key] {
+			return p.errorf("[[%s]] extends a value that is not an array of tables", strings.Join(path, "."))
+		}
+		parent.values[i] = append(items, t)
+	} else {
+		parent.add(last, []interface{}{t})
+		p.arrays[key] = true
+	}
+	p.current = t
+	return nil
+}
+
+// walk follows keys from m, creating the tables that are missing. An array
+// of tables stands for its last table, as in [[fruits]] then [fruits.info].
+func (p *tomlParser) walk(m *orderedMap, keys, path []string) (*orderedMap, error) {
+	for n, k := range keys {
+		i := m.index(k)
+		if i < 0 {
+			child := &orderedMap{}
+			m.add(k, child)
+			m = child
+			continue
+		}
+		switch v := m.values[i].(type) {
+		case *orderedMap:
+			m = v
+		case []interface{}:
+			last, ok := lastTable(v)
+			if !ok || !p.arrays[strings.Join(path[:n+1], "\x00")] {
+				return nil, p.errorf("%s is an array, not a table", strings.Join(path[:n+1], "."))
+			}
+			m = last
+		default:
+			return nil, p.errorf("%s is already a value, not a table", strings.Join(path[:n+1], "."))
+		}
+	}
+	return m, nil
+}
+
+// lastTable returns the last element of an array of tables
+func lastTable(items []interface{}) (*orderedMap, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+	m, ok := items[len(items)-1].(*orderedMap)
+	return m, ok
+}
+
+// headerKey reads the dotted key of a header up to its closing brackets
+func (p *tomlParser) headerKey(closing string) ([]string, error) {
+	p.skipSpace()
+	path, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, p.errorf("expected %q after the table name", closing)
+	}
+	p.pos += len(closing)
+	return path, nil
+}
+
+// keyValue reads "key = value" into m. Dotted keys create the tables
+// leading to the value.
+func (p *tomlParser) keyValue(m *orderedMap) error {
+	path, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after the key %s", strings.Join(path, "."))
+	}
+	p.pos++
+	p.skipSpace()
+
+	for n, k := range path[:len(path)-1] {
+		i := m.index(k)
+		if i < 0 {
+			child := &orderedMap{}
+			m.add(k, child)
+			m = child
+			continue
+		}
+		child, ok := m.values[i].(*orderedMap)
+		if !ok || p.defined[child] && child != p.current {
+			return p.errorf("%s is already defined", strings.Join(path[:n+1], "."))
+		}
+		m = child
+	}
+
+	last := path[len(path)-1]
+	if m.has(last) {
+		return p.errorf("%s is defined more than once", strings.Join(path, "."))
+	}
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	m.add(last, value)
+	return nil
+}
+
+// key reads a bare, quoted or dotted key
+func (p *tomlParser) key() ([]string, error) {
+	var path []string
+	for {
+		p.skipSpace()
+		var part string
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		case c == '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key, found %s", p.describe())
+			}
+			part = p.src[start:p.pos]
+		}
+		path = append(path, part)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+// isBareKeyChar reports whether c may appear in a key without quotes
+func isBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads any value
+func (p *tomlParser) value() (interface{}, error) {
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		return p.multilineString('"')
+	case strings.HasPrefix(p.src[p.pos:], "'''"):
+		return p.multilineString('\'')
+	case c == '"':
+		return p.basicString()
+	case c == '\'':
+		return p.literalString()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += 4
+		return literal("true"), nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += 5
+		return literal("false"), nil
+	}
+	return p.scalar()
+}
+
+var (
+	tomlDatetime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?)?$|^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	tomlInt      = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	tomlRadix    = regexp.MustCompile(`^0(x[0-9a-fA-F](_?[0-9a-fA-F])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	tomlFloat    = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+)
+
+// scalar reads a number, date or time
+func (p *tomlParser) scalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	// A space may separate the date and time of a datetime
+	if p.pos-start == 10 && p.peek() == ' ' && p.pos+3 < len(p.src) && isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+	}
+	text := p.src[start:p.pos]
+	if text == "" {
+		return nil, p.errorf("expected a value, found %s", p.describe())
+	}
+
+	plain := strings.ReplaceAll(text, "_", "")
+	n := new(big.Int)
+	switch {
+	case tomlDatetime.MatchString(text):
+		if !validDatetime(text) {
+			return nil, p.errorf("invalid date or time %s", text)
+		}
+		return text, nil
+	case tomlInt.MatchString(text):
+		n.SetString(strings.TrimPrefix(plain, "+"), 10)
+		return literal(n.String()), nil
+	case tomlRadix.MatchString(text):
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[plain[1]]
+		n.SetString(plain[2:], base)
+		return literal(n.String()), nil
+	case tomlFloat.MatchString(text):
+		return literal(strings.TrimPrefix(plain, "+")), nil
+	case strings.TrimLeft(text, "+-") == "inf" || strings.TrimLeft(text, "+-") == "nan":
+		return nil, p.errorf("%s has no JSON equivalent", text)
+	}
+	return nil, p.errorf("invalid value %s", text)
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// array reads [a, b, ...], which may span lines and hold comments
+func (p *tomlParser) array() (interface{}, error) {
+	p.pos++
+	items := []interface{}{}
+	for {
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in the array, found %s", p.describe())
+		}
+	}
+}
+
+// skipArraySpace skips whitespace, line breaks and comments inside an array
+func (p *tomlParser) skipArraySpace() error {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return nil
+		}
+	}
+	return p.errorf("unterminated array")
+}
+
+// inlineTable reads { key = value, ... } on a single line
+func (p *tomlParser) inlineTable() (interface{}, error) {
+	p.pos++
+	t := &orderedMap{}
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		p.skipSpace()
+		if err := p.keyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			p.defined[t] = true
+			return t, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in the inline table, found %s", p.describe())
+		}
+	}
+}
+
+// basicString reads a "..." string with its escapes
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++
+	var buf []byte
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return string(buf), nil
+		case '\\':
+			r, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+// escape decodes the escape sequence starting at the backslash
+func (p *tomlParser) escape() (rune, error) {
+	p.pos++
+	if p.eof() {
+		return 0, p.errorf("unterminated string")
+	}
+	e := p.src[p.pos]
+	p.pos++
+	simple := map[byte]rune{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', 'e': 0x1b, '"': '"', '\\': '\\'}
+	if r, ok := simple[e]; ok {
+		return r, nil
+	}
+
+	digits := map[byte]int{'u': 4, 'U': 8}[e]
+	if digits == 0 {
+		return 0, p.errorf("unknown escape \\%c", e)
+	}
+	if p.pos+digits > len(p.src) {
+		return 0, p.errorf("truncated escape \\%c", e)
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, p.errorf("invalid escape \\%c%s", e, p.src[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	return rune(n), nil
+}
+
+// literalString reads a '...' string, which has no escapes
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// multilineString reads a multi-line string, basic or literal, between three
+// quotes of either kind. A line break right after the opening quotes is
+// dropped, and in basic strings a backslash at the end of a line joins it to
+// the next non-blank text.
+func (p *tomlParser) multilineString(quote byte) (string, error) {
+	delim := strings.Repeat(string(quote), 3)
+	p.pos += 3
+	if p.peek() == '\n' {
+		p.pos++
+	}
+
+	var buf []byte
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			// Up to two quotes may come right before the closing ones
+			n := 3
+			for n < 5 && p.pos+n < len(p.src) && p.src[p.pos+n] == quote {
+				n++
+			}
+			buf = append(buf, p.src[p.pos:p.pos+n-3]...)
+			p.pos += n
+			return string(buf), nil
+		}
+
+		c := p.src[p.pos]
+		if c != '\\' || quote == '\'' {
+			buf = append(buf, c)
+			p.pos++
+			continue
+		}
+		if rest := strings.TrimLeft(p.src[p.pos+1:], " \t"); strings.HasPrefix(rest, "\n") {
+			// A line-ending backslash
+			p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\n"))
+			continue
+		}
+		r, err := p.escape()
+		if err != nil {
+			return "", err
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+}
+
+// eof reports whether the whole input was read
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current byte, or 0 at the end of the input
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to the end of the line
+func (p *tomlParser) skipComment() {
+	if i := strings.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+		p.pos += i
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+// endOfLine checks that nothing but a comment follows on the line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.skipComment()
+	}
+	if !p.eof() && p.peek() != '\n' {
+		return p.errorf("unexpected %s, expected the end of the line", p.describe())
+	}
+	return nil
+}
+
+// describe names the current token for error messages
+func (p *tomlParser) describe() string {
+	if p.eof() {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return strconv.QuoteRune(r)
+}
+
+// errorf returns an error located at the current line
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
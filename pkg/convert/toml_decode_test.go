@@ -0,0 +1,145 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestTOMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{
+			name: "Cargo manifest",
+			toml: "[package]\nname = \"fj\" # the tool\nedition = '2021'\n\n[dependencies]\nserde = { version = \"1\", features = [\"derive\"] }\n",
+			want: `{"package":{"name":"fj","edition":"2021"},"dependencies":{"serde":{"version":"1","features":["derive"]}}}`,
+		},
+		{
+			name: "Dotted keys",
+			toml: "a.b = 1\na.c = 2\n\"d.e\".f = true\n",
+			want: `{"a":{"b":1,"c":2},"d.e":{"f":true}}`,
+		},
+		{
+			name: "Arrays of tables with subtables",
+			toml: "[[bin]]\nname = \"a\"\n[bin.opts]\nx = 1\n\n[[bin]]\nname = \"b\"\n",
+			want: `{"bin":[{"name":"a","opts":{"x":1}},{"name":"b"}]}`,
+		},
+		{
+			name: "Super table defined after its subtable",
+			toml: "[x.y]\nz = 1\n[x]\nw = 2\n",
+			want: `{"x":{"y":{"z":1},"w":2}}`,
+		},
+		{
+			name: "Numbers",
+			toml: "a = +42\nb = 1_000\nc = 0xff\nd = 0o17\ne = 0b101\nf = 6.626e-34\ng = -0.5\nh = 9_223_372_036_854_775_807\n",
+			want: `{"a":42,"b":1000,"c":255,"d":15,"e":5,"f":6.626e-34,"g":-0.5,"h":9223372036854775807}`,
+		},
+		{
+			name: "Datetimes become strings",
+			toml: "a = 1979-05-27T07:32:00Z\nb = 1979-05-27 07:32:00.5-07:00\nc = 1979-05-27\nd = 07:32:00\n",
+			want: `{"a":"1979-05-27T07:32:00Z","b":"1979-05-27 07:32:00.5-07:00","c":"1979-05-27","d":"07:32:00"}`,
+		},
+		{
+			name: "Multi-line strings",
+			toml: "a = \"\"\"\none\\\n    two\"\"\"\nb = '''\nC:\\path\n'''\n",
+			want: `{"a":"onetwo","b":"C:\\path\n"}`,
+		},
+		{
+			name: "Escapes",
+			toml: `a = "tab\there \u00e9 \"q\" \e"` + "\n",
+			want: `{"a":"tab\there é \"q\" \u001b"}`,
+		},
+		{
+			name: "Arrays over several lines",
+			toml: "a = [\n  1, # one\n  2,\n]\nb = [[1, 2], [\"x\"]]\n",
+			want: `{"a":[1,2],"b":[[1,2],["x"]]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.toml), TOML, Options{})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		toml string
+		want string
+	}{
+		{"a = 1\na = 2\n", "line 2: a is defined more than once"},
+		{"[t]\n[t]\n", "table [t] is defined more than once"},
+		{"a = 1 b = 2\n", "line 1: unexpected 'b', expected the end of the line"},
+		{"a = \"open\n", "line 1: unterminated string"},
+		{"a = inf\n", "line 1: inf has no JSON equivalent"},
+		{"a = 2024-13-45\n", "line 1: invalid date or time 2024-13-45"},
+		{"a = [1, 2\n", "unterminated array"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON([]byte(tt.toml), TOML, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%q) error = %v, want it to contain %q", tt.toml, err, tt.want)
+		}
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	doc := `{"title":"fj","released":"2024-05-01T12:30:00Z","tags":["cli","json"],"big":1e+21,"owner":{"name":"nico","dob":"1979-05-27"},"servers":[{"ip":"10.0.0.1","ports":[80,443]},{"ip":"10.0.0.2","ports":[]}]}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	toml, err := Encode(v, TOML, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(toml, TOML, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for\n%s", err, toml)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
+
+func TestTOMLRoundTripKeepsFloats(t *testing.T) {
+	input := "f = 1.0\ne = 1e3\nx = 2.5\ni = 3\n"
+	data, err := ToJSON([]byte(input), TOML, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode(data, opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	out, err := formatter.FormatValue(v, formatter.Options{KeyOrder: opts.KeyOrder, Compact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"f":1.0,"e":1e3,"x":2.5,"i":3}`; string(out) != want {
+		t.Errorf("JSON = %s, want %s", out, want)
+	}
+
+	got, err := Encode(v, TOML, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if string(got) != input {
+		t.Errorf("round trip =\n%s\nwant\n%s", got, input)
+	}
+}
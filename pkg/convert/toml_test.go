@@ -0,0 +1,92 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "Values before tables",
+			json: `{"package": {"name": "fj", "version": "0.1.0"}, "title": "demo", "port": 8080}`,
+			want: "title = \"demo\"\nport = 8080\n\n[package]\nname = \"fj\"\nversion = \"0.1.0\"\n",
+		},
+		{
+			name: "Tables holding only tables get no header",
+			json: `{"tool": {"poetry": {"name": "fj"}}}`,
+			want: "[tool.poetry]\nname = \"fj\"\n",
+		},
+		{
+			name: "Arrays of tables",
+			json: `{"bin": [{"name": "fj", "path": "main.go"}, {"name": "fjd"}]}`,
+			want: "[[bin]]\nname = \"fj\"\npath = \"main.go\"\n\n[[bin]]\nname = \"fjd\"\n",
+		},
+		{
+			name: "Inline arrays and tables",
+			json: `{"tags": ["cli", 1, true], "mixed": [{"a": 1}, 2], "empty": {}, "none": []}`,
+			want: "tags = [\"cli\", 1, true]\nmixed = [{ a = 1 }, 2]\nempty = {}\nnone = []\n",
+		},
+		{
+			name: "Quoted keys and strings",
+			json: `{"eu west": {"ip": "10.0.0.1"}, "text": "a \"b\"\n\tc"}`,
+			want: "text = \"a \\\"b\\\"\\n\\tc\"\n\n[\"eu west\"]\nip = \"10.0.0.1\"\n",
+		},
+		{
+			name: "Datetimes are written unquoted",
+			json: `{"released": "2024-05-01T12:30:00Z", "day": "2024-05-01", "at": "07:32:00", "bad": "2024-13-45"}`,
+			want: "released = 2024-05-01T12:30:00Z\nday = 2024-05-01\nat = 07:32:00\nbad = \"2024-13-45\"\n",
+		},
+		{
+			name: "Numbers",
+			json: `{"int": 3, "whole": 3.0, "float": 2.5, "big": 1e21, "exact": 9007199254740993}`,
+			want: "int = 3\nwhole = 3.0\nfloat = 2.5\nbig = 1e21\nexact = 9007199254740993\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+			v, err := formatter.Decode([]byte(tt.json), opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := Encode(v, TOML, opts, Options{})
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeTOMLErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`[1, 2]`, "must be an object, not an array"},
+		{`{"a": {"b": null}}`, "a.b is null, which TOML cannot hold"},
+		{`{"n": 123456789012345678901234}`, "out of the range of TOML integers"},
+	}
+
+	for _, tt := range tests {
+		opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+		v, err := formatter.Decode([]byte(tt.json), opts)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		_, err = Encode(v, TOML, opts, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Encode(%s) error = %v, want it to contain %q", tt.json, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// xmlEncoder writes a document as indented XML. Keys starting with the
+// attribute prefix become attributes, the text key becomes the element's
+// text, and arrays become repeated elements.
+type xmlEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+	conv Options
+}
+
+// encodeXML writes v as an XML document. An object with a single entry
+// names the root element; anything else is wrapped in a <root> element.
+// Arrays inside arrays, having no name of their own, hold <item>s.
+func encodeXML(v interface{}, keys func(map[string]interface{}) []string, conv Options) ([]byte, error) {
+	e := &xmlEncoder{keys: keys, conv: conv}
+	e.buf.WriteString(xml.Header)
+
+	name, root := "root", v
+	if obj, ok := v.(map[string]interface{}); ok && len(obj) == 1 {
+		for k, child := range obj {
+			if _, isArray := child.([]interface{}); !isArray && !e.isAttr(k) && k != conv.TextKey {
+				name, root = k, child
+			}
+		}
+	}
+	// Nested arrays have no name of their own, so their items are <item>s
+	if _, ok := root.([]interface{}); ok {
+		root = map[string]interface{}{"item": root}
+	}
+	if err := e.element(name, root, 0); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// isAttr reports whether key k holds an attribute
+func (e *xmlEncoder) isAttr(k string) bool {
+	return e.conv.AttrPrefix != "" && strings.HasPrefix(k, e.conv.AttrPrefix)
+}
+
+// element writes v as elements called name: an array as one element per
+// item, and anything else as a single element
+func (e *xmlEncoder) element(name string, v interface{}, depth int) error {
+	if !validXMLName(name) {
+		return fmt.Errorf("key %q is not a valid XML element name", name)
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return e.single(name, v, depth)
+	}
+	for _, item := range items {
+		if _, nested := item.([]interface{}); nested {
+			item = map[string]interface{}{"item": item}
+		}
+		if err := e.single(name, item, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// single writes one element called name holding v
+func (e *xmlEncoder) single(name string, v interface{}, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		if v == nil {
+			e.buf.WriteString(indent + "<" + name + "/>\n")
+			return nil
+		}
+		e.buf.WriteString(indent + "<" + name + ">" + xmlText(v) + "</" + name + ">\n")
+		return nil
+	}
+
+	var children []string
+	text, hasText := "", false
+	e.buf.WriteString(indent + "<" + name)
+	for _, k := range e.keys(obj) {
+		switch {
+		case e.isAttr(k):
+			attr := strings.TrimPrefix(k, e.conv.AttrPrefix)
+			if !validXMLName(attr) {
+				return fmt.Errorf("key %q is not a valid XML attribute name", k)
+			}
+			switch obj[k].(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s.%s is an attribute, so it must hold a string, number or boolean", name, k)
+			}
+			e.buf.WriteString(" " + attr + `="` + xmlText(obj[k]) + `"`)
+		case k == e.conv.TextKey:
+			text, hasText = xmlText(obj[k]), true
+		default:
+			children = append(children, k)
+		}
+	}
+
+	switch {
+	case len(children) == 0 && !hasText:
+		e.buf.WriteString("/>\n")
+	case len(children) == 0:
+		e.buf.WriteString(">" + text + "</" + name + ">\n")
+	default:
+		e.buf.WriteString(">\n")
+		if hasText {
+			e.buf.WriteString(indent + "  " + text + "\n")
+		}
+		for _, k := range children {
+			if err := e.element(k, obj[k], depth+1); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteString(indent + "</" + name + ">\n")
+	}
+	return nil
+}
+
+// xmlText writes a scalar as escaped character data
+func xmlText(v interface{}) string {
+	var text string
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		text = val
+	case bool:
+		text = strconv.FormatBool(val)
+	case json.Number:
+		text = string(val)
+	case float64:
+		text = numberText(val)
+	default:
+		text = fmt.Sprint(val)
+	}
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// validXMLName reports whether name can be written as an element or
+// attribute name, optionally with a namespace prefix such as soap:Body
+func validXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.' || r == ':'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlNode is an element being read: its entries so far and its text
+type xmlNode struct {
+	name    string
+	entries *orderedMap
+	text    strings.Builder
+}
+
+// xmlToJSON converts an XML document to JSON text. The root element becomes
+// the single key of an object; attributes become keys starting with
+// conv.AttrPrefix, and repeated child elements become arrays. All values
+// are strings, and empty elements are null.
+func xmlToJSON(data []byte, conv Options) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	// RSS and other feeds often use HTML entities such as &nbsp;
+	d.Entity = xml.HTMLEntity
+
+	var stack []*xmlNode
+	var root *orderedMap
+	for {
+		// Raw tokens keep namespace prefixes such as soap:Envelope as written
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				return nil, fmt.Errorf("line %d: %s", syntax.Line, syntax.Msg)
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, xmlError(d, "more than one root element")
+			}
+			node := &xmlNode{name: qualifiedName(t.Name), entries: &orderedMap{}}
+			seen := make(map[string]bool, len(t.Attr))
+			for _, a := range t.Attr {
+				name := qualifiedName(a.Name)
+				if seen[name] {
+					return nil, xmlError(d, "attribute %s is repeated on <%s>", name, node.name)
+				}
+				seen[name] = true
+				addXMLEntry(node.entries, conv.AttrPrefix+name, a.Value)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, xmlError(d, "</%s> has no opening tag", qualifiedName(t.Name))
+			}
+			node := stack[len(stack)-1]
+			if name := qualifiedName(t.Name); name != node.name {
+				return nil, xmlError(d, "</%s> closes <%s>", name, node.name)
+			}
+			stack = stack[:len(stack)-1]
+			value := node.value(conv.TextKey)
+			if len(stack) == 0 {
+				root = &orderedMap{}
+				root.add(node.name, value)
+			} else {
+				addXMLEntry(stack[len(stack)-1].entries, node.name, value)
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, xmlError(d, "text outside the root element")
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unexpected end of document: <%s> is not closed", stack[len(stack)-1].name)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// value returns what the element becomes: its text when it has nothing
+// else, null when it is empty, and otherwise an object of its entries,
+// with any text under textKey
+func (n *xmlNode) value(textKey string) interface{} {
+	text := strings.TrimSpace(n.text.String())
+	switch {
+	case len(n.entries.keys) > 0:
+		if text != "" {
+			addXMLEntry(n.entries, textKey, text)
+		}
+		return n.entries
+	case text != "":
+		return text
+	}
+	return nil
+}
+
+// addXMLEntry adds an entry, turning a key seen before into an array of
+// its values
+func addXMLEntry(m *orderedMap, key string, value interface{}) {
+	i := m.index(key)
+	if i < 0 {
+		m.add(key, value)
+		return
+	}
+	if items, ok := m.values[i].([]interface{}); ok {
+		m.values[i] = append(items, value)
+	} else {
+		m.values[i] = []interface{}{m.values[i], value}
+	}
+}
+
+// qualifiedName writes a name with its namespace prefix, if any
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// xmlError reports an error at the decoder's current line
+func xmlError(d *xml.Decoder, format string, args ...interface{}) error {
+	line, _ := d.InputPos()
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,113 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestXMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		conv Options
+		want string
+	}{
+		{
+			name: "RSS feed",
+			xml: `<?xml version="1.0"?>
+<!-- generated -->
+<rss version="2.0">
+  <channel>
+    <title>fj &amp; co&nbsp;</title>
+    <item><title>One</title><guid isPermaLink="false">1</guid></item>
+    <item><title><![CDATA[Two <b>]]></title><description></description></item>
+  </channel>
+</rss>`,
+			conv: DefaultOptions(),
+			want: `{"rss":{"@version":"2.0","channel":{"title":"fj & co","item":[{"title":"One","guid":{"@isPermaLink":"false","#text":"1"}},{"title":"Two <b>","description":null}]}}}`,
+		},
+		{
+			name: "SOAP envelope keeps namespace prefixes",
+			xml: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body><m:Price xmlns:m="urn:shop">12.50</m:Price></soap:Body>
+</soap:Envelope>`,
+			conv: DefaultOptions(),
+			want: `{"soap:Envelope":{"@xmlns:soap":"http://www.w3.org/2003/05/soap-envelope","soap:Body":{"m:Price":{"@xmlns:m":"urn:shop","#text":"12.50"}}}}`,
+		},
+		{
+			name: "Mixed content",
+			xml:  `<p>see <a href="x">here</a> now</p>`,
+			conv: DefaultOptions(),
+			want: `{"p":{"a":{"@href":"x","#text":"here"},"#text":"see  now"}}`,
+		},
+		{
+			name: "Custom attribute prefix and text key",
+			xml:  `<price currency="EUR">9.50</price>`,
+			conv: Options{AttrPrefix: "_", TextKey: "value"},
+			want: `{"price":{"_currency":"EUR","value":"9.50"}}`,
+		},
+		{
+			name: "No attribute prefix merges attributes with children",
+			xml:  `<a id="1"><id>2</id></a>`,
+			conv: Options{TextKey: "#text"},
+			want: `{"a":{"id":["1","2"]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.xml), XML, tt.conv)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		xml  string
+		want string
+	}{
+		{"<a>\n<b></a>", "line 2: </a> closes <b>"},
+		{"<a/><b/>", "line 1: more than one root element"},
+		{"<a>", "<a> is not closed"},
+		{"</a>", "line 1: </a> has no opening tag"},
+		{"text <a/>", "line 1: text outside the root element"},
+		{"<!-- only -->", "no root element"},
+		{`<a x="1" x="2"/>`, "line 1: attribute x is repeated on <a>"},
+		{"<a>&bogus;</a>", "line 1: invalid character entity &bogus;"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON([]byte(tt.xml), XML, DefaultOptions())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%q) error = %v, want it to contain %q", tt.xml, err, tt.want)
+		}
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	doc := `{"feed":{"@xmlns":"http://www.w3.org/2005/Atom","title":"fj","entry":[{"id":"1","link":{"@href":"a"}},{"id":"2","summary":{"@type":"text","#text":"x < y"},"empty":null}]}}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	xml, err := Encode(v, XML, opts, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(xml, XML, DefaultOptions())
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for\n%s", err, xml)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
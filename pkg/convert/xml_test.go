@@ -0,0 +1,119 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeXML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		conv Options
+		want string
+	}{
+		{
+			name: "Single key names the root",
+			json: `{"rss": {"@version": "2.0", "channel": {"title": "fj & co", "item": [{"id": 1}, {"id": 2}]}}}`,
+			conv: DefaultOptions(),
+			want: `<rss version="2.0">
+  <channel>
+    <title>fj &amp; co</title>
+    <item>
+      <id>1</id>
+    </item>
+    <item>
+      <id>2</id>
+    </item>
+  </channel>
+</rss>
+`,
+		},
+		{
+			name: "Text next to attributes and children",
+			json: `{"p": {"@class": "note", "#text": "see", "a": {"@href": "x", "#text": "here"}, "br": null}}`,
+			conv: DefaultOptions(),
+			want: `<p class="note">
+  see
+  <a href="x">here</a>
+  <br/>
+</p>
+`,
+		},
+		{
+			name: "Other documents are wrapped in a root",
+			json: `[1234567, [true, "b"], {}]`,
+			conv: DefaultOptions(),
+			want: `<root>
+  <item>1234567</item>
+  <item>
+    <item>true</item>
+    <item>b</item>
+  </item>
+  <item/>
+</root>
+`,
+		},
+		{
+			name: "Custom attribute prefix and text key",
+			json: `{"price": {"_currency": "EUR", "value": "9.50"}}`,
+			conv: Options{AttrPrefix: "_", TextKey: "value"},
+			want: `<price currency="EUR">9.50</price>
+`,
+		},
+		{
+			name: "No attribute prefix",
+			json: `{"a": {"@b": 1}}`,
+			conv: Options{TextKey: "#text"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+			v, err := formatter.Decode([]byte(tt.json), opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := Encode(v, XML, opts, tt.conv)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("Encode() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + tt.want; string(got) != want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeXMLErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"eu west": 1}`, `key "eu west" is not a valid XML element name`},
+		{`{"a": {"@1x": 1}}`, `key "@1x" is not a valid XML attribute name`},
+		{`{"a": {"@x": [1]}}`, "a.@x is an attribute, so it must hold a string, number or boolean"},
+	}
+
+	for _, tt := range tests {
+		opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+		v, err := formatter.Decode([]byte(tt.json), opts)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		_, err = Encode(v, XML, opts, DefaultOptions())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Encode(%s) error = %v, want it to contain %q", tt.json, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlEncoder writes a document in YAML block style, two spaces per level
+type yamlEncoder struct {
+	buf  bytes.Buffer
+	keys func(map[string]interface{}) []string
+}
+
+// encodeYAML writes v as a YAML document ending with a line feed
+func encodeYAML(v interface{}, keys func(map[string]interface{}) []string) ([]byte, error) {
+	e := &yamlEncoder{keys: keys}
+	if isBlock(v) {
+		if err := e.block(v, 0, false); err != nil {
+			return nil, err
+		}
+	} else {
+		text, err := yamlScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		e.buf.WriteString(text)
+		e.buf.WriteByte('\n')
+	}
+	return e.buf.Bytes(), nil
+}
+
+// isBlock reports whether v is written over several lines: a non-empty
+// object or array
+func isBlock(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return len(val) > 0
+	case []interface{}:
+		return len(val) > 0
+	}
+	return false
+}
+
+// block writes a non-empty object or array at indent. When inline, the
+// first line continues a "- " already written for a sequence entry.
+func (e *yamlEncoder) block(v interface{}, indent int, inline bool) error {
+	first := true
+	line := func() {
+		if !first || !inline {
+			e.buf.WriteString(strings.Repeat(" ", indent))
+		}
+		first = false
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range e.keys(val) {
+			line()
+			e.buf.WriteString(yamlString(k))
+			e.buf.WriteByte(':')
+			if err := e.child(val[k], indent+2, false); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			line()
+			e.buf.WriteByte('-')
+			if err := e.child(item, indent+2, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// child writes the value of an entry after its "key:" or "-". Objects and
+// arrays start on the next line, except that a sequence entry holding one
+// starts it on the same line, as in "- name: x".
+func (e *yamlEncoder) child(v interface{}, indent int, entry bool) error {
+	if !isBlock(v) {
+		text, err := yamlScalar(v)
+		if err != nil {
+			return err
+		}
+		e.buf.WriteByte(' ')
+		e.buf.WriteString(text)
+		e.buf.WriteByte('\n')
+		return nil
+	}
+	if entry {
+		e.buf.WriteByte(' ')
+		return e.block(v, indent, true)
+	}
+	e.buf.WriteByte('\n')
+	return e.block(v, indent, false)
+}
+
+// yamlScalar writes a value that fits on one line: a scalar or an empty
+// object or array
+func yamlScalar(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case string:
+		return yamlString(val), nil
+	case json.Number:
+		return string(val), nil
+	case float64:
+		text, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(text), nil
+	case map[string]interface{}:
+		return "{}", nil
+	case []interface{}:
+		return "[]", nil
+	}
+	return "", fmt.Errorf("cannot write %T as YAML", v)
+}
+
+// yamlString writes s plain when a YAML parser reads it back as the same
+// string, and double-quoted otherwise
+func yamlString(s string) string {
+	if plainSafe(s) {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case 0x85:
+			sb.WriteString(`\N`)
+		case 0x2028:
+			sb.WriteString(`\L`)
+		case 0x2029:
+			sb.WriteString(`\P`)
+		case 0xfeff:
+			sb.WriteString(`\uFEFF`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\x%02X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// yamlKeywords are plain scalars that YAML 1.2 or the YAML 1.1 parsers
+// still in wide use read as something other than a string
+var yamlKeywords = map[string]bool{
+	"null": true, "~": true, "true": true, "false": true,
+	"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+	".inf": true, "-.inf": true, "+.inf": true, ".nan": true, "<<": true,
+}
+
+// plainSafe reports whether s can be written without quotes. It errs on the
+// side of quoting: numbers, dates and version strings alike are quoted.
+func plainSafe(s string) bool {
+	if s == "" || yamlKeywords[strings.ToLower(s)] || !utf8.ValidString(s) {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") || strings.HasSuffix(s, " ") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == 0x85 || r == 0x2028 || r == 0x2029 || r == 0xfeff {
+			return false
+		}
+	}
+
+	// Anything starting like a number could be one: 1e3, 0x1F, 1_000, 12:30
+	// (sexagesimal in YAML 1.1) or 2024-01-01 (a timestamp)
+	digits := strings.TrimLeft(s, "+")
+	return digits == "" || !(digits[0] == '.' || (digits[0] >= '0' && digits[0] <= '9'))
+}
@@ -0,0 +1,1003 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlParser reads YAML block and flow styles into orderedMap, []interface{},
+// string and literal values
+type yamlParser struct {
+	src     string
+	pos     int
+	anchors map[string]interface{}
+}
+
+// yamlToJSON converts a YAML stream to JSON text. A stream of several
+// documents becomes an array of them.
+func yamlToJSON(data []byte) ([]byte, error) {
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	p := &yamlParser{src: src, anchors: make(map[string]interface{})}
+	docs, err := p.documents()
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	switch len(docs) {
+	case 0:
+	case 1:
+		v = docs[0]
+	default:
+		v = docs
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// documents reads every document of the stream, skipping directives and
+// "---" and "..." markers
+func (p *yamlParser) documents() ([]interface{}, error) {
+	var docs []interface{}
+	for {
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() {
+			return docs, nil
+		}
+		if p.col() == 0 && p.peek() == '%' {
+			p.skipLine()
+			continue
+		}
+		if p.atMarker("...") {
+			p.pos += 3
+			continue
+		}
+
+		explicit := p.atMarker("---")
+		if explicit {
+			p.pos += 3
+			p.skipInline()
+			if p.atLineEnd() {
+				if err := p.skipToContent(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		var doc interface{}
+		if !p.eof() && !p.atMarker("---") && !p.atMarker("...") {
+			var err error
+			if doc, err = p.node(-1, true); err != nil {
+				return nil, err
+			}
+		}
+		docs = append(docs, doc)
+
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if !p.eof() && !p.atMarker("---") && !p.atMarker("...") {
+			return nil, p.errorf("unexpected %s, check the indentation", p.describe())
+		}
+	}
+}
+
+// node reads the node starting at the current position. parent is the
+// indentation of the enclosing collection, which nested lines must exceed.
+// allowMap is false after "key: ", where a second key cannot follow.
+func (p *yamlParser) node(parent int, allowMap bool) (interface{}, error) {
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+	if (anchor != "" || tag != "") && p.atLineEnd() {
+		// The properties stand alone; the node starts on the next line
+		save := p.pos
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() || p.atMarker("---") || p.atMarker("...") || p.col() <= parent && !(p.col() == parent && p.atSeqEntry()) {
+			p.pos = save
+			p.anchor(anchor, nil)
+			return nil, nil
+		}
+		allowMap = true
+	}
+
+	col := p.col()
+	var v interface{}
+	switch c := p.peek(); {
+	case c == '*':
+		if v, err = p.alias(false); err != nil {
+			return nil, err
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	case p.atSeqEntry():
+		if v, err = p.sequence(col); err != nil {
+			return nil, err
+		}
+	case c == '|' || c == '>':
+		if v, err = p.blockScalar(parent); err != nil {
+			return nil, err
+		}
+		if tag != "" {
+			if v, err = p.resolve(tag, v.(string), false); err != nil {
+				return nil, err
+			}
+		}
+	case c == '[' || c == '{':
+		if v, err = p.flow(); err != nil {
+			return nil, err
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	case c == '?' && p.atSeparator(p.pos+1):
+		return nil, p.errorf("complex mapping keys (\"? \") are not supported")
+	default:
+		text, plain, err := p.scalar(false)
+		if err != nil {
+			return nil, err
+		}
+		p.skipInline()
+		if p.peek() == ':' && p.atSeparator(p.pos+1) {
+			if !allowMap {
+				return nil, p.errorf("mapping values are not allowed here, quote the value if it contains \": \"")
+			}
+			if v, err = p.mapping(col, text, plain); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if plain {
+			if text, err = p.plainMore(text, parent); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+		if v, err = p.resolve(tag, text, plain); err != nil {
+			return nil, err
+		}
+	}
+
+	p.anchor(anchor, v)
+	return v, nil
+}
+
+// properties reads the anchor and tag that may precede a node
+func (p *yamlParser) properties() (anchor, tag string, err error) {
+	for {
+		switch p.peek() {
+		case '&':
+			p.pos++
+			if anchor = p.word(); anchor == "" {
+				return "", "", p.errorf("missing anchor name after &")
+			}
+		case '!':
+			tag = p.word()
+		default:
+			return anchor, tag, nil
+		}
+		p.skipInline()
+	}
+}
+
+// anchor records v under name, if there is one, for later aliases
+func (p *yamlParser) anchor(name string, v interface{}) {
+	if name != "" {
+		p.anchors[name] = v
+	}
+}
+
+// alias reads *name and returns the node anchored under that name
+func (p *yamlParser) alias(flow bool) (interface{}, error) {
+	p.pos++
+	name := p.word()
+	if flow {
+		// In a flow collection the name ends at the next indicator too
+		trimmed := strings.TrimRight(name, ",]}")
+		p.pos -= len(name) - len(trimmed)
+		name = trimmed
+	}
+	v, ok := p.anchors[name]
+	if !ok {
+		return nil, p.errorf("unknown alias *%s", name)
+	}
+	return v, nil
+}
+
+// mapping reads a block mapping at col whose first key was already read
+func (p *yamlParser) mapping(col int, key string, plain bool) (interface{}, error) {
+	m := &orderedMap{}
+	var merges []*orderedMap
+	for {
+		p.pos++ // the ':' after the key
+		p.skipInline()
+
+		var value interface{}
+		var err error
+		if p.atLineEnd() {
+			value, err = p.nextLineValue(col, true)
+		} else {
+			value, err = p.node(col, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "<<" && plain {
+			// A merge key copies the entries of other mappings
+			more, err := p.mergeSources(value)
+			if err != nil {
+				return nil, err
+			}
+			merges = append(merges, more...)
+		} else {
+			m.add(key, value)
+		}
+
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() || p.atMarker("---") || p.atMarker("...") || p.col() < col {
+			break
+		}
+		if p.col() > col {
+			return nil, p.errorf("unexpected %s, check the indentation", p.describe())
+		}
+		if key, plain, err = p.mappingKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Explicit keys take precedence over merged ones, and earlier merges
+	// over later ones
+	for _, src := range merges {
+		for i, k := range src.keys {
+			if !m.has(k) {
+				m.add(k, src.values[i])
+			}
+		}
+	}
+	return m, nil
+}
+
+// mergeSources returns the mappings a "<<" merge key refers to
+func (p *yamlParser) mergeSources(v interface{}) ([]*orderedMap, error) {
+	switch val := v.(type) {
+	case *orderedMap:
+		return []*orderedMap{val}, nil
+	case []interface{}:
+		maps := make([]*orderedMap, 0, len(val))
+		for _, item := range val {
+			m, ok := item.(*orderedMap)
+			if !ok {
+				return nil, p.errorf("a merge key (<<) needs a mapping or a list of mappings")
+			}
+			maps = append(maps, m)
+		}
+		return maps, nil
+	}
+	return nil, p.errorf("a merge key (<<) needs a mapping or a list of mappings")
+}
+
+// mappingKey reads a key after the first one of a block mapping, up to and
+// not including its ':'
+func (p *yamlParser) mappingKey() (string, bool, error) {
+	switch c := p.peek(); {
+	case c == '?' && p.atSeparator(p.pos+1):
+		return "", false, p.errorf("complex mapping keys (\"? \") are not supported")
+	case p.atSeqEntry():
+		return "", false, p.errorf("expected a mapping key, found a sequence entry")
+	case c == '[' || c == '{' || c == '*' || c == '&' || c == '!' || c == '|' || c == '>':
+		return "", false, p.errorf("unsupported mapping key starting with %q", c)
+	}
+
+	key, plain, err := p.scalar(false)
+	if err != nil {
+		return "", false, err
+	}
+	p.skipInline()
+	if p.peek() != ':' || !p.atSeparator(p.pos+1) {
+		return "", false, p.errorf("expected \": \" after the key %q", key)
+	}
+	return key, plain, nil
+}
+
+// sequence reads a block sequence whose entries start with "-" at col
+func (p *yamlParser) sequence(col int) (interface{}, error) {
+	items := []interface{}{}
+	for {
+		p.pos++ // the '-'
+		p.skipInline()
+
+		var item interface{}
+		var err error
+		if p.atLineEnd() {
+			item, err = p.nextLineValue(col, false)
+		} else {
+			item, err = p.node(col, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if err := p.skipToContent(); err != nil {
+			return nil, err
+		}
+		if p.eof() || p.atMarker("---") || p.atMarker("...") || p.col() < col {
+			break
+		}
+		if p.col() > col {
+			return nil, p.errorf("unexpected %s, check the indentation", p.describe())
+		}
+		if !p.atSeqEntry() {
+			// A sequence written at the indentation of its mapping key ends
+			// where the next key starts
+			break
+		}
+	}
+	return items, nil
+}
+
+// nextLineValue reads a value that starts on a later line than its key or
+// "-". It is null when no line is indented further than parent; with
+// compact, a sequence at the indentation of its key also counts.
+func (p *yamlParser) nextLineValue(parent int, compact bool) (interface{}, error) {
+	save := p.pos
+	if err := p.skipToContent(); err != nil {
+		return nil, err
+	}
+	if p.eof() || p.atMarker("---") || p.atMarker("...") {
+		p.pos = save
+		return nil, nil
+	}
+	if p.col() > parent || compact && p.col() == parent && p.atSeqEntry() {
+		return p.node(parent, true)
+	}
+	p.pos = save
+	return nil, nil
+}
+
+// scalar reads a quoted or plain scalar. Only the first line of a plain
+// scalar is read, since it may turn out to be a mapping key.
+func (p *yamlParser) scalar(flow bool) (string, bool, error) {
+	switch c := p.peek(); c {
+	case '"':
+		s, err := p.doubleQuoted()
+		return s, false, err
+	case '\'':
+		s, err := p.singleQuoted()
+		return s, false, err
+	case '@', '`':
+		return "", false, p.errorf("%q is reserved and cannot start a plain scalar, quote the value", c)
+	case ',', ']', '}', '#', '%':
+		if !flow {
+			return "", false, p.errorf("unexpected %q, quote the value", c)
+		}
+	}
+	text := p.plainLine(flow)
+	if text == "" {
+		return "", false, p.errorf("unexpected %s", p.describe())
+	}
+	return text, true, nil
+}
+
+// plainLine reads a plain scalar up to the end of the line, a ": ", a
+// comment or, in flow collections, a flow indicator
+func (p *yamlParser) plainLine(flow bool) string {
+	start, end := p.pos, p.pos
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c == '\n' {
+			break
+		}
+		if c == ':' && (p.atSeparator(p.pos+1) || flow && p.pos+1 < len(p.src) && strings.IndexByte(",[]{}", p.src[p.pos+1]) >= 0) {
+			break
+		}
+		if c == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			break
+		}
+		if flow && strings.IndexByte(",[]{}", c) >= 0 {
+			break
+		}
+		p.pos++
+		if c != ' ' && c != '\t' {
+			end = p.pos
+		}
+	}
+	p.pos = end
+	return p.src[start:end]
+}
+
+// plainMore reads the lines that continue a plain scalar, those indented
+// further than parent, folding single line breaks into spaces
+func (p *yamlParser) plainMore(text string, parent int) (string, error) {
+	for {
+		save := p.pos
+		p.skipInline()
+		if p.peek() != '\n' {
+			p.pos = save
+			return text, nil
+		}
+
+		breaks := 0
+		for p.peek() == '\n' {
+			p.pos++
+			breaks++
+			p.skipInline()
+		}
+		if p.eof() || p.peek() == '#' || p.col() <= parent || p.atMarker("---") || p.atMarker("...") {
+			p.pos = save
+			return text, nil
+		}
+
+		line := p.plainLine(false)
+		if p.peek() == ':' {
+			return "", p.errorf("mapping values are not allowed here, check the indentation")
+		}
+		if breaks == 1 {
+			text += " " + line
+		} else {
+			text += strings.Repeat("\n", breaks-1) + line
+		}
+	}
+}
+
+// doubleQuoted reads a "..." scalar with its escapes
+func (p *yamlParser) doubleQuoted() (string, error) {
+	p.pos++
+	var buf []byte
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated double-quoted string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return string(buf), nil
+		case '\n':
+			buf = p.fold(buf)
+		case '\\':
+			p.pos++
+			if p.eof() {
+				return "", p.errorf("unterminated double-quoted string")
+			}
+			e := p.src[p.pos]
+			p.pos++
+			if e == '\n' {
+				// An escaped line break joins the lines without a space
+				p.skipInline()
+				continue
+			}
+			r, err := p.escape(e)
+			if err != nil {
+				return "", err
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+// escape decodes the escape sequence whose letter e was just read
+func (p *yamlParser) escape(e byte) (rune, error) {
+	simple := map[byte]rune{
+		'0': 0, 'a': '\a', 'b': '\b', 't': '\t', '\t': '\t', 'n': '\n', 'v': '\v', 'f': '\f',
+		'r': '\r', 'e': 0x1b, ' ': ' ', '"': '"', '/': '/', '\\': '\\',
+		'N': 0x85, '_': 0xa0, 'L': 0x2028, 'P': 0x2029,
+	}
+	if r, ok := simple[e]; ok {
+		return r, nil
+	}
+
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+	if digits == 0 {
+		return 0, p.errorf("unknown escape \\%c", e)
+	}
+	if p.pos+digits > len(p.src) {
+		return 0, p.errorf("truncated escape \\%c", e)
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+digits], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape \\%c%s", e, p.src[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	return rune(n), nil
+}
+
+// singleQuoted reads a single-quoted scalar, where a doubled quote stands
+// for one quote
+func (p *yamlParser) singleQuoted() (string, error) {
+	p.pos++
+	var buf []byte
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated single-quoted string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '\'' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\'':
+			buf = append(buf, '\'')
+			p.pos += 2
+		case c == '\'':
+			p.pos++
+			return string(buf), nil
+		case c == '\n':
+			buf = p.fold(buf)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+// fold handles a line break inside a quoted scalar: the spaces around it are
+// dropped, a single break becomes a space and each empty line a line feed
+func (p *yamlParser) fold(buf []byte) []byte {
+	buf = bytes.TrimRight(buf, " \t")
+	breaks := 0
+	for p.peek() == '\n' {
+		p.pos++
+		breaks++
+		p.skipInline()
+	}
+	if breaks == 1 {
+		return append(buf, ' ')
+	}
+	return append(buf, strings.Repeat("\n", breaks-1)...)
+}
+
+// blockScalar reads a literal (|) or folded (>) block scalar, whose lines
+// are indented further than parent
+func (p *yamlParser) blockScalar(parent int) (string, error) {
+	folded := p.peek() == '>'
+	p.pos++
+
+	chomp, indent := byte(0), 0
+	for !p.eof() {
+		c := p.src[p.pos]
+		if (c == '+' || c == '-') && chomp == 0 {
+			chomp = c
+		} else if c >= '1' && c <= '9' && indent == 0 {
+			indent = max(parent, 0) + int(c-'0')
+		} else {
+			break
+		}
+		p.pos++
+	}
+	p.skipInline()
+	if !p.atLineEnd() {
+		return "", p.errorf("unexpected %s after the block scalar indicator", p.describe())
+	}
+	p.skipLine()
+
+	// Without an indentation indicator, the first non-empty line sets it
+	var lines []string
+	for !p.eof() {
+		start := p.pos
+		spaces := 0
+		for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+			p.pos++
+			spaces++
+		}
+		if p.eof() || p.peek() == '\n' {
+			lines = append(lines, "")
+			p.skipLine()
+			continue
+		}
+		if indent == 0 {
+			if spaces <= parent {
+				p.pos = start
+				break
+			}
+			indent = spaces
+		}
+		if spaces < indent || spaces == 0 && (p.atMarker("---") || p.atMarker("...")) {
+			p.pos = start
+			break
+		}
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		lines = append(lines, p.src[start+indent:p.pos+end])
+		p.pos += end
+		p.skipLine()
+	}
+
+	// Trailing empty lines only matter for keep chomping (+)
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var sb strings.Builder
+	moreIndented := func(line string) bool {
+		return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+	}
+	lastText := ""
+	for i, line := range lines {
+		switch {
+		case !folded:
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+		case line == "":
+			sb.WriteByte('\n')
+			continue
+		case i == 0:
+		case lines[i-1] != "":
+			if moreIndented(line) || moreIndented(lines[i-1]) {
+				sb.WriteByte('\n')
+			} else {
+				sb.WriteByte(' ')
+			}
+		case moreIndented(line) || moreIndented(lastText):
+			// Line breaks next to more indented lines are not folded
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(line)
+		if line != "" {
+			lastText = line
+		}
+	}
+
+	text := sb.String()
+	switch {
+	case len(lines) == 0 && chomp != '+':
+		return "", nil
+	case chomp == '-':
+		return text, nil
+	case chomp == '+':
+		if len(lines) > 0 {
+			text += "\n"
+		}
+		return text + strings.Repeat("\n", trailing), nil
+	}
+	return text + "\n", nil
+}
+
+// flow reads a [...] sequence or {...} mapping, which may span lines
+func (p *yamlParser) flow() (interface{}, error) {
+	open := p.peek()
+	p.pos++
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+
+	items := []interface{}{}
+	m := &orderedMap{}
+	for {
+		if err := p.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == closing {
+			p.pos++
+			break
+		}
+
+		if open == '[' {
+			item, err := p.flowNode()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		} else {
+			if err := p.flowEntry(m); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.skipFlowSpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case closing:
+		default:
+			return nil, p.errorf("expected ',' or '%c', found %s", closing, p.describe())
+		}
+	}
+
+	if open == '{' {
+		return m, nil
+	}
+	return items, nil
+}
+
+// flowEntry reads a "key: value" pair of a flow mapping into m. The value
+// may be left out, making it null.
+func (p *yamlParser) flowEntry(m *orderedMap) error {
+	if _, _, err := p.properties(); err != nil {
+		return err
+	}
+	if c := p.peek(); c == '[' || c == '{' || c == '*' {
+		return p.errorf("unsupported mapping key starting with %q", c)
+	}
+	key, _, err := p.scalar(true)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := p.skipFlowSpace(); err != nil {
+		return err
+	}
+	if p.peek() == ':' {
+		p.pos++
+		if err := p.skipFlowSpace(); err != nil {
+			return err
+		}
+		if c := p.peek(); c != ',' && c != '}' {
+			if value, err = p.flowNode(); err != nil {
+				return err
+			}
+		}
+	}
+	m.add(key, value)
+	return nil
+}
+
+// flowNode reads a node inside a flow collection
+func (p *yamlParser) flowNode() (interface{}, error) {
+	anchor, tag, err := p.properties()
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	switch p.peek() {
+	case '*':
+		v, err = p.alias(true)
+	case '[', '{':
+		v, err = p.flow()
+	default:
+		var text string
+		var plain bool
+		if text, plain, err = p.scalar(true); err == nil {
+			v, err = p.resolve(tag, text, plain)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.anchor(anchor, v)
+	return v, nil
+}
+
+// skipFlowSpace skips whitespace, line breaks and comments inside a flow
+// collection
+func (p *yamlParser) skipFlowSpace() error {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n':
+			p.pos++
+		case '#':
+			p.skipLine()
+		default:
+			return nil
+		}
+	}
+	return p.errorf("unterminated flow collection")
+}
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlOctal = regexp.MustCompile(`^0o[0-7]+$`)
+	yamlHex   = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlInf   = regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+)
+
+// resolve gives a scalar its type. Plain scalars follow the YAML 1.2 core
+// schema, so yes and no stay strings; quoted ones are strings unless a tag
+// such as !!int says otherwise.
+func (p *yamlParser) resolve(tag, text string, plain bool) (interface{}, error) {
+	switch tag {
+	case "!!str", "!!binary", "!!timestamp":
+		return text, nil
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		v, err := p.resolvePlain(text)
+		if _, ok := v.(literal); !ok && err == nil {
+			return nil, p.errorf("%q is not a valid %s", text, strings.TrimPrefix(tag, "!!"))
+		}
+		return v, err
+	}
+	if !plain {
+		return text, nil
+	}
+	return p.resolvePlain(text)
+}
+
+// resolvePlain resolves an untagged plain scalar
+func (p *yamlParser) resolvePlain(text string) (interface{}, error) {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return literal("true"), nil
+	case "false", "False", "FALSE":
+		return literal("false"), nil
+	}
+
+	n := new(big.Int)
+	switch {
+	case yamlInt.MatchString(text):
+		n.SetString(strings.TrimPrefix(text, "+"), 10)
+		return literal(n.String()), nil
+	case yamlOctal.MatchString(text):
+		n.SetString(text[2:], 8)
+		return literal(n.String()), nil
+	case yamlHex.MatchString(text):
+		n.SetString(text[2:], 16)
+		return literal(n.String()), nil
+	case yamlFloat.MatchString(text):
+		return literal(jsonFloat(text)), nil
+	case yamlInf.MatchString(text):
+		return nil, p.errorf("%s has no JSON equivalent", text)
+	}
+	return text, nil
+}
+
+// jsonFloat rewrites a YAML float such as +.5 or 1. as a JSON number
+func jsonFloat(text string) string {
+	sign := ""
+	switch text[0] {
+	case '-':
+		sign, text = "-", text[1:]
+	case '+':
+		text = text[1:]
+	}
+	mantissa, exp := text, ""
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		mantissa, exp = text[:i], text[i:]
+	}
+	whole, frac, dot := strings.Cut(mantissa, ".")
+	if whole = strings.TrimLeft(whole, "0"); whole == "" {
+		whole = "0"
+	}
+	if dot && frac == "" {
+		frac = "0"
+	}
+	if dot {
+		return sign + whole + "." + frac + exp
+	}
+	return sign + whole + exp
+}
+
+// eof reports whether the whole input was read
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current byte, or 0 at the end of the input
+func (p *yamlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// col returns the column of the current position, counting from 0
+func (p *yamlParser) col() int {
+	return p.pos - (strings.LastIndexByte(p.src[:p.pos], '\n') + 1)
+}
+
+// atSeparator reports whether the byte at i ends a token: a space, a tab,
+// a line break or the end of the input
+func (p *yamlParser) atSeparator(i int) bool {
+	return i >= len(p.src) || p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\n'
+}
+
+// atSeqEntry reports whether a block sequence entry starts here
+func (p *yamlParser) atSeqEntry() bool {
+	return p.peek() == '-' && p.atSeparator(p.pos+1)
+}
+
+// atMarker reports whether the "---" or "..." document marker starts here
+func (p *yamlParser) atMarker(marker string) bool {
+	return p.col() == 0 && strings.HasPrefix(p.src[p.pos:], marker) && p.atSeparator(p.pos+3)
+}
+
+// atLineEnd reports whether only a comment, if anything, is left on the
+// line. Call it after skipInline.
+func (p *yamlParser) atLineEnd() bool {
+	if p.eof() || p.peek() == '\n' {
+		return true
+	}
+	return p.peek() == '#' && (p.pos == 0 || p.atSeparator(p.pos-1))
+}
+
+// endOfLine checks that nothing but a comment follows a value
+func (p *yamlParser) endOfLine() error {
+	p.skipInline()
+	if !p.atLineEnd() {
+		return p.errorf("unexpected %s after the value", p.describe())
+	}
+	return nil
+}
+
+// skipInline skips spaces and tabs on the current line
+func (p *yamlParser) skipInline() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine moves past the next line break
+func (p *yamlParser) skipLine() {
+	if i := strings.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+		p.pos += i + 1
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+// skipToContent skips blank lines and comments up to the next content,
+// rejecting tabs in the indentation of the line it stops on
+func (p *yamlParser) skipToContent() error {
+	for {
+		p.skipInline()
+		if !p.atLineEnd() {
+			break
+		}
+		if p.eof() {
+			return nil
+		}
+		p.skipLine()
+	}
+	start := strings.LastIndexByte(p.src[:p.pos], '\n') + 1
+	if strings.IndexByte(p.src[start:p.pos], '\t') >= 0 {
+		return p.errorf("tabs cannot be used for indentation")
+	}
+	return nil
+}
+
+// word reads an anchor, alias or tag name, up to the next whitespace
+func (p *yamlParser) word() string {
+	start := p.pos
+	for !p.atSeparator(p.pos) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// describe names the current token for error messages
+func (p *yamlParser) describe() string {
+	if p.eof() {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return strconv.QuoteRune(r)
+}
+
+// errorf returns an error located at the current line
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
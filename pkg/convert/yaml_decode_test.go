@@ -0,0 +1,174 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestYAMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{
+			name: "Mapping keeps key order",
+			yaml: "name: fj\nversion: 2\nprivate: false\nlicense: ~\n",
+			want: `{"name":"fj","version":2,"private":false,"license":null}`,
+		},
+		{
+			name: "Nested mappings and sequences",
+			yaml: "owner:\n  login: nico\n  teams:\n    - core\n    - docs\ntags: [cli, json]\n",
+			want: `{"owner":{"login":"nico","teams":["core","docs"]},"tags":["cli","json"]}`,
+		},
+		{
+			name: "Sequence at the indentation of its key",
+			yaml: "steps:\n- run: make\n  name: build\n- run: test\nafter: done\n",
+			want: `{"steps":[{"run":"make","name":"build"},{"run":"test"}],"after":"done"}`,
+		},
+		{
+			name: "Nested sequences",
+			yaml: "- - 1\n  - 2\n- []\n-\n  - 3\n",
+			want: `[[1,2],[],[3]]`,
+		},
+		{
+			name: "Comments",
+			yaml: "# header\na: 1 # one\n\n  # indented\nb: \"x # y\"\n",
+			want: `{"a":1,"b":"x # y"}`,
+		},
+		{
+			name: "Core schema scalars",
+			yaml: "a: yes\nb: True\nc: 0x1F\nd: 0o17\ne: +.5\nf: 1.\ng: 007\nh: 1e3\ni: 2024-01-01\nj: ''\nk:\n",
+			want: `{"a":"yes","b":true,"c":31,"d":15,"e":0.5,"f":1.0,"g":7,"h":1e3,"i":"2024-01-01","j":"","k":null}`,
+		},
+		{
+			name: "Big integers stay exact",
+			yaml: "id: 12345678901234567890123\n",
+			want: `{"id":12345678901234567890123}`,
+		},
+		{
+			name: "Quoted scalars",
+			yaml: `a: "tab\there \u00e9 \"q\""` + "\nb: 'it''s'\nc: \"true\"\n\"d e\": 1\n",
+			want: `{"a":"tab\there é \"q\"","b":"it's","c":"true","d e":1}`,
+		},
+		{
+			name: "Multi-line scalars fold",
+			yaml: "a: one\n  two\n\n  three\nb: \"x\n  y\"\n",
+			want: `{"a":"one two\nthree","b":"x y"}`,
+		},
+		{
+			name: "Literal block scalar",
+			yaml: "script: |\n  make\n    indented\n\n  test\nnext: 1\n",
+			want: `{"script":"make\n  indented\n\ntest\n","next":1}`,
+		},
+		{
+			name: "Folded block scalar with strip chomping",
+			yaml: "text: >-\n  one\n  two\n\n  three\n\n",
+			want: `{"text":"one two\nthree"}`,
+		},
+		{
+			name: "Keep chomping",
+			yaml: "text: |+\n  a\n\n",
+			want: `{"text":"a\n\n"}`,
+		},
+		{
+			name: "Flow collections over several lines",
+			yaml: "a: {x: 1, y: [2, 3],\n  z: \"s\"}\nb: {k}\n",
+			want: `{"a":{"x":1,"y":[2,3],"z":"s"},"b":{"k":null}}`,
+		},
+		{
+			name: "Anchors, aliases and merge keys",
+			yaml: "base: &base\n  image: alpine\n  tty: true\njob:\n  <<: *base\n  tty: false\nlist: [*base]\n",
+			want: `{"base":{"image":"alpine","tty":true},"job":{"tty":false,"image":"alpine"},"list":[{"image":"alpine","tty":true}]}`,
+		},
+		{
+			name: "Tags",
+			yaml: "a: !!str 123\nb: !!int \"42\"\nc: !Ref name\n",
+			want: `{"a":"123","b":42,"c":"name"}`,
+		},
+		{
+			name: "Plain scalars with colons and hashes",
+			yaml: "url: http://example.com:8080/a#b\ntime: 12:30\n",
+			want: `{"url":"http://example.com:8080/a#b","time":"12:30"}`,
+		},
+		{
+			name: "Scalar document",
+			yaml: "--- plain text\n",
+			want: `"plain text"`,
+		},
+		{
+			name: "Several documents",
+			yaml: "%YAML 1.2\n---\na: 1\n---\n- b\n...\n---\n",
+			want: `[{"a":1},["b"],null]`,
+		},
+		{
+			name: "Empty input",
+			yaml: "# nothing\n",
+			want: `null`,
+		},
+		{
+			name: "Windows line endings",
+			yaml: "a: 1\r\nb:\r\n  - 2\r\n",
+			want: `{"a":1,"b":[2]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON([]byte(tt.yaml), YAML, Options{})
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		yaml string
+		want string
+	}{
+		{"a: b: c\n", "line 1: mapping values are not allowed here"},
+		{"a: 1\n  b: 2\n", "line 2: mapping values are not allowed here"},
+		{"a:\n  b: 1\n c: 2\n", "line 3: unexpected"},
+		{"a: \"open\n", "unterminated double-quoted string"},
+		{"a: [1, 2\n", "unterminated flow collection"},
+		{"a: *missing\n", "line 1: unknown alias *missing"},
+		{"a:\n\tb: 1\n", "line 2: tabs cannot be used for indentation"},
+		{"a: .inf\n", "line 1: .inf has no JSON equivalent"},
+		{"a: !!int abc\n", `"abc" is not a valid int`},
+		{"? complex\n: value\n", "complex mapping keys"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON([]byte(tt.yaml), YAML, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ToJSON(%q) error = %v, want it to contain %q", tt.yaml, err, tt.want)
+		}
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	doc := `{"name":"fj","tags":["yes","1.0","- x",""],"nested":{"empty":{},"list":[],"text":"a\nb","n":null},"big":1e+21}`
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	yaml, err := Encode(v, YAML, opts, Options{})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := ToJSON(yaml, YAML, Options{})
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v for\n%s", err, yaml)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip = %s, want %s", got, doc)
+	}
+}
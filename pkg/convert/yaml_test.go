@@ -0,0 +1,104 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestEncodeYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "Nested objects keep key order",
+			json: `{"name": "fj", "owner": {"login": "nico", "id": 7}, "private": false, "license": null}`,
+			want: "name: fj\nowner:\n  login: nico\n  id: 7\nprivate: false\nlicense: null\n",
+		},
+		{
+			name: "Sequences",
+			json: `{"tags": ["cli", "json"], "matrix": [[1, 2], []], "empty": {}}`,
+			want: "tags:\n  - cli\n  - json\nmatrix:\n  - - 1\n    - 2\n  - []\nempty: {}\n",
+		},
+		{
+			name: "Objects in a sequence",
+			json: `[{"id": 1, "labels": {"a": "b"}}, {"id": 2}]`,
+			want: "- id: 1\n  labels:\n    a: b\n- id: 2\n",
+		},
+		{
+			name: "Scalar document",
+			json: `"plain text"`,
+			want: "plain text\n",
+		},
+		{
+			name: "Large and exact numbers",
+			json: `{"big": 1e21, "exact": 12345678901234567890123, "pi": 3.14}`,
+			want: "big: 1e21\nexact: 12345678901234567890123\npi: 3.14\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+			v, err := formatter.Decode([]byte(tt.json), opts)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got, err := Encode(v, YAML, opts, Options{})
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hello world", "hello world"},
+		{"café", "café"},
+		{"a:b", "a:b"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"123", `"123"`},
+		{"-5", `"-5"`},
+		{"1.2.3", `"1.2.3"`},
+		{"2024-01-01", `"2024-01-01"`},
+		{".inf", `".inf"`},
+		{"key: value", `"key: value"`},
+		{"a #comment", `"a #comment"`},
+		{"ends:", `"ends:"`},
+		{" padded", `" padded"`},
+		{"- item", `"- item"`},
+		{"*alias", `"*alias"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{`say "hi"`, `say "hi"`},
+		{`"quoted"`, `"\"quoted\""`},
+		{"tab\there", `"tab\there"`},
+		{"bell\a", `"bell\x07"`},
+	}
+
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("yaml"); err != nil || f != YAML {
+		t.Errorf("ParseFormat(yaml) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("ini"); err == nil {
+		t.Error("ParseFormat(ini) expected an error")
+	}
+}
@@ -0,0 +1,91 @@
+package diag
+
+import (
+	"fmt"
+)
+
+// Severity tells whether a diagnostic fails a check
+type Severity string
+
+const (
+	// SeverityError marks a problem that makes the check fail
+	SeverityError Severity = "error"
+	// SeverityWarning marks a problem worth reporting that does not fail the check
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is one finding about a document, located both by byte offset
+// and by line and column for editors
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Offset   int      `json:"offset"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+	// Path names the value a lint finding is about, in the path syntax of
+	// the lint and redaction settings
+	Path string `json:"path,omitempty"`
+	// InstancePath and SchemaPath locate a schema violation as JSON Pointers
+	// to the failing value and keyword
+	InstancePath string `json:"instancePath,omitempty"`
+	SchemaPath   string `json:"schemaPath,omitempty"`
+}
+
+// New returns a diagnostic for the byte at offset in data
+func New(file string, data []byte, offset int, severity Severity, code, message string) Diagnostic {
+	line, column := Position(data, offset)
+	return Diagnostic{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Offset:   offset,
+		Code:     code,
+		Message:  message,
+		Severity: severity,
+	}
+}
+
+// String formats the diagnostic the way compilers do: file:line:column: severity: message (code).
+// Schema violations name the failing value and keyword instead of the code.
+func (d Diagnostic) String() string {
+	if d.SchemaPath != "" {
+		instance := d.InstancePath
+		if instance == "" {
+			instance = "(root)"
+		}
+		return fmt.Sprintf("%s:%d:%d: %s: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, instance, d.Message, d.SchemaPath)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// Position converts a byte offset into a 1-based line and column. Columns
+// count bytes, like the Go toolchain does. Offsets past the end of data
+// point just after the last byte.
+func Position(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+
+	line, column = 1, 1
+	for _, b := range data[:max(offset, 0)] {
+		if b == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return line, column
+}
+
+// HasErrors reports whether any diagnostic has error severity
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package diag
+
+import (
+	"testing"
+)
+
+func TestPosition(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  x\n}")
+
+	tests := []struct {
+		offset int
+		line   int
+		column int
+	}{
+		{offset: 0, line: 1, column: 1},
+		{offset: 1, line: 1, column: 2},
+		{offset: 2, line: 2, column: 1},
+		{offset: 14, line: 3, column: 3},
+		{offset: 100, line: 4, column: 2},
+		{offset: -1, line: 1, column: 1},
+	}
+
+	for _, tt := range tests {
+		line, column := Position(data, tt.offset)
+		if line != tt.line || column != tt.column {
+			t.Errorf("Position(%d) = %d:%d, want %d:%d", tt.offset, line, column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestDiagnostic(t *testing.T) {
+	d := New("a.json", []byte("{\n\"k\" 1}"), 6, SeverityError, "syntax", "expected ':'")
+	if got, want := d.String(), "a.json:2:5: error: expected ':' (syntax)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if HasErrors([]Diagnostic{{Severity: SeverityWarning}}) {
+		t.Error("HasErrors() = true for warnings only")
+	}
+	if !HasErrors([]Diagnostic{{Severity: SeverityWarning}, d}) {
+		t.Error("HasErrors() = false with an error")
+	}
+}
@@ -0,0 +1,278 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// DecodePatch reads the operations of an RFC 6902 JSON Patch from a decoded
+// document, checking that each has the members its op requires
+func DecodePatch(v interface{}) ([]Operation, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a JSON Patch is an array of operations")
+	}
+
+	ops := make([]Operation, 0, len(list))
+	for i, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %d is not an object", i)
+		}
+
+		var op Operation
+		var hasPath, hasFrom bool
+		op.Op, _ = obj["op"].(string)
+		op.Path, hasPath = obj["path"].(string)
+		op.From, hasFrom = obj["from"].(string)
+		value, hasValue := obj["value"]
+		op.Value = value
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if !hasValue {
+				return nil, fmt.Errorf("operation %d (%s) has no value", i, op.Op)
+			}
+		case "move", "copy":
+			if !hasFrom {
+				return nil, fmt.Errorf("operation %d (%s) has no from", i, op.Op)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d has unknown op %q", i, obj["op"])
+		}
+		if !hasPath {
+			return nil, fmt.Errorf("operation %d (%s) has no path", i, op.Op)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// ApplyPatch applies the operations of a JSON Patch to doc in order and
+// returns the result. The patch is atomic: when an operation fails, the error
+// names it and doc is left as it was. Objects keep the key order order
+// recorded for them, replaced keys stay in place and added keys come last.
+func ApplyPatch(doc interface{}, ops []Operation, order *formatter.KeyOrder) (interface{}, error) {
+	doc = cloneValue(doc, order)
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOperation(doc, op, order); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyOperation applies one operation to doc, which it may modify
+func applyOperation(doc interface{}, op Operation, order *formatter.KeyOrder) (interface{}, error) {
+	path, err := jsonpath.ParsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return addValue(doc, path, cloneValue(op.Value, order), order)
+	case "remove":
+		doc, _, err = removeValue(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := getValue(doc, path); err != nil {
+			return nil, err
+		}
+		return setValue(doc, path, cloneValue(op.Value, order))
+	case "test":
+		v, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, op.Value) {
+			return nil, fmt.Errorf("test failed: the value is %s", compactJSON(v, false))
+		}
+		return doc, nil
+	}
+
+	// move and copy take the value at from
+	from, err := jsonpath.ParsePointer(op.From)
+	if err != nil {
+		return nil, err
+	}
+	if op.Op == "copy" {
+		v, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, cloneValue(v, order), order)
+	}
+
+	if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+		return nil, fmt.Errorf("cannot move a value into itself")
+	}
+	doc, v, err := removeValue(doc, from)
+	if err != nil {
+		return nil, err
+	}
+	return addValue(doc, path, v, order)
+}
+
+// getValue returns the value at path
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for i, tok := range path {
+		switch val := doc.(type) {
+		case map[string]interface{}:
+			child, ok := val[tok]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointerTo(path[:i+1]))
+			}
+			doc = child
+		case []interface{}:
+			idx, err := elementIndex(tok, len(val)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", pointerTo(path[:i+1]), err)
+			}
+			doc = val[idx]
+		default:
+			return nil, fmt.Errorf("%s not found: %s is not a container", pointerTo(path[:i+1]), pointerTo(path[:i]))
+		}
+	}
+	return doc, nil
+}
+
+// addValue adds v at path, inserting into arrays, and returns the updated
+// document. A key new to its object is recorded after the existing ones.
+func addValue(doc interface{}, path []string, v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch val := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := val[last]; !ok {
+			order.Record(val, append(order.Keys(val), last))
+		}
+		val[last] = v
+		return doc, nil
+	case []interface{}:
+		idx := len(val)
+		if last != "-" {
+			if idx, err = elementIndex(last, len(val)); err != nil {
+				return nil, fmt.Errorf("%s: %v", pointerTo(path), err)
+			}
+		}
+		grown := make([]interface{}, 0, len(val)+1)
+		grown = append(append(append(grown, val[:idx]...), v), val[idx:]...)
+		return setValue(doc, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("%s is not a container", pointerTo(path[:len(path)-1]))
+}
+
+// removeValue removes the value at path and returns the updated document
+// along with the removed value
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	removed, err := getValue(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parent, _ := getValue(doc, path[:len(path)-1])
+	last := path[len(path)-1]
+	switch val := parent.(type) {
+	case map[string]interface{}:
+		delete(val, last)
+		return doc, removed, nil
+	case []interface{}:
+		idx, _ := elementIndex(last, len(val)-1)
+		shrunk := make([]interface{}, 0, len(val)-1)
+		shrunk = append(append(shrunk, val[:idx]...), val[idx+1:]...)
+		doc, err = setValue(doc, path[:len(path)-1], shrunk)
+		return doc, removed, err
+	}
+	return nil, nil, fmt.Errorf("%s is not a container", pointerTo(path[:len(path)-1]))
+}
+
+// setValue puts v at path, which already exists, and returns the updated
+// document. Arrays change length, so the new one replaces the old in its parent.
+func setValue(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch val := parent.(type) {
+	case map[string]interface{}:
+		val[last] = v
+	case []interface{}:
+		idx, err := elementIndex(last, len(val)-1)
+		if err != nil {
+			return nil, err
+		}
+		val[idx] = v
+	}
+	return doc, nil
+}
+
+// elementIndex parses an array index token, which may be at most max
+func elementIndex(tok string, max int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || tok[0] == '+' || tok[0] == '-' {
+		return 0, fmt.Errorf("%q is not an array index", tok)
+	}
+	idx, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an array index", tok)
+	}
+	if idx > max {
+		return 0, fmt.Errorf("index %d is out of range", idx)
+	}
+	return idx, nil
+}
+
+// pointerTo formats reference tokens back into a JSON Pointer for messages
+func pointerTo(tokens []string) string {
+	if len(tokens) == 0 {
+		return "the document"
+	}
+	path := make([]jsonpath.Segment, len(tokens))
+	for i, tok := range tokens {
+		path[i] = jsonpath.Key(tok)
+	}
+	return jsonpath.Pointer(path)
+}
+
+// cloneValue copies the objects and arrays of a document, so that applying a
+// patch never changes its input. Copied objects keep their recorded key order.
+func cloneValue(v interface{}, order *formatter.KeyOrder) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		keys := order.Keys(val)
+		for _, k := range keys {
+			out[k] = cloneValue(val[k], order)
+		}
+		order.Record(out, keys)
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = cloneValue(child, order)
+		}
+		return out
+	}
+	return v
+}
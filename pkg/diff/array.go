@@ -0,0 +1,180 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// maxLCSCells bounds the size of the LCS table; larger arrays fall back to
+// comparing the differing middle section position by position
+const maxLCSCells = 1 << 22
+
+// gap is a run of elements lying between two elements both arrays share
+type gap struct {
+	oldStart int
+	newStart int
+	// removed and added hold the old and new indexes that are not paired,
+	// including elements that moved elsewhere
+	removed []int
+	added   []int
+	// pairs holds old/new indexes compared element by element
+	pairs [][2]int
+}
+
+// alignment describes how the elements of two arrays correspond
+type alignment struct {
+	gaps []gap
+	// moves maps the new index of a moved element to its old index
+	moves map[int]int
+	// movedOut holds the old indexes of moved elements
+	movedOut map[int]bool
+}
+
+// align matches equal elements of a and b along their longest common
+// subsequence. Unmatched elements that appear on both sides are moves; the
+// rest of each gap is paired up position by position so edits inside an
+// element are reported as such rather than as a removal plus an addition.
+func align(a, b []interface{}) alignment {
+	ka, kb := elementKeys(a), elementKeys(b)
+
+	// Shared prefixes and suffixes need no table
+	prefix := 0
+	for prefix < len(ka) && prefix < len(kb) && ka[prefix] == kb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ka)-prefix && suffix < len(kb)-prefix && ka[len(ka)-1-suffix] == kb[len(kb)-1-suffix] {
+		suffix++
+	}
+
+	matches := lcs(ka[prefix:len(ka)-suffix], kb[prefix:len(kb)-suffix])
+	for i := range matches {
+		matches[i][0] += prefix
+		matches[i][1] += prefix
+	}
+
+	// Turn the stretches between matches into gaps, with sentinels on both ends
+	al := alignment{moves: make(map[int]int), movedOut: make(map[int]bool)}
+	bounds := append([][2]int{{prefix - 1, prefix - 1}}, matches...)
+	bounds = append(bounds, [2]int{len(a) - suffix, len(b) - suffix})
+	for i := 1; i < len(bounds); i++ {
+		g := gap{oldStart: bounds[i-1][0] + 1, newStart: bounds[i-1][1] + 1}
+		for x := g.oldStart; x < bounds[i][0]; x++ {
+			g.removed = append(g.removed, x)
+		}
+		for y := g.newStart; y < bounds[i][1]; y++ {
+			g.added = append(g.added, y)
+		}
+		if len(g.removed) > 0 || len(g.added) > 0 {
+			al.gaps = append(al.gaps, g)
+		}
+	}
+
+	// An element removed in one gap and added in another has moved
+	unmatched := make(map[string][]int)
+	for _, g := range al.gaps {
+		for _, x := range g.removed {
+			unmatched[ka[x]] = append(unmatched[ka[x]], x)
+		}
+	}
+	for _, g := range al.gaps {
+		for _, y := range g.added {
+			if olds := unmatched[kb[y]]; len(olds) > 0 {
+				al.moves[y] = olds[0]
+				al.movedOut[olds[0]] = true
+				unmatched[kb[y]] = olds[1:]
+			}
+		}
+	}
+
+	for i := range al.gaps {
+		al.gaps[i].pair(al)
+	}
+
+	return al
+}
+
+// pair matches the elements of g that did not move, position by position
+func (g *gap) pair(al alignment) {
+	var olds, news, removed, added []int
+	for _, x := range g.removed {
+		if !al.movedOut[x] {
+			olds = append(olds, x)
+		}
+	}
+	for _, y := range g.added {
+		if _, moved := al.moves[y]; !moved {
+			news = append(news, y)
+		}
+	}
+
+	n := min(len(olds), len(news))
+	paired := make(map[int]bool, n)
+	pairedNew := make(map[int]bool, n)
+	for i := 0; i < n; i++ {
+		g.pairs = append(g.pairs, [2]int{olds[i], news[i]})
+		paired[olds[i]] = true
+		pairedNew[news[i]] = true
+	}
+
+	for _, x := range g.removed {
+		if !paired[x] {
+			removed = append(removed, x)
+		}
+	}
+	for _, y := range g.added {
+		if !pairedNew[y] {
+			added = append(added, y)
+		}
+	}
+	g.removed, g.added = removed, added
+}
+
+// lcs returns the index pairs of a longest common subsequence of a and b
+func lcs(a, b []string) [][2]int {
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxLCSCells {
+		return nil
+	}
+
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	width := len(b) + 1
+	table := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches = append(matches, [2]int{i, j})
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// elementKeys returns a canonical encoding of every element, so equal values
+// compare equal as strings
+func elementKeys(arr []interface{}) []string {
+	keys := make([]string, len(arr))
+	for i, v := range arr {
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(fmt.Sprintf("%#v", v))
+		}
+		keys[i] = string(b)
+	}
+	return keys
+}
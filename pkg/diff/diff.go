@@ -0,0 +1,155 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// ChangeType describes how a value differs between two documents
+type ChangeType int
+
+const (
+	Added ChangeType = iota
+	Removed
+	Changed
+	Moved
+)
+
+// String returns a lowercase name for the change type
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Moved:
+		return "moved"
+	}
+	return "unknown"
+}
+
+// Change is a single difference between two documents
+type Change struct {
+	Type ChangeType
+	Path []jsonpath.Segment
+	// From is the location in the first document of a Moved value; Path is
+	// where it ends up in the second
+	From []jsonpath.Segment
+	// Old is the value in the first document (unset for Added)
+	Old interface{}
+	// New is the value in the second document (unset for Removed)
+	New interface{}
+}
+
+// Compare returns the differences that turn a into b, in document order.
+// Objects are compared key by key. Arrays are aligned on their longest common
+// subsequence, so inserting or removing an element does not shift every later
+// index, and an element found at a different position is reported as Moved.
+// Any other difference, including a change of type, is a single Changed entry.
+func Compare(a, b interface{}) []Change {
+	var changes []Change
+	compare(nil, a, b, &changes)
+	return changes
+}
+
+// CompareJSON parses both documents and compares them
+func CompareJSON(a, b []byte) ([]Change, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return nil, err
+	}
+	return Compare(va, vb), nil
+}
+
+// compare appends the differences between a and b found below path
+func compare(path []jsonpath.Segment, a, b interface{}, changes *[]Change) {
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		compareObjects(path, va, vb, changes)
+		return
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		compareArrays(path, va, vb, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Type: Changed, Path: clonePath(path), Old: a, New: b})
+	}
+}
+
+// compareObjects compares two objects key by key, in sorted key order
+func compareObjects(path []jsonpath.Segment, a, b map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		va, inA := a[k]
+		vb, inB := b[k]
+		child := append(path, jsonpath.Key(k))
+
+		switch {
+		case !inB:
+			*changes = append(*changes, Change{Type: Removed, Path: clonePath(child), Old: va})
+		case !inA:
+			*changes = append(*changes, Change{Type: Added, Path: clonePath(child), New: vb})
+		default:
+			compare(child, va, vb, changes)
+		}
+	}
+}
+
+// compareArrays compares two arrays after aligning their elements. Within each
+// stretch of differing elements, edits inside paired elements come first, then
+// removals at their old index, then additions and moves at their new index.
+func compareArrays(path []jsonpath.Segment, a, b []interface{}, changes *[]Change) {
+	al := align(a, b)
+
+	for _, g := range al.gaps {
+		for _, p := range g.pairs {
+			compare(append(path, jsonpath.Index(p[1])), a[p[0]], b[p[1]], changes)
+		}
+		for _, x := range g.removed {
+			if !al.movedOut[x] {
+				*changes = append(*changes, Change{Type: Removed, Path: clonePath(append(path, jsonpath.Index(x))), Old: a[x]})
+			}
+		}
+		for _, y := range g.added {
+			child := clonePath(append(path, jsonpath.Index(y)))
+			if x, moved := al.moves[y]; moved {
+				from := clonePath(append(path, jsonpath.Index(x)))
+				*changes = append(*changes, Change{Type: Moved, Path: child, From: from, Old: a[x], New: b[y]})
+				continue
+			}
+			*changes = append(*changes, Change{Type: Added, Path: child, New: b[y]})
+		}
+	}
+}
+
+// clonePath copies path so later appends cannot overwrite a recorded change
+func clonePath(path []jsonpath.Segment) []jsonpath.Segment {
+	return append([]jsonpath.Segment(nil), path...)
+}
@@ -0,0 +1,385 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+func TestCompareJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []string
+	}{
+		{
+			name: "Identical documents",
+			a:    `{"a":1,"b":[1,2]}`,
+			b:    `{"b":[1,2],"a":1}`,
+			want: nil,
+		},
+		{
+			name: "Added removed and changed keys",
+			a:    `{"keep":1,"old":true,"status":"ok"}`,
+			b:    `{"keep":1,"new":null,"status":"failed"}`,
+			want: []string{"added new", "removed old", "changed status"},
+		},
+		{
+			name: "Nested array elements",
+			a:    `{"items":[{"id":1},{"id":2}]}`,
+			b:    `{"items":[{"id":1},{"id":3},{"id":4}]}`,
+			want: []string{"changed items[1].id", "added items[2]"},
+		},
+		{
+			name: "Prepended element",
+			a:    `{"items":[{"id":1},{"id":2},{"id":3}]}`,
+			b:    `{"items":[{"id":0},{"id":1},{"id":2},{"id":3}]}`,
+			want: []string{"added items[0]"},
+		},
+		{
+			name: "Removed element",
+			a:    `[1,2,3,4]`,
+			b:    `[1,3,4]`,
+			want: []string{"removed [1]"},
+		},
+		{
+			name: "Moved element",
+			a:    `["a","b","c","d"]`,
+			b:    `["b","c","d","a"]`,
+			want: []string{"moved [3]"},
+		},
+		{
+			name: "Edited element between unchanged ones",
+			a:    `[1,{"id":2,"ok":true},3]`,
+			b:    `[1,{"id":2,"ok":false},3,4]`,
+			want: []string{"changed [1].ok", "added [3]"},
+		},
+		{
+			name: "Type change",
+			a:    `{"v":{"x":1}}`,
+			b:    `{"v":[1]}`,
+			want: []string{"changed v"},
+		},
+		{
+			name: "Root scalar",
+			a:    `1`,
+			b:    `2`,
+			want: []string{"changed "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := CompareJSON([]byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("CompareJSON() error = %v", err)
+			}
+
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.Type.String()+" "+jsonpath.String(c.Path))
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("CompareJSON() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("CompareJSON()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompareJSONInvalid(t *testing.T) {
+	if _, err := CompareJSON([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Errorf("CompareJSON() expected an error for invalid input")
+	}
+}
+
+func TestRender(t *testing.T) {
+	changes, err := CompareJSON([]byte(`{"a":1,"b":2}`), []byte(`{"a":3,"c":[true]}`))
+	if err != nil {
+		t.Fatalf("CompareJSON() error = %v", err)
+	}
+
+	want := "~ a\n  - 1\n  + 3\n- b\n  - 2\n+ c\n  + [true]\n"
+	if got := Render(changes, RenderOptions{}); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	colored := Render(changes[:1], RenderOptions{Color: true})
+	wantColored := colorYellow + colorBold + "~ a" + colorReset + "\n" +
+		colorRed + "  - 1" + colorReset + "\n" +
+		colorGreen + "  + 3" + colorReset + "\n"
+	if colored != wantColored {
+		t.Errorf("Render() colored = %q, want %q", colored, wantColored)
+	}
+}
+
+func TestCompareMoveFrom(t *testing.T) {
+	changes, err := CompareJSON([]byte(`{"x":[1,2,3]}`), []byte(`{"x":[3,1,2]}`))
+	if err != nil {
+		t.Fatalf("CompareJSON() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != Moved {
+		t.Fatalf("CompareJSON() = %v, want a single move", changes)
+	}
+	if from, to := jsonpath.String(changes[0].From), jsonpath.String(changes[0].Path); from != "x[2]" || to != "x[0]" {
+		t.Errorf("move = %s -> %s, want x[2] -> x[0]", from, to)
+	}
+
+	want := "> x[0]\n  from x[2]: 3\n"
+	if got := Render(changes, RenderOptions{}); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "Identical documents",
+			a:    `{"a":1}`,
+			b:    `{"a":1}`,
+			want: `[]`,
+		},
+		{
+			name: "Object members",
+			a:    `{"keep":1,"old":true,"status":"ok","a/b":1}`,
+			b:    `{"keep":1,"new":null,"status":"failed","a/b":2}`,
+			want: `[{"op":"replace","path":"/a~1b","value":2},{"op":"add","path":"/new","value":null},{"op":"remove","path":"/old"},{"op":"replace","path":"/status","value":"failed"}]`,
+		},
+		{
+			name: "Trailing array elements are removed from the end",
+			a:    `{"items":[1,2,3,4]}`,
+			b:    `{"items":[1]}`,
+			want: `[{"op":"remove","path":"/items/3"},{"op":"remove","path":"/items/2"},{"op":"remove","path":"/items/1"}]`,
+		},
+		{
+			name: "Prepended element",
+			a:    `[1,2,3]`,
+			b:    `[0,1,2,3]`,
+			want: `[{"op":"add","path":"/0","value":0}]`,
+		},
+		{
+			name: "Moved element",
+			a:    `["a","b","c","d"]`,
+			b:    `["b","c","d","a"]`,
+			want: `[{"op":"move","path":"/3","from":"/0"}]`,
+		},
+		{
+			name: "Moves and edits",
+			a:    `[1,2,3,4,5,7]`,
+			b:    `[5,1,3,2,6]`,
+			want: `[{"op":"remove","path":"/5"},{"op":"move","path":"/0","from":"/4"},{"op":"move","path":"/3","from":"/2"},{"op":"replace","path":"/4","value":6}]`,
+		},
+		{
+			name: "Replaced root",
+			a:    `[1]`,
+			b:    `{"a":1}`,
+			want: `[{"op":"replace","path":"","value":{"a":1}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := decode(t, tt.a), decode(t, tt.b)
+
+			got, err := json.Marshal(Patch(a, b))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Patch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{`[1,2,3,4,5]`, `[5,1,3,2,6]`},
+		{`["a","b","c","d"]`, `["b","c","d","a"]`},
+		{`[{"id":1,"v":1},{"id":2},{"id":3}]`, `[{"id":3},{"id":1,"v":2},{"id":4}]`},
+		{`{"x":[[1,2],[3]],"y":{"z":[true,false]}}`, `{"x":[[0,1,2],[3,4]],"y":{"z":[false]}}`},
+		{`[1,1,2,2,3]`, `[2,1,3,1,2,2]`},
+		{`[]`, `[1,2]`},
+		{`[1,2]`, `[]`},
+		{`[{"id":1},{"id":2},[1,2],{"id":3}]`, `[{"id":3},[1,3],{"id":1},{"id":2}]`},
+		{`["m1","s1","s2","m2"]`, `["s1","m2","s2","m1"]`},
+	}
+
+	for _, p := range pairs {
+		a, b := decode(t, p[0]), decode(t, p[1])
+		got, err := ApplyPatch(a, Patch(a, b), nil)
+		if err != nil {
+			t.Errorf("ApplyPatch() of Patch(%s, %s) error = %v", p[0], p[1], err)
+		} else if !reflect.DeepEqual(got, b) {
+			t.Errorf("applying Patch(%s, %s) gave %v", p[0], p[1], got)
+		}
+	}
+}
+
+// decode parses a test document
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", s, err)
+	}
+	return v
+}
+
+func TestApplyPatch(t *testing.T) {
+	// Examples from RFC 6902, appendix A
+	tests := []struct {
+		doc, patch, want string
+		wantErr          bool
+	}{
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, want: `{"baz":"qux","foo":"bar"}`},
+		{doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, want: `{"foo":["bar","qux","baz"]}`},
+		{doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, want: `{"foo":"bar"}`},
+		{doc: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, want: `{"foo":["bar","baz"]}`},
+		{doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, want: `{"baz":"boo","foo":"bar"}`},
+		{doc: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{doc: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, want: `{"foo":["all","cows","eat","grass"]}`},
+		{doc: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{doc: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, wantErr: true},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, want: `{"foo":"bar","child":{"grandchild":{}}}`},
+		{doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, wantErr: true},
+		{doc: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, want: `{"foo":["bar",["abc","def"]]}`},
+		{doc: `{"foo":["bar"]}`, patch: `[{"op":"copy","from":"/foo","path":"/baz"}]`, want: `{"foo":["bar"],"baz":["bar"]}`},
+		{doc: `{"foo":["bar"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, wantErr: true},
+		{doc: `{"foo":{"a":1}}`, patch: `[{"op":"move","from":"/foo","path":"/foo/b"}]`, wantErr: true},
+		{doc: `{"foo":1}`, patch: `[{"op":"add","path":"","value":[1]}]`, want: `[1]`},
+	}
+
+	for _, tt := range tests {
+		doc := decode(t, tt.doc)
+		ops, err := DecodePatch(decode(t, tt.patch))
+		if err != nil {
+			t.Fatalf("DecodePatch(%s) error = %v", tt.patch, err)
+		}
+
+		got, err := ApplyPatch(doc, ops, nil)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ApplyPatch(%s, %s) = %v, want an error", tt.doc, tt.patch, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ApplyPatch(%s, %s) error = %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("ApplyPatch(%s, %s) = %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+		if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+			t.Errorf("ApplyPatch(%s, %s) modified the document", tt.doc, tt.patch)
+		}
+	}
+
+	for _, patch := range []string{`{}`, `[{"op":"add","path":"/a"}]`, `[{"op":"move","path":"/a"}]`, `[{"op":"nop","path":"/a"}]`, `[{"op":"remove"}]`} {
+		if _, err := DecodePatch(decode(t, patch)); err == nil {
+			t.Errorf("DecodePatch(%s) accepted an invalid patch", patch)
+		}
+	}
+}
+
+func TestApplyPatchKeepsKeyOrder(t *testing.T) {
+	order := formatter.NewKeyOrder()
+	opts := formatter.Options{KeyOrder: order, Compact: true}
+	doc, err := formatter.Decode([]byte(`{"zeta":1,"mid":{"y":1,"x":2},"alpha":{"b":1,"a":2}}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := formatter.Decode([]byte(`[
+		{"op":"replace","path":"/zeta","value":0},
+		{"op":"add","path":"/new","value":{"q":1,"p":2}},
+		{"op":"add","path":"/alpha/0","value":3},
+		{"op":"move","from":"/mid/y","path":"/mid/w"}
+	]`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := DecodePatch(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ApplyPatch(doc, ops, order)
+	if err != nil {
+		t.Fatalf("ApplyPatch() error = %v", err)
+	}
+	out, err := formatter.FormatValue(got, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"zeta":0,"mid":{"x":2,"w":1},"alpha":{"b":1,"a":2,"0":3},"new":{"q":1,"p":2}}`
+	if string(out) != want {
+		t.Errorf("ApplyPatch() = %s, want %s", out, want)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{name: "Equal", a: "{}\n", b: "{}\n", want: ""},
+		{
+			name: "Changed line",
+			a:    "{\n  \"a\": 1,\n  \"b\":2\n}\n",
+			b:    "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n {\n   \"a\": 1,\n-  \"b\":2\n+  \"b\": 2\n }\n",
+		},
+		{
+			name: "Missing final line feed",
+			a:    "[]",
+			b:    "[]\n",
+			want: "--- old\n+++ new\n@@ -1 +1 @@\n-[]\n\\ No newline at end of file\n+[]\n",
+		},
+		{
+			name: "Separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "0\n1\n2\n3\n4\n5\n6\n7\n8\n10\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -6,5 +7,4 @@\n 6\n 7\n 8\n-9\n 10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", "new", []byte(tt.a), []byte(tt.b))
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEscapeHTML(t *testing.T) {
+	changes, err := CompareJSON([]byte(`{"a":"<b>"}`), []byte(`{"a":"a & b"}`))
+	if err != nil {
+		t.Fatalf("CompareJSON() error = %v", err)
+	}
+	if got, want := Render(changes, RenderOptions{}), "~ a\n  - \"<b>\"\n  + \"a & b\"\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if got, want := Render(changes, RenderOptions{EscapeHTML: true}), "~ a\n  - \"\\u003cb\\u003e\"\n  + \"a \\u0026 b\"\n"; got != want {
+		t.Errorf("Render(EscapeHTML) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is how many unchanged lines Unified shows around each change
+const contextLines = 3
+
+// lineOp is one line of a line-by-line comparison: kept, removed or added
+type lineOp struct {
+	kind byte
+	text string
+	// old and new are the 0-based line numbers on each side
+	old int
+	new int
+}
+
+// Unified compares two texts line by line and renders the differences as a
+// unified diff, as diff -u does, with oldName and newName in the header. It
+// returns "" when the texts are equal.
+func Unified(oldName, newName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	ops := lineOps(splitLines(string(a)), splitLines(string(b)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change, and extend the hunk until the unchanged lines
+		// between two changes are too many to show
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*contextLines {
+				break
+			}
+		}
+		from := max(first-contextLines, start)
+		to := min(last+contextLines+1, len(ops))
+		writeHunk(&sb, ops[from:to])
+		start = to
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines that keep their line feed, so that a
+// missing one at the end of the text is a difference like any other
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps lines a and b up along their longest common subsequence
+func lineOps(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	// Texts too large to compare in the middle are shown as replaced there
+	matches := lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for i := range matches {
+		matches[i][0] += prefix
+		matches[i][1] += prefix
+	}
+	matches = append(matches, [2]int{len(a) - suffix, len(b) - suffix})
+
+	var ops []lineOp
+	i, j := 0, 0
+	for i < prefix {
+		ops = append(ops, lineOp{kind: ' ', text: a[i], old: i, new: j})
+		i++
+		j++
+	}
+	for _, m := range matches {
+		for ; i < m[0]; i++ {
+			ops = append(ops, lineOp{kind: '-', text: a[i], old: i, new: j})
+		}
+		for ; j < m[1]; j++ {
+			ops = append(ops, lineOp{kind: '+', text: b[j], old: i, new: j})
+		}
+		if i < len(a) && j < len(b) {
+			ops = append(ops, lineOp{kind: ' ', text: a[i], old: i, new: j})
+			i++
+			j++
+		}
+	}
+	for i < len(a) {
+		ops = append(ops, lineOp{kind: ' ', text: a[i], old: i, new: j})
+		i++
+		j++
+	}
+	return ops
+}
+
+// writeHunk writes one @@ section of a unified diff
+func writeHunk(sb *strings.Builder, ops []lineOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].old, oldCount), hunkRange(ops[0].new, newCount))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		if !strings.HasSuffix(op.text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the start and length of one side of a hunk. An empty
+// range starts at the line before it, as in diff -u.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Operation is one step of an RFC 6902 JSON Patch
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON always includes the value of operations that require one, even
+// when it is null
+func (o Operation) MarshalJSON() ([]byte, error) {
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{o.Op, o.Path, o.Value})
+	}
+
+	type plain Operation
+	return json.Marshal(plain(o))
+}
+
+// Patch returns a JSON Patch that turns a into b. It follows the same alignment
+// as Compare, but every index refers to the array as it stands when the
+// operation is applied, so the operations must be applied in order. Moved array
+// elements are moved with a move operation.
+func Patch(a, b interface{}) []Operation {
+	ops := make([]Operation, 0)
+	patch(nil, a, b, &ops)
+	return ops
+}
+
+// patch appends the operations that turn a into b below path
+func patch(path []jsonpath.Segment, a, b interface{}, ops *[]Operation) {
+	switch va := a.(type) {
+	case map[string]interface{}:
+		if vb, ok := b.(map[string]interface{}); ok {
+			patchObjects(path, va, vb, ops)
+			return
+		}
+	case []interface{}:
+		if vb, ok := b.([]interface{}); ok {
+			patchArrays(path, va, vb, ops)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*ops = append(*ops, Operation{Op: "replace", Path: jsonpath.Pointer(path), Value: b})
+	}
+}
+
+// patchObjects emits operations for two objects, in sorted key order
+func patchObjects(path []jsonpath.Segment, a, b map[string]interface{}, ops *[]Operation) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		va, inA := a[k]
+		vb, inB := b[k]
+		child := append(path, jsonpath.Key(k))
+
+		switch {
+		case !inB:
+			*ops = append(*ops, Operation{Op: "remove", Path: jsonpath.Pointer(child)})
+		case !inA:
+			*ops = append(*ops, Operation{Op: "add", Path: jsonpath.Pointer(child), Value: vb})
+		default:
+			patch(child, va, vb, ops)
+		}
+	}
+}
+
+// patchArrays emits operations for two arrays. Elements that are gone are
+// removed first, from the last down. Moved elements are then moved, each in
+// the order of its new position to just after the element that precedes it
+// in b, which leaves the elements that stayed in order where they are. New
+// elements are added at their final index, and only then are paired
+// elements patched in place.
+func patchArrays(path []jsonpath.Segment, a, b []interface{}, ops *[]Operation) {
+	al := align(a, b)
+	pointer := func(i int) string {
+		return jsonpath.Pointer(append(path, jsonpath.Index(i)))
+	}
+
+	// src holds the old index of every new element, or -1 for additions
+	src := make([]int, len(b))
+	for y := range src {
+		src[y] = -1
+	}
+	used := make(map[int]bool)
+	gone := make(map[int]bool)
+	added := make(map[int]bool)
+	for _, g := range al.gaps {
+		for _, x := range g.removed {
+			used[x] = true
+			if !al.movedOut[x] {
+				gone[x] = true
+			}
+		}
+		for _, y := range g.added {
+			if x, moved := al.moves[y]; moved {
+				src[y] = x
+			} else {
+				added[y] = true
+			}
+		}
+		for _, p := range g.pairs {
+			src[p[1]] = p[0]
+			used[p[0]] = true
+		}
+	}
+	// The elements outside the gaps match one for one, in order
+	next := 0
+	for y := range src {
+		if src[y] >= 0 || added[y] {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		src[y] = next
+		next++
+	}
+
+	for x := len(a) - 1; x >= 0; x-- {
+		if gone[x] {
+			*ops = append(*ops, Operation{Op: "remove", Path: pointer(x)})
+		}
+	}
+	// cur holds the old index of each element of the array being patched
+	cur := make([]int, 0, len(a))
+	for x := range a {
+		if !gone[x] {
+			cur = append(cur, x)
+		}
+	}
+
+	prev := -1
+	for y := range b {
+		x := src[y]
+		if x < 0 {
+			continue
+		}
+		if al.movedOut[x] {
+			from := slices.Index(cur, x)
+			cur = slices.Delete(cur, from, from+1)
+			to := 0
+			if prev >= 0 {
+				to = slices.Index(cur, prev) + 1
+			}
+			cur = slices.Insert(cur, to, x)
+			if from != to {
+				*ops = append(*ops, Operation{Op: "move", From: pointer(from), Path: pointer(to)})
+			}
+		}
+		prev = x
+	}
+
+	for y := range b {
+		if added[y] {
+			*ops = append(*ops, Operation{Op: "add", Path: pointer(y), Value: b[y]})
+		}
+	}
+	for y := range b {
+		if x := src[y]; x >= 0 && !al.movedOut[x] {
+			patch(append(path, jsonpath.Index(y)), a[x], b[y], ops)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorGreen  = "\x1b[32m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+	colorBold   = "\x1b[1m"
+)
+
+// RenderOptions controls Render
+type RenderOptions struct {
+	// Color wraps lines in terminal colors
+	Color bool
+	// EscapeHTML writes <, > and & in strings as \u003c, \u003e and \u0026
+	EscapeHTML bool
+}
+
+// Render formats changes as a human-readable report. Every change starts with a
+// header line holding a marker (+ added, - removed, ~ changed, > moved) and the
+// path, followed by the old and/or new value; a move names its old path instead.
+// With opts.Color, additions are green, removals red, changes yellow and
+// moves cyan; the markers are always present so the output stays readable
+// without color.
+func Render(changes []Change, opts RenderOptions) string {
+	var sb strings.Builder
+
+	for _, c := range changes {
+		path := jsonpath.String(c.Path)
+		if path == "" {
+			path = "(root)"
+		}
+
+		switch c.Type {
+		case Added:
+			writeLine(&sb, opts.Color, colorGreen+colorBold, "+ "+path)
+			writeLine(&sb, opts.Color, colorGreen, "  + "+compactJSON(c.New, opts.EscapeHTML))
+		case Removed:
+			writeLine(&sb, opts.Color, colorRed+colorBold, "- "+path)
+			writeLine(&sb, opts.Color, colorRed, "  - "+compactJSON(c.Old, opts.EscapeHTML))
+		case Changed:
+			writeLine(&sb, opts.Color, colorYellow+colorBold, "~ "+path)
+			writeLine(&sb, opts.Color, colorRed, "  - "+compactJSON(c.Old, opts.EscapeHTML))
+			writeLine(&sb, opts.Color, colorGreen, "  + "+compactJSON(c.New, opts.EscapeHTML))
+		case Moved:
+			writeLine(&sb, opts.Color, colorCyan+colorBold, "> "+path)
+			writeLine(&sb, opts.Color, colorCyan, "  from "+jsonpath.String(c.From)+": "+compactJSON(c.New, opts.EscapeHTML))
+		}
+	}
+
+	return sb.String()
+}
+
+// writeLine writes text on its own line, wrapped in style when color is enabled
+func writeLine(sb *strings.Builder, color bool, style, text string) {
+	if color {
+		sb.WriteString(style + text + colorReset + "\n")
+		return
+	}
+	sb.WriteString(text + "\n")
+}
+
+// compactJSON renders a value as single-line JSON
+func compactJSON(v interface{}, escapeHTML bool) string {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
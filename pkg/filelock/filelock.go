@@ -0,0 +1,32 @@
+package filelock
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrTimeout is returned when another process holds the lock for too long
+var ErrTimeout = errors.New("timed out waiting for the file lock")
+
+// Timeout bounds how long Lock waits for another holder to release the lock
+var Timeout = 10 * time.Second
+
+// retryInterval is how often a held lock is tried again
+const retryInterval = 20 * time.Millisecond
+
+// Lock takes an exclusive lock on an open file, waiting up to Timeout.
+// The returned function releases it.
+func Lock(f *os.File) (unlock func() error, err error) {
+	deadline := time.Now().Add(Timeout)
+	for {
+		unlock, held, err := tryLock(f)
+		if err != nil || !held {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrTimeout
+		}
+		time.Sleep(retryInterval)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package filelock
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// tryLock creates a sibling ".lock" file exclusively, since the standard
+// library has no portable advisory lock outside unix. held reports that
+// another process has it.
+func tryLock(f *os.File) (unlock func() error, held bool, err error) {
+	name := f.Name() + ".lock"
+	lock, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	_ = lock.Close()
+	return func() error {
+		return os.Remove(name)
+	}, false, nil
+}
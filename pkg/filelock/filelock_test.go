@@ -0,0 +1,45 @@
+package filelock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.ndjson")
+	first, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	unlock, err := Lock(first)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	saved := Timeout
+	Timeout = 50 * time.Millisecond
+	defer func() { Timeout = saved }()
+
+	if _, err := Lock(second); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Lock() on a held file error = %v, want ErrTimeout", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+	unlock, err = Lock(second)
+	if err != nil {
+		t.Fatalf("Lock() after unlock error = %v", err)
+	}
+	_ = unlock()
+}
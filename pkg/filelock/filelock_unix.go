@@ -0,0 +1,24 @@
+//go:build unix
+
+package filelock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock takes an advisory flock on the file. held reports that another
+// process has it.
+func tryLock(f *os.File) (unlock func() error, held bool, err error) {
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return func() error {
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	}, false, nil
+}
@@ -0,0 +1,115 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Narrate renders JSON data as plain sentences that a screen reader can announce.
+// Every container states its kind and size before its contents ("object with 3 keys")
+// and is closed with an explicit "end of" line, so no punctuation or color is needed
+// to follow the structure. Entries matching the highlight rules are announced as
+// "highlighted" instead of relying on color. Keys are read in the order
+// FormatValue writes them with opts.
+func Narrate(data []byte, opts Options, highlight []string) ([]byte, error) {
+	if opts.KeyOrder == nil {
+		opts.KeyOrder = NewKeyOrder()
+	}
+	jsonObj, err := Decode(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := jsonpath.CompileAll(highlight)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &narrator{opts: opts, highlight: patterns}
+	n.value("", jsonObj, 0)
+
+	return []byte(strings.TrimSuffix(n.sb.String(), "\n")), nil
+}
+
+// narrator accumulates the spoken description while tracking the current path
+type narrator struct {
+	sb        strings.Builder
+	opts      Options
+	highlight []*jsonpath.Pattern
+	path      []jsonpath.Segment
+}
+
+// child narrates a nested value located at seg
+func (n *narrator) child(seg jsonpath.Segment, label string, value interface{}, depth int) {
+	n.path = append(n.path, seg)
+	if jsonpath.MatchAny(n.highlight, n.path) {
+		label = "highlighted " + label
+	}
+	n.value(label, value, depth)
+	n.path = n.path[:len(n.path)-1]
+}
+
+// value writes one line for value, prefixed by label, followed by its children
+func (n *narrator) value(label string, value interface{}, depth int) {
+	sb := &n.sb
+	indent := strings.Repeat("  ", depth)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(sb, "%s%sempty object\n", indent, label)
+			return
+		}
+		fmt.Fprintf(sb, "%s%sobject with %s\n", indent, label, plural(len(v), "key", "keys"))
+
+		for _, k := range n.opts.Keys(v) {
+			n.child(jsonpath.Key(k), fmt.Sprintf("key %s: ", k), v[k], depth+1)
+		}
+		fmt.Fprintf(sb, "%send of object\n", indent)
+	case []interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(sb, "%s%sempty array\n", indent, label)
+			return
+		}
+		fmt.Fprintf(sb, "%s%sarray with %s\n", indent, label, plural(len(v), "item", "items"))
+
+		for i, item := range v {
+			n.child(jsonpath.Index(i), fmt.Sprintf("item %d: ", i+1), item, depth+1)
+		}
+		fmt.Fprintf(sb, "%send of array\n", indent)
+	case string:
+		if v == "" {
+			fmt.Fprintf(sb, "%s%sempty string\n", indent, label)
+			return
+		}
+		fmt.Fprintf(sb, "%s%sstring %s\n", indent, label, v)
+	case float64:
+		fmt.Fprintf(sb, "%s%snumber %s\n", indent, label, formatNumber(v))
+	case json.Number:
+		fmt.Fprintf(sb, "%s%snumber %s\n", indent, label, v)
+	case bool:
+		fmt.Fprintf(sb, "%s%sboolean %t\n", indent, label, v)
+	case nil:
+		fmt.Fprintf(sb, "%s%snull\n", indent, label)
+	}
+}
+
+// plural returns "1 key" or "3 keys"
+func plural(n int, singular, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// formatNumber prints a number the same way encoding/json does
+func formatNumber(f float64) string {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Sprint(f)
+	}
+	return string(b)
+}
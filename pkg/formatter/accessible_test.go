@@ -0,0 +1,85 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func TestNarrate(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		opts      Options
+		highlight []string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:  "Object with nested array",
+			input: `{"name":"John","tags":["a","b"],"age":30}`,
+			opts:  Options{SortKeys: true},
+			want: `object with 3 keys
+  key age: number 30
+  key name: string John
+  key tags: array with 2 items
+    item 1: string a
+    item 2: string b
+  end of array
+end of object`,
+			wantErr: false,
+		},
+		{
+			name:  "Keys in document order",
+			input: `{"zeta":1,"alpha":{"y":true,"b":null}}`,
+			want:  "object with 2 keys\n  key zeta: number 1\n  key alpha: object with 2 keys\n    key y: boolean true\n    key b: null\n  end of object\nend of object",
+		},
+		{
+			name:  "Sort mode and key priority",
+			input: `{"item10":1,"id":2,"item2":3}`,
+			opts:  Options{SortKeys: true, KeySort: KeySortNatural, KeyPriority: []string{"id"}},
+			want:  "object with 3 keys\n  key id: number 2\n  key item2: number 3\n  key item10: number 1\nend of object",
+		},
+		{
+			name:  "Duplicate keys",
+			input: `{"a":1,"a":2}`,
+			opts:  Options{DuplicateKeys: DuplicateFirstWins},
+			want:  "object with 1 key\n  key a: number 1\nend of object",
+		},
+		{
+			name:    "Empty containers and scalars",
+			input:   `[{},[],"",null,true]`,
+			want:    "array with 5 items\n  item 1: empty object\n  item 2: empty array\n  item 3: empty string\n  item 4: null\n  item 5: boolean true\nend of array",
+			wantErr: false,
+		},
+		{
+			name:    "Single key",
+			input:   `{"a":1}`,
+			want:    "object with 1 key\n  key a: number 1\nend of object",
+			wantErr: false,
+		},
+		{
+			name:      "Highlighted key",
+			input:     `{"error":"boom","ok":false}`,
+			highlight: []string{"error"},
+			want:      "object with 2 keys\n  highlighted key error: string boom\n  key ok: boolean false\nend of object",
+			wantErr:   false,
+		},
+		{
+			name:    "Invalid JSON",
+			input:   `{"a":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Narrate([]byte(tt.input), tt.opts, tt.highlight)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Narrate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("Narrate() = %q, want %q", string(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Canonical writes v in the JSON Canonicalization Scheme of RFC 8785: no
+// whitespace, object keys sorted by their UTF-16 code units, numbers in the
+// shortest ECMAScript form and strings with only the required escapes. The
+// same document always produces the same bytes, so the output can be hashed
+// or signed. Numbers are IEEE 754 doubles in JCS, so json.Number values are
+// rounded to the nearest float64.
+func Canonical(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCanonical appends the canonical form of v to buf
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			canonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, val[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case string:
+		canonicalString(buf, val)
+	case bool:
+		buf.WriteString(strconv.FormatBool(val))
+	case nil:
+		buf.WriteString("null")
+	case float64:
+		text, err := canonicalNumber(val)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(text)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(val), 64)
+		if err != nil {
+			return fmt.Errorf("number %s cannot be represented in canonical JSON", val)
+		}
+		text, err := canonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(text)
+	default:
+		return fmt.Errorf("unsupported value of type %T", v)
+	}
+	return nil
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as JCS requires
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// canonicalString writes s quoted, escaping only quotes, backslashes and
+// control characters
+func canonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalNumber formats f the way ECMAScript's Number.prototype.toString
+// does: the shortest digits that round-trip, in plain notation for exponents
+// from -7 to 20 and in exponent notation otherwise
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %v cannot be represented in canonical JSON", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// Shortest round-trip digits and the decimal exponent, d.ddde±x
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, err := strconv.Atoi(exp)
+	if err != nil {
+		return "", err
+	}
+	k := len(digits)
+	// n is the position of the decimal point relative to the digits
+	n := e + 1
+
+	var text string
+	switch {
+	case k <= n && n <= 21:
+		text = digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		text = digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		text = "0." + strings.Repeat("0", -n) + digits
+	default:
+		text = digits[:1]
+		if k > 1 {
+			text += "." + digits[1:]
+		}
+		if n-1 < 0 {
+			text += "e-" + strconv.Itoa(1-n)
+		} else {
+			text += "e+" + strconv.Itoa(n-1)
+		}
+	}
+	return sign + text, nil
+}
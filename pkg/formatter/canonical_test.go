@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	// Example from RFC 8785, section 3.2.2
+	input := `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],` +
+		`"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`
+
+	v, err := Decode([]byte(input), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got, err := Canonical(v)
+	if err != nil {
+		t.Fatalf("Canonical() error = %v", err)
+	}
+
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	if string(got) != want {
+		t.Errorf("Canonical() = %s, want %s", got, want)
+	}
+}
+
+func TestCanonicalKeyOrder(t *testing.T) {
+	// Example from RFC 8785, section 3.2.3: keys sorted by UTF-16 code units
+	input := `{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`
+
+	v, err := Decode([]byte(input), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got, err := Canonical(v)
+	if err != nil {
+		t.Fatalf("Canonical() error = %v", err)
+	}
+
+	want := "{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}"
+	if string(got) != want {
+		t.Errorf("Canonical() = %s, want %s", got, want)
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{1, "1"},
+		{-1.5, "-1.5"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{1e-6, "0.000001"},
+		{1e-7, "1e-7"},
+		{123e-20, "1.23e-18"},
+		{9007199254740992, "9007199254740992"},
+		{295147905179352830000, "295147905179352830000"},
+		{5e-324, "5e-324"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+	}
+
+	for _, tt := range tests {
+		got, err := canonicalNumber(tt.in)
+		if err != nil {
+			t.Errorf("canonicalNumber(%v) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("canonicalNumber(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := canonicalNumber(math.Inf(1)); err == nil {
+		t.Errorf("canonicalNumber() accepted infinity")
+	}
+}
@@ -0,0 +1,125 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a Unicode encoding a document can be written in
+type Encoding string
+
+const (
+	// EncodingUTF8 is UTF-8, the encoding JSON requires
+	EncodingUTF8 Encoding = "utf-8"
+	// EncodingUTF16LE is little-endian UTF-16, written by Windows tools and
+	// PowerShell redirects
+	EncodingUTF16LE Encoding = "utf-16le"
+	// EncodingUTF16BE is big-endian UTF-16
+	EncodingUTF16BE Encoding = "utf-16be"
+	// EncodingUTF32LE is little-endian UTF-32
+	EncodingUTF32LE Encoding = "utf-32le"
+	// EncodingUTF32BE is big-endian UTF-32
+	EncodingUTF32BE Encoding = "utf-32be"
+)
+
+// byte order marks, longest first so that UTF-32LE is not taken for UTF-16LE
+var boms = []struct {
+	mark     []byte
+	encoding Encoding
+}{
+	{[]byte{0x00, 0x00, 0xfe, 0xff}, EncodingUTF32BE},
+	{[]byte{0xff, 0xfe, 0x00, 0x00}, EncodingUTF32LE},
+	{[]byte{0xef, 0xbb, 0xbf}, EncodingUTF8},
+	{[]byte{0xfe, 0xff}, EncodingUTF16BE},
+	{[]byte{0xff, 0xfe}, EncodingUTF16LE},
+}
+
+// DetectEncoding finds the encoding of a document from its byte order mark,
+// returning the length of the mark. Without one, the zero bytes around the
+// first character tell the encodings apart, since JSON text starts with an
+// ASCII character (RFC 4627, section 3).
+func DetectEncoding(data []byte) (Encoding, int) {
+	for _, b := range boms {
+		if bytes.HasPrefix(data, b.mark) {
+			return b.encoding, len(b.mark)
+		}
+	}
+
+	switch {
+	case len(data) >= 4 && data[0] == 0 && data[1] == 0 && data[2] == 0 && data[3] != 0:
+		return EncodingUTF32BE, 0
+	case len(data) >= 4 && data[0] != 0 && data[1] == 0 && data[2] == 0 && data[3] == 0:
+		return EncodingUTF32LE, 0
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		return EncodingUTF16BE, 0
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		return EncodingUTF16LE, 0
+	}
+	return EncodingUTF8, 0
+}
+
+// ToUTF8 returns a document as UTF-8 without a byte order mark, whichever
+// Unicode encoding it was written in. UTF-8 input is returned as it is,
+// apart from the mark.
+func ToUTF8(data []byte) ([]byte, Encoding, error) {
+	enc, bom := DetectEncoding(data)
+	data = data[bom:]
+
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		if len(data)%2 != 0 {
+			return nil, enc, fmt.Errorf("%s input has an odd number of bytes", enc)
+		}
+		order := byteOrder(enc == EncodingUTF16BE)
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		return []byte(string(utf16.Decode(units))), enc, nil
+	case EncodingUTF32LE, EncodingUTF32BE:
+		if len(data)%4 != 0 {
+			return nil, enc, fmt.Errorf("%s input is not a whole number of 4-byte characters", enc)
+		}
+		order := byteOrder(enc == EncodingUTF32BE)
+		out := make([]byte, 0, len(data)/4)
+		for i := 0; i < len(data); i += 4 {
+			out = utf8.AppendRune(out, rune(order.Uint32(data[i:])))
+		}
+		return out, enc, nil
+	}
+	return data, enc, nil
+}
+
+// byteOrder picks the byte order of a UTF-16 or UTF-32 encoding
+func byteOrder(bigEndian bool) binary.ByteOrder {
+	if bigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// InvalidUTF8 returns the offsets of the byte sequences in data that are not
+// valid UTF-8. A run of invalid bytes counts as one sequence.
+func InvalidUTF8(data []byte) []int {
+	var offsets []int
+	inRun := false
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		invalid := r == utf8.RuneError && size == 1
+		if invalid && !inRun {
+			offsets = append(offsets, i)
+		}
+		inRun = invalid
+		i += size
+	}
+	return offsets
+}
+
+// ReplaceInvalidUTF8 replaces each sequence InvalidUTF8 finds with U+FFFD,
+// the Unicode replacement character
+func ReplaceInvalidUTF8(data []byte) []byte {
+	return bytes.ToValidUTF8(data, []byte(string(utf8.RuneError)))
+}
@@ -0,0 +1,87 @@
+package formatter
+
+import (
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16 writes s as UTF-16 in the given byte order
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+// encodeUTF32 writes s as UTF-32 in the given byte order
+func encodeUTF32(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, r := range s {
+		if bigEndian {
+			out = append(out, byte(r>>24), byte(r>>16), byte(r>>8), byte(r))
+		} else {
+			out = append(out, byte(r), byte(r>>8), byte(r>>16), byte(r>>24))
+		}
+	}
+	return out
+}
+
+func TestToUTF8(t *testing.T) {
+	const doc = `{"name": "Zoë", "emoji": "😀"}`
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  Encoding
+	}{
+		{"utf-8", []byte(doc), EncodingUTF8},
+		{"utf-8 bom", append([]byte{0xef, 0xbb, 0xbf}, doc...), EncodingUTF8},
+		{"utf-16le bom", encodeUTF16("\ufeff"+doc, false), EncodingUTF16LE},
+		{"utf-16be bom", encodeUTF16("\ufeff"+doc, true), EncodingUTF16BE},
+		{"utf-16le", encodeUTF16(doc, false), EncodingUTF16LE},
+		{"utf-16be", encodeUTF16(doc, true), EncodingUTF16BE},
+		{"utf-32le bom", encodeUTF32("\ufeff"+doc, false), EncodingUTF32LE},
+		{"utf-32be", encodeUTF32(doc, true), EncodingUTF32BE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc, err := ToUTF8(tt.input)
+			if err != nil {
+				t.Fatalf("ToUTF8() error = %v", err)
+			}
+			if enc != tt.want {
+				t.Errorf("ToUTF8() encoding = %s, want %s", enc, tt.want)
+			}
+			if string(got) != doc {
+				t.Errorf("ToUTF8() = %q, want %q", got, doc)
+			}
+		})
+	}
+
+	if _, _, err := ToUTF8([]byte{0xff, 0xfe, '{', 0, '}'}); err == nil {
+		t.Errorf("ToUTF8() accepted UTF-16 with an odd number of bytes")
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	input := []byte("{\"a\": \"x\xff\xfey\", \"b\": \"\xc3\"}")
+
+	offsets := InvalidUTF8(input)
+	if len(offsets) != 2 || offsets[0] != 8 || offsets[1] != 20 {
+		t.Errorf("InvalidUTF8() = %v, want [8 20]", offsets)
+	}
+	if got := InvalidUTF8([]byte(`{"name": "Zoë"}`)); got != nil {
+		t.Errorf("InvalidUTF8() = %v for valid input", got)
+	}
+
+	want := "{\"a\": \"x�y\", \"b\": \"�\"}"
+	if got := string(ReplaceInvalidUTF8(input)); got != want {
+		t.Errorf("ReplaceInvalidUTF8() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DuplicateKeys selects what happens when an object repeats a key
+type DuplicateKeys string
+
+const (
+	// DuplicateLastWins keeps the last value, like encoding/json
+	DuplicateLastWins DuplicateKeys = "last"
+	// DuplicateFirstWins keeps the first value and ignores later ones
+	DuplicateFirstWins DuplicateKeys = "first"
+	// DuplicateError rejects the document
+	DuplicateError DuplicateKeys = "error"
+	// DuplicateArray collects every value into an array, in document order
+	DuplicateArray DuplicateKeys = "array"
+)
+
+// ParseDuplicateKeys validates a duplicate-key strategy name; empty means last-wins
+func ParseDuplicateKeys(name string) (DuplicateKeys, error) {
+	switch d := DuplicateKeys(name); d {
+	case "":
+		return DuplicateLastWins, nil
+	case DuplicateLastWins, DuplicateFirstWins, DuplicateError, DuplicateArray:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown duplicate key strategy %q (use last, first, error or array)", name)
+}
+
+// DuplicateKeyError is returned by the DuplicateError strategy
+type DuplicateKeyError struct {
+	Key string
+	// Offset is the byte offset of the repeated key
+	Offset int64
+	// Line and Column are 1-based, and set by Decode
+	Line   int
+	Column int
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d, column %d: duplicate key %q", e.Line, e.Column, e.Key)
+	}
+	return fmt.Sprintf("duplicate key %q at offset %d", e.Key, e.Offset)
+}
+
+// decoder builds the value tree from the token stream of encoding/json, which
+// unlike json.Unmarshal lets us see every occurrence of a repeated key
+type decoder struct {
+	data       []byte
+	dec        *json.Decoder
+	duplicates DuplicateKeys
+	// order, when set, receives the key order of every object
+	order *KeyOrder
+}
+
+// decodeValue parses data into maps, slices and scalars
+func decodeValue(data []byte, duplicates DuplicateKeys, order *KeyOrder) (interface{}, error) {
+	d := &decoder{
+		data:       data,
+		dec:        json.NewDecoder(bytes.NewReader(data)),
+		duplicates: duplicates,
+		order:      order,
+	}
+	d.dec.UseNumber()
+
+	value, err := d.value()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	// Anything after the top-level value is an error, as with json.Unmarshal
+	end := int(d.dec.InputOffset())
+	if _, err := d.dec.Token(); !errors.Is(err, io.EOF) {
+		offset := end + len(data[end:]) - len(bytes.TrimLeft(data[end:], " \t\r\n"))
+		return nil, &SyntaxError{Msg: "unexpected data after top-level value", Offset: offset}
+	}
+
+	return value, nil
+}
+
+// value reads one complete value
+func (d *decoder) value() (interface{}, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return d.object()
+		case '[':
+			return d.array()
+		}
+		return nil, &SyntaxError{Msg: fmt.Sprintf("unexpected %q", t), Offset: int(d.dec.InputOffset()) - 1}
+	case json.Number:
+		return decodeNumber(t), nil
+	default:
+		return t, nil
+	}
+}
+
+// object reads the members of an object whose opening brace was consumed
+func (d *decoder) object() (interface{}, error) {
+	obj := make(map[string]interface{})
+	// merged tracks keys already turned into arrays by DuplicateArray
+	var merged map[string]bool
+	// keys lists each key once, where it first appeared
+	var keys []string
+
+	for d.dec.More() {
+		// The key starts after the separator that precedes it
+		offset := d.dec.InputOffset()
+		offset += int64(len(d.data[offset:]) - len(bytes.TrimLeft(d.data[offset:], ", \t\r\n")))
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+
+		val, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		existing, dup := obj[key]
+		if !dup {
+			obj[key] = val
+			keys = append(keys, key)
+			continue
+		}
+
+		switch d.duplicates {
+		case DuplicateFirstWins:
+			// Keep the existing value
+		case DuplicateError:
+			return nil, &DuplicateKeyError{Key: key, Offset: offset}
+		case DuplicateArray:
+			if merged == nil {
+				merged = make(map[string]bool)
+			}
+			if merged[key] {
+				obj[key] = append(existing.([]interface{}), val)
+			} else {
+				obj[key] = []interface{}{existing, val}
+				merged[key] = true
+			}
+		default:
+			obj[key] = val
+		}
+	}
+
+	// Consume the closing brace
+	if _, err := d.dec.Token(); err != nil {
+		return nil, err
+	}
+	if d.order != nil {
+		d.order.Record(obj, keys)
+	}
+	return obj, nil
+}
+
+// array reads the elements of an array whose opening bracket was consumed
+func (d *decoder) array() (interface{}, error) {
+	arr := make([]interface{}, 0)
+
+	for d.dec.More() {
+		val, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+	}
+
+	// Consume the closing bracket
+	if _, err := d.dec.Token(); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
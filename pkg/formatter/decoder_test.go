@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDuplicateKeys(t *testing.T) {
+	input := `{"a":1,"b":{"c":true},"a":2,"a":[3]}`
+
+	tests := []struct {
+		name       string
+		duplicates DuplicateKeys
+		want       string
+		wantErr    bool
+	}{
+		{name: "Default is last wins", duplicates: "", want: `{"a":[3],"b":{"c":true}}`, wantErr: false},
+		{name: "Last wins", duplicates: DuplicateLastWins, want: `{"a":[3],"b":{"c":true}}`, wantErr: false},
+		{name: "First wins", duplicates: DuplicateFirstWins, want: `{"a":1,"b":{"c":true}}`, wantErr: false},
+		{name: "Merge into array", duplicates: DuplicateArray, want: `{"a":[1,2,[3]],"b":{"c":true}}`, wantErr: false},
+		{name: "Error", duplicates: DuplicateError, wantErr: true},
+		{name: "Unknown strategy", duplicates: "newest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(input), Options{DuplicateKeys: tt.duplicates})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			b, _ := json.Marshal(got)
+			if string(b) != tt.want {
+				t.Errorf("Decode() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	inputs := []string{
+		``,
+		`{"a":1`,
+		`{"a" 1}`,
+		`[1,]`,
+		`{"a":1} trailing`,
+		`{"a":1}{"b":2}`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Decode([]byte(input), Options{}); err == nil {
+			t.Errorf("Decode(%q) expected an error", input)
+		}
+	}
+}
+
+func TestDecodeMatchesUnmarshal(t *testing.T) {
+	input := `{"s":"xé","n":-1.5e3,"b":false,"z":null,"a":[{},[]],"o":{"k":[1,"2"]}}`
+
+	got, err := Decode([]byte(input), Options{})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	// Numbers keep their text, as with UseNumber
+	var want interface{}
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(&want); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("Decode() = %s, want %s", gotJSON, wantJSON)
+	}
+}
@@ -0,0 +1,268 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kindOrder fixes the order in which JSON types are listed
+var kindOrder = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// shape merges every value found at the same place in a document, such as one
+// field across all the records of an array
+type shape struct {
+	seen  int
+	kinds map[string]int
+	// keys holds the members of the object values, and names lists them in
+	// the order they were first seen
+	keys  map[string]*shape
+	names []string
+	// items holds the elements of the array values
+	items *shape
+}
+
+// newShape returns an empty shape
+func newShape() *shape {
+	return &shape{kinds: make(map[string]int)}
+}
+
+// add merges v into the shape, taking the keys of each object in the order
+// order gives
+func (s *shape) add(v interface{}, order *KeyOrder) {
+	s.seen++
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		s.kinds["object"]++
+		if s.keys == nil {
+			s.keys = make(map[string]*shape)
+		}
+		for _, k := range order.Keys(val) {
+			if s.keys[k] == nil {
+				s.keys[k] = newShape()
+				s.names = append(s.names, k)
+			}
+			s.keys[k].add(val[k], order)
+		}
+	case []interface{}:
+		s.kinds["array"]++
+		if s.items == nil {
+			s.items = newShape()
+		}
+		for _, item := range val {
+			s.items.add(item, order)
+		}
+	case string:
+		s.kinds["string"]++
+	case float64, json.Number:
+		s.kinds["number"]++
+	case bool:
+		s.kinds["boolean"]++
+	case nil:
+		s.kinds["null"]++
+	}
+}
+
+// only reports whether every value merged into s has the given kind
+func (s *shape) only(kind string) bool {
+	return s.seen > 0 && s.kinds[kind] == s.seen
+}
+
+// Describe returns a prose summary of the structure of v, one line per
+// container down to maxDepth levels. Arrays are summarized by the shape of
+// their elements rather than listed, so "an array of 1,204 objects, each
+// having id: number, name: string" stands in for every record. Keys are
+// listed in document order, as recorded in order, the first object that has
+// a key placing it among objects merged together; a nil order sorts them.
+func Describe(v interface{}, maxDepth int, order *KeyOrder) string {
+	root := newShape()
+	root.add(v, order)
+
+	d := &describer{maxDepth: maxDepth, sorted: order == nil}
+	d.line(0, strings.TrimPrefix(strings.TrimPrefix(d.summary(root), "an "), "a "))
+	d.children(root, 1)
+
+	return strings.TrimSuffix(d.sb.String(), "\n")
+}
+
+// describer accumulates the lines of a description
+type describer struct {
+	sb       strings.Builder
+	maxDepth int
+	sorted   bool
+}
+
+// keyNames returns the member names of s, sorted if keys are
+func (d *describer) keyNames(s *shape) []string {
+	if !d.sorted {
+		return s.names
+	}
+	names := slices.Clone(s.names)
+	sort.Strings(names)
+	return names
+}
+
+// line writes text at the given depth
+func (d *describer) line(depth int, text string) {
+	d.sb.WriteString(strings.Repeat("  ", depth) + text + "\n")
+}
+
+// children writes the lines that detail the contents of s
+func (d *describer) children(s *shape, depth int) {
+	if depth > d.maxDepth {
+		return
+	}
+
+	// A single object gets one line per key; merged objects and array elements
+	// already list their scalar fields inline, so only containers get a line
+	single := s.seen == 1 && s.only("object")
+	if s.kinds["object"] > 0 {
+		for _, k := range d.keyNames(s) {
+			child := s.keys[k]
+			if single || child.kinds["object"] > 0 || child.kinds["array"] > 0 {
+				d.line(depth, fmt.Sprintf("'%s' is %s", k, d.summary(child)))
+				d.children(child, depth+1)
+			}
+		}
+	}
+
+	if s.items != nil && s.items.seen > 0 {
+		// Mixed elements say which fields the object elements have, since the
+		// summary line only names the types
+		items := s.items
+		if items.kinds["object"] > 0 {
+			if !items.only("object") && len(items.keys) > 0 {
+				d.line(depth, "object items have "+d.fieldList(items))
+			}
+			d.children(items, depth)
+		}
+		switch {
+		case items.only("array"):
+			d.line(depth, "each item is "+d.summary(items))
+			d.children(items, depth+1)
+		case items.kinds["array"] > 0:
+			d.line(depth, "array items are "+d.arraySummary(items))
+			d.children(items, depth+1)
+		}
+	}
+}
+
+// summary describes the values merged into s in a single phrase
+func (d *describer) summary(s *shape) string {
+	switch {
+	case s.only("object"):
+		if s.seen == 1 {
+			if len(s.keys) == 0 {
+				return "an empty object"
+			}
+			return "an object with " + plural(len(s.keys), "key", "keys")
+		}
+		return "an object with keys " + d.fieldList(s)
+	case s.only("array"):
+		return d.arraySummary(s)
+	case s.seen == 1:
+		for _, kind := range kindOrder {
+			if s.kinds[kind] > 0 {
+				return article(kind)
+			}
+		}
+	}
+
+	return article(strings.Join(kindNames(s), " or "))
+}
+
+// arraySummary describes array values by the shape of their elements
+func (d *describer) arraySummary(s *shape) string {
+	items := s.items
+	if items == nil || items.seen == 0 {
+		return "an empty array"
+	}
+
+	var text string
+	if s.seen == 1 {
+		text = "an array of " + countNoun(items)
+	} else {
+		text = fmt.Sprintf("an array of %s (%s in total)", pluralNoun(items), thousands(items.seen))
+	}
+
+	if items.only("object") && len(items.keys) > 0 {
+		text += ", each having " + d.fieldList(items)
+	}
+	return text
+}
+
+// countNoun returns "1,204 objects", "3 strings" or "5 values (numbers, nulls)"
+func countNoun(items *shape) string {
+	n := thousands(items.seen)
+	if items.seen == 1 {
+		for _, kind := range kindOrder {
+			if items.kinds[kind] > 0 {
+				return n + " " + kind
+			}
+		}
+	}
+	return n + " " + pluralNoun(items)
+}
+
+// pluralNoun returns "objects" or "values (numbers, nulls)" for mixed elements
+func pluralNoun(items *shape) string {
+	names := kindNames(items)
+	if len(names) == 1 {
+		return names[0] + "s"
+	}
+	for i := range names {
+		names[i] += "s"
+	}
+	return "values (" + strings.Join(names, ", ") + ")"
+}
+
+// fieldList lists the members of merged objects with their types, noting keys
+// that only some of the objects have
+func (d *describer) fieldList(s *shape) string {
+	objects := s.kinds["object"]
+	fields := make([]string, 0, len(s.keys))
+	for _, k := range d.keyNames(s) {
+		child := s.keys[k]
+		field := k + ": " + strings.Join(kindNames(child), "|")
+		if child.seen < objects {
+			field += fmt.Sprintf(" (in %s of %s)", thousands(child.seen), thousands(objects))
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// kindNames returns the JSON types present in s
+func kindNames(s *shape) []string {
+	var names []string
+	for _, kind := range kindOrder {
+		if s.kinds[kind] > 0 {
+			names = append(names, kind)
+		}
+	}
+	return names
+}
+
+// article prefixes a type name with "a" or "an"; null takes none
+func article(name string) string {
+	switch {
+	case name == "null":
+		return name
+	case strings.IndexAny(name[:1], "aeiou") == 0:
+		return "an " + name
+	}
+	return "a " + name
+}
+
+// thousands formats n with comma separators, e.g. 1,204
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
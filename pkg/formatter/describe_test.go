@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxDepth int
+		want     string
+	}{
+		{
+			name:     "Records",
+			input:    `{"count":2,"items":[{"id":1,"name":"a","tags":["x","y"]},{"id":2,"name":null,"tags":[]}],"meta":{"next":null}}`,
+			maxDepth: 3,
+			want: `object with 3 keys
+  'count' is a number
+  'items' is an array of 2 objects, each having id: number, name: string|null, tags: array
+    'tags' is an array of strings (2 in total)
+  'meta' is an object with 1 key
+    'next' is null`,
+		},
+		{
+			name:     "Optional fields and mixed arrays",
+			input:    `[{"a":1},{"a":2,"b":true},[1,"x",null]]`,
+			maxDepth: 3,
+			want: `array of 3 values (objects, arrays)
+  object items have a: number, b: boolean (in 1 of 2)
+  array items are an array of values (strings, numbers, nulls) (3 in total)`,
+		},
+		{
+			name:     "Depth limit",
+			input:    `{"a":{"b":{"c":1}}}`,
+			maxDepth: 1,
+			want: `object with 1 key
+  'a' is an object with 1 key`,
+		},
+		{
+			name:     "Large counts and scalars",
+			input:    `"x"`,
+			maxDepth: 3,
+			want:     `string`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := Describe(v, tt.maxDepth, nil); got != tt.want {
+				t.Errorf("Describe() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeDocumentOrder(t *testing.T) {
+	opts := Options{KeyOrder: NewKeyOrder()}
+	v, err := Decode([]byte(`{"name":"x","id":1,"items":[{"z":1,"a":2},{"y":true,"z":3}]}`), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := `object with 3 keys
+  'name' is a string
+  'id' is a number
+  'items' is an array of 2 objects, each having z: number, a: number (in 1 of 2), y: boolean (in 1 of 2)`
+	if got := Describe(v, 3, opts.KeyOrder); got != want {
+		t.Errorf("Describe() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Without an order, keys are sorted
+	want = `object with 3 keys
+  'id' is a number
+  'items' is an array of 2 objects, each having a: number (in 1 of 2), y: boolean (in 1 of 2), z: number
+  'name' is a string`
+	if got := Describe(v, 3, nil); got != want {
+		t.Errorf("Describe() without an order =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestThousands(t *testing.T) {
+	for n, want := range map[int]string{0: "0", 999: "999", 1204: "1,204", 1234567: "1,234,567"} {
+		if got := thousands(n); got != want {
+			t.Errorf("thousands(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
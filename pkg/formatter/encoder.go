@@ -0,0 +1,381 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// TokenKind identifies the kind of token written by the encoder
+type TokenKind int
+
+const (
+	TokenKey TokenKind = iota
+	TokenString
+	TokenNumber
+	TokenBool
+	TokenNull
+	TokenPunctuation
+)
+
+// encoder writes a parsed JSON value as indented text, one token at a time,
+// so that each token can be styled independently
+type encoder struct {
+	buf     bytes.Buffer
+	indent  string
+	compact bool
+	// maxDepth, when positive, collapses containers nested this deep
+	maxDepth int
+	// maxStringLen and maxArrayItems, when positive, truncate longer strings and arrays
+	maxStringLen  int
+	maxArrayItems int
+	// wrapWidth, when positive, keeps containers on one line if they fit in
+	// this many columns. inline is set while writing such a container, and
+	// prefix is the width of the key and comma around the value being written.
+	wrapWidth int
+	inline    bool
+	prefix    int
+	// escapeHTML writes <, > and & as \u003c, \u003e and \u0026
+	escapeHTML bool
+	// order supplies the document order of keys unless sortKeys is set
+	order    *KeyOrder
+	sortKeys bool
+	keySort  KeySort
+	reverse  bool
+	priority []string
+	theme    *Theme
+	numbers  numberStyle
+
+	// highlight lists the paths whose keys and scalar values are emphasized
+	highlight []*jsonpath.Pattern
+	path      []jsonpath.Segment
+	emphasis  bool
+
+	// comments are written around the values they belong to; trailing holds
+	// the comments to write at the end of the current line
+	comments *Comments
+	trailing []string
+}
+
+// encodeDocument writes the root value along with the comments before and
+// after it
+func (e *encoder) encodeDocument(v interface{}) error {
+	if e.comments == nil {
+		return e.encode(v, 0)
+	}
+
+	for _, text := range e.comments.leading[""] {
+		e.buf.WriteString(text)
+		e.buf.WriteByte('\n')
+	}
+	if err := e.encode(v, 0); err != nil {
+		return err
+	}
+	e.trailing = e.comments.trailing[""]
+	e.flushTrailing()
+	for _, text := range e.comments.after {
+		e.buf.WriteByte('\n')
+		e.buf.WriteString(text)
+	}
+	return nil
+}
+
+// encode writes v at the given nesting depth
+func (e *encoder) encode(v interface{}, depth int) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && e.footer() == nil {
+			e.token(TokenPunctuation, "{}")
+			return nil
+		}
+		if e.collapsed(depth) {
+			e.token(TokenPunctuation, "{…"+plural(len(val), "key", "keys")+"}")
+			return nil
+		}
+		if ok, err := e.wrapInline(val, depth); ok || err != nil {
+			return err
+		}
+
+		keys := e.keys(val)
+
+		e.token(TokenPunctuation, "{")
+		for i, k := range keys {
+			if i > 0 {
+				e.comma()
+			}
+			e.newline(depth + 1)
+			e.enter(jsonpath.Key(k))
+			e.leadingComments(depth + 1)
+			if err := e.scalar(TokenKey, k); err != nil {
+				return err
+			}
+			e.token(TokenPunctuation, ":")
+			if !e.compact {
+				e.buf.WriteByte(' ')
+			}
+			e.prefix = utf8.RuneCountInString(k) + 4
+			if i < len(keys)-1 {
+				e.prefix++
+			}
+			if err := e.encode(val[k], depth+1); err != nil {
+				return err
+			}
+			e.trailingComments()
+			e.leave()
+		}
+		e.footerComments(depth + 1)
+		e.newline(depth)
+		e.token(TokenPunctuation, "}")
+	case []interface{}:
+		if len(val) == 0 && e.footer() == nil {
+			e.token(TokenPunctuation, "[]")
+			return nil
+		}
+		if e.collapsed(depth) {
+			e.token(TokenPunctuation, "[…"+plural(len(val), "item", "items")+"]")
+			return nil
+		}
+		if ok, err := e.wrapInline(val, depth); ok || err != nil {
+			return err
+		}
+
+		shown := val
+		if e.maxArrayItems > 0 && len(val) > e.maxArrayItems {
+			shown = val[:e.maxArrayItems]
+		}
+
+		e.token(TokenPunctuation, "[")
+		for i, item := range shown {
+			if i > 0 {
+				e.comma()
+			}
+			e.newline(depth + 1)
+			e.enter(jsonpath.Index(i))
+			e.leadingComments(depth + 1)
+			e.prefix = 0
+			if i < len(val)-1 {
+				e.prefix++
+			}
+			if err := e.encode(item, depth+1); err != nil {
+				return err
+			}
+			e.trailingComments()
+			e.leave()
+		}
+		if rest := len(val) - len(shown); rest > 0 {
+			e.comma()
+			e.newline(depth + 1)
+			e.token(TokenPunctuation, "…"+plural(rest, "more item", "more items"))
+		}
+		e.footerComments(depth + 1)
+		e.newline(depth)
+		e.token(TokenPunctuation, "]")
+	case string:
+		if e.maxStringLen > 0 && utf8.RuneCountInString(val) > e.maxStringLen {
+			return e.truncated(val)
+		}
+		return e.scalar(TokenString, val)
+	case bool:
+		return e.scalar(TokenBool, val)
+	case nil:
+		e.token(TokenNull, "null")
+	case float64:
+		if text, ok := e.numbers.text(val); ok {
+			e.token(TokenNumber, text)
+			return nil
+		}
+		return e.scalar(TokenNumber, val)
+	case json.Number:
+		// Numbers are written as they were read, unless a notation was asked
+		// for and a float64 holds them exactly
+		if f, ok := exactFloat(val); ok {
+			if text, ok := e.numbers.text(f); ok {
+				e.token(TokenNumber, text)
+				return nil
+			}
+		}
+		e.token(TokenNumber, string(val))
+	default:
+		return e.scalar(TokenNumber, val)
+	}
+
+	return nil
+}
+
+// truncated writes the start of a long string followed by its full length
+func (e *encoder) truncated(s string) error {
+	n := 0
+	for i := range s {
+		if n == e.maxStringLen {
+			if err := e.scalar(TokenString, s[:i]+"…"); err != nil {
+				return err
+			}
+			break
+		}
+		n++
+	}
+	e.token(TokenPunctuation, fmt.Sprintf(" (%d chars)", utf8.RuneCountInString(s)))
+	return nil
+}
+
+// wrapInline writes a container on one line, like [1, 2, 3], when that fits
+// within wrapWidth along with its indentation and key, and reports whether it
+// did. Containers holding comments are always written over several lines.
+func (e *encoder) wrapInline(v interface{}, depth int) (bool, error) {
+	if e.wrapWidth <= 0 || e.compact || e.inline || e.comments.Under(jsonpath.Pointer(e.path)).Len() > 0 {
+		return false, nil
+	}
+
+	// Measure the line without colors
+	plain := *e
+	plain.buf = bytes.Buffer{}
+	plain.theme = nil
+	plain.comments = nil
+	plain.inline = true
+	plain.path = clonePath(e.path)
+	if err := plain.encode(v, depth); err != nil {
+		return false, err
+	}
+	width := utf8.RuneCountInString(e.indent)*depth + e.prefix + utf8.RuneCount(plain.buf.Bytes())
+	if width > e.wrapWidth {
+		return false, nil
+	}
+
+	e.inline = true
+	err := e.encode(v, depth)
+	e.inline = false
+	return true, err
+}
+
+// collapsed reports whether a non-empty container at depth is summarized
+// instead of written out
+func (e *encoder) collapsed(depth int) bool {
+	return e.maxDepth > 0 && depth >= e.maxDepth
+}
+
+// keys returns the keys of obj in the order they are written
+func (e *encoder) keys(obj map[string]interface{}) []string {
+	if e.sortKeys {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sortKeys(keys, e.keySort, e.reverse)
+		return prioritize(keys, e.priority)
+	}
+	return prioritize(e.order.Keys(obj), e.priority)
+}
+
+// enter descends into seg, deciding whether the entry is highlighted
+func (e *encoder) enter(seg jsonpath.Segment) {
+	e.path = append(e.path, seg)
+	e.emphasis = len(e.highlight) > 0 && jsonpath.MatchAny(e.highlight, e.path)
+}
+
+// leave returns to the parent of the current path
+func (e *encoder) leave() {
+	e.path = e.path[:len(e.path)-1]
+	e.emphasis = false
+}
+
+// scalar marshals a single value and writes it as a token of the given kind.
+// <, > and & are only escaped when escapeHTML is set, so URLs and markup stay readable.
+func (e *encoder) scalar(kind TokenKind, v interface{}) error {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(e.escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	e.token(kind, strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+// token writes text, wrapped in the theme's style for kind when a theme is set
+func (e *encoder) token(kind TokenKind, text string) {
+	style := ""
+	if e.theme != nil {
+		style = e.theme.style(kind)
+		if e.emphasis && kind != TokenPunctuation && e.theme.Highlight != "" {
+			style = e.theme.Highlight
+		}
+	}
+
+	if style == "" {
+		e.buf.WriteString(text)
+		return
+	}
+
+	e.buf.WriteString(style)
+	e.buf.WriteString(text)
+	e.buf.WriteString(ansiReset)
+}
+
+// comma separates the entries of a container, followed by a space on a wrapped line
+func (e *encoder) comma() {
+	e.token(TokenPunctuation, ",")
+	if e.inline {
+		e.buf.WriteByte(' ')
+	}
+}
+
+// newline starts a new line indented to depth; compact output and wrapped
+// containers have none
+func (e *encoder) newline(depth int) {
+	if e.compact || e.inline {
+		return
+	}
+	e.flushTrailing()
+	e.buf.WriteByte('\n')
+	e.buf.WriteString(strings.Repeat(e.indent, depth))
+}
+
+// leadingComments writes the comments before the current entry, each on its
+// own line at depth
+func (e *encoder) leadingComments(depth int) {
+	if e.comments == nil {
+		return
+	}
+	for _, text := range e.comments.leading[jsonpath.Pointer(e.path)] {
+		e.buf.WriteString(text)
+		e.newline(depth)
+	}
+}
+
+// trailingComments queues the comments after the current entry, to be
+// written once its line is complete
+func (e *encoder) trailingComments() {
+	if e.comments == nil {
+		return
+	}
+	e.trailing = append(e.trailing, e.comments.trailing[jsonpath.Pointer(e.path)]...)
+}
+
+// footer returns the comments before the closing bracket of the current container
+func (e *encoder) footer() []string {
+	if e.comments == nil {
+		return nil
+	}
+	return e.comments.footer[jsonpath.Pointer(e.path)]
+}
+
+// footerComments writes the comments closing the current container
+func (e *encoder) footerComments(depth int) {
+	for _, text := range e.footer() {
+		e.newline(depth)
+		e.buf.WriteString(text)
+	}
+}
+
+// flushTrailing writes the queued trailing comments at the end of the line
+func (e *encoder) flushTrailing() {
+	for _, text := range e.trailing {
+		e.buf.WriteByte(' ')
+		e.buf.WriteString(text)
+	}
+	e.trailing = e.trailing[:0]
+}
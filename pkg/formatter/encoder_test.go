@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncoderMatchesMarshalIndent(t *testing.T) {
+	inputs := []string{
+		`{"name":"John","age":30,"tags":["a","b"],"meta":{}}`,
+		`[1,2.5,-3e+21,true,false,null,[],{"x":"<tag> & \"quote\""}]`,
+		`"plain string"`,
+		`{"nested":{"deeper":{"deepest":[{"k":1}]}}}`,
+	}
+
+	for _, input := range inputs {
+		var v interface{}
+		if err := json.Unmarshal([]byte(input), &v); err != nil {
+			t.Fatalf("invalid test input %s: %v", input, err)
+		}
+
+		want, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			t.Fatalf("MarshalIndent() error = %v", err)
+		}
+
+		enc := &encoder{indent: "  ", escapeHTML: true}
+		if err := enc.encode(v, 0); err != nil {
+			t.Fatalf("encode() error = %v", err)
+		}
+
+		if got := enc.buf.String(); got != string(want) {
+			t.Errorf("encode() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestEncoderCompact(t *testing.T) {
+	input := `{ "name": "John Doe", "tags": [ "a", "b" ], "meta": {}, "n": [ ] }`
+
+	got, err := Format([]byte(input), Options{IndentSpaces: 4, SortKeys: true, Compact: true})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"meta":{},"n":[],"name":"John Doe","tags":["a","b"]}`; string(got) != want {
+		t.Errorf("Format(Compact) = %s, want %s", got, want)
+	}
+}
+
+func TestEncoderEscapeHTML(t *testing.T) {
+	input := `{"url":"https://example.com/?a=1&b=<2>"}`
+
+	got, err := Format([]byte(input), Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(got) != input {
+		t.Errorf("Format() = %s, want %s", got, input)
+	}
+
+	got, err = Format([]byte(input), Options{Compact: true, EscapeHTML: true})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"url":"https://example.com/?a=1\u0026b=\u003c2\u003e"}`; string(got) != want {
+		t.Errorf("Format(EscapeHTML) = %s, want %s", got, want)
+	}
+}
+
+func TestEncoderMaxDepth(t *testing.T) {
+	input := `{"id":1,"user":{"name":"a","tags":["x"]},"items":[1,2,3],"one":[{}],"empty":{}}`
+
+	got, err := Format([]byte(input), Options{Compact: true, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"id":1,"user":{…2 keys},"items":[…3 items],"one":[…1 item],"empty":{}}`; string(got) != want {
+		t.Errorf("Format(MaxDepth 1) = %s, want %s", got, want)
+	}
+
+	got, err = Format([]byte(input), Options{Compact: true, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"id":1,"user":{"name":"a","tags":[…1 item]},"items":[1,2,3],"one":[{}],"empty":{}}`; string(got) != want {
+		t.Errorf("Format(MaxDepth 2) = %s, want %s", got, want)
+	}
+}
+
+func TestEncoderTruncation(t *testing.T) {
+	input := `{"blob":"aGVsbG8gd29ybGQ=","short":"ok","ids":[1,2,3,4],"pair":[1,2]}`
+
+	got, err := Format([]byte(input), Options{IndentSpaces: 2, MaxStringLen: 5, MaxArrayItems: 2})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := "{\n" +
+		"  \"blob\": \"aGVsb…\" (16 chars),\n" +
+		"  \"short\": \"ok\",\n" +
+		"  \"ids\": [\n    1,\n    2,\n    …2 more items\n  ],\n" +
+		"  \"pair\": [\n    1,\n    2\n  ]\n" +
+		"}"
+	if string(got) != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncoderWrapWidth(t *testing.T) {
+	input := `{"id":7,"tags":["a","b","c"],"point":{"x":1,"y":2},"long":["aaaaaaaaaa","bbbbbbbbbb","cccccccccc"],"nested":{"deep":[[1,2],[3,4]]}}`
+
+	got, err := Format([]byte(input), Options{IndentSpaces: 2, WrapWidth: 30})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := `{
+  "id": 7,
+  "tags": ["a", "b", "c"],
+  "point": {"x": 1, "y": 2},
+  "long": [
+    "aaaaaaaaaa",
+    "bbbbbbbbbb",
+    "cccccccccc"
+  ],
+  "nested": {
+    "deep": [[1, 2], [3, 4]]
+  }
+}`
+	if string(got) != want {
+		t.Errorf("Format(WrapWidth) =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, _ := Format([]byte(`{"a":[1]}`), Options{Compact: true, WrapWidth: 80}); string(got) != `{"a":[1]}` {
+		t.Errorf("Format(Compact, WrapWidth) = %s", got)
+	}
+}
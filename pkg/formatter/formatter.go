@@ -5,38 +5,147 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
 )
 
 // Options defines formatting options
 type Options struct {
 	IndentSpaces int
+	// IndentString, when set, is written for each level of indentation
+	// instead of IndentSpaces spaces, e.g. "\t"
+	IndentString string
 	SortKeys     bool
+	// KeySort and ReverseKeys select how SortKeys compares keys
+	KeySort     KeySort
+	ReverseKeys bool
+	// KeyPriority lists keys written first, in this order, whether or not
+	// the rest are sorted
+	KeyPriority []string
+	// KeyOrder, when set, receives the key order of the objects read by Decode,
+	// and FormatValue writes the keys of unsorted output in that order
+	KeyOrder *KeyOrder
+	// Compact writes the value on one line without any whitespace, ignoring IndentSpaces
+	Compact bool
+	// MaxDepth, when positive, writes containers nested this deep collapsed
+	// to a summary such as {…3 keys} or […120 items]. The result is a preview
+	// and no longer valid JSON.
+	MaxDepth int
+	// MaxStringLen and MaxArrayItems, when positive, shorten longer strings
+	// and arrays for previews, marking what was left out
+	MaxStringLen  int
+	MaxArrayItems int
+	// WrapWidth, when positive, writes objects and arrays on a single line,
+	// like [1, 2, 3], when that line including indentation fits in WrapWidth columns
+	WrapWidth int
+	// EscapeHTML writes <, > and & in strings as \u003c, \u003e and \u0026,
+	// as encoding/json does, for output embedded in HTML
+	EscapeHTML bool
+	// Theme colors the output when set; nil produces plain text
+	Theme *Theme
+	// Highlight lists keys or paths to emphasize with the theme's highlight style
+	Highlight []string
+	// DuplicateKeys selects how repeated object keys are resolved while decoding
+	DuplicateKeys DuplicateKeys
+	// Numbers selects plain or exponent notation; ExponentAbove and ExponentBelow
+	// are the magnitudes outside which NumbersExponent switches to exponent form
+	// (zero means the encoding/json thresholds)
+	Numbers       NumberFormat
+	ExponentAbove float64
+	ExponentBelow float64
+	// Comments, as returned by StripComments, are written back around the
+	// values they belong to; compact output drops them
+	Comments *Comments
 }
 
-// Format formats JSON data according to the provided options
+// Format formats JSON data according to the provided options. Unless keys
+// are sorted, they keep the order they had in data.
 func Format(data []byte, opts Options) ([]byte, error) {
-	var jsonObj interface{}
+	if opts.KeyOrder == nil {
+		opts.KeyOrder = NewKeyOrder()
+	}
+	jsonObj, err := Decode(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return FormatValue(jsonObj, opts)
+}
+
+// Decode parses JSON data into the value tree used by FormatValue,
+// resolving repeated keys according to opts.DuplicateKeys
+func Decode(data []byte, opts Options) (interface{}, error) {
+	duplicates, err := ParseDuplicateKeys(string(opts.DuplicateKeys))
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse JSON
-	if err := json.Unmarshal(data, &jsonObj); err != nil {
-		return nil, fmt.Errorf("invalid JSON: %v", err)
+	jsonObj, err := decodeValue(data, duplicates, opts.KeyOrder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", syntaxError(data, err))
 	}
 
+	return jsonObj, nil
+}
+
+// FormatValue formats an already decoded value according to the provided options
+func FormatValue(jsonObj interface{}, opts Options) ([]byte, error) {
 	// Sort keys if requested
 	if opts.SortKeys {
 		jsonObj = sortJSONKeys(jsonObj)
 	}
 
-	// Create indentation string
-	indent := strings.Repeat(" ", opts.IndentSpaces)
+	// Compile highlight rules, which only matter when coloring
+	var highlight []*jsonpath.Pattern
+	if opts.Theme != nil {
+		patterns, err := jsonpath.CompileAll(opts.Highlight)
+		if err != nil {
+			return nil, err
+		}
+		highlight = patterns
+	}
 
-	// Marshal with indentation
-	formattedJSON, err := json.MarshalIndent(jsonObj, "", indent)
+	numbers, err := newNumberStyle(opts)
+	if err != nil {
+		return nil, err
+	}
+	keySort, err := ParseKeySort(string(opts.KeySort))
 	if err != nil {
+		return nil, err
+	}
+
+	indent := strings.Repeat(" ", opts.IndentSpaces)
+	if opts.IndentString != "" {
+		indent = opts.IndentString
+	}
+
+	// Encode with indentation, token by token
+	enc := &encoder{
+		indent:        indent,
+		order:         opts.KeyOrder,
+		sortKeys:      opts.SortKeys,
+		keySort:       keySort,
+		reverse:       opts.ReverseKeys,
+		priority:      opts.KeyPriority,
+		compact:       opts.Compact,
+		escapeHTML:    opts.EscapeHTML,
+		maxDepth:      opts.MaxDepth,
+		maxStringLen:  opts.MaxStringLen,
+		wrapWidth:     opts.WrapWidth,
+		maxArrayItems: opts.MaxArrayItems,
+		theme:         opts.Theme,
+		numbers:       numbers,
+		highlight:     highlight,
+	}
+	if !opts.Compact {
+		enc.comments = opts.Comments
+	}
+	if err := enc.encodeDocument(jsonObj); err != nil {
 		return nil, fmt.Errorf("error formatting JSON: %v", err)
 	}
 
-	return formattedJSON, nil
+	return enc.buf.Bytes(), nil
 }
 
 // sortJSONKeys recursively sorts keys in JSON objects
@@ -80,36 +189,3 @@ func ValidateJSON(data []byte) (bool, error) {
 	}
 	return true, nil
 }
-
-// AutoCorrect attempts to fix common JSON syntax errors
-// This is a simple implementation and won't handle all cases
-func AutoCorrect(data []byte) ([]byte, error) {
-	str := string(data)
-
-	// Try to fix missing quotes around keys
-	// This is a very simplified approach
-	lines := strings.Split(str, "\n")
-	for i, line := range lines {
-		if strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			key := strings.TrimSpace(parts[0])
-
-			// If key doesn't start and end with quotes, add them
-			if !strings.HasPrefix(key, "\"") && !strings.HasSuffix(key, "\"") {
-				lines[i] = strings.Replace(line, key, "\""+key+"\"", 1)
-			}
-		}
-	}
-	str = strings.Join(lines, "\n")
-
-	// Try to fix trailing commas
-	str = strings.ReplaceAll(str, ",\n}", "\n}")
-	str = strings.ReplaceAll(str, ",\n]", "\n]")
-
-	// Validate the corrected JSON
-	if _, err := ValidateJSON([]byte(str)); err != nil {
-		return nil, fmt.Errorf("auto-correction failed: %v", err)
-	}
-
-	return []byte(str), nil
-}
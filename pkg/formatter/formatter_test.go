@@ -147,13 +147,13 @@ func TestAutoCorrect(t *testing.T) {
 		{
 			name:    "Severely malformed JSON",
 			input:   `{name:"John","age:30`,
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := AutoCorrect([]byte(tt.input))
+			got, err := AutoCorrect([]byte(tt.input), RepairOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("AutoCorrect() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -169,3 +169,13 @@ func TestAutoCorrect(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatIndentString(t *testing.T) {
+	got, err := Format([]byte(`{"a":[1]}`), Options{IndentSpaces: 4, IndentString: "\t"})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "{\n\t\"a\": [\n\t\t1\n\t]\n}"; string(got) != want {
+		t.Errorf("Format(IndentString) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxExactInteger is the largest integer an IEEE 754 double represents exactly (2^53 - 1)
+const maxExactInteger = 1<<53 - 1
+
+// IJSONError reports the first I-JSON (RFC 7493) violation found in a document
+type IJSONError struct {
+	Offset int
+	Reason string
+}
+
+func (e *IJSONError) Error() string {
+	return fmt.Sprintf("I-JSON violation at offset %d: %s", e.Offset, e.Reason)
+}
+
+// ValidateIJSON checks that data is an I-JSON message as defined by RFC 7493:
+// valid UTF-8 without surrogate or noncharacter code points, no duplicate object
+// keys, and numbers that an IEEE 754 double holds without overflow, underflow or,
+// for integers, loss of precision. When rejectScalars is set the top-level value
+// must also be an object or an array.
+func ValidateIJSON(data []byte, rejectScalars bool) error {
+	if !utf8.Valid(data) {
+		return &IJSONError{Offset: invalidUTF8Offset(data), Reason: "invalid UTF-8"}
+	}
+
+	if !json.Valid(data) {
+		_, err := decodeValue(data, DuplicateLastWins, nil)
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	// Duplicate keys are detected by the decoder
+	if _, err := decodeValue(data, DuplicateError, nil); err != nil {
+		var dup *DuplicateKeyError
+		if errors.As(err, &dup) {
+			return &IJSONError{Offset: int(dup.Offset), Reason: fmt.Sprintf("duplicate key %q", dup.Key)}
+		}
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	s := &ijsonScanner{data: data}
+	if rejectScalars {
+		if start := s.skipSpace(0); data[start] != '{' && data[start] != '[' {
+			return &IJSONError{Offset: start, Reason: "top-level value is not an object or array"}
+		}
+	}
+
+	return s.scan()
+}
+
+// ijsonScanner walks the raw bytes of a syntactically valid document, checking
+// string and number literals that encoding/json would silently normalize
+type ijsonScanner struct {
+	data []byte
+}
+
+// scan checks every string and number literal
+func (s *ijsonScanner) scan() error {
+	for i := 0; i < len(s.data); {
+		c := s.data[i]
+		switch {
+		case c == '"':
+			end, err := s.checkString(i)
+			if err != nil {
+				return err
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end, err := s.checkNumber(i)
+			if err != nil {
+				return err
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+// checkString validates the string literal starting at start and returns the offset after it
+func (s *ijsonScanner) checkString(start int) (int, error) {
+	for i := start + 1; i < len(s.data); {
+		c := s.data[i]
+		switch c {
+		case '"':
+			return i + 1, nil
+		case '\\':
+			if s.data[i+1] != 'u' {
+				i += 2
+				continue
+			}
+
+			r := s.hex4(i + 2)
+			if utf16.IsSurrogate(r) {
+				// A high surrogate must be followed by an escaped low surrogate
+				if r >= 0xDC00 || i+11 >= len(s.data) || s.data[i+6] != '\\' || s.data[i+7] != 'u' {
+					return 0, &IJSONError{Offset: i, Reason: fmt.Sprintf("unpaired surrogate \\u%04X", r)}
+				}
+				low := s.hex4(i + 8)
+				if low < 0xDC00 || low > 0xDFFF {
+					return 0, &IJSONError{Offset: i, Reason: fmt.Sprintf("unpaired surrogate \\u%04X", r)}
+				}
+				r = utf16.DecodeRune(r, low)
+				if isNoncharacter(r) {
+					return 0, &IJSONError{Offset: i, Reason: fmt.Sprintf("noncharacter U+%04X", r)}
+				}
+				i += 12
+				continue
+			}
+			if isNoncharacter(r) {
+				return 0, &IJSONError{Offset: i, Reason: fmt.Sprintf("noncharacter U+%04X", r)}
+			}
+			i += 6
+		default:
+			r, size := utf8.DecodeRune(s.data[i:])
+			if isNoncharacter(r) {
+				return 0, &IJSONError{Offset: i, Reason: fmt.Sprintf("noncharacter U+%04X", r)}
+			}
+			i += size
+		}
+	}
+	return len(s.data), nil
+}
+
+// hex4 decodes the four hex digits at offset i
+func (s *ijsonScanner) hex4(i int) rune {
+	n, _ := strconv.ParseUint(string(s.data[i:i+4]), 16, 32)
+	return rune(n)
+}
+
+// checkNumber validates the number literal starting at start and returns the offset after it
+func (s *ijsonScanner) checkNumber(start int) (int, error) {
+	end := start
+	for end < len(s.data) && isNumberByte(s.data[end]) {
+		end++
+	}
+	literal := string(s.data[start:end])
+
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, &IJSONError{Offset: start, Reason: fmt.Sprintf("invalid number %s", literal)}
+	}
+
+	switch {
+	case math.IsInf(f, 0):
+		return 0, &IJSONError{Offset: start, Reason: fmt.Sprintf("number %s overflows an IEEE 754 double", literal)}
+	case f == 0 && !isZeroLiteral(literal):
+		return 0, &IJSONError{Offset: start, Reason: fmt.Sprintf("number %s underflows an IEEE 754 double", literal)}
+	case f == math.Trunc(f) && math.Abs(f) > maxExactInteger:
+		return 0, &IJSONError{Offset: start, Reason: fmt.Sprintf("integer %s is outside the exact IEEE 754 range of ±(2^53-1)", literal)}
+	}
+
+	return end, nil
+}
+
+// skipSpace returns the offset of the first non-whitespace byte at or after i
+func (s *ijsonScanner) skipSpace(i int) int {
+	for i < len(s.data) && (s.data[i] == ' ' || s.data[i] == '\t' || s.data[i] == '\n' || s.data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// isNumberByte reports whether c can appear in a JSON number literal
+func isNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+// isZeroLiteral reports whether a number literal has only zero digits in its mantissa
+func isZeroLiteral(literal string) bool {
+	for _, c := range literal {
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if c >= '1' && c <= '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNoncharacter reports whether r is a Unicode noncharacter (U+FDD0..U+FDEF or U+xxFFFE/U+xxFFFF)
+func isNoncharacter(r rune) bool {
+	return (r >= 0xFDD0 && r <= 0xFDEF) || r&0xFFFE == 0xFFFE
+}
+
+// invalidUTF8Offset returns the offset of the first invalid UTF-8 sequence
+func invalidUTF8Offset(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(data)
+}
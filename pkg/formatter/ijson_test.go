@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateIJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		rejectScalars bool
+		wantErr       bool
+	}{
+		{name: "Plain object", input: `{"a":[1,2.5,-3e10],"b":"café ☕"}`, wantErr: false},
+		{name: "Escaped surrogate pair", input: `["\ud83d\ude00"]`, wantErr: false},
+		{name: "Largest exact integer", input: `[9007199254740991, -9007199254740991]`, wantErr: false},
+		{name: "Zero with exponent", input: `[0.0e-999]`, wantErr: false},
+		{name: "Top-level scalar allowed", input: `42`, wantErr: false},
+		{name: "Top-level scalar rejected", input: ` "x"`, rejectScalars: true, wantErr: true},
+		{name: "Invalid UTF-8", input: "{\"a\":\"\xff\"}", wantErr: true},
+		{name: "Lone high surrogate", input: `["\ud83d"]`, wantErr: true},
+		{name: "Lone low surrogate", input: `["\ude00x"]`, wantErr: true},
+		{name: "Escaped noncharacter", input: `["\uffff"]`, wantErr: true},
+		{name: "Raw noncharacter", input: "[\"\ufdd0\"]", wantErr: true},
+		{name: "Duplicate key", input: `{"a":1,"b":{"a":2,"a":3}}`, wantErr: true},
+		{name: "Integer beyond 2^53", input: `{"id":9007199254740993}`, wantErr: true},
+		{name: "Integral exponent beyond 2^53", input: `[1e16]`, wantErr: true},
+		{name: "Overflow", input: `[1e400]`, wantErr: true},
+		{name: "Underflow", input: `[1e-400]`, wantErr: true},
+		{name: "Malformed", input: `{"a":}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIJSON([]byte(tt.input), tt.rejectScalars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIJSON(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIJSONOffset(t *testing.T) {
+	err := ValidateIJSON([]byte(`{"ok": 1, "big": 12345678901234567890}`), false)
+
+	var ijsonErr *IJSONError
+	if !errors.As(err, &ijsonErr) {
+		t.Fatalf("ValidateIJSON() error = %v, want an *IJSONError", err)
+	}
+	if ijsonErr.Offset != 17 {
+		t.Errorf("Offset = %d, want 17", ijsonErr.Offset)
+	}
+}
@@ -0,0 +1,281 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Comments holds the comments of a JSONC document, keyed by the JSON Pointer
+// of the value they belong to, so that they can be written back around the
+// same values after formatting
+type Comments struct {
+	// leading comments stand on their own lines before a value
+	leading map[string][]string
+	// trailing comments follow a value on the same line
+	trailing map[string][]string
+	// footer comments come last inside a container, before its closing bracket
+	footer map[string][]string
+	// after comments follow the whole document
+	after []string
+}
+
+// newComments returns an empty set of comments
+func newComments() *Comments {
+	return &Comments{
+		leading:  make(map[string][]string),
+		trailing: make(map[string][]string),
+		footer:   make(map[string][]string),
+	}
+}
+
+// Len returns the number of comments
+func (c *Comments) Len() int {
+	if c == nil {
+		return 0
+	}
+	n := len(c.after)
+	for _, m := range []map[string][]string{c.leading, c.trailing, c.footer} {
+		for _, texts := range m {
+			n += len(texts)
+		}
+	}
+	return n
+}
+
+// Under returns the comments inside the value at a JSON Pointer, keyed
+// relative to that value, for output narrowed down with a pointer
+func (c *Comments) Under(ptr string) *Comments {
+	if c == nil || ptr == "" {
+		return c
+	}
+
+	sub := newComments()
+	for _, pair := range []struct{ from, to map[string][]string }{
+		{c.leading, sub.leading},
+		{c.trailing, sub.trailing},
+		{c.footer, sub.footer},
+	} {
+		for key, texts := range pair.from {
+			if key == ptr {
+				pair.to[""] = texts
+			} else if rest, ok := strings.CutPrefix(key, ptr+"/"); ok {
+				pair.to["/"+rest] = texts
+			}
+		}
+	}
+	return sub
+}
+
+// StripComments removes // line comments and /* */ block comments from JSONC
+// input, as used by VS Code settings files, and returns the comments found.
+// Comments are replaced by spaces, keeping line breaks, so that offsets and
+// line numbers in later error messages still match the input.
+func StripComments(data []byte) ([]byte, *Comments, error) {
+	s := &commentScanner{
+		out:      bytes.Clone(data),
+		comments: newComments(),
+	}
+	if err := s.scan(data); err != nil {
+		return nil, nil, err
+	}
+	return s.out, s.comments, nil
+}
+
+// containerFrame is an object or array the scanner is inside
+type containerFrame struct {
+	path      []jsonpath.Segment
+	object    bool
+	expectKey bool
+	key       string
+	index     int
+}
+
+// commentScanner walks JSONC text, tracking the location of every value so
+// that each comment can be attached to one
+type commentScanner struct {
+	out      []byte
+	comments *Comments
+	stack    []*containerFrame
+
+	// pending holds comments waiting for the value they precede
+	pending []string
+	// last is the pointer of the value completed most recently, and sameLine
+	// reports that no line break has been seen since
+	last     string
+	hasLast  bool
+	sameLine bool
+}
+
+// scan processes the whole input
+func (s *commentScanner) scan(data []byte) error {
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			s.sameLine = false
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			j := i + 2
+			for j < len(data) && data[j] != '\n' {
+				j++
+			}
+			s.comment(data, i, j)
+			i = j
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return syntaxError(data, &SyntaxError{Msg: "unterminated comment", Offset: i})
+			}
+			j := i + 2 + end + 2
+			s.comment(data, i, j)
+			if bytes.IndexByte(data[i:j], '\n') >= 0 {
+				s.sameLine = false
+			}
+			i = j
+		case c == '"':
+			j, ok := stringEnd(data, i)
+			if !ok {
+				return syntaxError(data, &SyntaxError{Msg: "unterminated string", Offset: i})
+			}
+			if top := s.top(); top != nil && top.object && top.expectKey {
+				var key string
+				if err := json.Unmarshal(data[i:j], &key); err != nil {
+					key = string(data[i+1 : j-1])
+				}
+				top.key = key
+				top.expectKey = false
+				s.attachPending(append(clonePath(top.path), jsonpath.Key(key)))
+			} else {
+				s.endValue(s.beginValue())
+			}
+			i = j
+		case c == '{' || c == '[':
+			path := s.beginValue()
+			s.stack = append(s.stack, &containerFrame{path: path, object: c == '{', expectKey: c == '{'})
+			s.sameLine = false
+			i++
+		case c == '}' || c == ']':
+			if top := s.top(); top != nil {
+				if len(s.pending) > 0 {
+					ptr := jsonpath.Pointer(top.path)
+					s.comments.footer[ptr] = append(s.comments.footer[ptr], s.pending...)
+					s.pending = nil
+				}
+				s.stack = s.stack[:len(s.stack)-1]
+				s.endValue(top.path)
+			}
+			i++
+		case c == ',':
+			if top := s.top(); top != nil {
+				if top.object {
+					top.expectKey = true
+				} else {
+					top.index++
+				}
+			}
+			i++
+		case c == ':':
+			i++
+		default:
+			// A number or literal runs to the next delimiter
+			j := i
+			for j < len(data) && !bytes.ContainsRune([]byte(" \t\r\n,:[]{}\"/"), rune(data[j])) {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			s.endValue(s.beginValue())
+			i = j
+		}
+	}
+
+	s.comments.after = append(s.comments.after, s.pending...)
+	return nil
+}
+
+// comment records the comment data[i:j] and blanks it out of the output
+func (s *commentScanner) comment(data []byte, i, j int) {
+	text := string(data[i:j])
+	if strings.HasPrefix(text, "//") {
+		text = strings.TrimRight(text, " \t\r")
+	}
+	for k := i; k < j; k++ {
+		if s.out[k] != '\n' {
+			s.out[k] = ' '
+		}
+	}
+
+	if s.hasLast && s.sameLine {
+		s.comments.trailing[s.last] = append(s.comments.trailing[s.last], text)
+		return
+	}
+	s.pending = append(s.pending, text)
+}
+
+// top returns the innermost container, or nil at the top level
+func (s *commentScanner) top() *containerFrame {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+// beginValue returns the path of the value starting here and gives it the
+// comments written before it
+func (s *commentScanner) beginValue() []jsonpath.Segment {
+	var path []jsonpath.Segment
+	if top := s.top(); top != nil {
+		path = clonePath(top.path)
+		if top.object {
+			path = append(path, jsonpath.Key(top.key))
+		} else {
+			path = append(path, jsonpath.Index(top.index))
+		}
+	}
+	s.attachPending(path)
+	return path
+}
+
+// endValue marks the value at path as complete, so that a comment on the
+// rest of the line trails it
+func (s *commentScanner) endValue(path []jsonpath.Segment) {
+	s.last = jsonpath.Pointer(path)
+	s.hasLast = true
+	s.sameLine = true
+}
+
+// attachPending makes the pending comments lead the value at path
+func (s *commentScanner) attachPending(path []jsonpath.Segment) {
+	if len(s.pending) == 0 {
+		return
+	}
+	ptr := jsonpath.Pointer(path)
+	s.comments.leading[ptr] = append(s.comments.leading[ptr], s.pending...)
+	s.pending = nil
+	s.hasLast = false
+}
+
+// stringEnd returns the offset just past the string starting at data[i], and
+// false when the input ends before the closing quote
+func stringEnd(data []byte, i int) (int, bool) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, true
+		}
+	}
+	return len(data), false
+}
+
+// clonePath copies a path so that appending to it does not share memory
+func clonePath(path []jsonpath.Segment) []jsonpath.Segment {
+	return append([]jsonpath.Segment(nil), path...)
+}
@@ -0,0 +1,130 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	input := "{\n  // note\n  \"url\": \"http://x/*y*/\", /* block\n  spans */ \"n\": 1\n}"
+
+	got, comments, err := StripComments([]byte(input))
+	if err != nil {
+		t.Fatalf("StripComments() error = %v", err)
+	}
+	if len(got) != len(input) || strings.Count(string(got), "\n") != strings.Count(input, "\n") {
+		t.Errorf("StripComments() changed offsets: %q", got)
+	}
+	if strings.Contains(string(got), "note") || !strings.Contains(string(got), `"http://x/*y*/"`) {
+		t.Errorf("StripComments() = %q", got)
+	}
+	if comments.Len() != 2 {
+		t.Errorf("StripComments() found %d comments, want 2", comments.Len())
+	}
+
+	if _, _, err := StripComments([]byte(`{"a": 1 /* open`)); err == nil {
+		t.Errorf("StripComments() accepted an unterminated comment")
+	}
+}
+
+func TestStripCommentsTruncated(t *testing.T) {
+	tests := []struct {
+		input  string
+		msg    string
+		offset int
+	}{
+		{`{"`, "unterminated string", 1},
+		{`{"a`, "unterminated string", 1},
+		{`{"a\"`, "unterminated string", 1},
+		{`{"a": "b`, "unterminated string", 6},
+		{`"`, "unterminated string", 0},
+		{`/*`, "unterminated comment", 0},
+		{`{"a": 1 /* x`, "unterminated comment", 8},
+	}
+
+	for _, tt := range tests {
+		_, _, err := StripComments([]byte(tt.input))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("StripComments(%q) error = %v, want a SyntaxError", tt.input, err)
+			continue
+		}
+		if syntaxErr.Msg != tt.msg || syntaxErr.Offset != tt.offset {
+			t.Errorf("StripComments(%q) = %q at %d, want %q at %d",
+				tt.input, syntaxErr.Msg, syntaxErr.Offset, tt.msg, tt.offset)
+		}
+	}
+
+	// A key cut off after its closing quote is still complete JSONC text
+	// as far as the comment scanner is concerned
+	if _, _, err := StripComments([]byte(`{"a"`)); err != nil {
+		t.Errorf("StripComments(%q) error = %v", `{"a"`, err)
+	}
+}
+
+func TestFormatKeepsComments(t *testing.T) {
+	input := `// settings
+{
+  // size in px
+  "size": 14, // default
+  "list": [1, // one
+    2],
+  "empty": {
+    // nothing yet
+  }
+}
+// end`
+
+	stripped, comments, err := StripComments([]byte(input))
+	if err != nil {
+		t.Fatalf("StripComments() error = %v", err)
+	}
+
+	opts := Options{IndentSpaces: 2, KeyOrder: NewKeyOrder(), Comments: comments}
+	v, err := Decode(stripped, opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got, err := FormatValue(v, opts)
+	if err != nil {
+		t.Fatalf("FormatValue() error = %v", err)
+	}
+
+	want := `// settings
+{
+  // size in px
+  "size": 14, // default
+  "list": [
+    1, // one
+    2
+  ],
+  "empty": {
+    // nothing yet
+  }
+}
+// end`
+	if string(got) != want {
+		t.Errorf("FormatValue() =\n%s\nwant\n%s", got, want)
+	}
+
+	opts.Compact = true
+	if got, _ := FormatValue(v, opts); strings.Contains(string(got), "//") {
+		t.Errorf("FormatValue(Compact) kept comments: %s", got)
+	}
+}
+
+func TestCommentsUnder(t *testing.T) {
+	_, comments, err := StripComments([]byte("{\"a\": {\n  // inner\n  \"b\": 1\n}}"))
+	if err != nil {
+		t.Fatalf("StripComments() error = %v", err)
+	}
+
+	sub := comments.Under("/a")
+	if texts := sub.leading["/b"]; len(texts) != 1 || texts[0] != "// inner" {
+		t.Errorf("Under() leading = %v", sub.leading)
+	}
+	if comments.Under("/x").Len() != 0 {
+		t.Errorf("Under() kept comments outside the pointer")
+	}
+}
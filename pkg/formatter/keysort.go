@@ -0,0 +1,158 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeySort selects how object keys are compared when they are sorted
+type KeySort string
+
+const (
+	// KeySortASCII compares keys byte by byte, so "B" comes before "a"
+	KeySortASCII KeySort = "ascii"
+	// KeySortIgnoreCase compares keys without regard to case
+	KeySortIgnoreCase KeySort = "ignore-case"
+	// KeySortNatural compares runs of digits by their numeric value, so "item2"
+	// comes before "item10"
+	KeySortNatural KeySort = "natural"
+)
+
+// ParseKeySort validates a key sort name; empty means ascii
+func ParseKeySort(name string) (KeySort, error) {
+	switch s := KeySort(name); s {
+	case "":
+		return KeySortASCII, nil
+	case KeySortASCII, KeySortIgnoreCase, KeySortNatural:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown key sort %q (use ascii, ignore-case or natural)", name)
+}
+
+// less reports whether key a sorts before key b. Keys that compare equal,
+// such as "a" and "A" when ignoring case, fall back to byte order so that
+// the result does not depend on map iteration.
+func (s KeySort) less(a, b string) bool {
+	switch s {
+	case KeySortIgnoreCase:
+		if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+			return la < lb
+		}
+	case KeySortNatural:
+		if c := compareNatural(a, b); c != 0 {
+			return c < 0
+		}
+	}
+	return a < b
+}
+
+// sortKeys sorts keys in place, in reverse when asked
+func sortKeys(keys []string, s KeySort, reverse bool) {
+	sort.Slice(keys, func(i, j int) bool {
+		if reverse {
+			return s.less(keys[j], keys[i])
+		}
+		return s.less(keys[i], keys[j])
+	})
+}
+
+// prioritize moves the keys listed in priority to the front, in the order
+// of the list, leaving the others in their current order
+func prioritize(keys []string, priority []string) []string {
+	if len(priority) == 0 {
+		return keys
+	}
+
+	present := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		present[k] = true
+	}
+
+	ordered := make([]string, 0, len(keys))
+	first := make(map[string]bool, len(priority))
+	for _, k := range priority {
+		if present[k] && !first[k] {
+			ordered = append(ordered, k)
+			first[k] = true
+		}
+	}
+	for _, k := range keys {
+		if !first[k] {
+			ordered = append(ordered, k)
+		}
+	}
+	return ordered
+}
+
+// compareNatural compares strings chunk by chunk, comparing runs of digits
+// by numeric value and everything else byte by byte
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		if da && db {
+			na, ra := digitRun(a)
+			nb, rb := digitRun(b)
+			// Without leading zeros, the longer run is the larger number
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return compareInt(len(ta), len(tb))
+			}
+			if c := strings.Compare(ta, tb); c != 0 {
+				return c
+			}
+			a, b = ra, rb
+			continue
+		}
+		if a[0] != b[0] {
+			return compareInt(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+	return compareInt(len(a), len(b))
+}
+
+// digitRun splits s after its leading run of ASCII digits
+func digitRun(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareInt returns -1, 0 or 1 as a is less than, equal to or greater than b
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Keys returns the keys of obj in the order FormatValue writes them with
+// these options, for writers of other formats. An invalid KeySort sorts
+// byte by byte.
+func (opts Options) Keys(obj map[string]interface{}) []string {
+	if !opts.SortKeys {
+		return prioritize(opts.KeyOrder.Keys(obj), opts.KeyPriority)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	s, err := ParseKeySort(string(opts.KeySort))
+	if err != nil {
+		s = KeySortASCII
+	}
+	sortKeys(keys, s, opts.ReverseKeys)
+	return prioritize(keys, opts.KeyPriority)
+}
@@ -0,0 +1,94 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortKeys(t *testing.T) {
+	keys := []string{"item10", "Beta", "item2", "alpha", "item02", "Alpha"}
+
+	tests := []struct {
+		name    string
+		sort    KeySort
+		reverse bool
+		want    []string
+	}{
+		{name: "ASCII", sort: KeySortASCII, want: []string{"Alpha", "Beta", "alpha", "item02", "item10", "item2"}},
+		{name: "Ignore case", sort: KeySortIgnoreCase, want: []string{"Alpha", "alpha", "Beta", "item02", "item10", "item2"}},
+		{name: "Natural", sort: KeySortNatural, want: []string{"Alpha", "Beta", "alpha", "item02", "item2", "item10"}},
+		{name: "Natural reversed", sort: KeySortNatural, reverse: true, want: []string{"item10", "item2", "item02", "alpha", "Beta", "Alpha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append([]string(nil), keys...)
+			sortKeys(got, tt.sort, tt.reverse)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"item2", "item10", -1},
+		{"item10", "item9", 1},
+		{"v1.10", "v1.9", 1},
+		{"a", "a1", -1},
+		{"007", "7", 0},
+		{"x", "x", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareNatural(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareNatural(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFormatKeyPriority(t *testing.T) {
+	input := `{"zeta":1,"name":"n","extra":true,"id":7}`
+
+	got, err := Format([]byte(input), Options{SortKeys: true, Compact: true, KeyPriority: []string{"id", "name", "missing"}})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"id":7,"name":"n","extra":true,"zeta":1}`; string(got) != want {
+		t.Errorf("Format(sorted) = %s, want %s", got, want)
+	}
+
+	got, err = Format([]byte(input), Options{Compact: true, KeyPriority: []string{"id"}})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"id":7,"zeta":1,"name":"n","extra":true}`; string(got) != want {
+		t.Errorf("Format(unsorted) = %s, want %s", got, want)
+	}
+
+	if _, err := Format([]byte(input), Options{SortKeys: true, KeySort: "random"}); err == nil {
+		t.Errorf("Format() accepted an unknown key sort")
+	}
+}
+
+func TestOptionsKeys(t *testing.T) {
+	opts := Options{KeyOrder: NewKeyOrder()}
+	v, err := Decode([]byte(`{"b": 1, "id": 2, "a": 3}`), opts)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	obj := v.(map[string]interface{})
+
+	if got, want := opts.Keys(obj), []string{"b", "id", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	opts.SortKeys = true
+	opts.KeyPriority = []string{"id"}
+	if got, want := opts.Keys(obj), []string{"id", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() with SortKeys = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat selects the notation used to write numbers
+type NumberFormat string
+
+const (
+	// NumbersAuto writes numbers as they were read, and computed ones like
+	// encoding/json: exponent form below 1e-6 and from 1e21
+	NumbersAuto NumberFormat = "auto"
+	// NumbersPlain always writes plain decimal notation, e.g. 1000000000000000000000
+	NumbersPlain NumberFormat = "plain"
+	// NumbersExponent uses exponent form outside the configured thresholds
+	NumbersExponent NumberFormat = "exponent"
+)
+
+const (
+	// DefaultExponentAbove is the magnitude from which encoding/json switches to exponent form
+	DefaultExponentAbove = 1e21
+	// DefaultExponentBelow is the magnitude under which encoding/json switches to exponent form
+	DefaultExponentBelow = 1e-6
+)
+
+// ParseNumberFormat validates a number format name; empty means auto
+func ParseNumberFormat(name string) (NumberFormat, error) {
+	switch f := NumberFormat(name); f {
+	case "":
+		return NumbersAuto, nil
+	case NumbersAuto, NumbersPlain, NumbersExponent:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown number format %q (use auto, plain or exponent)", name)
+}
+
+// exactDigits is the number of significant decimal digits that always
+// survive a round trip through float64
+const exactDigits = 15
+
+// maxExactExponent bounds the exponents checked exactly; beyond it a number
+// is kept as written rather than expanded into a huge rational
+const maxExactExponent = 400
+
+// decodeNumber converts a number to float64 when the float64 is written back
+// as the same text, so that every number keeps its text: 1.0, 1.10, 1E5 and
+// integers beyond 2^53 stay json.Number and are written back as they were.
+func decodeNumber(n json.Number) interface{} {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || floatText(f) != string(n) {
+		return n
+	}
+	return f
+}
+
+// exactFloat returns n as a float64, unless that would change its value, as
+// with integers beyond 2^53 or decimals with more digits than a float64
+// holds. Numbers it converts can be written in another notation.
+func exactFloat(n json.Number) (float64, bool) {
+	text := string(n)
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	mantissa, exp, _ := strings.Cut(strings.ToLower(text), "e")
+	e := 0
+	if exp != "" {
+		if e, err = strconv.Atoi(exp); err != nil || e > maxExactExponent || e < -maxExactExponent {
+			return 0, false
+		}
+	}
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(mantissa), "0")
+	if len(digits) <= exactDigits && e < 300 && e > -300 {
+		return f, true
+	}
+
+	// Compare the exact value with the shortest text of the float64
+	exact, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return 0, false
+	}
+	short, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok || exact.Cmp(short) != 0 {
+		return 0, false
+	}
+	return f, true
+}
+
+// floatText writes f as encoding/json does
+func floatText(f float64) string {
+	if abs := math.Abs(f); abs != 0 && (abs < DefaultExponentBelow || abs >= DefaultExponentAbove) {
+		return exponent(f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// numberStyle holds the resolved number options of one encoding
+type numberStyle struct {
+	format NumberFormat
+	above  float64
+	below  float64
+}
+
+// newNumberStyle validates the number options, filling in default thresholds
+func newNumberStyle(opts Options) (numberStyle, error) {
+	format, err := ParseNumberFormat(string(opts.Numbers))
+	if err != nil {
+		return numberStyle{}, err
+	}
+
+	style := numberStyle{format: format, above: opts.ExponentAbove, below: opts.ExponentBelow}
+	if style.above == 0 {
+		style.above = DefaultExponentAbove
+	}
+	if style.below == 0 {
+		style.below = DefaultExponentBelow
+	}
+	if style.above < 0 || style.below < 0 || style.below >= style.above {
+		return numberStyle{}, fmt.Errorf("invalid exponent thresholds: below (%g) must be positive and less than above (%g)", style.below, style.above)
+	}
+
+	return style, nil
+}
+
+// text writes f in the selected notation; it returns false for auto, which
+// leaves the number to encoding/json
+func (s numberStyle) text(f float64) (string, bool) {
+	switch s.format {
+	case NumbersPlain:
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	case NumbersExponent:
+		abs := math.Abs(f)
+		if abs >= s.above || (abs != 0 && abs < s.below) {
+			return exponent(f), true
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// exponent writes f in exponent form without a leading zero in the exponent,
+// e.g. 1e-7 rather than 1e-07, as encoding/json does
+func exponent(f float64) string {
+	b := strconv.AppendFloat(nil, f, 'e', -1, 64)
+	if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-2] == '0' {
+		b = append(b[:n-2], b[n-1])
+	}
+	return string(b)
+}
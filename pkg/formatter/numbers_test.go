@@ -0,0 +1,125 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatNumbers(t *testing.T) {
+	input := `[1e21,1.5e-7,123456.789,0,-2e30,42]`
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Auto keeps numbers as written",
+			opts: Options{},
+			want: `[1e21,1.5e-7,123456.789,0,-2e30,42]`,
+		},
+		{
+			name: "Plain never uses an exponent",
+			opts: Options{Numbers: NumbersPlain},
+			want: `[1000000000000000000000,0.00000015,123456.789,0,-2000000000000000000000000000000,42]`,
+		},
+		{
+			name: "Exponent with default thresholds",
+			opts: Options{Numbers: NumbersExponent},
+			want: `[1e+21,1.5e-7,123456.789,0,-2e+30,42]`,
+		},
+		{
+			name: "Exponent with custom thresholds",
+			opts: Options{Numbers: NumbersExponent, ExponentAbove: 1e5, ExponentBelow: 1e-3},
+			want: `[1e+21,1.5e-7,1.23456789e+5,0,-2e+30,42]`,
+		},
+		{
+			name:    "Unknown format",
+			opts:    Options{Numbers: "hex"},
+			wantErr: true,
+		},
+		{
+			name:    "Inverted thresholds",
+			opts:    Options{Numbers: NumbersExponent, ExponentAbove: 1e-3, ExponentBelow: 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(input), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Format() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// IndentSpaces is 0, so only newlines separate the elements
+			compact := strings.ReplaceAll(string(got), "\n", "")
+			if compact != tt.want {
+				t.Errorf("Format() = %s, want %s", compact, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatKeepsPrecision(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"id":9007199254740993}`, `{"id":9007199254740993}`},
+		{`[12345678901234567890,-12345678901234567890]`, `[12345678901234567890,-12345678901234567890]`},
+		{`3.14159265358979323846264338327950288`, `3.14159265358979323846264338327950288`},
+		{`[1E400,1e-999999999]`, `[1E400,1e-999999999]`},
+		// Numbers that float64 holds exactly are still normalized
+		{`[0.1,1.50,1e2,9007199254740992]`, `[0.1,1.5,100,9007199254740992]`},
+	}
+
+	for _, tt := range tests {
+		got, err := Format([]byte(tt.input), Options{Compact: true, Numbers: NumbersPlain})
+		if err != nil {
+			t.Fatalf("Format(%s) error = %v", tt.input, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Format(%s) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatKeepsNumberText(t *testing.T) {
+	input := `[1.0,1.10,1E5,-0,0.0,2.50,7,1.5e21,1e-7]`
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"Auto", Options{}, `[1.0,1.10,1E5,-0,0.0,2.50,7,1.5e21,1e-7]`},
+		{"Plain", Options{Numbers: NumbersPlain}, `[1,1.1,100000,-0,0,2.5,7,1500000000000000000000,0.0000001]`},
+		{"Exponent", Options{Numbers: NumbersExponent, ExponentAbove: 1e4}, `[1,1.1,1e+5,-0,0,2.5,7,1.5e+21,1e-7]`},
+	}
+	for _, tt := range tests {
+		tt.opts.Compact = true
+		got, err := Format([]byte(input), tt.opts)
+		if err != nil {
+			t.Fatalf("Format(%s) error = %v", input, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Format(%s) %s = %s, want %s", input, tt.name, got, tt.want)
+		}
+	}
+
+	v, err := Decode([]byte(input), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Canonical(v)
+	if err != nil {
+		t.Fatalf("Canonical() error = %v", err)
+	}
+	if want := `[1,1.1,100000,0,0,2.5,7,1.5e+21,1e-7]`; string(got) != want {
+		t.Errorf("Canonical() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package formatter
+
+import (
+	"reflect"
+	"sort"
+)
+
+// KeyOrder remembers the order in which the keys of decoded objects appeared,
+// which Go maps do not keep. Objects are identified by the map itself, so the
+// order follows an object wherever it moves (e.g. selected with a pointer or
+// reordered within an array) and survives in-place edits such as redaction.
+// Objects built afresh after decoding are written with sorted keys unless
+// their order is recorded with Record.
+type KeyOrder struct {
+	objects map[uintptr]orderedObject
+}
+
+// orderedObject pairs an object with its keys. Holding the map keeps it
+// alive, so its address cannot be reused by another map.
+type orderedObject struct {
+	obj  map[string]interface{}
+	keys []string
+}
+
+// NewKeyOrder returns an empty KeyOrder to be filled by Decode
+func NewKeyOrder() *KeyOrder {
+	return &KeyOrder{objects: make(map[uintptr]orderedObject)}
+}
+
+// Record stores the order of the keys of obj, for objects built after
+// decoding that should not be written with sorted keys. Recording into a nil
+// KeyOrder does nothing.
+func (o *KeyOrder) Record(obj map[string]interface{}, keys []string) {
+	if o == nil {
+		return
+	}
+	o.objects[reflect.ValueOf(obj).Pointer()] = orderedObject{obj: obj, keys: keys}
+}
+
+// Keys returns the keys of obj in document order. Keys added after decoding
+// follow in sorted order, and keys since removed are left out. A nil
+// KeyOrder sorts all keys.
+func (o *KeyOrder) Keys(obj map[string]interface{}) []string {
+	var recorded []string
+	if o != nil {
+		recorded = o.objects[reflect.ValueOf(obj).Pointer()].keys
+	}
+
+	keys := make([]string, 0, len(obj))
+	seen := make(map[string]bool, len(recorded))
+	for _, k := range recorded {
+		if _, ok := obj[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	rest := make([]string, 0, len(obj)-len(keys))
+	for k := range obj {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
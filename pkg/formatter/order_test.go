@@ -0,0 +1,57 @@
+package formatter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatKeepsKeyOrder(t *testing.T) {
+	input := `{"zeta":1,"alpha":{"b":true,"a":null},"mid":[{"y":1,"x":2}],"zeta":3}`
+
+	got, err := Format([]byte(input), Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"zeta":3,"alpha":{"b":true,"a":null},"mid":[{"y":1,"x":2}]}`; string(got) != want {
+		t.Errorf("Format() = %s, want %s", got, want)
+	}
+
+	sorted, err := Format([]byte(input), Options{Compact: true, SortKeys: true})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := `{"alpha":{"a":null,"b":true},"mid":[{"x":2,"y":1}],"zeta":3}`; string(sorted) != want {
+		t.Errorf("Format(SortKeys) = %s, want %s", sorted, want)
+	}
+}
+
+func TestKeyOrderFollowsEdits(t *testing.T) {
+	order := NewKeyOrder()
+	v, err := Decode([]byte(`{"c":1,"a":{"z":1,"y":2},"b":2}`), Options{KeyOrder: order})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	obj := v.(map[string]interface{})
+
+	// Nested objects keep their order when selected on their own
+	if got, want := order.Keys(obj["a"].(map[string]interface{})), []string{"z", "y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys(nested) = %v, want %v", got, want)
+	}
+
+	// Removed keys drop out, added keys follow in sorted order
+	delete(obj, "a")
+	obj["e"], obj["d"] = 1, 1
+	if got, want := order.Keys(obj), []string{"c", "b", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys(edited) = %v, want %v", got, want)
+	}
+
+	// Objects built after decoding are sorted
+	fresh := map[string]interface{}{"b": 1, "a": 2}
+	if got, want := order.Keys(fresh), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys(fresh) = %v, want %v", got, want)
+	}
+	var none *KeyOrder
+	if got, want := none.Keys(fresh), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nil Keys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,886 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+)
+
+// NonFinite selects what repair writes for the JavaScript values JSON lacks:
+// NaN, Infinity, -Infinity and undefined
+type NonFinite string
+
+const (
+	// NonFiniteNull writes null, as JSON.stringify does
+	NonFiniteNull NonFinite = "null"
+	// NonFiniteString writes the name as a string, such as "NaN", so the
+	// value can still be told apart
+	NonFiniteString NonFinite = "string"
+)
+
+// ParseNonFinite validates a non-finite policy name; empty means null
+func ParseNonFinite(name string) (NonFinite, error) {
+	switch n := NonFinite(name); n {
+	case "":
+		return NonFiniteNull, nil
+	case NonFiniteNull, NonFiniteString:
+		return n, nil
+	}
+	return "", fmt.Errorf("unknown non-finite policy %q (use null or string)", name)
+}
+
+// RepairOptions adjusts how AutoCorrect repairs its input
+type RepairOptions struct {
+	// NonFinite selects the replacement for NaN, Infinity, -Infinity and undefined
+	NonFinite NonFinite
+	// Python reads the output of Python's repr(): True, False and None, tuples,
+	// nan and inf, and string prefixes such as u'' and r''
+	Python bool
+}
+
+// repairState is what a repairer expects next within the current container
+type repairState int
+
+const (
+	// expectValue is the start of a value: the document, an array item or an object value
+	expectValue repairState = iota
+	// expectKey is the start of an object key
+	expectKey
+	// expectColon follows an object key
+	expectColon
+	// expectComma follows a complete entry, before the next one or the closing bracket
+	expectComma
+	// expectEnd follows the complete document
+	expectEnd
+)
+
+// repairer rewrites malformed JSON token by token. Strings are read whole, so
+// colons, commas and brackets inside them are never mistaken for structure.
+type repairer struct {
+	data []byte
+	pos  int
+	out  bytes.Buffer
+	opts RepairOptions
+
+	// stack holds the opening bracket of each enclosing container
+	stack []byte
+	state repairState
+
+	// space is the whitespace read since the last token, and comma is set when
+	// a comma was read there; both are written only once the next token shows
+	// whether they belong in the output
+	space   []byte
+	comma   bool
+	commaAt int
+
+	// truncated is set when the input ended in the middle of the document
+	truncated bool
+	fixes     []RepairFix
+}
+
+// RepairFix is one change Repair made to its input
+type RepairFix struct {
+	// Offset is the byte offset in the input; Line and Column are 1-based
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Rule names the kind of mistake fixed: unquoted-key, unquoted-value,
+	// single-quotes, smart-quotes, escape, missing-comma, extra-comma,
+	// trailing-comma, comment, unicode-space, unicode-dash, non-finite,
+	// python-literal, tuple or truncated
+	Rule string `json:"rule"`
+	// Before is the input text that was replaced and After its replacement;
+	// Before is empty for an insertion and After for a removal
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// fixTextLen is the longest text shown in a fix description
+const fixTextLen = 40
+
+// String describes the fix on one line, such as
+// line 2, column 5: unquoted-key: name → "name"
+func (f RepairFix) String() string {
+	var change string
+	switch {
+	case f.Before == "":
+		change = "inserted " + shorten(f.After)
+	case f.After == "":
+		change = "removed " + shorten(f.Before)
+	default:
+		change = shorten(f.Before) + " → " + shorten(f.After)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", f.Line, f.Column, f.Rule, change)
+}
+
+// shorten cuts text longer than fixTextLen runes, and keeps it on one line
+func shorten(text string) string {
+	text = strings.ReplaceAll(text, "\n", `\n`)
+	if runes := []rune(text); len(runes) > fixTextLen {
+		return string(runes[:fixTextLen]) + "…"
+	}
+	return text
+}
+
+// RepairReport describes the outcome of Repair
+type RepairReport struct {
+	// Output is the repaired document
+	Output []byte `json:"-"`
+	// Truncated is set when the input ended in the middle of the document,
+	// as a cut-off log line does, and was completed: open strings, arrays and
+	// objects were closed and a missing last value was set to null
+	Truncated bool `json:"truncated"`
+	// Fixes lists the changes made, in input order
+	Fixes []RepairFix `json:"fixes"`
+}
+
+// AutoCorrect repairs common JSON syntax errors: unquoted keys and values,
+// single-quoted strings, trailing, doubled and missing commas, NaN, Infinity
+// and undefined, and removes JavaScript comments. The input is read as a
+// sequence of tokens, so text inside strings is left alone, and whitespace is
+// kept as it was. Input that ends early is completed.
+func AutoCorrect(data []byte, opts RepairOptions) ([]byte, error) {
+	report, err := Repair(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return report.Output, nil
+}
+
+// Repair is AutoCorrect, reporting how the input was repaired
+func Repair(data []byte, opts RepairOptions) (*RepairReport, error) {
+	if _, err := ParseNonFinite(string(opts.NonFinite)); err != nil {
+		return nil, err
+	}
+
+	r := &repairer{data: data, opts: opts}
+	if err := r.run(); err != nil {
+		return nil, fmt.Errorf("auto-correction failed: %v", err)
+	}
+
+	// Validate the corrected JSON
+	fixed := r.out.Bytes()
+	if _, err := ValidateJSON(fixed); err != nil {
+		return nil, fmt.Errorf("auto-correction failed: %v", err)
+	}
+	// A trailing comma is only known to be one at the bracket, after any comments before it
+	sort.SliceStable(r.fixes, func(i, j int) bool { return r.fixes[i].Offset < r.fixes[j].Offset })
+	locate(data, r.fixes)
+	return &RepairReport{Output: fixed, Truncated: r.truncated, Fixes: r.fixes}, nil
+}
+
+// locate fills in the line and column of fixes, which are in input order
+func locate(data []byte, fixes []RepairFix) {
+	line, column, from := 1, 1, 0
+	for i := range fixes {
+		to := min(fixes[i].Offset, len(data))
+		l, c := diag.Position(data[from:], to-from)
+		if l > 1 {
+			line, column = line+l-1, c
+		} else {
+			column += c - 1
+		}
+		from = to
+		fixes[i].Line, fixes[i].Column = line, column
+	}
+}
+
+// ApplyFixes applies a selection of the fixes Repair reported for data, in
+// input order. Fixes that do not match data, or overlap a fix already
+// applied, are skipped. The result may not be valid JSON when fixes were left out.
+func ApplyFixes(data []byte, fixes []RepairFix) []byte {
+	var out bytes.Buffer
+	pos := 0
+	for _, f := range fixes {
+		end := f.Offset + len(f.Before)
+		if f.Offset < pos || end > len(data) || string(data[f.Offset:end]) != f.Before {
+			continue
+		}
+		out.Write(data[pos:f.Offset])
+		out.WriteString(f.After)
+		pos = end
+	}
+	out.Write(data[pos:])
+	return out.Bytes()
+}
+
+// fix records a change made to the input at offset
+func (r *repairer) fix(offset int, rule, before, after string) {
+	r.fixes = append(r.fixes, RepairFix{Offset: offset, Rule: rule, Before: before, After: after})
+}
+
+// run reads the whole input, writing the repaired document to out
+func (r *repairer) run() error {
+	for {
+		if err := r.skipSpace(); err != nil {
+			return err
+		}
+		if r.pos >= len(r.data) {
+			break
+		}
+
+		var err error
+		switch c := r.data[r.pos]; {
+		case c == '{' || c == '[' || c == '(' && r.opts.Python:
+			err = r.open(c)
+		case c == '}' || c == ']' || c == ')' && r.opts.Python:
+			err = r.close(c)
+		case c == ':':
+			err = r.colon()
+		case c == ',':
+			r.separator(r.pos)
+			r.pos++
+		case c == '"' || c == '\'':
+			err = r.str(r.pos, false)
+		case isBarewordByte(c):
+			err = r.bareword()
+		case c >= utf8.RuneSelf:
+			err = r.punctuation()
+		default:
+			err = r.unquotedValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(r.stack) > 0 || r.state != expectEnd {
+		if err := r.finish(); err != nil {
+			return err
+		}
+	}
+	r.out.Write(r.space)
+	return nil
+}
+
+// finish completes a document that ended early: a key without a value gets
+// null, a trailing comma is dropped and open containers are closed
+func (r *repairer) finish() error {
+	if len(r.stack) == 0 && r.state == expectValue {
+		return fmt.Errorf("unexpected end of input")
+	}
+
+	// Trailing whitespace stays at the very end
+	space := r.space
+	r.space = nil
+	if r.comma {
+		r.fix(r.commaAt, "trailing-comma", ",", "")
+		r.comma = false
+	}
+
+	var completion []byte
+	switch r.state {
+	case expectColon:
+		completion = []byte(": null")
+	case expectValue:
+		if r.stack[len(r.stack)-1] == '{' {
+			completion = []byte(" null")
+		}
+	}
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		completion = append(completion, closerOf(r.stack[i]))
+	}
+	r.emit(completion)
+	r.fix(len(r.data), "truncated", "", string(completion))
+
+	r.stack = r.stack[:0]
+	r.state = expectEnd
+	r.space = space
+	r.truncated = true
+	return nil
+}
+
+// skipSpace moves whitespace into space and drops // and /* */ comments
+func (r *repairer) skipSpace() error {
+	for r.pos < len(r.data) {
+		switch r.data[r.pos] {
+		case ' ', '\t', '\n', '\r':
+			r.space = append(r.space, r.data[r.pos])
+			r.pos++
+		case '/':
+			if ok, err := r.skipComment(); !ok || err != nil {
+				return err
+			}
+		default:
+			// Non-breaking and other Unicode spaces, as pasted from a word processor
+			c, size := utf8.DecodeRune(r.data[r.pos:])
+			if !isUnicodeSpace(c) {
+				return nil
+			}
+			r.fix(r.pos, "unicode-space", string(r.data[r.pos:r.pos+size]), " ")
+			r.space = append(r.space, ' ')
+			r.pos += size
+		}
+	}
+	return nil
+}
+
+// isUnicodeSpace reports whether c is a space character other than the ones
+// JSON allows between tokens
+func isUnicodeSpace(c rune) bool {
+	switch c {
+	case '\u00a0', '\u2002', '\u2003', '\u2007', '\u2009', '\u202f', '\u3000', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// skipComment moves past a comment at the current position, and reports
+// whether there was one. The line break ending a // comment is kept.
+func (r *repairer) skipComment() (bool, error) {
+	rest := r.data[r.pos:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("//")):
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+		r.fix(r.pos, "comment", strings.TrimRight(string(rest[:end]), "\r"), "")
+		r.pos += end
+	case bytes.HasPrefix(rest, []byte("/*")):
+		end := bytes.Index(rest[2:], []byte("*/"))
+		if end < 0 {
+			return false, fmt.Errorf("unterminated comment at offset %d", r.pos)
+		}
+		r.fix(r.pos, "comment", string(rest[:end+4]), "")
+		r.pos += end + 4
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// emit writes a token along with the comma and whitespace before it
+func (r *repairer) emit(text []byte) {
+	if r.comma {
+		r.out.WriteByte(',')
+		r.comma = false
+	}
+	r.out.Write(r.space)
+	r.space = r.space[:0]
+	r.out.Write(text)
+}
+
+// beginEntry prepares for a key or value about to be written, inserting the
+// comma missing between two entries such as [1 2]. It returns the state the
+// entry is read in.
+func (r *repairer) beginEntry() (repairState, error) {
+	if r.state == expectComma {
+		r.fix(r.pos, "missing-comma", "", ",")
+		r.separator(r.pos)
+	}
+	switch r.state {
+	case expectValue, expectKey:
+		return r.state, nil
+	}
+	return r.state, r.unexpected()
+}
+
+// endValue moves past a complete value
+func (r *repairer) endValue() {
+	if len(r.stack) == 0 {
+		r.state = expectEnd
+	} else {
+		r.state = expectComma
+	}
+}
+
+// separator handles a comma at offset. Commas with no entry before them, as
+// in [1,,2] or [,1], are dropped; the others wait to see whether an entry
+// follows.
+func (r *repairer) separator(offset int) {
+	if r.state != expectComma {
+		r.fix(offset, "extra-comma", ",", "")
+		return
+	}
+	r.comma = true
+	r.commaAt = offset
+	if r.stack[len(r.stack)-1] == '{' {
+		r.state = expectKey
+	} else {
+		r.state = expectValue
+	}
+}
+
+// open starts an object or array
+func (r *repairer) open(c byte) error {
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+	if state == expectKey {
+		return r.unexpected()
+	}
+
+	r.pos++
+	r.stack = append(r.stack, c)
+	if c == '(' {
+		// Python tuples become arrays
+		r.fix(r.pos-1, "tuple", "(", "[")
+		c = '['
+	}
+	r.emit([]byte{c})
+	if c == '{' {
+		r.state = expectKey
+	} else {
+		r.state = expectValue
+	}
+	return nil
+}
+
+// close ends the current object or array, dropping a trailing comma before it
+func (r *repairer) close(c byte) error {
+	if len(r.stack) == 0 || r.stack[len(r.stack)-1] != openerOf(c) {
+		return r.unexpected()
+	}
+	switch r.state {
+	case expectColon:
+		return r.unexpected()
+	case expectValue:
+		// Only an array may end where a value was expected, after [ or a comma
+		if c == '}' {
+			return r.unexpected()
+		}
+	}
+
+	if r.comma {
+		r.fix(r.commaAt, "trailing-comma", ",", "")
+		r.comma = false
+	}
+	if c == ')' {
+		r.fix(r.pos, "tuple", ")", "]")
+		c = ']'
+	}
+	r.pos++
+	r.emit([]byte{c})
+	r.stack = r.stack[:len(r.stack)-1]
+	r.endValue()
+	return nil
+}
+
+// openerOf returns the opening bracket matching a closing one
+func openerOf(c byte) byte {
+	switch c {
+	case '}':
+		return '{'
+	case ')':
+		return '('
+	}
+	return '['
+}
+
+// closerOf returns the bracket written to close an opening one; tuples are
+// written as arrays
+func closerOf(c byte) byte {
+	if c == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+// colon separates an object key from its value
+func (r *repairer) colon() error {
+	if r.state != expectColon {
+		return r.unexpected()
+	}
+	r.pos++
+	r.emit([]byte{':'})
+	r.state = expectValue
+	return nil
+}
+
+// str copies a string, converting single quotes and JavaScript escapes
+// to their JSON form. Backslashes in raw strings are taken literally. from is
+// the offset of the string's prefix, if it has one.
+func (r *repairer) str(from int, raw bool) error {
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+
+	start := r.pos
+	end, ok := quotedEnd(r.data, start)
+	if r.data[start] == '\'' {
+		end, ok = singleQuotedEnd(r.data, start)
+	}
+	var body []byte
+	if ok {
+		body = r.data[start+1 : end-1]
+	} else {
+		// The input ended inside the string
+		body = r.data[start+1:]
+		r.truncated = true
+	}
+	r.pos = end
+	if raw {
+		body = bytes.ReplaceAll(body, []byte(`\`), []byte(`\\`))
+	}
+	quoted := requote(body)
+	r.emit(quoted)
+
+	switch before := r.data[from:end]; {
+	case !ok:
+		r.fix(from, "truncated", string(before), string(quoted))
+	case from < start:
+		r.fix(from, "python-literal", string(before), string(quoted))
+	case r.data[start] == '\'':
+		r.fix(from, "single-quotes", string(before), string(quoted))
+	case !bytes.Equal(before, quoted):
+		r.fix(from, "escape", string(before), string(quoted))
+	}
+	r.afterEntry(state)
+	return nil
+}
+
+// punctuation handles typographic characters that word processors and chat
+// tools put in place of JSON syntax: curly quotes around strings, and dashes
+// in place of minus signs. Other characters start an unquoted value.
+func (r *repairer) punctuation() error {
+	c, size := utf8.DecodeRune(r.data[r.pos:])
+	switch c {
+	case '“', '”', '„':
+		return r.smartString(false)
+	case '‘', '’', '‚':
+		return r.smartString(true)
+	case '–', '—', '−':
+		if r.pos+size < len(r.data) && isDigit(r.data[r.pos+size]) {
+			return r.dashNumber(size)
+		}
+	}
+	return r.unquotedValue()
+}
+
+// smartString reads a string between curly quotes, or single curly quotes
+// when single is set. As with straight single quotes, a closing ’ that is
+// followed by more text is an apostrophe.
+func (r *repairer) smartString(single bool) error {
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+
+	start := r.pos
+	_, size := utf8.DecodeRune(r.data[start:])
+	bodyEnd, end := len(r.data), len(r.data)
+	for i := start + size; i < len(r.data); {
+		c, n := utf8.DecodeRune(r.data[i:])
+		if c == '\\' {
+			i += 2
+			continue
+		}
+		if isSmartCloser(c, single) && (!single || closesString(r.data[i+n:])) {
+			bodyEnd, end = i, i+n
+			break
+		}
+		i += n
+	}
+
+	r.pos = end
+	quoted := requote(r.data[start+size : bodyEnd])
+	r.emit(quoted)
+	rule := "smart-quotes"
+	if bodyEnd == len(r.data) {
+		rule = "truncated"
+		r.truncated = true
+	}
+	r.fix(start, rule, string(r.data[start:end]), string(quoted))
+	r.afterEntry(state)
+	return nil
+}
+
+// isSmartCloser reports whether c can close a string opened with a curly
+// quote: any quote of the same kind, curly or straight
+func isSmartCloser(c rune, single bool) bool {
+	if single {
+		return c == '‘' || c == '’' || c == '\''
+	}
+	return c == '“' || c == '”' || c == '"'
+}
+
+// dashNumber reads a number whose minus sign is an en dash, an em dash or a
+// Unicode minus sign of the given size in bytes
+func (r *repairer) dashNumber(size int) error {
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+	if state != expectValue {
+		return r.unexpected()
+	}
+
+	start := r.pos
+	end := start + size
+	for end < len(r.data) && isBarewordByte(r.data[end]) {
+		end++
+	}
+	number := append([]byte("-"), r.data[start+size:end]...)
+	if !isJSONScalar(number) {
+		r.unquoted()
+		return nil
+	}
+
+	r.pos = end
+	r.emit(number)
+	r.fix(start, "unicode-dash", string(r.data[start:end]), string(number))
+	r.endValue()
+	return nil
+}
+
+// bareword handles a run of unquoted characters: a literal or a number is
+// copied as a value, and keys and other values are quoted
+func (r *repairer) bareword() error {
+	start := r.pos
+	end := start
+	for end < len(r.data) && isBarewordByte(r.data[end]) {
+		end++
+	}
+	original := r.data[start:end]
+	word := original
+	if r.opts.Python {
+		if end < len(r.data) && (r.data[end] == '\'' || r.data[end] == '"') && isStringPrefix(word) {
+			r.pos = end
+			return r.str(start, bytes.ContainsAny(word, "rR"))
+		}
+		word = pythonLiteral(word)
+	}
+
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+	if state == expectValue && !isJSONScalar(word) && !isNonFinite(word) {
+		r.unquoted()
+		return nil
+	}
+
+	r.pos = end
+	switch {
+	case state == expectKey:
+		quoted, _ := json.Marshal(string(word))
+		r.emit(quoted)
+		r.fix(start, "unquoted-key", string(original), string(quoted))
+	case isNonFinite(word):
+		replacement := r.nonFinite(word)
+		r.emit(replacement)
+		r.fix(start, "non-finite", string(original), string(replacement))
+	default:
+		r.emit(word)
+		if !bytes.Equal(word, original) {
+			r.fix(start, "python-literal", string(original), string(word))
+		}
+	}
+	r.afterEntry(state)
+	return nil
+}
+
+// isStringPrefix reports whether word is a Python string prefix such as the
+// u in u'text'
+func isStringPrefix(word []byte) bool {
+	switch strings.ToLower(string(word)) {
+	case "u", "b", "r", "br", "rb":
+		return true
+	}
+	return false
+}
+
+// pythonLiteral translates the Python spelling of a literal to JavaScript's,
+// leaving other words unchanged
+func pythonLiteral(word []byte) []byte {
+	switch string(word) {
+	case "True":
+		return []byte("true")
+	case "False":
+		return []byte("false")
+	case "None":
+		return []byte("null")
+	case "nan":
+		return []byte("NaN")
+	case "inf":
+		return []byte("Infinity")
+	case "-inf":
+		return []byte("-Infinity")
+	}
+	return word
+}
+
+// isNonFinite reports whether word is one of the JavaScript values JSON lacks
+func isNonFinite(word []byte) bool {
+	switch string(word) {
+	case "NaN", "Infinity", "+Infinity", "-Infinity", "undefined":
+		return true
+	}
+	return false
+}
+
+// nonFinite returns the replacement for NaN, Infinity or undefined
+func (r *repairer) nonFinite(word []byte) []byte {
+	if r.opts.NonFinite != NonFiniteString {
+		return []byte("null")
+	}
+	quoted, _ := json.Marshal(strings.TrimPrefix(string(word), "+"))
+	return quoted
+}
+
+// unquotedValue quotes a value that starts with a character no JSON value
+// starts with, such as /usr/bin
+func (r *repairer) unquotedValue() error {
+	state, err := r.beginEntry()
+	if err != nil {
+		return err
+	}
+	if state != expectValue {
+		return r.unexpected()
+	}
+	r.unquoted()
+	return nil
+}
+
+// unquoted writes the value at the current position as a string. Without
+// quotes to end it, the value runs to the end of the line or to the next
+// comma or closing bracket, so {status: not found} and {url: http://x} keep
+// their spaces and colons.
+func (r *repairer) unquoted() {
+	start := r.pos
+	end := start
+	for end < len(r.data) && strings.IndexByte(",}]\r\n", r.data[end]) < 0 && !startsComment(r.data, end) {
+		end++
+	}
+	text := bytes.TrimRight(r.data[start:end], " \t")
+
+	r.pos = start + len(text)
+	quoted, _ := json.Marshal(string(text))
+	r.emit(quoted)
+	r.fix(start, "unquoted-value", string(text), string(quoted))
+	r.endValue()
+}
+
+// startsComment reports whether a comment starts at offset i of an unquoted
+// value. Only a comment after whitespace counts, so that the // in
+// http://example.com stays part of the value.
+func startsComment(data []byte, i int) bool {
+	if i == 0 || (data[i-1] != ' ' && data[i-1] != '\t') {
+		return false
+	}
+	return bytes.HasPrefix(data[i:], []byte("//")) || bytes.HasPrefix(data[i:], []byte("/*"))
+}
+
+// quotedEnd returns the offset just past the string starting with the quote
+// at start, and whether the closing quote was found
+func quotedEnd(data []byte, start int) (int, bool) {
+	quote := data[start]
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1, true
+		}
+	}
+	return len(data), false
+}
+
+// singleQuotedEnd is quotedEnd for a single-quoted string. An apostrophe
+// only closes the string when the string could end there: at the end of the
+// input or a line, or before a colon, a comma, a closing bracket or a
+// parenthesis. Others, as in 'it's', are part of the text.
+func singleQuotedEnd(data []byte, start int) (int, bool) {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '\'':
+			if closesString(data[i+1:]) {
+				return i + 1, true
+			}
+		}
+	}
+	return len(data), false
+}
+
+// closesString reports whether the text after a quote shows that the quote
+// ends a string
+func closesString(rest []byte) bool {
+	for _, c := range rest {
+		switch c {
+		case ' ', '\t', '\r':
+			continue
+		case '\n', ':', ',', '}', ']', ')':
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// requote writes the body of a string between double quotes, escaping double
+// quotes and converting the escapes JSON lacks: \' becomes ' and \xHH becomes \u00HH
+func requote(body []byte) []byte {
+	out := make([]byte, 0, len(body)+2)
+	out = append(out, '"')
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '"':
+			out = append(out, '\\', '"')
+		case c == '\\' && i+1 == len(body):
+			// A backslash cut off from what it escaped
+			out = append(out, '\\', '\\')
+		case c == '\\':
+			i++
+			switch next := body[i]; {
+			case next == '\'':
+				out = append(out, '\'')
+			case next == 'x' && i+2 < len(body) && isHex(body[i+1]) && isHex(body[i+2]):
+				out = append(out, `\u00`...)
+				out = append(out, body[i+1:i+3]...)
+				i += 2
+			default:
+				out = append(out, '\\', next)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return append(out, '"')
+}
+
+// isHex reports whether c is a hexadecimal digit
+func isHex(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// isBarewordByte reports whether c can be part of an unquoted key or value
+func isBarewordByte(c byte) bool {
+	return isLiteralByte(c) || c == '$'
+}
+
+// afterEntry moves past a key or a scalar value read in state
+func (r *repairer) afterEntry(state repairState) {
+	if state == expectKey {
+		r.state = expectColon
+		return
+	}
+	r.endValue()
+}
+
+// isJSONScalar reports whether word is a literal or a number as written in JSON
+func isJSONScalar(word []byte) bool {
+	switch string(word) {
+	case "true", "false", "null":
+		return true
+	}
+	var n json.Number
+	return json.Unmarshal(word, &n) == nil
+}
+
+// unexpected reports the token at the current position
+func (r *repairer) unexpected() error {
+	if r.pos >= len(r.data) {
+		return fmt.Errorf("unexpected end of input")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", tokenAt(r.data, r.pos), r.pos)
+}
@@ -0,0 +1,267 @@
+package formatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAutoCorrectRepairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unquoted keys", `{name: "John", $id: 1, first-name: "J"}`, `{"name": "John", "$id": 1, "first-name": "J"}`},
+		{"trailing commas", "{\"a\": [1, 2,],\n}", "{\"a\": [1, 2]\n}"},
+		{"doubled commas", `[1,, 2, ,3]`, `[1, 2, 3]`},
+		{"leading comma", `[, 1]`, `[ 1]`},
+		{"missing commas", "{\n  \"a\": 1\n  \"b\": [1 2]\n}", "{\n  \"a\": 1,\n  \"b\": [1, 2]\n}"},
+		{"missing comma after container", `[{"a": 1} {"b": 2}]`, `[{"a": 1}, {"b": 2}]`},
+		{"punctuation inside strings", `{url: "http://x/a,b", "note": "{a: 1,}"}`, `{"url": "http://x/a,b", "note": "{a: 1,}"}`},
+		{"escaped quotes", `{a: "say \"hi\", then: go",}`, `{"a": "say \"hi\", then: go"}`},
+		{"single quotes", `{'name': 'John', 'tags': ['a', 'b']}`, `{"name": "John", "tags": ["a", "b"]}`},
+		{"apostrophes", `{'msg': 'it\'s', 'note': 'don't, won't'}`, `{"msg": "it's", "note": "don't, won't"}`},
+		{"double quotes in single quotes", `['say "hi"']`, `["say \"hi\""]`},
+		{"javascript escapes", `['\x41\n', "\'"]`, `["\u0041\n", "'"]`},
+		{"unquoted values", `{status: ok, count: 3, ratio: 1.2.3}`, `{"status": "ok", "count": 3, "ratio": "1.2.3"}`},
+		{"unquoted values with spaces", "{reason: not found ,\n url: http://x/y?a=1 }", "{\"reason\": \"not found\", \n \"url\": \"http://x/y?a=1\" }"},
+		{"unquoted array items", "[red, /tmp, @me]", `["red", "/tmp", "@me"]`},
+		{"comments", "{\n  // name\n  name: 'x', /* id */ id: 1, // last\n}", "{\n  \n  \"name\": \"x\",  \"id\": 1 \n}"},
+		{"comment after unquoted value", "{url: http://x/y // home\n}", "{\"url\": \"http://x/y\" \n}"},
+		{"comment markers in strings", `{"a": "// not a comment", 'b': '/* nor this */'}`, `{"a": "// not a comment", "b": "/* nor this */"}`},
+		{"curly quotes", `{“name”: “John”, ‘tag’: ‘it’s’}`, `{"name": "John", "tag": "it’s"}`},
+		{"curly quotes inside strings", `{"quote": "“hi”", “a”: "b"}`, `{"quote": "“hi”", "a": "b"}`},
+		{"non-breaking spaces", "{\"a\":\u00a01,\u202f\"b\": 2}", `{"a": 1, "b": 2}`},
+		{"dashes", `{"a": –5, "b": −1.5, "c": —2, "d": "2–3"}`, `{"a": -5, "b": -1.5, "c": -2, "d": "2–3"}`},
+		{"numbers and literals", `{n: -1.5e3, t: true, f: false, z: null}`, `{"n": -1.5e3, "t": true, "f": false, "z": null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AutoCorrect([]byte(tt.input), RepairOptions{})
+			if err != nil {
+				t.Fatalf("AutoCorrect() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AutoCorrect() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoCorrectFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"missing value", `{"a": }`, `unexpected "}" at offset 6`},
+		{"mismatched bracket", `[1}`, `unexpected "}" at offset 2`},
+		{"empty input", "  ", "unexpected end of input"},
+		{"unterminated comment", `{"a": 1 /* b`, "unterminated comment at offset 8"},
+		{"second document", `{} {}`, `unexpected "{" at offset 3`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AutoCorrect([]byte(tt.input), RepairOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("AutoCorrect() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairTruncated(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"open containers", "{\"a\": [1, {\"b\": 2\n", "{\"a\": [1, {\"b\": 2}]}\n"},
+		{"open string", `{"a": ["x", "unfinished`, `{"a": ["x", "unfinished"]}`},
+		{"open key", `{name:"John","age:30`, `{"name":"John","age:30": null}`},
+		{"missing value", `{"a": 1, "b":`, `{"a": 1, "b": null}`},
+		{"trailing comma", `[1, 2,`, `[1, 2]`},
+		{"cut escape", `["a\`, `["a\\"]`},
+		{"tuple", `(1, (2`, `[1, [2]]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Repair([]byte(tt.input), RepairOptions{Python: true})
+			if err != nil {
+				t.Fatalf("Repair() error = %v", err)
+			}
+			if string(report.Output) != tt.want {
+				t.Errorf("Repair() = %s, want %s", report.Output, tt.want)
+			}
+			if !report.Truncated {
+				t.Error("Repair() did not report the input as truncated")
+			}
+		})
+	}
+
+	report, err := Repair([]byte(`[1, 2,]`), RepairOptions{})
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if report.Truncated {
+		t.Error("Repair() reported complete input as truncated")
+	}
+}
+
+func TestAutoCorrectNonFinite(t *testing.T) {
+	input := `{a: NaN, b: [Infinity, -Infinity, +Infinity], c: undefined, d: "NaN"}`
+	tests := []struct {
+		policy NonFinite
+		want   string
+	}{
+		{"", `{"a": null, "b": [null, null, null], "c": null, "d": "NaN"}`},
+		{NonFiniteNull, `{"a": null, "b": [null, null, null], "c": null, "d": "NaN"}`},
+		{NonFiniteString, `{"a": "NaN", "b": ["Infinity", "-Infinity", "Infinity"], "c": "undefined", "d": "NaN"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			got, err := AutoCorrect([]byte(input), RepairOptions{NonFinite: tt.policy})
+			if err != nil {
+				t.Fatalf("AutoCorrect() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AutoCorrect() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := AutoCorrect([]byte(input), RepairOptions{NonFinite: "zero"}); err == nil {
+		t.Error("AutoCorrect() accepted an unknown policy")
+	}
+}
+
+func TestAutoCorrectPython(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"literals", `{'a': True, 'b': False, 'c': None}`, `{"a": true, "b": false, "c": null}`},
+		{"tuples", `{'point': (1, 2), 'single': ('a',), 'pair': ('a', 'b'), 'empty': ()}`, `{"point": [1, 2], "single": ["a"], "pair": ["a", "b"], "empty": []}`},
+		{"non-finite", `[nan, inf, -inf]`, `[null, null, null]`},
+		{"string prefixes", `[u'caf\xe9', b'raw', r'C:\dir']`, `["caf\u00e9", "raw", "C:\\dir"]`},
+		{"apostrophes", `{'msg': "it's", 'n': 1}`, `{"msg": "it's", "n": 1}`},
+		{"keys", `{1: 'one', True: 'yes'}`, `{"1": "one", "true": "yes"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AutoCorrect([]byte(tt.input), RepairOptions{Python: true})
+			if err != nil {
+				t.Fatalf("AutoCorrect() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("AutoCorrect() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Without the Python option, the words are only quoted
+	got, err := AutoCorrect([]byte(`[True, (1)]`), RepairOptions{})
+	if err != nil {
+		t.Fatalf("AutoCorrect() error = %v", err)
+	}
+	if want := `["True", "(1)"]`; string(got) != want {
+		t.Errorf("AutoCorrect() = %s, want %s", got, want)
+	}
+}
+
+func TestRepairFixes(t *testing.T) {
+	input := "{name: 'x',\n  n: NaN // note\n  list: [1,, 2,],\n  ok: True, t: (1"
+	report, err := Repair([]byte(input), RepairOptions{Python: true})
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+
+	want := []RepairFix{
+		{Offset: 1, Line: 1, Column: 2, Rule: "unquoted-key", Before: "name", After: `"name"`},
+		{Offset: 7, Line: 1, Column: 8, Rule: "single-quotes", Before: "'x'", After: `"x"`},
+		{Offset: 14, Line: 2, Column: 3, Rule: "unquoted-key", Before: "n", After: `"n"`},
+		{Offset: 17, Line: 2, Column: 6, Rule: "non-finite", Before: "NaN", After: "null"},
+		{Offset: 21, Line: 2, Column: 10, Rule: "comment", Before: "// note", After: ""},
+		{Offset: 31, Line: 3, Column: 3, Rule: "missing-comma", Before: "", After: ","},
+		{Offset: 31, Line: 3, Column: 3, Rule: "unquoted-key", Before: "list", After: `"list"`},
+		{Offset: 40, Line: 3, Column: 12, Rule: "extra-comma", Before: ",", After: ""},
+		{Offset: 43, Line: 3, Column: 15, Rule: "trailing-comma", Before: ",", After: ""},
+		{Offset: 49, Line: 4, Column: 3, Rule: "unquoted-key", Before: "ok", After: `"ok"`},
+		{Offset: 53, Line: 4, Column: 7, Rule: "python-literal", Before: "True", After: "true"},
+		{Offset: 59, Line: 4, Column: 13, Rule: "unquoted-key", Before: "t", After: `"t"`},
+		{Offset: 62, Line: 4, Column: 16, Rule: "tuple", Before: "(", After: "["},
+		{Offset: 64, Line: 4, Column: 18, Rule: "truncated", Before: "", After: "]}"},
+	}
+	if !reflect.DeepEqual(report.Fixes, want) {
+		t.Errorf("Repair() fixes:\n%v\nwant:\n%v", report.Fixes, want)
+	}
+
+	// Applying every fix gives the same document, up to where missing commas go
+	applied := ApplyFixes([]byte(input), report.Fixes)
+	if want := "{\"name\": \"x\",\n  \"n\": null \n  ,\"list\": [1, 2],\n  \"ok\": true, \"t\": [1]}"; string(applied) != want {
+		t.Errorf("ApplyFixes() = %q, want %q", applied, want)
+	}
+
+	valid, err := Repair([]byte(`{"a": [1, "b"]}`), RepairOptions{})
+	if err != nil || len(valid.Fixes) != 0 {
+		t.Errorf("Repair() of valid JSON = %v, %v, want no fixes", valid.Fixes, err)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	input := `{a: 'x', b: NaN,}`
+	report, err := Repair([]byte(input), RepairOptions{})
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+
+	// Keep only the fixes to keys
+	var keys []RepairFix
+	for _, fix := range report.Fixes {
+		if fix.Rule == "unquoted-key" {
+			keys = append(keys, fix)
+		}
+	}
+	if got, want := string(ApplyFixes([]byte(input), keys)), `{"a": 'x', "b": NaN,}`; got != want {
+		t.Errorf("ApplyFixes() = %s, want %s", got, want)
+	}
+
+	// Fixes that do not match the input are skipped
+	stale := []RepairFix{{Offset: 1, Before: "z", After: `"z"`}}
+	if got := string(ApplyFixes([]byte(input), stale)); got != input {
+		t.Errorf("ApplyFixes() = %s, want the input unchanged", got)
+	}
+
+	// A truncated single-quoted string is closed and converted at once
+	report, err = Repair([]byte(`['it's`), RepairOptions{})
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if got, want := string(ApplyFixes([]byte(`['it's`), report.Fixes)), `["it's"]`; got != want {
+		t.Errorf("ApplyFixes() = %s, want %s", got, want)
+	}
+}
+
+func TestRepairFixString(t *testing.T) {
+	tests := []struct {
+		fix  RepairFix
+		want string
+	}{
+		{RepairFix{Line: 2, Column: 5, Rule: "unquoted-key", Before: "name", After: `"name"`}, `line 2, column 5: unquoted-key: name → "name"`},
+		{RepairFix{Line: 1, Column: 3, Rule: "missing-comma", After: ","}, "line 1, column 3: missing-comma: inserted ,"},
+		{RepairFix{Line: 1, Column: 9, Rule: "comment", Before: "/* a\nb */"}, `line 1, column 9: comment: removed /* a\nb */`},
+		{RepairFix{Line: 1, Column: 1, Rule: "comment", Before: "// " + strings.Repeat("x", 50)}, "line 1, column 1: comment: removed // " + strings.Repeat("x", 37) + "…"},
+	}
+	for _, tt := range tests {
+		if got := tt.fix.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
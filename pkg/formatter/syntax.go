@@ -0,0 +1,190 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+)
+
+// SyntaxError reports where input stops being valid JSON: the position, the
+// text found there and a snippet of the surrounding input
+type SyntaxError struct {
+	Msg string
+	// Offset is the byte offset of the offending token
+	Offset int
+	// Line and Column are 1-based; columns count bytes
+	Line   int
+	Column int
+	// Token is the text at Offset, empty at the end of the input
+	Token string
+	// Snippet shows the offending line and the one before it, with a caret
+	// under the error
+	Snippet string
+}
+
+// Error returns the position and the reason, without the snippet
+func (e *SyntaxError) Error() string {
+	msg := fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	if e.Token != "" && !strings.Contains(e.Msg, "'"+e.Token+"'") {
+		msg += fmt.Sprintf(" (found %q)", e.Token)
+	}
+	return msg
+}
+
+// snippetWidth is the number of columns of a line shown around an error, so
+// that minified documents on a single line still give a readable snippet
+const snippetWidth = 72
+
+// syntaxError maps a decoding error to a SyntaxError located in data.
+// Rejected duplicate keys keep their own type, with their line and column
+// filled in; other errors are returned unchanged.
+func syntaxError(data []byte, err error) error {
+	var located *SyntaxError
+	var jsonErr *json.SyntaxError
+	var dupErr *DuplicateKeyError
+	switch {
+	case errors.As(err, &dupErr):
+		dupErr.Line, dupErr.Column = diag.Position(data, int(dupErr.Offset))
+		return err
+	case errors.As(err, &located):
+		// Raised by the decoder itself, with the offset already known
+	case errors.As(err, &jsonErr):
+		// The token reader stops at the comma before a closing bracket, while
+		// the scanner behind json.Unmarshal, which fj validate reports with,
+		// stops at the bracket; rescanning gives both the same position and
+		// message
+		var scanErr *json.SyntaxError
+		if errors.As(json.Unmarshal(data, new(json.RawMessage)), &scanErr) {
+			jsonErr = scanErr
+		}
+		// The offset is just past the offending character, which may take
+		// several bytes, or the length of the input when it ends early
+		offset := min(int(jsonErr.Offset), len(data))
+		_, size := utf8.DecodeLastRune(data[:max(offset, 0)])
+		offset -= size
+		if strings.Contains(jsonErr.Error(), "end of JSON input") {
+			offset = len(data)
+		}
+		located = &SyntaxError{Msg: jsonErr.Error(), Offset: offset}
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		located = &SyntaxError{Msg: "unexpected end of input", Offset: len(data)}
+	default:
+		return err
+	}
+
+	located.Offset = min(max(located.Offset, 0), len(data))
+	located.Line, located.Column = diag.Position(data, located.Offset)
+	located.Token = tokenAt(data, located.Offset)
+	located.Snippet = snippet(data, located.Offset, located.Line)
+	return located
+}
+
+// tokenAt returns the token starting at offset: a literal or number, the
+// start of a string, or a single character
+func tokenAt(data []byte, offset int) string {
+	if offset >= len(data) {
+		return ""
+	}
+
+	rest := data[offset:]
+	end := 1
+	switch c := rest[0]; {
+	case c == '"':
+		end = min(len(rest), 21)
+		if i := strings.IndexByte(string(rest[1:end]), '"'); i >= 0 {
+			end = i + 2
+		}
+	case isLiteralByte(c):
+		for end < len(rest) && end < 20 && isLiteralByte(rest[end]) {
+			end++
+		}
+	default:
+		_, end = utf8.DecodeRune(rest)
+	}
+	return string(rest[:end])
+}
+
+// isLiteralByte reports whether c can be part of a literal or number
+func isLiteralByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '+' || c == '.' || c == '_'
+}
+
+// snippet renders the line holding offset and the line before it, numbered,
+// with a caret under the offending byte
+func snippet(data []byte, offset, line int) string {
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	// Byte offset of the error within its line
+	lineStart := strings.LastIndexByte(string(data[:offset]), '\n') + 1
+	current := lines[line-1]
+	column := min(offset-lineStart, len(current))
+
+	// Keep long lines to a window of characters around the error
+	from := 0
+	if before := current[:column]; utf8.RuneCountInString(before) > snippetWidth/2 {
+		from = column
+		for n := 0; n < snippetWidth/2; n++ {
+			_, size := utf8.DecodeLastRuneInString(current[:from])
+			from -= size
+		}
+	}
+	shown, caret := window(current, from, column)
+
+	gutter := len(fmt.Sprint(line))
+	var sb strings.Builder
+	if line > 1 && from == 0 {
+		prev, _ := window(lines[line-2], 0, 0)
+		fmt.Fprintf(&sb, "%*d | %s\n", gutter, line-1, prev)
+	}
+	fmt.Fprintf(&sb, "%*d | %s\n", gutter, line, shown)
+	fmt.Fprintf(&sb, "%*s | %s^", gutter, "", caret)
+	return sb.String()
+}
+
+// window cuts at most snippetWidth columns of line starting at byte from,
+// marking cut ends with an ellipsis, and returns it along with the padding
+// that puts a caret under byte column
+func window(line string, from, column int) (string, string) {
+	line = strings.TrimRight(line, "\r")
+	for from > 0 && from < len(line) && !utf8.RuneStart(line[from]) {
+		from++
+	}
+	to := len(line)
+	if utf8.RuneCountInString(line[min(from, len(line)):]) > snippetWidth {
+		to = from
+		for n := 0; n < snippetWidth && to < len(line); n++ {
+			_, size := utf8.DecodeRuneInString(line[to:])
+			to += size
+		}
+	}
+
+	prefix := ""
+	if from > 0 {
+		prefix = "…"
+	}
+	shown := prefix + line[min(from, len(line)):to]
+	if to < len(line) {
+		shown += "…"
+	}
+
+	// Tabs stay tabs so the caret lines up with the text above it
+	var caret strings.Builder
+	caret.WriteString(strings.Repeat(" ", utf8.RuneCountInString(prefix)))
+	for _, r := range line[min(from, len(line)):min(max(column, from), len(line))] {
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	return shown, caret.String()
+}
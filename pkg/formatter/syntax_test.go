@@ -0,0 +1,164 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSyntaxError(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		line    int
+		column  int
+		token   string
+		snippet string
+	}{
+		{
+			name:    "Missing comma",
+			input:   "{\n  \"a\": 1\n  \"b\": 2\n}",
+			line:    3,
+			column:  3,
+			token:   `"b"`,
+			snippet: "2 |   \"a\": 1\n3 |   \"b\": 2\n  |   ^",
+		},
+		{
+			name:    "Bad literal",
+			input:   `{"a": tru}`,
+			line:    1,
+			column:  10,
+			token:   "}",
+			snippet: "1 | {\"a\": tru}\n  |          ^",
+		},
+		{
+			name:    "Truncated",
+			input:   `[1, 2`,
+			line:    1,
+			column:  6,
+			token:   "",
+			snippet: "1 | [1, 2\n  |      ^",
+		},
+		{
+			name:    "Multibyte character",
+			input:   `{"a": é}`,
+			line:    1,
+			column:  7,
+			token:   "é",
+			snippet: "1 | {\"a\": é}\n  |       ^",
+		},
+		{
+			name:    "Smart quote after multibyte text",
+			input:   `{"café": “x”}`,
+			line:    1,
+			column:  11,
+			token:   "“",
+			snippet: "1 | {\"café\": “x”}\n  |          ^",
+		},
+		{
+			name:    "Trailing comma in array",
+			input:   `[1,2,]`,
+			line:    1,
+			column:  6,
+			token:   "]",
+			snippet: "1 | [1,2,]\n  |      ^",
+		},
+		{
+			name:    "Trailing comma in object",
+			input:   "{\n  \"a\": 1,\n}",
+			line:    3,
+			column:  1,
+			token:   "}",
+			snippet: "2 |   \"a\": 1,\n3 | }\n  | ^",
+		},
+		{
+			name:    "Trailing data",
+			input:   `{} x`,
+			line:    1,
+			column:  4,
+			token:   "x",
+			snippet: "1 | {} x\n  |    ^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.input), Options{})
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Decode() error = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column || syntaxErr.Token != tt.token {
+				t.Errorf("Decode() error at %d:%d %q, want %d:%d %q",
+					syntaxErr.Line, syntaxErr.Column, syntaxErr.Token, tt.line, tt.column, tt.token)
+			}
+			if syntaxErr.Snippet != tt.snippet {
+				t.Errorf("Snippet =\n%s\nwant\n%s", syntaxErr.Snippet, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorLongLine(t *testing.T) {
+	input := `{"items":[` + strings.Repeat(`"xxxxxxxxxx",`, 20) + `]}`
+
+	_, err := Decode([]byte(input), Options{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Decode() error = %v, want a SyntaxError", err)
+	}
+
+	lines := strings.Split(syntaxErr.Snippet, "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "1 | …") || !strings.HasSuffix(lines[0], "]}") {
+		t.Fatalf("Snippet = %q", syntaxErr.Snippet)
+	}
+	// The closing bracket after the trailing comma is reported
+	if caret := strings.Index(lines[1], "^"); []rune(lines[0])[caret] != ']' {
+		t.Errorf("caret does not point at the closing bracket: %q", syntaxErr.Snippet)
+	}
+}
+
+func TestSyntaxErrorLongMultibyteLine(t *testing.T) {
+	input := `{"items":[` + strings.Repeat(`"ééééééééé",`, 20) + `]}`
+
+	_, err := Decode([]byte(input), Options{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Decode() error = %v, want a SyntaxError", err)
+	}
+
+	lines := strings.Split(syntaxErr.Snippet, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Snippet = %q", syntaxErr.Snippet)
+	}
+	// Half the window lies before the error, counted in characters
+	caret := strings.Index(lines[1], "^")
+	if want := len("1 | …") - len("…") + 1 + snippetWidth/2; caret != want {
+		t.Errorf("caret at %d, want %d: %q", caret, want, syntaxErr.Snippet)
+	}
+	if []rune(lines[0])[caret] != ']' {
+		t.Errorf("caret does not point at the closing bracket: %q", syntaxErr.Snippet)
+	}
+}
+
+func TestDecodeKeepsDuplicateKeyError(t *testing.T) {
+	_, err := Decode([]byte(`{"a":1,"a":2}`), Options{DuplicateKeys: DuplicateError})
+	var dupErr *DuplicateKeyError
+	if !errors.As(err, &dupErr) {
+		t.Errorf("Decode() error = %v, want a DuplicateKeyError", err)
+	}
+}
+
+func TestDuplicateKeyErrorPosition(t *testing.T) {
+	_, err := Decode([]byte("{\n  \"a\": 1,\n  \"a\": 2\n}"), Options{DuplicateKeys: DuplicateError})
+	var dupErr *DuplicateKeyError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("Decode() error = %v, want a DuplicateKeyError", err)
+	}
+	if dupErr.Key != "a" || dupErr.Line != 3 || dupErr.Column != 3 {
+		t.Errorf("DuplicateKeyError = %+v, want key a at line 3, column 3", dupErr)
+	}
+	if want := `line 3, column 3: duplicate key "a"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("Error() = %q, want it to contain %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,206 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ansiReset = "\x1b[0m"
+
+// Theme holds the ANSI escape sequence used for each kind of token
+type Theme struct {
+	Key         string
+	String      string
+	Number      string
+	Bool        string
+	Null        string
+	Punctuation string
+	// Highlight replaces the token style of keys and values matched by highlight rules
+	Highlight string
+}
+
+// builtinThemes are the themes shipped with fj, described with the same
+// style syntax users can put in their config file
+var builtinThemes = map[string]map[string]string{
+	"dark": {
+		"key":       "bold blue",
+		"string":    "green",
+		"number":    "cyan",
+		"bool":      "yellow",
+		"null":      "magenta",
+		"highlight": "bold reverse",
+	},
+	"light": {
+		"key":       "bold #0000af",
+		"string":    "#005f00",
+		"number":    "#005f87",
+		"bool":      "#875f00",
+		"null":      "#870087",
+		"highlight": "bold reverse",
+	},
+	"solarized": {
+		"key":         "#268bd2",
+		"string":      "#2aa198",
+		"number":      "#d33682",
+		"bool":        "#b58900",
+		"null":        "#dc322f",
+		"punctuation": "#586e75",
+		"highlight":   "bold underline #cb4b16",
+	},
+	"monochrome": {
+		"key":       "bold",
+		"null":      "italic",
+		"highlight": "bold reverse",
+	},
+}
+
+// DefaultTheme is used for colored output when no theme is chosen
+const DefaultTheme = "dark"
+
+// BuiltinThemeNames returns the names of the themes shipped with fj
+func BuiltinThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupTheme resolves a theme by name, preferring user-defined themes over
+// built-ins. An empty name selects DefaultTheme.
+func LookupTheme(name string, custom map[string]map[string]string) (Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	if specs, ok := custom[name]; ok {
+		return NewTheme(specs)
+	}
+	if specs, ok := builtinThemes[name]; ok {
+		return NewTheme(specs)
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (built-in themes: %s)", name, strings.Join(BuiltinThemeNames(), ", "))
+}
+
+// NewTheme builds a theme from a map of token names (key, string, number, bool,
+// null, punctuation, highlight) to style specs such as "bold blue", "#ff8700" or "208"
+func NewTheme(specs map[string]string) (Theme, error) {
+	var theme Theme
+
+	for token, spec := range specs {
+		style, err := ParseStyle(spec)
+		if err != nil {
+			return Theme{}, fmt.Errorf("invalid style for %q: %v", token, err)
+		}
+
+		switch token {
+		case "key":
+			theme.Key = style
+		case "string":
+			theme.String = style
+		case "number":
+			theme.Number = style
+		case "bool":
+			theme.Bool = style
+		case "null":
+			theme.Null = style
+		case "punctuation":
+			theme.Punctuation = style
+		case "highlight":
+			theme.Highlight = style
+		default:
+			return Theme{}, fmt.Errorf("unknown token type %q", token)
+		}
+	}
+
+	return theme, nil
+}
+
+// style returns the escape sequence for a token kind
+func (t *Theme) style(kind TokenKind) string {
+	switch kind {
+	case TokenKey:
+		return t.Key
+	case TokenString:
+		return t.String
+	case TokenNumber:
+		return t.Number
+	case TokenBool:
+		return t.Bool
+	case TokenNull:
+		return t.Null
+	case TokenPunctuation:
+		return t.Punctuation
+	}
+	return ""
+}
+
+// styleAttributes maps attribute and color names to SGR parameters
+var styleAttributes = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"reverse":   "7",
+	"black":     "30",
+	"red":       "31",
+	"green":     "32",
+	"yellow":    "33",
+	"blue":      "34",
+	"magenta":   "35",
+	"cyan":      "36",
+	"white":     "37",
+	"gray":      "90",
+	"grey":      "90",
+
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+}
+
+// ParseStyle converts a space-separated style spec into an ANSI escape sequence.
+// Each word is an attribute or color name ("bold", "red", "bright-cyan"),
+// a 256-color palette index ("208") or a truecolor hex value ("#ff8700").
+// An empty spec yields an empty sequence.
+func ParseStyle(spec string) (string, error) {
+	var params []string
+
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if p, ok := styleAttributes[word]; ok {
+			params = append(params, p)
+			continue
+		}
+
+		if strings.HasPrefix(word, "#") {
+			hex := strings.TrimPrefix(word, "#")
+			if len(hex) != 6 {
+				return "", fmt.Errorf("hex color %q must have 6 digits", word)
+			}
+			rgb, err := strconv.ParseUint(hex, 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid hex color %q", word)
+			}
+			params = append(params, fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, (rgb>>8)&0xff, rgb&0xff))
+			continue
+		}
+
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			params = append(params, fmt.Sprintf("38;5;%d", n))
+			continue
+		}
+
+		return "", fmt.Errorf("unknown color or attribute %q", word)
+	}
+
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m", nil
+}
@@ -0,0 +1,106 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Empty", spec: "", want: "", wantErr: false},
+		{name: "Named color", spec: "red", want: "\x1b[31m", wantErr: false},
+		{name: "Attribute and color", spec: "bold blue", want: "\x1b[1;34m", wantErr: false},
+		{name: "Palette index", spec: "208", want: "\x1b[38;5;208m", wantErr: false},
+		{name: "Truecolor", spec: "#ff8700", want: "\x1b[38;2;255;135;0m", wantErr: false},
+		{name: "Unknown word", spec: "sparkly", wantErr: true},
+		{name: "Short hex", spec: "#fff", wantErr: true},
+		{name: "Palette out of range", spec: "300", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStyle(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStyle() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseStyle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupTheme(t *testing.T) {
+	for _, name := range BuiltinThemeNames() {
+		if _, err := LookupTheme(name, nil); err != nil {
+			t.Errorf("LookupTheme(%q) error = %v", name, err)
+		}
+	}
+
+	custom := map[string]map[string]string{
+		"dark": {"key": "red"},
+		"bad":  {"keys": "red"},
+	}
+
+	theme, err := LookupTheme("dark", custom)
+	if err != nil {
+		t.Fatalf("LookupTheme() error = %v", err)
+	}
+	if theme.Key != "\x1b[31m" || theme.String != "" {
+		t.Errorf("LookupTheme() did not prefer the user-defined theme: %+v", theme)
+	}
+
+	if _, err := LookupTheme("bad", custom); err == nil {
+		t.Errorf("LookupTheme() accepted an unknown token type")
+	}
+
+	if _, err := LookupTheme("missing", custom); err == nil {
+		t.Errorf("LookupTheme() accepted an unknown theme name")
+	}
+
+	if theme, err := LookupTheme("", custom); err != nil || theme.Key != "\x1b[31m" {
+		t.Errorf("LookupTheme(\"\") = %+v, %v, want the %s theme", theme, err, DefaultTheme)
+	}
+}
+
+func TestFormatWithTheme(t *testing.T) {
+	theme := Theme{Key: "\x1b[1m", Number: "\x1b[36m"}
+
+	got, err := Format([]byte(`{"a":1}`), Options{IndentSpaces: 2, Theme: &theme})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := "{\n  \x1b[1m\"a\"\x1b[0m: \x1b[36m1\x1b[0m\n}"
+	if string(got) != want {
+		t.Errorf("Format() = %q, want %q", string(got), want)
+	}
+}
+
+func TestFormatWithHighlight(t *testing.T) {
+	theme := Theme{Highlight: "\x1b[7m"}
+
+	got, err := Format([]byte(`{"data":{"status":"ok","id":1}}`), Options{
+		IndentSpaces: 2,
+		SortKeys:     true,
+		Theme:        &theme,
+		Highlight:    []string{"status"},
+	})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	want := "{\n  \"data\": {\n    \"id\": 1,\n    \x1b[7m\"status\"\x1b[0m: \x1b[7m\"ok\"\x1b[0m\n  }\n}"
+	if string(got) != want {
+		t.Errorf("Format() = %q, want %q", string(got), want)
+	}
+
+	if _, err := Format([]byte(`{}`), Options{Theme: &theme, Highlight: []string{"a["}}); err == nil {
+		t.Errorf("Format() accepted an invalid highlight rule")
+	}
+}
@@ -0,0 +1,337 @@
+// Package gostruct writes Go type declarations that a sample JSON document
+// decodes into
+package gostruct
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Options holds the settings of Generate
+type Options struct {
+	// Package names the package the declarations belong to
+	Package string
+	// Name names the type of the whole document
+	Name string
+	// Keys returns the keys of an object in the order their fields are
+	// declared; nil sorts them
+	Keys func(map[string]interface{}) []string
+}
+
+// initialisms are written in capitals in Go names, as in UserID
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "QPS": true, "RAM": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// shape merges every value found at the same place in a document, such as
+// one field across all the records of an array
+type shape struct {
+	seen  int
+	kinds map[string]int
+	// keys lists the members of the object values in the order they were
+	// first seen, and fields holds their shapes
+	keys   []string
+	fields map[string]*shape
+	// items holds the elements of the array values
+	items *shape
+}
+
+// newShape returns an empty shape
+func newShape() *shape {
+	return &shape{kinds: map[string]int{}}
+}
+
+// add merges v into the shape
+func (s *shape) add(v interface{}, keys func(map[string]interface{}) []string) {
+	s.seen++
+	switch val := v.(type) {
+	case map[string]interface{}:
+		s.kinds["object"]++
+		if s.fields == nil {
+			s.fields = map[string]*shape{}
+		}
+		for _, k := range keys(val) {
+			if s.fields[k] == nil {
+				s.fields[k] = newShape()
+				s.keys = append(s.keys, k)
+			}
+			s.fields[k].add(val[k], keys)
+		}
+	case []interface{}:
+		s.kinds["array"]++
+		if s.items == nil {
+			s.items = newShape()
+		}
+		for _, item := range val {
+			s.items.add(item, keys)
+		}
+	case string:
+		s.kinds["string"]++
+	case float64:
+		if val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 {
+			s.kinds["int"]++
+		} else {
+			s.kinds["float"]++
+		}
+	case json.Number:
+		if _, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			s.kinds["int"]++
+		} else {
+			s.kinds["float"]++
+		}
+	case bool:
+		s.kinds["bool"]++
+	case nil:
+		s.kinds["null"]++
+	}
+}
+
+// generator collects the type declarations of a document
+type generator struct {
+	decls []string
+	// bodies holds the struct bodies by type name, so that objects of the
+	// same shape share a type and objects of different shapes do not
+	bodies map[string]string
+	// root is the shape of the whole document, whose type name is reserved
+	// with an empty body
+	root *shape
+}
+
+// Generate writes the declarations of the types v decodes into: a struct
+// per kind of object, with json tags, slices for arrays, and pointers for
+// values that are sometimes null. Fields missing from some of the objects
+// are tagged omitempty.
+func Generate(v interface{}, opts Options) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("%q is not a valid package name", opts.Package)
+	}
+	if !token.IsIdentifier(opts.Name) {
+		return nil, fmt.Errorf("%q is not a valid type name", opts.Name)
+	}
+	keys := opts.Keys
+	if keys == nil {
+		keys = sortedKeys
+	}
+
+	root := newShape()
+	root.add(v, keys)
+	g := &generator{bodies: map[string]string{opts.Name: ""}, root: root}
+	switch {
+	case root.only("object"):
+		g.structType(root, opts.Name)
+	case root.only("array") && root.items.seen > 0:
+		// The elements of a top-level array are named after the document
+		g.insert(0, fmt.Sprintf("type %s []%s", opts.Name, g.goType(root.items, opts.Name+"Item")))
+	default:
+		g.insert(0, fmt.Sprintf("type %s %s", opts.Name, g.goType(root, opts.Name)))
+	}
+
+	src := "package " + opts.Package + "\n\n" + strings.Join(g.decls, "\n\n") + "\n"
+	return format.Source([]byte(src))
+}
+
+// goType returns the type of the values merged into s, declaring a struct
+// named name for objects
+func (g *generator) goType(s *shape, name string) string {
+	var kinds []string
+	for kind := range s.kinds {
+		if kind != "null" {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 2 && s.kinds["int"] > 0 && s.kinds["float"] > 0 {
+		kinds = []string{"float"}
+	}
+	if len(kinds) != 1 {
+		// Only nulls, or values of several kinds
+		return "interface{}"
+	}
+
+	var t string
+	switch kinds[0] {
+	case "object":
+		t = g.structType(s, name)
+	case "array":
+		if s.items.seen == 0 {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(s.items, singular(name))
+	case "string":
+		t = "string"
+	case "int":
+		t = "int64"
+	case "float":
+		t = "float64"
+	case "bool":
+		t = "bool"
+	}
+	if s.kinds["null"] > 0 {
+		return "*" + t
+	}
+	return t
+}
+
+// structType declares a struct for the objects merged into s and returns
+// its name: name itself, or name with a number when another struct has it
+func (g *generator) structType(s *shape, name string) string {
+	at := len(g.decls)
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	used := map[string]bool{}
+	for _, k := range s.keys {
+		if !validTag(k) {
+			fmt.Fprintf(&b, "// The key %s cannot be named in a json tag\n", strconv.Quote(k))
+			continue
+		}
+		field := unique(exportedName(k), used)
+		child := s.fields[k]
+		tag := k
+		if child.seen < s.kinds["object"] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s `json:%s`\n", field, g.goType(child, field), strconv.Quote(tag))
+	}
+	b.WriteString("}")
+	body := b.String()
+
+	for i := 1; ; i++ {
+		typeName := name
+		if i > 1 {
+			typeName += strconv.Itoa(i)
+		}
+		switch existing, ok := g.bodies[typeName]; {
+		case !ok, existing == "" && s == g.root:
+			g.bodies[typeName] = body
+			g.insert(at, "type "+typeName+" "+body)
+			return typeName
+		case existing == body:
+			return typeName
+		}
+	}
+}
+
+// insert adds a declaration before those of the types it uses, so that
+// every type is declared before the types it holds
+func (g *generator) insert(at int, decl string) {
+	g.decls = append(g.decls, "")
+	copy(g.decls[at+1:], g.decls[at:])
+	g.decls[at] = decl
+}
+
+// only reports whether every value merged into s has the given kind
+func (s *shape) only(kind string) bool {
+	return s.seen > 0 && s.kinds[kind] == s.seen
+}
+
+// exportedName turns a JSON key such as user_id or avatarUrl into an
+// exported Go name such as UserID or AvatarURL
+func exportedName(key string) string {
+	var b strings.Builder
+	for _, word := range words(key) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// words splits a key into its words, at punctuation and at the start of
+// every capitalized word, as in user_id, userId and HTTPServer
+func words(key string) []string {
+	var out []string
+	var word []rune
+	runes := []rune(key)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				out = append(out, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				out = append(out, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		out = append(out, string(word))
+	}
+	return out
+}
+
+// unique returns name, or name with a number when used already has it
+func unique(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// singular turns a plural field name into a name for its elements, e.g.
+// Addresses -> Address
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") &&
+		!strings.HasSuffix(name, "us") && !strings.HasSuffix(name, "is"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// validTag reports whether encoding/json accepts key as the name in a tag
+func validTag(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", r):
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys returns the keys of obj in sorted order
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
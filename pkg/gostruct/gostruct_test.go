@@ -0,0 +1,179 @@
+package gostruct
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// generate decodes doc and generates the types of package models
+func generate(t *testing.T, doc, name string) string {
+	t.Helper()
+	opts := formatter.Options{KeyOrder: formatter.NewKeyOrder()}
+	v, err := formatter.Decode([]byte(doc), opts)
+	if err != nil {
+		t.Fatalf("Decode(%s) error = %v", doc, err)
+	}
+	out, err := Generate(v, Options{Package: "models", Name: name, Keys: opts.Keys})
+	if err != nil {
+		t.Fatalf("Generate(%s) error = %v", doc, err)
+	}
+	return strings.TrimPrefix(string(out), "package models\n\n")
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			"Scalars, in document order",
+			`{"name": "fj", "stars": 42, "score": 4.5, "public": true}`,
+			"type Response struct {\n" +
+				"\tName   string  `json:\"name\"`\n" +
+				"\tStars  int64   `json:\"stars\"`\n" +
+				"\tScore  float64 `json:\"score\"`\n" +
+				"\tPublic bool    `json:\"public\"`\n" +
+				"}\n",
+		},
+		{
+			"Go names with initialisms",
+			`{"user_id": 1, "avatarUrl": "a", "HTTPServer": "b", "2fa": true}`,
+			"type Response struct {\n" +
+				"\tUserID     int64  `json:\"user_id\"`\n" +
+				"\tAvatarURL  string `json:\"avatarUrl\"`\n" +
+				"\tHTTPServer string `json:\"HTTPServer\"`\n" +
+				"\tX2fa       bool   `json:\"2fa\"`\n" +
+				"}\n",
+		},
+		{
+			"Nested structs and slices",
+			`{"owner": {"login": "a"}, "addresses": [{"zip": "1"}], "tags": ["x"], "empty": []}`,
+			"type Response struct {\n" +
+				"\tOwner     Owner         `json:\"owner\"`\n" +
+				"\tAddresses []Address     `json:\"addresses\"`\n" +
+				"\tTags      []string      `json:\"tags\"`\n" +
+				"\tEmpty     []interface{} `json:\"empty\"`\n" +
+				"}\n\n" +
+				"type Owner struct {\n" +
+				"\tLogin string `json:\"login\"`\n" +
+				"}\n\n" +
+				"type Address struct {\n" +
+				"\tZip string `json:\"zip\"`\n" +
+				"}\n",
+		},
+		{
+			"Array elements are merged",
+			`{"items": [{"sku": "a", "price": 1, "note": null}, {"sku": "b", "price": 2.5, "note": "x", "gift": true}]}`,
+			"type Response struct {\n" +
+				"\tItems []Item `json:\"items\"`\n" +
+				"}\n\n" +
+				"type Item struct {\n" +
+				"\tSku   string  `json:\"sku\"`\n" +
+				"\tPrice float64 `json:\"price\"`\n" +
+				"\tNote  *string `json:\"note\"`\n" +
+				"\tGift  bool    `json:\"gift,omitempty\"`\n" +
+				"}\n",
+		},
+		{
+			"Null and mixed values",
+			`{"parent": null, "mixed": [1, "a"]}`,
+			"type Response struct {\n" +
+				"\tParent interface{}   `json:\"parent\"`\n" +
+				"\tMixed  []interface{} `json:\"mixed\"`\n" +
+				"}\n",
+		},
+		{
+			"Objects of the same shape share a type",
+			`{"author": {"name": "a"}, "reviewers": [{"author": {"name": "b"}}], "team": {"author": {"id": 1}}}`,
+			"type Response struct {\n" +
+				"\tAuthor    Author     `json:\"author\"`\n" +
+				"\tReviewers []Reviewer `json:\"reviewers\"`\n" +
+				"\tTeam      Team       `json:\"team\"`\n" +
+				"}\n\n" +
+				"type Author struct {\n" +
+				"\tName string `json:\"name\"`\n" +
+				"}\n\n" +
+				"type Reviewer struct {\n" +
+				"\tAuthor Author `json:\"author\"`\n" +
+				"}\n\n" +
+				"type Team struct {\n" +
+				"\tAuthor Author2 `json:\"author\"`\n" +
+				"}\n\n" +
+				"type Author2 struct {\n" +
+				"\tID int64 `json:\"id\"`\n" +
+				"}\n",
+		},
+		{
+			"The document keeps its name",
+			`{"response": {"ok": true}}`,
+			"type Response struct {\n" +
+				"\tResponse Response2 `json:\"response\"`\n" +
+				"}\n\n" +
+				"type Response2 struct {\n" +
+				"\tOk bool `json:\"ok\"`\n" +
+				"}\n",
+		},
+		{
+			"Repeated Go names",
+			`{"user_id": 1, "userId": 2}`,
+			"type Response struct {\n" +
+				"\tUserID  int64 `json:\"user_id\"`\n" +
+				"\tUserID2 int64 `json:\"userId\"`\n" +
+				"}\n",
+		},
+		{
+			"Top-level arrays",
+			`[{"a": 1}, {"a": null, "b": "x"}]`,
+			"type Response []ResponseItem\n\n" +
+				"type ResponseItem struct {\n" +
+				"\tA *int64 `json:\"a\"`\n" +
+				"\tB string `json:\"b,omitempty\"`\n" +
+				"}\n",
+		},
+		{"Top-level scalars", `"x"`, "type Response string\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generate(t, tt.doc, "Response"); got != tt.want {
+				t.Errorf("Generate() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateKeysWithoutTags(t *testing.T) {
+	got := generate(t, `{"a,b": 1, "c": 2}`, "Doc")
+	if !strings.Contains(got, `// The key "a,b" cannot be named in a json tag`) || !strings.Contains(got, "C int64 `json:\"c\"`") {
+		t.Errorf("Generate() =\n%s", got)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate(nil, Options{Package: "my-models", Name: "Doc"}); err == nil || !strings.Contains(err.Error(), `"my-models" is not a valid package name`) {
+		t.Errorf("Generate() error = %v, want an invalid package name", err)
+	}
+	if _, err := Generate(nil, Options{Package: "models", Name: "1Doc"}); err == nil || !strings.Contains(err.Error(), `"1Doc" is not a valid type name`) {
+		t.Errorf("Generate() error = %v, want an invalid type name", err)
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"Items":     "Item",
+		"Addresses": "Address",
+		"Boxes":     "Box",
+		"Entries":   "Entry",
+		"Status":    "StatusItem",
+		"Class":     "ClassItem",
+		"Data":      "DataItem",
+	}
+	for in, want := range tests {
+		if got := singular(in); got != want {
+			t.Errorf("singular(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
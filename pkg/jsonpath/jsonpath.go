@@ -0,0 +1,356 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is one step of a concrete location inside a document:
+// either an object key or an array index
+type Segment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// Key returns an object key segment
+func Key(name string) Segment {
+	return Segment{Key: name}
+}
+
+// Index returns an array index segment
+func Index(i int) Segment {
+	return Segment{Index: i, IsIndex: true}
+}
+
+// String formats a concrete path in dotted notation, e.g. data.items[2].id
+func String(path []Segment) string {
+	var sb strings.Builder
+	for _, seg := range path {
+		if seg.IsIndex {
+			fmt.Fprintf(&sb, "[%d]", seg.Index)
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(seg.Key)
+	}
+	return sb.String()
+}
+
+// step is one element of a compiled pattern
+type step struct {
+	descendant bool   // matches at any depth below the previous step (..)
+	name       string // key glob, "*" matches any key
+	index      int
+	isIndex    bool
+	anyIndex   bool // [*]
+}
+
+// Pattern is a compiled path expression that can be matched against concrete paths
+type Pattern struct {
+	expr  string
+	steps []step
+}
+
+// String returns the expression the pattern was compiled from
+func (p *Pattern) String() string {
+	return p.expr
+}
+
+// Compile parses a path expression. Three forms are accepted:
+//   - a bare key such as "password" or "*secret*", which matches that key at any depth
+//   - a dotted path such as "data.items[*].id", anchored at the document root
+//   - a JSONPath-style expression such as "$.data..id" or "$['odd key'][0]"
+//
+// Key names may contain "*" wildcards; [*] matches any array index.
+func Compile(expr string) (*Pattern, error) {
+	return compile(expr, false)
+}
+
+// CompileRooted parses a path expression like Compile, except that a bare key
+// names a key of the root object rather than matching at any depth, so that
+// every expression without ".." is anchored at the document root.
+func CompileRooted(expr string) (*Pattern, error) {
+	return compile(expr, true)
+}
+
+// compile parses expr, matching a bare key at any depth unless rooted
+func compile(expr string, rooted bool) (*Pattern, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty path expression")
+	}
+
+	src := expr
+	switch {
+	case strings.HasPrefix(src, "$"):
+		src = src[1:]
+	case strings.HasPrefix(src, ".") || strings.HasPrefix(src, "["):
+		// Already written relative to the root
+	case !rooted && !strings.ContainsAny(src, ".["):
+		// Bare key: match it anywhere in the document
+		src = ".." + src
+	default:
+		src = "." + src
+	}
+
+	steps, err := parseSteps(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path expression %q: %v", expr, err)
+	}
+
+	return &Pattern{expr: expr, steps: steps}, nil
+}
+
+// ParsePath parses a concrete location such as "user.settings.theme",
+// "items[3]" or "$['odd key'][0]", always anchored at the document root. A
+// path starting with "/" is read as a JSON Pointer, where every token names a
+// key. Wildcards and ".." have no place in a concrete path; "*" in a key name
+// is taken literally.
+func ParsePath(expr string) ([]Segment, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "/") {
+		tokens, err := ParsePointer(expr)
+		if err != nil {
+			return nil, err
+		}
+		path := make([]Segment, len(tokens))
+		for i, tok := range tokens {
+			path[i] = Key(tok)
+		}
+		return path, nil
+	}
+
+	src := strings.TrimPrefix(expr, "$")
+	if src == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(src, ".") && !strings.HasPrefix(src, "[") {
+		src = "." + src
+	}
+
+	steps, err := parseSteps(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %v", expr, err)
+	}
+	path := make([]Segment, len(steps))
+	for i, st := range steps {
+		switch {
+		case st.descendant || st.anyIndex:
+			return nil, fmt.Errorf("invalid path %q: a path names one location, without .. or [*]", expr)
+		case st.isIndex:
+			path[i] = Index(st.index)
+		default:
+			path[i] = Key(st.name)
+		}
+	}
+	return path, nil
+}
+
+// CompileAll compiles every expression, stopping at the first error
+func CompileAll(exprs []string) ([]*Pattern, error) {
+	patterns := make([]*Pattern, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// parseSteps parses the part of an expression following the optional $
+func parseSteps(src string) ([]step, error) {
+	var steps []step
+
+	for i := 0; i < len(src); {
+		switch src[i] {
+		case '.':
+			descendant := false
+			i++
+			if i < len(src) && src[i] == '.' {
+				descendant = true
+				i++
+			}
+			if i < len(src) && src[i] == '[' {
+				if !descendant {
+					return nil, fmt.Errorf("unexpected '[' after '.'")
+				}
+				// "..[0]" is handled by the bracket case with the descendant flag
+				st, n, err := parseBracket(src[i:])
+				if err != nil {
+					return nil, err
+				}
+				st.descendant = true
+				steps = append(steps, st)
+				i += n
+				continue
+			}
+
+			start := i
+			for i < len(src) && src[i] != '.' && src[i] != '[' {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("missing key name at offset %d", start)
+			}
+			steps = append(steps, step{descendant: descendant, name: src[start:i]})
+		case '[':
+			st, n, err := parseBracket(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, st)
+			i += n
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", src[i], i)
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no path steps")
+	}
+
+	return steps, nil
+}
+
+// parseBracket parses [n], [*] or ['key'] / ["key"] and returns the number of bytes consumed
+func parseBracket(src string) (step, int, error) {
+	end := strings.IndexByte(src, ']')
+	if end < 0 {
+		return step{}, 0, fmt.Errorf("unterminated '['")
+	}
+
+	inner := src[1:end]
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') {
+		quote := inner[0]
+		// The key may itself contain ']', so search for the closing quote first
+		closing := strings.IndexByte(src[2:], quote)
+		if closing < 0 {
+			return step{}, 0, fmt.Errorf("unterminated quoted key")
+		}
+		closing += 2
+		if closing+1 >= len(src) || src[closing+1] != ']' {
+			return step{}, 0, fmt.Errorf("expected ']' after quoted key")
+		}
+		return step{name: src[2:closing]}, closing + 2, nil
+	}
+
+	if inner == "*" {
+		return step{anyIndex: true}, end + 1, nil
+	}
+
+	n, err := strconv.Atoi(inner)
+	if err != nil || n < 0 {
+		return step{}, 0, fmt.Errorf("invalid array index %q", inner)
+	}
+	return step{index: n, isIndex: true}, end + 1, nil
+}
+
+// Match reports whether the pattern matches the whole concrete path
+func (p *Pattern) Match(path []Segment) bool {
+	return matchSteps(p.steps, path)
+}
+
+// MatchAny reports whether any of the patterns matches path
+func MatchAny(patterns []*Pattern, path []Segment) bool {
+	for _, p := range patterns {
+		if p.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSteps matches steps against path, backtracking over descendant steps
+func matchSteps(steps []step, path []Segment) bool {
+	if len(steps) == 0 {
+		return len(path) == 0
+	}
+
+	st := steps[0]
+	if st.descendant {
+		// Try every possible depth for the descendant step
+		for i := range path {
+			if matchStep(st, path[i]) && matchSteps(steps[1:], path[i+1:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 || !matchStep(st, path[0]) {
+		return false
+	}
+	return matchSteps(steps[1:], path[1:])
+}
+
+// matchStep matches a single step against a single segment
+func matchStep(st step, seg Segment) bool {
+	switch {
+	case st.anyIndex:
+		return seg.IsIndex
+	case st.isIndex:
+		return seg.IsIndex && seg.Index == st.index
+	case seg.IsIndex:
+		// A "*" key step also matches array elements, like JSONPath's .*
+		return st.name == "*"
+	default:
+		return Glob(st.name, seg.Key)
+	}
+}
+
+// Glob reports whether name matches pattern, where "*" matches any run of characters.
+// Matching is case-sensitive and no other characters are special.
+func Glob(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(name, part)
+		if idx < 0 {
+			return false
+		}
+		name = name[idx+len(part):]
+	}
+
+	return strings.HasSuffix(name, last)
+}
+
+// Lookup returns the value found at path inside root, as decoded by encoding/json
+func Lookup(root interface{}, path []Segment) (interface{}, bool) {
+	v := root
+	for _, seg := range path {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if seg.IsIndex {
+				return nil, false
+			}
+			child, ok := val[seg.Key]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []interface{}:
+			if !seg.IsIndex || seg.Index < 0 || seg.Index >= len(val) {
+				return nil, false
+			}
+			v = val[seg.Index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,265 @@
+package jsonpath
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompileAndMatch(t *testing.T) {
+	path := func(segs ...Segment) []Segment { return segs }
+
+	tests := []struct {
+		name  string
+		expr  string
+		path  []Segment
+		match bool
+	}{
+		{name: "Bare key at root", expr: "error", path: path(Key("error")), match: true},
+		{name: "Bare key nested", expr: "error", path: path(Key("data"), Index(3), Key("error")), match: true},
+		{name: "Bare key different name", expr: "error", path: path(Key("errors")), match: false},
+		{name: "Bare key glob", expr: "*secret*", path: path(Key("auth"), Key("client_secret_id")), match: true},
+		{name: "Dotted path", expr: "data.status", path: path(Key("data"), Key("status")), match: true},
+		{name: "Dotted path is anchored", expr: "data.status", path: path(Key("x"), Key("data"), Key("status")), match: false},
+		{name: "Dotted path too short", expr: "data.status", path: path(Key("data")), match: false},
+		{name: "Any index", expr: "items[*].id", path: path(Key("items"), Index(7), Key("id")), match: true},
+		{name: "Specific index", expr: "items[1].id", path: path(Key("items"), Index(7), Key("id")), match: false},
+		{name: "JSONPath root", expr: "$.items[0]", path: path(Key("items"), Index(0)), match: true},
+		{name: "JSONPath descendant", expr: "$..id", path: path(Key("a"), Index(0), Key("b"), Key("id")), match: true},
+		{name: "JSONPath wildcard key", expr: "$.*.id", path: path(Key("user"), Key("id")), match: true},
+		{name: "Wildcard key matches index", expr: "$.items.*", path: path(Key("items"), Index(2)), match: true},
+		{name: "Quoted key", expr: "$['odd.key'][0]", path: path(Key("odd.key"), Index(0)), match: true},
+		{name: "Descendant index", expr: "$..[0]", path: path(Key("a"), Index(0)), match: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.expr, err)
+			}
+			if got := p.Match(tt.path); got != tt.match {
+				t.Errorf("Compile(%q).Match(%s) = %v, want %v", tt.expr, String(tt.path), got, tt.match)
+			}
+		})
+	}
+}
+
+func TestCompileRooted(t *testing.T) {
+	p, err := CompileRooted("error")
+	if err != nil {
+		t.Fatalf("CompileRooted() error = %v", err)
+	}
+	if !p.Match([]Segment{Key("error")}) {
+		t.Errorf("CompileRooted(%q) does not match the root key", "error")
+	}
+	if p.Match([]Segment{Key("data"), Key("error")}) {
+		t.Errorf("CompileRooted(%q) matches a nested key", "error")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{"", "$", "a..", "items[", "items[x]", "items[-1]", "$['open", "$.a.[0]"} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	got := String([]Segment{Key("data"), Key("items"), Index(2), Key("id")})
+	if got != "data.items[2].id" {
+		t.Errorf("String() = %q, want %q", got, "data.items[2].id")
+	}
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"password", "password", true},
+		{"password", "Password", false},
+		{"*", "", true},
+		{"*token", "access_token", true},
+		{"api*", "api_key", true},
+		{"*sec*ret*", "my_secure_retry", true},
+		{"a*b*b", "ab", false},
+	}
+
+	for _, tt := range tests {
+		if got := Glob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Glob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": 1.0},
+		},
+	}
+
+	if v, ok := Lookup(doc, []Segment{Key("items"), Index(0), Key("id")}); !ok || v != 1.0 {
+		t.Errorf("Lookup() = %v, %v, want 1, true", v, ok)
+	}
+
+	for _, path := range [][]Segment{
+		{Key("missing")},
+		{Key("items"), Index(1)},
+		{Key("items"), Key("id")},
+		{Key("items"), Index(0), Key("id"), Key("deeper")},
+	} {
+		if _, ok := Lookup(doc, path); ok {
+			t.Errorf("Lookup(%s) unexpectedly succeeded", String(path))
+		}
+	}
+
+	if v, ok := Lookup(doc, nil); !ok || v == nil {
+		t.Errorf("Lookup() of the root failed")
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		ptr     string
+		want    []string
+		wantErr bool
+	}{
+		{ptr: "", want: nil},
+		{ptr: "/", want: []string{""}},
+		{ptr: "/data/items/0", want: []string{"data", "items", "0"}},
+		{ptr: "/a~1b/m~0n/~01", want: []string{"a/b", "m~n", "~1"}},
+		{ptr: "data", wantErr: true},
+		{ptr: "/a~2", wantErr: true},
+		{ptr: "/a~", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePointer(tt.ptr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePointer(%q) error = %v, wantErr %v", tt.ptr, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePointer(%q) = %q, want %q", tt.ptr, got, tt.want)
+		}
+	}
+}
+
+func TestPointer(t *testing.T) {
+	got := Pointer([]Segment{Key("data"), Key("a/b~c"), Index(3)})
+	if got != "/data/a~1b~0c/3" {
+		t.Errorf("Pointer() = %q, want %q", got, "/data/a~1b~0c/3")
+	}
+}
+
+func TestResolvePointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "first"},
+			},
+		},
+		"a/b": 1.0,
+		"":    "empty key",
+	}
+
+	tests := []struct {
+		ptr      string
+		want     interface{}
+		notFound string
+	}{
+		{ptr: "/data/items/0/name", want: "first"},
+		{ptr: "/a~1b", want: 1.0},
+		{ptr: "/", want: "empty key"},
+		{ptr: "/data/missing", notFound: `key "missing" not found at /data`},
+		{ptr: "/data/items/1", notFound: "index 1 out of range at /data/items (length 1)"},
+		{ptr: "/data/items/01", notFound: `"01" is not an array index at /data/items`},
+		{ptr: "/data/items/-", notFound: `"-" refers to the element after the end of the array at /data/items (length 1)`},
+		{ptr: "/data/items/0/name/x", notFound: `cannot look up "x" in the string at /data/items/0/name`},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolvePointer(doc, tt.ptr)
+		if tt.notFound != "" {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("ResolvePointer(%q) error = %v, want ErrNotFound", tt.ptr, err)
+			} else if !strings.HasSuffix(err.Error(), tt.notFound) {
+				t.Errorf("ResolvePointer(%q) error = %q, want it to end in %q", tt.ptr, err, tt.notFound)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolvePointer(%q) = %v, %v, want %v", tt.ptr, got, err, tt.want)
+		}
+	}
+
+	if _, err := ResolvePointer(doc, "data"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolvePointer() of a malformed pointer error = %v, want a syntax error", err)
+	}
+}
+
+func TestLeaves(t *testing.T) {
+	doc := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":      "ann",
+			"addresses": []interface{}{map[string]interface{}{"zip": "123"}, nil},
+		},
+		"tags":  []interface{}{},
+		"admin": false,
+	}
+
+	var got []string
+	for _, leaf := range Leaves(doc, nil) {
+		got = append(got, String(leaf.Path)+" "+leaf.Type())
+	}
+	want := []string{
+		"admin boolean",
+		"tags array",
+		"user.addresses[0].zip string",
+		"user.addresses[1] null",
+		"user.name string",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Leaves() = %q, want %q", got, want)
+	}
+
+	if leaves := Leaves(1.0, nil); len(leaves) != 1 || len(leaves[0].Path) != 0 || leaves[0].Type() != "number" {
+		t.Errorf("Leaves() of a scalar = %v", leaves)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []Segment
+	}{
+		{"user.settings.theme", []Segment{Key("user"), Key("settings"), Key("theme")}},
+		{"items[3].id", []Segment{Key("items"), Index(3), Key("id")}},
+		{"$['odd key'][0]", []Segment{Key("odd key"), Index(0)}},
+		{".a", []Segment{Key("a")}},
+		{"[1]", []Segment{Index(1)}},
+		{"/a~1b/0", []Segment{Key("a/b"), Key("0")}},
+		{"$", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.expr)
+		if err != nil {
+			t.Errorf("ParsePath(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePath(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"a..b", "items[*]", "a[", "a.", "/a~2"} {
+		if _, err := ParsePath(expr); err == nil {
+			t.Errorf("ParsePath(%q) expected an error", expr)
+		}
+	}
+}
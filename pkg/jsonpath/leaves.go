@@ -0,0 +1,77 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Leaf is a value with no children, found at Path: a scalar, or an empty
+// object or array
+type Leaf struct {
+	Path  []Segment
+	Value interface{}
+}
+
+// Type names the JSON type of the leaf's value
+func (l Leaf) Type() string {
+	switch l.Value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+// Leaves lists the leaves of a decoded document in document order. keys gives
+// the order of an object's keys; when it is nil, keys are sorted.
+func Leaves(v interface{}, keys func(obj map[string]interface{}) []string) []Leaf {
+	if keys == nil {
+		keys = sortedKeys
+	}
+	var leaves []Leaf
+	collectLeaves(nil, v, keys, &leaves)
+	return leaves
+}
+
+// collectLeaves appends the leaves of v, located at path, to leaves
+func collectLeaves(path []Segment, v interface{}, keys func(map[string]interface{}) []string, leaves *[]Leaf) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) > 0 {
+			for _, k := range keys(val) {
+				collectLeaves(append(path, Key(k)), val[k], keys, leaves)
+			}
+			return
+		}
+	case []interface{}:
+		if len(val) > 0 {
+			for i, child := range val {
+				collectLeaves(append(path, Index(i)), child, keys, leaves)
+			}
+			return
+		}
+	}
+
+	leaf := Leaf{Path: make([]Segment, len(path)), Value: v}
+	copy(leaf.Path, path)
+	*leaves = append(*leaves, leaf)
+}
+
+// sortedKeys returns the keys of obj in sorted order
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
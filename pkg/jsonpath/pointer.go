@@ -0,0 +1,126 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when a JSON Pointer does not resolve to a value
+var ErrNotFound = errors.New("pointer does not resolve")
+
+// ParsePointer splits an RFC 6901 JSON Pointer such as "/data/items/0" into its
+// unescaped reference tokens. The empty pointer refers to the whole document.
+func ParsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with '/'", ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid JSON pointer %q: '~' must be followed by 0 or 1", ptr)
+			}
+		}
+		// ~1 must be replaced before ~0, so "~01" becomes "~1" and not "/"
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// Pointer formats a concrete path as an RFC 6901 JSON Pointer
+func Pointer(path []Segment) string {
+	var sb strings.Builder
+	for _, seg := range path {
+		sb.WriteByte('/')
+		if seg.IsIndex {
+			sb.WriteString(strconv.Itoa(seg.Index))
+			continue
+		}
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(seg.Key, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// ResolvePointer returns the value a JSON Pointer refers to inside root. The error
+// wraps ErrNotFound when the pointer is well-formed but names a missing location,
+// and names the first reference token that could not be followed.
+func ResolvePointer(root interface{}, ptr string) (interface{}, error) {
+	tokens, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	v := root
+	for i, tok := range tokens {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			child, ok := val[tok]
+			if !ok {
+				return nil, fmt.Errorf("%w: key %q not found at %s", ErrNotFound, tok, pointerPrefix(tokens[:i]))
+			}
+			v = child
+		case []interface{}:
+			if tok == "-" {
+				return nil, fmt.Errorf("%w: \"-\" refers to the element after the end of the array at %s (length %d)", ErrNotFound, pointerPrefix(tokens[:i]), len(val))
+			}
+			idx, ok := arrayIndex(tok)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q is not an array index at %s", ErrNotFound, tok, pointerPrefix(tokens[:i]))
+			}
+			if idx >= len(val) {
+				return nil, fmt.Errorf("%w: index %d out of range at %s (length %d)", ErrNotFound, idx, pointerPrefix(tokens[:i]), len(val))
+			}
+			v = val[idx]
+		default:
+			return nil, fmt.Errorf("%w: cannot look up %q in the %s at %s", ErrNotFound, tok, scalarType(v), pointerPrefix(tokens[:i]))
+		}
+	}
+
+	return v, nil
+}
+
+// scalarType names the JSON type of a value that has no children
+func scalarType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	return "number"
+}
+
+// arrayIndex parses an array reference token: digits without leading zeros
+func arrayIndex(tok string) (int, bool) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, false
+	}
+	for _, c := range tok {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(tok)
+	return n, err == nil
+}
+
+// pointerPrefix re-escapes tokens into a pointer for error messages; the root is shown as "/"
+func pointerPrefix(tokens []string) string {
+	if len(tokens) == 0 {
+		return "/"
+	}
+	path := make([]Segment, len(tokens))
+	for i, tok := range tokens {
+		path[i] = Key(tok)
+	}
+	return Pointer(path)
+}
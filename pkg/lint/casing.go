@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Key naming conventions recognized by the key-casing rule
+const (
+	styleCamel     = "camelCase"
+	stylePascal    = "PascalCase"
+	styleSnake     = "snake_case"
+	styleScreaming = "SCREAMING_SNAKE_CASE"
+	styleKebab     = "kebab-case"
+)
+
+// keyStyle returns the naming convention of a key, or "" when the key fits
+// several conventions (a single lowercase word) or none of them
+func keyStyle(key string) string {
+	hasUpper := strings.IndexFunc(key, unicode.IsUpper) >= 0
+	hasLower := strings.IndexFunc(key, unicode.IsLower) >= 0
+	hasUnderscore := strings.Contains(strings.Trim(key, "_"), "_")
+	hasDash := strings.Contains(strings.Trim(key, "-"), "-")
+
+	switch {
+	case hasUnderscore && hasDash:
+		return ""
+	case hasUnderscore && !hasUpper:
+		return styleSnake
+	case hasUnderscore && !hasLower:
+		return styleScreaming
+	case hasDash && !hasUpper:
+		return styleKebab
+	case hasUnderscore || hasDash || !hasUpper || !hasLower:
+		return ""
+	}
+
+	first, _ := utf8.DecodeRuneInString(key)
+	switch {
+	case unicode.IsUpper(first):
+		return stylePascal
+	case unicode.IsLower(first):
+		return styleCamel
+	}
+	return ""
+}
+
+// keyCasing applies the key-casing rule: keys that do not follow the
+// convention most keys of the document follow are reported at the key itself
+func (w *walker) keyCasing() {
+	severity := w.linter.settings[RuleKeyCasing].Severity
+	if severity == Off {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, k := range w.keys {
+		counts[k.style]++
+	}
+	if len(counts) < 2 {
+		return
+	}
+
+	// The most common style wins; ties go to the alphabetically first name
+	dominant := ""
+	for style, n := range counts {
+		if dominant == "" || n > counts[dominant] || (n == counts[dominant] && style < dominant) {
+			dominant = style
+		}
+	}
+
+	for _, k := range w.keys {
+		if k.style != dominant {
+			w.report(RuleKeyCasing, severity, w.pos.keyOffset(k.path), k.path, k.order,
+				fmt.Sprintf("key %q is %s while most keys are %s", k.key, k.style, dominant))
+		}
+	}
+}
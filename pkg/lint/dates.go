@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// isoDate matches strings that start like an RFC 3339 date, 2006-01-02
+var isoDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(.*)$`)
+
+// localDates match other common ways of writing a date: 01/02/2006,
+// 02.01.2006, 2006/01/02, 2006-1-2, "Jan 2, 2006", "2 January 2006" and
+// the HTTP date format
+var localDates = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{1,2}([/.])\d{1,2}([/.])\d{2}(\d{2})?$`),
+	regexp.MustCompile(`^\d{1,2}-\d{1,2}-\d{4}$`),
+	regexp.MustCompile(`^\d{4}([/.])\d{1,2}([/.])\d{1,2}$`),
+	regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}$`),
+	regexp.MustCompile(`^(?i)(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.? \d{1,2}(st|nd|rd|th)?,? \d{4}$`),
+	regexp.MustCompile(`^(?i)\d{1,2}(st|nd|rd|th)? (jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,? \d{4}$`),
+	regexp.MustCompile(`^(?i)(mon|tue|wed|thu|fri|sat|sun), \d{2} [a-z]{3} \d{4} \d{2}:\d{2}:\d{2} [a-z]+$`),
+}
+
+// checkDate applies the date-format rule to a string: dates must be
+// written as RFC 3339 dates or date-times, the ISO 8601 profile JSON APIs
+// and JSON Schema's date and date-time formats use. It returns "" for
+// strings that are not dates or are written correctly.
+func checkDate(s string) string {
+	if m := isoDate.FindStringSubmatch(s); m != nil {
+		rest := m[1]
+		switch {
+		case rest == "":
+			if _, err := time.Parse("2006-01-02", s); err != nil {
+				return fmt.Sprintf("%q is not a valid date", s)
+			}
+		case rest[0] == 'T' || rest[0] == 't':
+			if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return ""
+			}
+			if _, err := time.Parse("2006-01-02T15:04:05.999999999", s); err == nil {
+				return fmt.Sprintf("date-time %q has no time zone; add Z or an offset such as +02:00", s)
+			}
+			return fmt.Sprintf("%q is not a valid RFC 3339 date-time", s)
+		case rest[0] == ' ' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+			return fmt.Sprintf("date-time %q separates the date and time with a space; RFC 3339 uses T", s)
+		}
+		return ""
+	}
+
+	for _, re := range localDates {
+		if re.MatchString(s) {
+			return fmt.Sprintf("date %q is not in RFC 3339 format, e.g. 2006-01-02", s)
+		}
+	}
+	return ""
+}
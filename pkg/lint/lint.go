@@ -0,0 +1,463 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// Names of the built-in rules
+const (
+	RuleKeyCasing   = "key-casing"
+	RuleMixedTypes  = "mixed-types"
+	RuleMaxDepth    = "max-depth"
+	RuleLineLength  = "line-length"
+	RuleEmptyObject = "empty-object"
+	RuleDuplicate   = "duplicate-key"
+	RuleDateFormat  = "date-format"
+)
+
+// Off disables a rule when used as its severity
+const Off diag.Severity = "off"
+
+// Setting configures a built-in rule
+type Setting struct {
+	// Severity is diag.SeverityError, diag.SeverityWarning or Off
+	Severity diag.Severity
+	// Max is the limit of max-depth and line-length
+	Max int
+}
+
+// defaults are the settings of the built-in rules when not configured
+var defaults = map[string]Setting{
+	RuleKeyCasing:   {Severity: diag.SeverityWarning},
+	RuleMixedTypes:  {Severity: diag.SeverityWarning},
+	RuleMaxDepth:    {Severity: diag.SeverityWarning, Max: 10},
+	RuleLineLength:  {Severity: diag.SeverityWarning, Max: 160},
+	RuleEmptyObject: {Severity: diag.SeverityWarning},
+	RuleDuplicate:   {Severity: diag.SeverityWarning},
+	RuleDateFormat:  {Severity: diag.SeverityWarning},
+}
+
+// Check is a user-defined rule: every value whose path matches Pattern must
+// satisfy Query, meaning the query produces at least one value and all of
+// its values are truthy
+type Check struct {
+	Name     string
+	Pattern  *jsonpath.Pattern
+	Query    *query.Query
+	Severity diag.Severity
+	// Message replaces the default description of a failure
+	Message string
+}
+
+// Finding is one problem reported by a rule. Rules about the text of the
+// document set Line; rules about its values set Path, and Line too when the
+// value could be located in the text. Column and Offset locate the finding
+// along with Line.
+type Finding struct {
+	File     string        `json:"file"`
+	Rule     string        `json:"rule"`
+	Line     int           `json:"line,omitempty"`
+	Column   int           `json:"column,omitempty"`
+	Offset   int           `json:"offset,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Severity diag.Severity `json:"severity"`
+	Message  string        `json:"message"`
+
+	// order places findings about the same line in the order they were found
+	order int
+	// text marks findings about the text rather than a value
+	text bool
+}
+
+// String formats the finding as file:line:column, file:line:column: path or
+// file: path, then severity, message and rule, so that editors can jump to it
+func (f Finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+	}
+	if !f.text {
+		location += ": " + displayPath(f.Path)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, f.Severity, f.Message, f.Rule)
+}
+
+// Diagnostic converts the finding to the diagnostic format that validation
+// also reports, with the rule as the code
+func (f Finding) Diagnostic() diag.Diagnostic {
+	return diag.Diagnostic{
+		File:     f.File,
+		Line:     f.Line,
+		Column:   f.Column,
+		Offset:   f.Offset,
+		Code:     f.Rule,
+		Message:  f.Message,
+		Severity: f.Severity,
+		Path:     f.Path,
+	}
+}
+
+// Diagnostics converts findings with Finding.Diagnostic
+func Diagnostics(findings []Finding) []diag.Diagnostic {
+	diagnostics := make([]diag.Diagnostic, len(findings))
+	for i, f := range findings {
+		diagnostics[i] = f.Diagnostic()
+	}
+	return diagnostics
+}
+
+// displayPath names the document root explicitly
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// HasErrors reports whether any finding has error severity
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == diag.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSeverity validates a configured severity; empty means the default
+func ParseSeverity(name string) (diag.Severity, error) {
+	switch s := diag.Severity(name); s {
+	case "", diag.SeverityError, diag.SeverityWarning, Off:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (use error, warning or off)", name)
+}
+
+// Linter runs the built-in rules and the checks over documents
+type Linter struct {
+	settings map[string]Setting
+	checks   []Check
+}
+
+// New returns a linter with the given settings applied over the defaults.
+// A zero Severity or Max in a setting keeps the default.
+func New(settings map[string]Setting, checks []Check) (*Linter, error) {
+	l := &Linter{settings: make(map[string]Setting, len(defaults)), checks: checks}
+	for name, s := range defaults {
+		l.settings[name] = s
+	}
+
+	for name, s := range settings {
+		current, ok := l.settings[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown lint rule %q (expected one of %s)", name, strings.Join(RuleNames(), ", "))
+		}
+		if _, err := ParseSeverity(string(s.Severity)); err != nil {
+			return nil, fmt.Errorf("lint rule %q: %v", name, err)
+		}
+		if s.Max < 0 {
+			return nil, fmt.Errorf("lint rule %q: max must be positive", name)
+		}
+		if s.Severity != "" {
+			current.Severity = s.Severity
+		}
+		if s.Max != 0 {
+			current.Max = s.Max
+		}
+		l.settings[name] = current
+	}
+
+	for _, c := range checks {
+		if c.Severity == "" || c.Severity == Off {
+			continue
+		}
+		if _, err := ParseSeverity(string(c.Severity)); err != nil {
+			return nil, fmt.Errorf("lint check %q: %v", c.Name, err)
+		}
+	}
+	return l, nil
+}
+
+// RuleNames lists the built-in rules
+func RuleNames() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lint checks a document, given both as text and decoded, and returns the
+// findings in document order
+func (l *Linter) Lint(file string, data []byte, v interface{}) []Finding {
+	w := &walker{linter: l, file: file, pos: scan(data)}
+	w.lines(data)
+	w.duplicates()
+	w.walk(nil, v, 1, false)
+	w.keyCasing()
+
+	for i, f := range w.findings {
+		if f.Offset >= 0 {
+			w.findings[i].Line, w.findings[i].Column = diag.Position(data, f.Offset)
+		} else {
+			w.findings[i].Offset = 0
+		}
+	}
+
+	// Findings that could not be located come last
+	sort.SliceStable(w.findings, func(i, j int) bool {
+		a, b := w.findings[i], w.findings[j]
+		if a.Line != b.Line {
+			return b.Line == 0 || (a.Line != 0 && a.Line < b.Line)
+		}
+		return a.order < b.order
+	})
+	return w.findings
+}
+
+// walker collects findings during one pass over a document
+type walker struct {
+	linter   *Linter
+	file     string
+	pos      *positions
+	findings []Finding
+	// visited counts the values seen so far, giving each finding its order
+	visited int
+	keys    []keyUse
+}
+
+// keyUse records an object key for the key-casing rule
+type keyUse struct {
+	key   string
+	style string
+	path  string
+	order int
+}
+
+// report adds a finding about the value at path, unless the rule is off.
+// An offset of -1 is looked up from the path, and stays -1 when the value
+// could not be located; Lint turns offsets into lines and columns.
+func (w *walker) report(rule string, severity diag.Severity, offset int, path string, order int, message string) {
+	if severity == Off {
+		return
+	}
+	if offset < 0 {
+		offset = w.pos.offset(path)
+	}
+	w.findings = append(w.findings, Finding{
+		File:     w.file,
+		Rule:     rule,
+		Offset:   offset,
+		Path:     path,
+		Severity: severity,
+		Message:  message,
+		order:    order,
+	})
+}
+
+// lines applies the line-length rule
+func (w *walker) lines(data []byte) {
+	s := w.linter.settings[RuleLineLength]
+	offset := 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		start := offset
+		offset += len(line) + 1
+		if n := utf8.RuneCount(bytes.TrimSuffix(line, []byte("\r"))); n > s.Max && s.Severity != Off {
+			w.findings = append(w.findings, Finding{
+				File:     w.file,
+				Rule:     RuleLineLength,
+				Offset:   start,
+				Severity: s.Severity,
+				Message:  fmt.Sprintf("line is %d characters long (max %d)", n, s.Max),
+				order:    -1,
+				text:     true,
+			})
+		}
+	}
+}
+
+// duplicates applies the duplicate-key rule. Decoding keeps one of the
+// values of a repeated key, so the others are silently lost.
+func (w *walker) duplicates() {
+	severity := w.linter.settings[RuleDuplicate].Severity
+	for _, dup := range w.pos.dups {
+		w.report(RuleDuplicate, severity, dup.offset, dup.path, -1, fmt.Sprintf("key %q is repeated", dup.key))
+	}
+}
+
+// walk visits v, located at path and nested depth containers deep. deep is
+// set once max-depth has been reported on the way down, so that it is
+// reported only once per branch.
+func (w *walker) walk(path []jsonpath.Segment, v interface{}, depth int, deep bool) {
+	order := w.visited
+	w.visited++
+	name := jsonpath.String(path)
+
+	w.runChecks(path, name, v, order)
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if s := w.linter.settings[RuleMaxDepth]; depth > s.Max && !deep {
+			w.report(RuleMaxDepth, s.Severity, -1, name, order, fmt.Sprintf("nesting depth %d exceeds %d", depth, s.Max))
+			deep = true
+		}
+		if len(val) == 0 {
+			w.report(RuleEmptyObject, w.linter.settings[RuleEmptyObject].Severity, -1, name, order, "empty object")
+		}
+
+		for _, k := range sortedKeys(val) {
+			childPath := append(path, jsonpath.Key(k))
+			if style := keyStyle(k); style != "" {
+				w.keys = append(w.keys, keyUse{key: k, style: style, path: jsonpath.String(childPath), order: w.visited})
+			}
+			w.walk(childPath, val[k], depth+1, deep)
+		}
+	case []interface{}:
+		if s := w.linter.settings[RuleMaxDepth]; depth > s.Max && !deep {
+			w.report(RuleMaxDepth, s.Severity, -1, name, order, fmt.Sprintf("nesting depth %d exceeds %d", depth, s.Max))
+			deep = true
+		}
+		w.mixedTypes(name, val, order)
+
+		for i, item := range val {
+			w.walk(append(path, jsonpath.Index(i)), item, depth+1, deep)
+		}
+	case string:
+		if message := checkDate(val); message != "" {
+			w.report(RuleDateFormat, w.linter.settings[RuleDateFormat].Severity, -1, name, order, message)
+		}
+	}
+}
+
+// runChecks applies the user-defined checks whose pattern matches path
+func (w *walker) runChecks(path []jsonpath.Segment, name string, v interface{}, order int) {
+	for _, c := range w.linter.checks {
+		if !c.Pattern.Match(path) {
+			continue
+		}
+
+		severity := c.Severity
+		if severity == "" {
+			severity = diag.SeverityWarning
+		}
+
+		results, err := c.Query.Run(v)
+		switch {
+		case err != nil:
+			w.report(c.Name, severity, -1, name, order, fmt.Sprintf("%s: %v", c.Query, err))
+		case !passes(results):
+			message := c.Message
+			if message == "" {
+				message = fmt.Sprintf("does not satisfy %s", c.Query)
+			}
+			w.report(c.Name, severity, -1, name, order, message)
+		}
+	}
+}
+
+// passes reports whether a check's query produced only truthy values
+func passes(results []query.Result) bool {
+	if len(results) == 0 {
+		return false
+	}
+	for _, r := range results {
+		if !query.Truthy(r.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// mixedTypes applies the mixed-types rule to the object elements of an array
+func (w *walker) mixedTypes(name string, items []interface{}, order int) {
+	severity := w.linter.settings[RuleMixedTypes].Severity
+	if severity == Off {
+		return
+	}
+
+	kinds := make(map[string]map[string]int)
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range obj {
+			if v == nil {
+				continue
+			}
+			if kinds[k] == nil {
+				kinds[k] = make(map[string]int)
+			}
+			kinds[k][kindName(v)]++
+		}
+	}
+
+	for _, field := range sortedKeys(kinds) {
+		counts := kinds[field]
+		if len(counts) < 2 {
+			continue
+		}
+		w.report(RuleMixedTypes, severity, w.pos.offset(name), name+"[*]."+field, order,
+			fmt.Sprintf("field %q mixes types: %s", field, describeCounts(counts)))
+	}
+}
+
+// describeCounts lists type counts, most frequent first, e.g. "3 numbers, 1 string"
+func describeCounts(counts map[string]int) string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		noun := kind
+		if counts[kind] != 1 {
+			noun += "s"
+		}
+		parts[i] = fmt.Sprintf("%d %s", counts[kind], noun)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// kindName names the JSON type of a decoded value
+func kindName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "number"
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
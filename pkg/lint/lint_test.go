@@ -0,0 +1,229 @@
+package lint
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// lint runs a linter over a test document and returns its findings as strings
+func lint(t *testing.T, l *Linter, input string) []string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("invalid test input: %v", err)
+	}
+
+	var got []string
+	for _, f := range l.Lint("f.json", []byte(input), v) {
+		got = append(got, f.String())
+	}
+	return got
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]Setting
+		input    string
+		want     []string
+	}{
+		{
+			name:  "Clean document",
+			input: `{"userId": 1, "items": [{"id": 1, "name": "a"}, {"id": 2, "name": null}]}`,
+		},
+		{
+			name:  "Key casing",
+			input: `{"userId": 1, "firstName": "a", "last_name": "b", "Meta": {"createdAt": 1}}`,
+			want: []string{
+				`f.json:1:51: Meta: warning: key "Meta" is PascalCase while most keys are camelCase (key-casing)`,
+				`f.json:1:33: last_name: warning: key "last_name" is snake_case while most keys are camelCase (key-casing)`,
+			},
+		},
+		{
+			name:  "Mixed types and empty objects",
+			input: `{"rows": [{"id": 1, "x": {}}, {"id": "2"}, {"id": 3}, {"id": null}]}`,
+			want: []string{
+				`f.json:1:10: rows[*].id: warning: field "id" mixes types: 2 numbers, 1 string (mixed-types)`,
+				`f.json:1:26: rows[0].x: warning: empty object (empty-object)`,
+			},
+		},
+		{
+			name:     "Max depth is reported once per branch",
+			settings: map[string]Setting{RuleMaxDepth: {Max: 2}},
+			input:    `{"a": {"b": {"c": {"d": 1}}}, "e": [[1]]}`,
+			want: []string{
+				`f.json:1:13: a.b: warning: nesting depth 3 exceeds 2 (max-depth)`,
+				`f.json:1:37: e[0]: warning: nesting depth 3 exceeds 2 (max-depth)`,
+			},
+		},
+		{
+			name:     "Line length",
+			settings: map[string]Setting{RuleLineLength: {Max: 15, Severity: diag.SeverityError}},
+			input:    "{\n  \"short\": 1,\n  \"much_longer\": 2\n}",
+			want: []string{
+				`f.json:3:1: error: line is 18 characters long (max 15) (line-length)`,
+			},
+		},
+		{
+			name:  "Values are located by line",
+			input: "{\n  \"rows\": [\n    {\"id\": 1},\n    {\"id\": \"2\", \"meta\": {}}\n  ],\n  \"Name\": \"x\"\n}",
+			want: []string{
+				`f.json:2:11: rows[*].id: warning: field "id" mixes types: 1 number, 1 string (mixed-types)`,
+				`f.json:4:25: rows[1].meta: warning: empty object (empty-object)`,
+			},
+		},
+		{
+			name:  "Keys are located at the key, not its value",
+			input: "{\n  \"userId\": 1,\n  \"firstName\": \"a\",\n  \"last_name\":\n    \"b\"\n}",
+			want: []string{
+				`f.json:4:3: last_name: warning: key "last_name" is snake_case while most keys are camelCase (key-casing)`,
+			},
+		},
+		{
+			name:  "Duplicate keys",
+			input: "{\"a\": 1,\n \"b\": {\"c\": 1, \"c\": 2},\n \"a\": 3}",
+			want: []string{
+				`f.json:2:16: b.c: warning: key "c" is repeated (duplicate-key)`,
+				`f.json:3:2: a: warning: key "a" is repeated (duplicate-key)`,
+			},
+		},
+		{
+			name:     "Date formats",
+			settings: map[string]Setting{RuleLineLength: {Severity: Off}},
+			input: `{"ok": ["2024-05-01", "2024-05-01T10:00:00Z", "2024-05-01T10:00:00.5+02:00", "2024-05-01 notes", "12/2024", "v1.2.3"],` +
+				` "bad": ["2024-02-30", "2024-05-01T10:00:00", "2024-05-01T25:00:00Z", "2024-05-01 10:00:00", "05/01/2024", "1.5.2024", "2024/5/1", "Jan 5, 2024", "5th March 2024", "Mon, 02 Jan 2006 15:04:05 GMT"]}`,
+			want: []string{
+				`f.json:1:128: bad[0]: warning: "2024-02-30" is not a valid date (date-format)`,
+				`f.json:1:142: bad[1]: warning: date-time "2024-05-01T10:00:00" has no time zone; add Z or an offset such as +02:00 (date-format)`,
+				`f.json:1:165: bad[2]: warning: "2024-05-01T25:00:00Z" is not a valid RFC 3339 date-time (date-format)`,
+				`f.json:1:189: bad[3]: warning: date-time "2024-05-01 10:00:00" separates the date and time with a space; RFC 3339 uses T (date-format)`,
+				`f.json:1:212: bad[4]: warning: date "05/01/2024" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+				`f.json:1:226: bad[5]: warning: date "1.5.2024" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+				`f.json:1:238: bad[6]: warning: date "2024/5/1" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+				`f.json:1:250: bad[7]: warning: date "Jan 5, 2024" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+				`f.json:1:265: bad[8]: warning: date "5th March 2024" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+				`f.json:1:283: bad[9]: warning: date "Mon, 02 Jan 2006 15:04:05 GMT" is not in RFC 3339 format, e.g. 2006-01-02 (date-format)`,
+			},
+		},
+		{
+			name:     "Rules can be turned off",
+			settings: map[string]Setting{RuleEmptyObject: {Severity: Off}},
+			input:    `{"a": {}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := New(tt.settings, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got := lint(t, l, tt.input)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Lint() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintDiagnostics(t *testing.T) {
+	l, err := New(map[string]Setting{RuleLineLength: {Max: 20}}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	input := "{\n  \"a\": {},\n  \"b\": \"xxxxxxxxxxxxxxxxxxxx\"\n}"
+	var v interface{}
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("invalid test input: %v", err)
+	}
+
+	got := Diagnostics(l.Lint("f.json", []byte(input), v))
+	want := []diag.Diagnostic{
+		{File: "f.json", Line: 2, Column: 8, Offset: 9, Code: RuleEmptyObject, Message: "empty object", Severity: diag.SeverityWarning, Path: "a"},
+		{File: "f.json", Line: 3, Column: 1, Offset: 13, Code: RuleLineLength, Message: "line is 29 characters long (max 20)", Severity: diag.SeverityWarning},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diagnostics() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLintChecks(t *testing.T) {
+	positive, _ := query.Parse(". > 0")
+	hasID, _ := query.Parse(".id")
+	items, _ := jsonpath.Compile("items[*].price")
+	rows, _ := jsonpath.Compile("items[*]")
+
+	l, err := New(nil, []Check{
+		{Name: "positive-price", Pattern: items, Query: positive, Severity: diag.SeverityError},
+		{Name: "has-id", Pattern: rows, Query: hasID, Message: "item without an id"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := lint(t, l, `{"items": [{"id": 1, "price": 3}, {"price": 0}, {"id": 3, "price": -1}]}`)
+	want := []string{
+		`f.json:1:35: items[1]: warning: item without an id (has-id)`,
+		`f.json:1:45: items[1].price: error: does not satisfy . > 0 (positive-price)`,
+		`f.json:1:68: items[2].price: error: does not satisfy . > 0 (positive-price)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Lint() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	tests := []struct {
+		f    Finding
+		want string
+	}{
+		{Finding{File: "f.json", Rule: "r", Line: 3, Column: 5, Path: "a.b", Severity: diag.SeverityWarning, Message: "m"}, "f.json:3:5: a.b: warning: m (r)"},
+		{Finding{File: "f.json", Rule: "r", Line: 1, Column: 1, Severity: diag.SeverityError, Message: "m"}, "f.json:1:1: (root): error: m (r)"},
+		{Finding{File: "f.json", Rule: "r", Path: "a", Severity: diag.SeverityWarning, Message: "m"}, "f.json: a: warning: m (r)"},
+		{Finding{File: "f.json", Rule: "r", Line: 2, Column: 1, Severity: diag.SeverityWarning, Message: "m", text: true}, "f.json:2:1: warning: m (r)"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(map[string]Setting{"no-such-rule": {}}, nil); err == nil {
+		t.Error("New() expected an error for an unknown rule")
+	}
+	if _, err := New(map[string]Setting{RuleMaxDepth: {Severity: "fatal"}}, nil); err == nil {
+		t.Error("New() expected an error for an unknown severity")
+	}
+}
+
+func TestKeyStyle(t *testing.T) {
+	tests := map[string]string{
+		"userId":     styleCamel,
+		"UserId":     stylePascal,
+		"user_id":    styleSnake,
+		"_private":   "",
+		"USER_ID":    styleScreaming,
+		"user-id":    styleKebab,
+		"id":         "",
+		"ID":         "",
+		"user_Id":    "",
+		"$ref":       "",
+		"__proto__":  "",
+		"x-user_id":  "",
+		"api2Client": styleCamel,
+	}
+
+	for key, want := range tests {
+		if got := keyStyle(key); got != want {
+			t.Errorf("keyStyle(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
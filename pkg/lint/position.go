@@ -0,0 +1,116 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// positions locates the values of a document in its text, which the
+// decoded document no longer knows
+type positions struct {
+	// offsets holds the byte offset each value starts at, by path; for a
+	// repeated key, the offset of the last value, which decoding keeps
+	offsets map[string]int
+	// keys holds the byte offset of the key each object member is named by,
+	// by the path of its value
+	keys map[string]int
+	// dups lists the keys repeated within an object, in document order
+	dups []repeatedKey
+}
+
+// repeatedKey is a key that appears more than once in the same object
+type repeatedKey struct {
+	key    string
+	path   string
+	offset int
+}
+
+// scan reads the text of a document. Text that is not plain JSON, such as
+// JSON with comments, leaves the values after the first problem unlocated.
+func scan(data []byte) *positions {
+	p := &positions{offsets: make(map[string]int), keys: make(map[string]int)}
+	s := &scanner{dec: json.NewDecoder(bytes.NewReader(data)), data: data, positions: p}
+	_ = s.value(nil)
+	return p
+}
+
+// offset returns the byte offset the value at path starts at, or -1 when unknown
+func (p *positions) offset(path string) int {
+	if offset, ok := p.offsets[path]; ok {
+		return offset
+	}
+	return -1
+}
+
+// keyOffset returns the byte offset of the key naming the value at path, or
+// -1 when unknown
+func (p *positions) keyOffset(path string) int {
+	if offset, ok := p.keys[path]; ok {
+		return offset
+	}
+	return -1
+}
+
+// scanner walks the token stream of a document
+type scanner struct {
+	dec  *json.Decoder
+	data []byte
+	*positions
+}
+
+// value reads one complete value located at path
+func (s *scanner) value(path []jsonpath.Segment) error {
+	name := jsonpath.String(path)
+	s.offsets[name] = s.next()
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+		for s.dec.More() {
+			at := s.next()
+			tok, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			child := append(path[:len(path):len(path)], jsonpath.Key(key))
+			name := jsonpath.String(child)
+			if seen[key] {
+				s.dups = append(s.dups, repeatedKey{key: key, path: name, offset: at})
+			}
+			s.keys[name] = at
+			seen[key] = true
+			if err := s.value(child); err != nil {
+				return err
+			}
+		}
+		_, err = s.dec.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; s.dec.More(); i++ {
+			if err := s.value(append(path[:len(path):len(path)], jsonpath.Index(i))); err != nil {
+				return err
+			}
+		}
+		_, err = s.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// next returns the offset of the next token. The decoder reports the
+// offset before the separators it has yet to consume.
+func (s *scanner) next() int {
+	offset := int(s.dec.InputOffset())
+	for offset < len(s.data) && strings.IndexByte(" \t\r\n,:", s.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
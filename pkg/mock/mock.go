@@ -0,0 +1,144 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/query"
+)
+
+// Route maps a URL path to the part of the document it serves
+type Route struct {
+	Path string
+	// Query selects the value to serve; nil serves the whole document
+	Query *query.Query
+}
+
+// ParseRoute parses a route written as PATH=EXPR, e.g. "/users=.users"
+func ParseRoute(spec string) (Route, error) {
+	path, expr, ok := strings.Cut(spec, "=")
+	if !ok || !strings.HasPrefix(path, "/") || strings.TrimSpace(expr) == "" {
+		return Route{}, fmt.Errorf("invalid route %q: use PATH=EXPR, e.g. /users=.users", spec)
+	}
+
+	q, err := query.Parse(expr)
+	if err != nil {
+		return Route{}, fmt.Errorf("invalid route %q: %v", spec, err)
+	}
+	return Route{Path: cleanPath(path), Query: q}, nil
+}
+
+// Server serves a JSON document over HTTP. The document is loaded again for
+// every request, so edits to the file show up without a restart.
+type Server struct {
+	// Routes lists the paths served; when empty, "/" serves the whole document
+	Routes []Route
+	// Load returns the current document, along with the key order recorded
+	// while decoding it, which may be nil
+	Load func() (interface{}, *formatter.KeyOrder, error)
+	// Format renders a response body, with the key order Load returned
+	Format func(v interface{}, order *formatter.KeyOrder) ([]byte, error)
+	// Latency delays every response
+	Latency time.Duration
+	// Status is the status code of successful responses; zero means 200
+	Status int
+}
+
+// ServeHTTP answers a request with the value of the matching route
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Latency > 0 {
+		select {
+		case <-time.After(s.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	// Mocks are usually called from a frontend served on another port
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	route, ok := s.match(r.URL.Path)
+	if !ok {
+		s.error(w, http.StatusNotFound, fmt.Sprintf("no route for %s", r.URL.Path))
+		return
+	}
+
+	doc, order, err := s.Load()
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	value := doc
+	if route.Query != nil {
+		results, err := route.Query.Run(doc)
+		if err != nil {
+			s.error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		switch len(results) {
+		case 0:
+			s.error(w, http.StatusNotFound, fmt.Sprintf("%s produced no value", route.Query))
+			return
+		case 1:
+			value = results[0].Value
+		default:
+			values := make([]interface{}, len(results))
+			for i, res := range results {
+				values[i] = res.Value
+			}
+			value = values
+		}
+	}
+
+	body, err := s.Format(value, order)
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status := s.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	s.write(w, status, body)
+}
+
+// match finds the route for a request path
+func (s *Server) match(path string) (Route, bool) {
+	path = cleanPath(path)
+	if len(s.Routes) == 0 {
+		return Route{Path: "/"}, path == "/"
+	}
+	for _, route := range s.Routes {
+		if route.Path == path {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// error writes a JSON error body
+func (s *Server) error(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	s.write(w, status, body)
+}
+
+// write sends a JSON response
+func (s *Server) write(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// cleanPath drops a trailing slash so /users and /users/ are the same route
+func cleanPath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -0,0 +1,109 @@
+package mock
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// newServer returns a server over a fixed document
+func newServer(t *testing.T, routes ...string) *Server {
+	t.Helper()
+	s := &Server{
+		Load: func() (interface{}, *formatter.KeyOrder, error) {
+			order := formatter.NewKeyOrder()
+			doc, err := formatter.Decode([]byte(`{"users":[{"id":1},{"id":2}],"meta":{"total":2},"tags":[]}`), formatter.Options{KeyOrder: order})
+			return doc, order, err
+		},
+		Format: func(v interface{}, order *formatter.KeyOrder) ([]byte, error) {
+			return formatter.FormatValue(v, formatter.Options{KeyOrder: order, Compact: true})
+		},
+	}
+	for _, spec := range routes {
+		route, err := ParseRoute(spec)
+		if err != nil {
+			t.Fatalf("ParseRoute(%q) error = %v", spec, err)
+		}
+		s.Routes = append(s.Routes, route)
+	}
+	return s
+}
+
+// get performs a request against s and returns the status and body
+func get(s *Server, path string) (*http.Response, string) {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	res := rec.Result()
+	body, _ := io.ReadAll(res.Body)
+	return res, strings.TrimSpace(string(body))
+}
+
+func TestServeRoutes(t *testing.T) {
+	s := newServer(t, "/users=.users", "/total=.meta.total", "/ids=.users[] | .id", "/none=.tags[]")
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/users", status: 200, body: `[{"id":1},{"id":2}]`},
+		{path: "/users/", status: 200, body: `[{"id":1},{"id":2}]`},
+		{path: "/total", status: 200, body: `2`},
+		{path: "/ids", status: 200, body: `[1,2]`},
+		{path: "/none", status: 404, body: `{"error":".tags[] produced no value"}`},
+		{path: "/other", status: 404, body: `{"error":"no route for /other"}`},
+	}
+
+	for _, tt := range tests {
+		res, body := get(s, tt.path)
+		if res.StatusCode != tt.status || body != tt.body {
+			t.Errorf("GET %s = %d %s, want %d %s", tt.path, res.StatusCode, body, tt.status, tt.body)
+		}
+		if ct := res.Header.Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Errorf("GET %s Content-Type = %q", tt.path, ct)
+		}
+	}
+}
+
+func TestServeWholeDocument(t *testing.T) {
+	s := newServer(t)
+	s.Status = http.StatusAccepted
+
+	res, body := get(s, "/")
+	// The response keeps the key order of the file
+	if res.StatusCode != http.StatusAccepted || body != `{"users":[{"id":1},{"id":2}],"meta":{"total":2},"tags":[]}` {
+		t.Errorf("GET / = %d %s", res.StatusCode, body)
+	}
+	if res, _ := get(s, "/users"); res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /users without routes = %d, want 404", res.StatusCode)
+	}
+}
+
+func TestServeLatencyAndErrors(t *testing.T) {
+	s := newServer(t)
+	s.Latency = 20 * time.Millisecond
+	s.Load = func() (interface{}, *formatter.KeyOrder, error) { return nil, nil, errors.New("file vanished") }
+
+	start := time.Now()
+	res, body := get(s, "/")
+	if elapsed := time.Since(start); elapsed < s.Latency {
+		t.Errorf("response took %v, want at least %v", elapsed, s.Latency)
+	}
+	if res.StatusCode != http.StatusInternalServerError || body != `{"error":"file vanished"}` {
+		t.Errorf("GET / = %d %s", res.StatusCode, body)
+	}
+}
+
+func TestParseRouteErrors(t *testing.T) {
+	for _, spec := range []string{"users=.users", "/users", "/users=", "/users=.a |"} {
+		if _, err := ParseRoute(spec); err == nil {
+			t.Errorf("ParseRoute(%q) expected an error", spec)
+		}
+	}
+}
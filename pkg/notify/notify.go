@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds how long Send waits for the notification program
+const DefaultTimeout = 5 * time.Second
+
+// Send shows a desktop notification by using the programs present on each platform:
+// - osascript for MacOS
+// - PowerShell toast notifications for Windows
+// - notify-send for Linux
+func Send(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	name, args, err := command(runtime.GOOS, title, message)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("could not send notification: %s did not finish in time", name)
+		}
+		return fmt.Errorf("could not send notification: %w", err)
+	}
+	return nil
+}
+
+// command returns the notification program for a platform and its arguments
+func command(goos, title, message string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return "osascript", []string{"-e", script}, nil
+	case "windows":
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", toastScript(title, message)}, nil
+	case "linux":
+		return "notify-send", []string{"--app-name=fj", title, message}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported platform: %s", goos)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string, in which
+// only the quote itself needs escaping
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// toastScript builds a PowerShell script that shows a two-line toast
+func toastScript(title, message string) string {
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$x = $t.GetElementsByTagName('text')",
+		"$x.Item(0).AppendChild($t.CreateTextNode(" + powerShellString(title) + ")) > $null",
+		"$x.Item(1).AppendChild($t.CreateTextNode(" + powerShellString(message) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('fj').Show([Windows.UI.Notifications.ToastNotification]::new($t))",
+	}, "; ")
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		wantName string
+		wantArg  string
+	}{
+		{"linux", "notify-send", `done "x"`},
+		{"darwin", "osascript", `display notification "done \"x\"" with title "fj \\ ok"`},
+		{"windows", "powershell", `CreateTextNode('it''s fj')`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			title, message := `fj \ ok`, `done "x"`
+			if tt.goos == "windows" {
+				title = "it's fj"
+			}
+			name, args, err := command(tt.goos, title, message)
+			if err != nil {
+				t.Fatalf("command() error = %v", err)
+			}
+			if name != tt.wantName {
+				t.Errorf("command() name = %q, want %q", name, tt.wantName)
+			}
+			if !strings.Contains(strings.Join(args, "\n"), tt.wantArg) {
+				t.Errorf("command() args = %q, want them to contain %q", args, tt.wantArg)
+			}
+		})
+	}
+
+	if _, _, err := command("plan9", "t", "m"); err == nil {
+		t.Error("command() succeeded on an unsupported platform")
+	}
+}
@@ -0,0 +1,193 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// DefaultPercentiles are reported when no percentiles are requested
+var DefaultPercentiles = []float64{50, 90, 99}
+
+// Report summarizes the numeric fields of a set of records
+type Report struct {
+	Records int          `json:"records"`
+	Fields  []FieldStats `json:"fields"`
+}
+
+// FieldStats holds the statistics of one numeric field. Count is the number of
+// numeric values; Min, Max, Mean, Stddev and Percentiles are computed over them.
+type FieldStats struct {
+	Field       string             `json:"field"`
+	Count       int                `json:"count"`
+	Nulls       int                `json:"nulls"`
+	Missing     int                `json:"missing"`
+	NonNumeric  int                `json:"non_numeric"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Stddev      float64            `json:"stddev"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+}
+
+// DecodeRecords parses either a single JSON array of records or a stream of
+// JSON values such as NDJSON, returning one entry per record
+func DecodeRecords(data []byte) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var values []interface{}
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON in record %d: %v", len(values)+1, err)
+		}
+		values = append(values, v)
+	}
+
+	if len(values) == 1 {
+		if arr, ok := values[0].([]interface{}); ok {
+			return arr, nil
+		}
+	}
+
+	return values, nil
+}
+
+// fieldData accumulates raw observations for one field
+type fieldData struct {
+	numbers    []float64
+	nulls      int
+	nonNumeric int
+	seen       int
+}
+
+// Profile computes statistics for every field that holds a number in at least
+// one record. Nested objects are walked and reported with dotted paths; arrays
+// are treated as non-numeric values.
+func Profile(records []interface{}, percentiles []float64) (Report, error) {
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return Report{}, fmt.Errorf("percentile %v is outside 0-100", p)
+		}
+	}
+
+	fields := make(map[string]*fieldData)
+	for _, record := range records {
+		collect(nil, record, fields)
+	}
+
+	report := Report{Records: len(records), Fields: []FieldStats{}}
+	names := make([]string, 0, len(fields))
+	for name, data := range fields {
+		if len(data.numbers) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		report.Fields = append(report.Fields, summarize(name, fields[name], len(records), percentiles))
+	}
+
+	return report, nil
+}
+
+// collect records every leaf value of v, located at path
+func collect(path []jsonpath.Segment, v interface{}, fields map[string]*fieldData) {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for k, child := range obj {
+			collect(append(path, jsonpath.Key(k)), child, fields)
+		}
+		return
+	}
+
+	name := jsonpath.String(path)
+	if name == "" {
+		// The records themselves are scalars
+		name = "(root)"
+	}
+	data, ok := fields[name]
+	if !ok {
+		data = &fieldData{}
+		fields[name] = data
+	}
+	data.seen++
+
+	switch val := v.(type) {
+	case float64:
+		data.numbers = append(data.numbers, val)
+	case json.Number:
+		// Statistics are computed in float64 anyway
+		f, _ := val.Float64()
+		data.numbers = append(data.numbers, f)
+	case nil:
+		data.nulls++
+	default:
+		data.nonNumeric++
+	}
+}
+
+// summarize turns the observations of one field into statistics
+func summarize(name string, data *fieldData, records int, percentiles []float64) FieldStats {
+	nums := append([]float64(nil), data.numbers...)
+	sort.Float64s(nums)
+
+	stats := FieldStats{
+		Field:      name,
+		Count:      len(nums),
+		Nulls:      data.nulls,
+		Missing:    records - data.seen,
+		NonNumeric: data.nonNumeric,
+	}
+
+	sum := 0.0
+	for _, n := range nums {
+		sum += n
+	}
+	stats.Mean = sum / float64(len(nums))
+
+	variance := 0.0
+	for _, n := range nums {
+		variance += (n - stats.Mean) * (n - stats.Mean)
+	}
+	stats.Stddev = math.Sqrt(variance / float64(len(nums)))
+
+	stats.Min, stats.Max = nums[0], nums[len(nums)-1]
+
+	if len(percentiles) > 0 {
+		stats.Percentiles = make(map[string]float64, len(percentiles))
+		for _, p := range percentiles {
+			stats.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = percentile(nums, p)
+		}
+	}
+
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted values using linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	frac := rank - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
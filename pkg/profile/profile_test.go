@@ -0,0 +1,90 @@
+package profile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "Array of records", input: `[{"a":1},{"a":2},{"a":3}]`, want: 3, wantErr: false},
+		{name: "NDJSON", input: "{\"a\":1}\n{\"a\":2}\n", want: 2, wantErr: false},
+		{name: "Single object", input: `{"a":1}`, want: 1, wantErr: false},
+		{name: "Empty input", input: ``, want: 0, wantErr: false},
+		{name: "Broken record", input: "{\"a\":1}\n{\"a\":\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRecords([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeRecords() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.want {
+				t.Errorf("DecodeRecords() returned %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	records, err := DecodeRecords([]byte(`[
+		{"price": 10, "qty": 1, "name": "a", "dims": {"w": 2}},
+		{"price": 20, "qty": null, "name": "b", "dims": {"w": 4}},
+		{"price": 30, "qty": "many", "name": "c"},
+		{"price": 40, "name": "d"}
+	]`))
+	if err != nil {
+		t.Fatalf("DecodeRecords() error = %v", err)
+	}
+
+	report, err := Profile(records, DefaultPercentiles)
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+
+	if report.Records != 4 {
+		t.Errorf("Profile().Records = %d, want 4", report.Records)
+	}
+
+	var names []string
+	for _, f := range report.Fields {
+		names = append(names, f.Field)
+	}
+	if len(names) != 3 || names[0] != "dims.w" || names[1] != "price" || names[2] != "qty" {
+		t.Fatalf("Profile() fields = %v, want [dims.w price qty]", names)
+	}
+
+	price := report.Fields[1]
+	if price.Count != 4 || price.Min != 10 || price.Max != 40 || price.Mean != 25 {
+		t.Errorf("price stats = count %d min %v max %v mean %v", price.Count, price.Min, price.Max, price.Mean)
+	}
+	if math.Abs(price.Stddev-11.180339887) > 1e-6 {
+		t.Errorf("price stddev = %v", price.Stddev)
+	}
+	if price.Percentiles["p50"] != 25 || price.Percentiles["p90"] != 37 {
+		t.Errorf("price percentiles = %v", price.Percentiles)
+	}
+
+	qty := report.Fields[2]
+	if qty.Count != 1 || qty.Nulls != 1 || qty.NonNumeric != 1 || qty.Missing != 1 {
+		t.Errorf("qty stats = %+v", qty)
+	}
+
+	dims := report.Fields[0]
+	if dims.Missing != 2 || dims.Count != 2 {
+		t.Errorf("dims.w stats = %+v", dims)
+	}
+}
+
+func TestProfileInvalidPercentile(t *testing.T) {
+	if _, err := Profile(nil, []float64{101}); err == nil {
+		t.Errorf("Profile() accepted a percentile above 100")
+	}
+}
@@ -0,0 +1,509 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// node is an element of a compiled expression. eval returns every output
+// produced for one input.
+type node interface {
+	eval(in Result) ([]Result, error)
+}
+
+// identityNode is "."
+type identityNode struct{}
+
+func (n *identityNode) eval(in Result) ([]Result, error) {
+	return []Result{in}, nil
+}
+
+// literalNode is a constant; it keeps the input path so failures can still be located
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(in Result) ([]Result, error) {
+	return []Result{{Value: n.value, Path: in.Path}}, nil
+}
+
+// pipeNode feeds every output of left into right
+type pipeNode struct {
+	left, right node
+}
+
+func (n *pipeNode) eval(in Result) ([]Result, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, l := range lefts {
+		rights, err := n.right.eval(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rights...)
+	}
+	return out, nil
+}
+
+// indexNode is target[key], covering both .name and .[n]
+type indexNode struct {
+	target node
+	key    node
+}
+
+func (n *indexNode) eval(in Result) ([]Result, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := n.key.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, t := range targets {
+		for _, k := range keys {
+			r, err := index(t, k.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// index looks up key in the target value
+func index(t Result, key interface{}) (Result, error) {
+	switch k := key.(type) {
+	case string:
+		switch v := t.Value.(type) {
+		case map[string]interface{}:
+			return Result{Value: v[k], Path: appendPath(t.Path, jsonpath.Key(k))}, nil
+		case nil:
+			return Result{Value: nil, Path: appendPath(t.Path, jsonpath.Key(k))}, nil
+		}
+		return Result{}, fmt.Errorf("cannot index %s with %q", typeName(t.Value), k)
+	case float64:
+		i := int(k)
+		if float64(i) != k {
+			return Result{}, fmt.Errorf("array index %v is not an integer", k)
+		}
+		switch v := t.Value.(type) {
+		case []interface{}:
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return Result{Value: nil, Path: appendPath(t.Path, jsonpath.Index(i))}, nil
+			}
+			return Result{Value: v[i], Path: appendPath(t.Path, jsonpath.Index(i))}, nil
+		case nil:
+			return Result{Value: nil, Path: appendPath(t.Path, jsonpath.Index(i))}, nil
+		}
+		return Result{}, fmt.Errorf("cannot index %s with number", typeName(t.Value))
+	}
+	return Result{}, fmt.Errorf("cannot index %s with %s", typeName(t.Value), typeName(key))
+}
+
+// iterateNode is target[], producing every element
+type iterateNode struct {
+	target node
+}
+
+func (n *iterateNode) eval(in Result) ([]Result, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, t := range targets {
+		switch v := t.Value.(type) {
+		case []interface{}:
+			for i, item := range v {
+				out = append(out, Result{Value: item, Path: appendPath(t.Path, jsonpath.Index(i))})
+			}
+		case map[string]interface{}:
+			for _, k := range sortedKeys(v) {
+				out = append(out, Result{Value: v[k], Path: appendPath(t.Path, jsonpath.Key(k))})
+			}
+		default:
+			return nil, fmt.Errorf("cannot iterate over %s", typeName(t.Value))
+		}
+	}
+	return out, nil
+}
+
+// compareNode is left <op> right
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(in Result) ([]Result, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := n.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, l := range lefts {
+		for _, r := range rights {
+			c := Compare(l.Value, r.Value)
+			var ok bool
+			switch n.op {
+			case "==":
+				ok = c == 0
+			case "!=":
+				ok = c != 0
+			case "<":
+				ok = c < 0
+			case "<=":
+				ok = c <= 0
+			case ">":
+				ok = c > 0
+			case ">=":
+				ok = c >= 0
+			}
+			// The result is located where the left operand was found
+			out = append(out, Result{Value: ok, Path: l.Path})
+		}
+	}
+	return out, nil
+}
+
+// logicNode is "left and right" or "left or right", short-circuiting like jq
+type logicNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicNode) eval(in Result) ([]Result, error) {
+	lefts, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, l := range lefts {
+		if Truthy(l.Value) != n.and {
+			// false and ... / true or ...: the left side decides
+			out = append(out, Result{Value: !n.and, Path: l.Path})
+			continue
+		}
+
+		rights, err := n.right.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rights {
+			out = append(out, Result{Value: Truthy(r.Value), Path: r.Path})
+		}
+	}
+	return out, nil
+}
+
+// notNode is the prefix "!" operator
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(in Result) ([]Result, error) {
+	results, err := n.operand.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Value = !Truthy(results[i].Value)
+	}
+	return results, nil
+}
+
+// negateNode is the prefix "-" operator
+type negateNode struct {
+	operand node
+}
+
+func (n *negateNode) eval(in Result) ([]Result, error) {
+	results, err := n.operand.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range results {
+		switch v := r.Value.(type) {
+		case float64:
+			results[i].Value = -v
+		case json.Number:
+			if neg, ok := strings.CutPrefix(string(v), "-"); ok {
+				results[i].Value = json.Number(neg)
+			} else {
+				results[i].Value = json.Number("-" + string(v))
+			}
+		default:
+			return nil, fmt.Errorf("cannot negate %s", typeName(r.Value))
+		}
+	}
+	return results, nil
+}
+
+// builtin describes a built-in function. Functions receive the input and the
+// unevaluated argument expressions.
+type builtin struct {
+	arity int
+	call  func(in Result, args []node) ([]Result, error)
+}
+
+// builtins lists the functions available in queries
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"length": {arity: 0, call: builtinLength},
+		"keys":   {arity: 0, call: builtinKeys},
+		"map":    {arity: 1, call: builtinMap},
+		"select": {arity: 1, call: builtinSelect},
+		"not": {arity: 0, call: func(in Result, args []node) ([]Result, error) {
+			return []Result{{Value: !Truthy(in.Value), Path: in.Path}}, nil
+		}},
+	}
+}
+
+// callNode invokes a built-in function
+type callNode struct {
+	name string
+	fn   builtin
+	args []node
+}
+
+func (n *callNode) eval(in Result) ([]Result, error) {
+	out, err := n.fn.call(in, n.args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.name, err)
+	}
+	return out, nil
+}
+
+// builtinLength implements length for strings, arrays, objects, numbers and null
+func builtinLength(in Result, args []node) ([]Result, error) {
+	var n float64
+	switch v := in.Value.(type) {
+	case nil:
+		n = 0
+	case bool:
+		return nil, fmt.Errorf("boolean has no length")
+	case float64:
+		n = math.Abs(v)
+	case json.Number:
+		return []Result{{Value: json.Number(strings.TrimPrefix(string(v), "-")), Path: in.Path}}, nil
+	case string:
+		n = float64(utf8.RuneCountInString(v))
+	case []interface{}:
+		n = float64(len(v))
+	case map[string]interface{}:
+		n = float64(len(v))
+	}
+	return []Result{{Value: n, Path: in.Path}}, nil
+}
+
+// builtinKeys implements keys: the sorted keys of an object, or the indices of an array
+func builtinKeys(in Result, args []node) ([]Result, error) {
+	var keys []interface{}
+	switch v := in.Value.(type) {
+	case map[string]interface{}:
+		keys = make([]interface{}, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			keys = append(keys, k)
+		}
+	case []interface{}:
+		keys = make([]interface{}, len(v))
+		for i := range v {
+			keys[i] = float64(i)
+		}
+	default:
+		return nil, fmt.Errorf("%s has no keys", typeName(in.Value))
+	}
+	return []Result{{Value: keys, Path: in.Path}}, nil
+}
+
+// builtinMap implements map(f): an array of every output of f for each
+// element of the input, like [.[] | f] in jq
+func builtinMap(in Result, args []node) ([]Result, error) {
+	items, err := (&iterateNode{target: &identityNode{}}).eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		results, err := args[0].eval(item)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			out = append(out, r.Value)
+		}
+	}
+	return []Result{{Value: out, Path: in.Path}}, nil
+}
+
+// builtinSelect implements select(f): the input once for each true output of
+// f, and nothing otherwise
+func builtinSelect(in Result, args []node) ([]Result, error) {
+	results, err := args[0].eval(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Result
+	for _, r := range results {
+		if Truthy(r.Value) {
+			out = append(out, in)
+		}
+	}
+	return out, nil
+}
+
+// typeOrder ranks value types the way jq sorts them
+func typeOrder(v interface{}) int {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if val {
+			return 2
+		}
+		return 1
+	case float64, json.Number:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	case map[string]interface{}:
+		return 6
+	}
+	return 7
+}
+
+// Compare orders two values: null < false < true < numbers < strings < arrays < objects.
+// Arrays compare element by element; objects compare their sorted keys, then their values.
+func Compare(a, b interface{}) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		return ta - tb
+	}
+
+	switch va := a.(type) {
+	case float64, json.Number:
+		return compareNumbers(va, b)
+	case string:
+		vb := b.(string)
+		switch {
+		case va < vb:
+			return -1
+		case va > vb:
+			return 1
+		}
+		return 0
+	case []interface{}:
+		vb := b.([]interface{})
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if c := Compare(va[i], vb[i]); c != 0 {
+				return c
+			}
+		}
+		return len(va) - len(vb)
+	case map[string]interface{}:
+		vb := b.(map[string]interface{})
+		ka, kb := sortedKeys(va), sortedKeys(vb)
+		keysA := make([]interface{}, len(ka))
+		keysB := make([]interface{}, len(kb))
+		for i, k := range ka {
+			keysA[i] = k
+		}
+		for i, k := range kb {
+			keysB[i] = k
+		}
+		if c := Compare(keysA, keysB); c != 0 {
+			return c
+		}
+		for _, k := range ka {
+			if c := Compare(va[k], vb[k]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// typeName names the JSON type of v for error messages
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendPath returns a new path with seg appended, never sharing storage with path
+func appendPath(path []jsonpath.Segment, seg jsonpath.Segment) []jsonpath.Segment {
+	out := make([]jsonpath.Segment, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, seg)
+}
+
+// compareNumbers orders two numbers exactly, including json.Number values
+// too large or too precise for float64
+func compareNumbers(a, b interface{}) int {
+	return bigNumber(a).Cmp(bigNumber(b))
+}
+
+// bigNumber converts a decoded number to a big.Float
+func bigNumber(v interface{}) *big.Float {
+	switch n := v.(type) {
+	case float64:
+		return big.NewFloat(n)
+	case json.Number:
+		if f, _, err := big.ParseFloat(string(n), 10, 256, big.ToNearestEven); err == nil {
+			return f
+		}
+	}
+	return new(big.Float)
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"regexp"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Match is an object key or a string value found by Grep
+type Match struct {
+	Result
+	// Key is set when the last key of Path matched rather than the value
+	Key bool
+	// Parent is the object or array holding the match, or nil at the root
+	Parent interface{}
+}
+
+// Grep searches the keys and string values of a document for re, in
+// document order. keys gives the order of an object's keys; when it is nil,
+// keys are sorted. A key and its value can both match.
+func Grep(v interface{}, re *regexp.Regexp, keys func(obj map[string]interface{}) []string) []Match {
+	if keys == nil {
+		keys = sortedKeys
+	}
+	var matches []Match
+	grep(nil, v, nil, re, keys, &matches)
+	return matches
+}
+
+// grep searches v, located at path inside parent
+func grep(path []jsonpath.Segment, v, parent interface{}, re *regexp.Regexp, keys func(map[string]interface{}) []string, matches *[]Match) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range keys(val) {
+			childPath := appendPath(path, jsonpath.Key(k))
+			if re.MatchString(k) {
+				*matches = append(*matches, Match{Result: Result{Value: val[k], Path: childPath}, Key: true, Parent: val})
+			}
+			grep(childPath, val[k], val, re, keys, matches)
+		}
+	case []interface{}:
+		for i, child := range val {
+			grep(appendPath(path, jsonpath.Index(i)), child, val, re, keys, matches)
+		}
+	case string:
+		if re.MatchString(val) {
+			*matches = append(*matches, Match{Result: Result{Value: val, Path: path}, Parent: parent})
+		}
+	}
+}
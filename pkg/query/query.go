@@ -0,0 +1,498 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Result is one value produced by a query, together with its location in the
+// input document when the value was reached by navigation (.a, .[0], .[])
+type Result struct {
+	Value interface{}
+	Path  []jsonpath.Segment
+}
+
+// Query is a compiled expression
+type Query struct {
+	src  string
+	root node
+}
+
+// String returns the source the query was compiled from
+func (q *Query) String() string {
+	return q.src
+}
+
+// Parse compiles an expression written in a small jq-like language:
+//
+//	.            the input itself
+//	.a.b  .["k"] object fields
+//	.[0]         array elements
+//	.[]          every element of an array or object
+//	a | b        feed each output of a into b
+//	== != < <= > >=    comparisons
+//	and or not   logic (&&, || and ! are accepted too)
+//	length keys  built-in functions
+//	map(f)       an array of f applied to every element
+//	select(f)    the input if f is true, and nothing otherwise
+func Parse(src string) (*Query, error) {
+	return parse(src, false)
+}
+
+// ParsePredicate compiles an expression like Parse, except that a bare name
+// that is not a built-in function refers to a field of the input, so that
+// predicates over records read naturally: status == "active" && age > 30.
+func ParsePredicate(src string) (*Query, error) {
+	return parse(src, true)
+}
+
+// parse compiles an expression, with or without bare field names
+func parse(src string, bareFields bool) (*Query, error) {
+	p := &parser{src: src, bareFields: bareFields}
+	if err := p.lex(); err != nil {
+		return nil, fmt.Errorf("invalid query %q: %v", src, err)
+	}
+
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %v", src, err)
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("invalid query %q: unexpected %s", src, tok)
+	}
+
+	return &Query{src: src, root: root}, nil
+}
+
+// Run evaluates the query against input and returns every output
+func (q *Query) Run(input interface{}) ([]Result, error) {
+	return q.root.eval(Result{Value: input})
+}
+
+// Filter returns the elements of items for which the query produces a true
+// value, in their original order
+func (q *Query) Filter(items []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		results, err := q.root.eval(Result{Value: item, Path: []jsonpath.Segment{jsonpath.Index(i)}})
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		for _, r := range results {
+			if Truthy(r.Value) {
+				out = append(out, item)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Truthy reports whether v counts as true: everything except false and null
+func Truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	}
+	return true
+}
+
+// tokenKind identifies a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokDot
+	tokField // .name
+	tokIdent
+	tokNumber
+	tokString
+	tokOp // operators and punctuation
+)
+
+// token is a lexical token with its byte offset in the source
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// String describes the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokField:
+		return fmt.Sprintf("'.%s' at offset %d", t.text, t.pos)
+	case tokString:
+		return fmt.Sprintf("string %q at offset %d", t.text, t.pos)
+	}
+	return fmt.Sprintf("'%s' at offset %d", t.text, t.pos)
+}
+
+// operators lists multi- and single-character operators, longest first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "|", "<", ">", "!", "(", ")", "[", "]", ",", ":", ";", "+", "-", "*", "/", "%", "{", "}"}
+
+// parser holds the token stream for a recursive-descent parse
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	// bareFields reads names that are not functions as fields of the input
+	bareFields bool
+}
+
+// lex splits the source into tokens
+func (p *parser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '.':
+			start := i
+			i++
+			if i < len(src) && isIdentStart(rune(src[i])) {
+				j := i
+				for j < len(src) && isIdentPart(rune(src[j])) {
+					j++
+				}
+				p.tokens = append(p.tokens, token{kind: tokField, text: src[i:j], pos: start})
+				i = j
+				continue
+			}
+			p.tokens = append(p.tokens, token{kind: tokDot, text: ".", pos: start})
+		case c == '"':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return fmt.Errorf("%v at offset %d", err, i)
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: s, pos: i})
+			i += n
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E' ||
+				(src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			num, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q at offset %d", src[i:j], i)
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: src[i:j], num: num, pos: i})
+			i = j
+		case isIdentStart(rune(c)):
+			j := i
+			for j < len(src) && isIdentPart(rune(src[j])) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: src[i:j], pos: i})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					p.tokens = append(p.tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: len(src)})
+	return nil
+}
+
+// lexString reads a double-quoted string with JSON escapes and returns its
+// value and the number of bytes consumed
+func lexString(src string) (string, int, error) {
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			s, err := strconv.Unquote(src[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", src[:i+1])
+			}
+			return s, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// peek returns the current token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp reports whether the current token is the operator or keyword op
+func (p *parser) isOp(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// expect consumes the operator op or fails
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return fmt.Errorf("expected '%s', found %s", op, p.peek())
+	}
+	p.next()
+	return nil
+}
+
+// parsePipe parses a | b | c
+func (p *parser) parsePipe() (node, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		p.next()
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = &pipeNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseOr parses a or b
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a and b
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("and", "&&") {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseComparison parses a single, non-associative comparison
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("==", "!=", "<", "<=", ">", ">=") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+// parseUnary parses prefix ! and -
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	if p.isOp("-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses a primary expression followed by field and index accesses
+func (p *parser) parsePostfix() (node, error) {
+	target, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokField:
+			p.next()
+			target = &indexNode{target: target, key: &literalNode{value: tok.text}}
+		case tok.kind == tokDot && p.tokens[p.pos+1].kind == tokString:
+			// ."quoted key"
+			p.next()
+			key := p.next()
+			target = &indexNode{target: target, key: &literalNode{value: key.text}}
+		case p.isOp("["):
+			p.next()
+			if p.isOp("]") {
+				p.next()
+				target = &iterateNode{target: target}
+				continue
+			}
+			key, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			target = &indexNode{target: target, key: key}
+		case tok.kind == tokDot && p.tokens[p.pos+1].kind == tokOp && p.tokens[p.pos+1].text == "[":
+			// .[0] written after another access, as in .items.[0]
+			p.next()
+		default:
+			return target, nil
+		}
+	}
+}
+
+// parsePrimary parses literals, paths, parentheses and function calls
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokDot:
+		if p.peek().kind == tokString {
+			// ."quoted key"
+			key := p.next()
+			return &indexNode{target: &identityNode{}, key: &literalNode{value: key.text}}, nil
+		}
+		return &identityNode{}, nil
+	case tokField:
+		return &indexNode{target: &identityNode{}, key: &literalNode{value: tok.text}}, nil
+	case tokNumber:
+		// Keep the written digits when float64 would round them, so that
+		// comparisons with large document numbers stay exact
+		if compareNumbers(json.Number(tok.text), tok.num) != 0 {
+			return &literalNode{value: json.Number(tok.text)}, nil
+		}
+		return &literalNode{value: tok.num}, nil
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if _, ok := builtins[tok.text]; !ok && p.bareFields {
+			return &indexNode{target: &identityNode{}, key: &literalNode{value: tok.text}}, nil
+		}
+		return p.parseCall(tok)
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %s", tok)
+}
+
+// parseCall parses a built-in function call with optional ;-separated arguments
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := builtins[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+
+	var args []node
+	if p.isOp("(") {
+		p.next()
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.isOp(";") {
+				p.next()
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if len(args) != fn.arity {
+		return nil, fmt.Errorf("function %s takes %d argument(s), got %d", name.text, fn.arity, len(args))
+	}
+
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
@@ -0,0 +1,233 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+const testDoc = `{
+	"name": "shop",
+	"price": -3,
+	"items": [{"id": 1, "price": 10}, {"id": 2, "price": -1}],
+	"tags": [],
+	"meta": {"odd key": true, "owner": null}
+}`
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return v
+}
+
+func TestRun(t *testing.T) {
+	doc := decode(t, testDoc)
+
+	tests := []struct {
+		query     string
+		want      []interface{}
+		wantPaths []string
+	}{
+		{query: ".", want: []interface{}{doc}, wantPaths: []string{""}},
+		{query: ".name", want: []interface{}{"shop"}, wantPaths: []string{"name"}},
+		{query: ".items[1].id", want: []interface{}{2.0}, wantPaths: []string{"items[1].id"}},
+		{query: ".items[-1].id", want: []interface{}{2.0}, wantPaths: []string{"items[1].id"}},
+		{query: `.meta["odd key"]`, want: []interface{}{true}, wantPaths: []string{"meta.odd key"}},
+		{query: `.meta."odd key"`, want: []interface{}{true}, wantPaths: []string{"meta.odd key"}},
+		{query: ".missing.deeper", want: []interface{}{nil}, wantPaths: []string{"missing.deeper"}},
+		{query: ".items[] | .price", want: []interface{}{10.0, -1.0}, wantPaths: []string{"items[0].price", "items[1].price"}},
+		{query: ".items[].id", want: []interface{}{1.0, 2.0}, wantPaths: []string{"items[0].id", "items[1].id"}},
+		{query: ".items | length", want: []interface{}{2.0}, wantPaths: []string{"items"}},
+		{query: ".tags | length > 0", want: []interface{}{false}, wantPaths: []string{"tags"}},
+		{query: ".price >= 0", want: []interface{}{false}, wantPaths: []string{"price"}},
+		{query: ".items[] | .price >= 0", want: []interface{}{true, false}, wantPaths: []string{"items[0].price", "items[1].price"}},
+		{query: `.name == "shop" and .price < 0`, want: []interface{}{true}, wantPaths: []string{"price"}},
+		{query: `.name == "x" && .price < 0`, want: []interface{}{false}, wantPaths: []string{"name"}},
+		{query: `.name == "x" || .meta.owner`, want: []interface{}{false}, wantPaths: []string{"meta.owner"}},
+		{query: "!.meta.owner", want: []interface{}{true}, wantPaths: []string{"meta.owner"}},
+		{query: ".meta.owner | not", want: []interface{}{true}, wantPaths: []string{"meta.owner"}},
+		{query: "-.price", want: []interface{}{3.0}, wantPaths: []string{"price"}},
+		{query: `(.name | length) == 4`, want: []interface{}{true}, wantPaths: []string{"name"}},
+		{query: ".meta | keys", want: []interface{}{[]interface{}{"odd key", "owner"}}, wantPaths: []string{"meta"}},
+		{query: ".items | keys", want: []interface{}{[]interface{}{0.0, 1.0}}, wantPaths: []string{"items"}},
+		{query: ".items | map(.id)", want: []interface{}{[]interface{}{1.0, 2.0}}, wantPaths: []string{"items"}},
+		{query: ".items | map(.price > 0)", want: []interface{}{[]interface{}{true, false}}, wantPaths: []string{"items"}},
+		{query: ".items[] | select(.price < 0) | .id", want: []interface{}{2.0}, wantPaths: []string{"items[1].id"}},
+		{query: ".items | map(select(.id > 1))", want: []interface{}{[]interface{}{map[string]interface{}{"id": 2.0, "price": -1.0}}}, wantPaths: []string{"items"}},
+		{query: ".tags[] | select(true)", wantPaths: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			results, err := q.Run(doc)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			var got []interface{}
+			var gotPaths []string
+			for _, r := range results {
+				got = append(got, r.Value)
+				gotPaths = append(gotPaths, jsonpath.String(r.Path))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() values = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotPaths, tt.wantPaths) {
+				t.Errorf("Run() paths = %q, want %q", gotPaths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{"", ".a |", ".a ==", "(.a", ".a[0", "nosuchfn", "length(1)", `"open`, ".a == 1 == 2", "@"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) expected an error", src)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	doc := decode(t, testDoc)
+
+	for _, src := range []string{".name.first", ".name[]", ".items.id", ".meta[0]", "true | length", "-.name"} {
+		q, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", src, err)
+		}
+		if _, err := q.Run(doc); err == nil {
+			t.Errorf("Run(%q) expected an error", src)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := decode(t, `[
+		{"name": "ann", "status": "active", "age": 41},
+		{"name": "bob", "status": "active", "age": 25},
+		{"name": "cid", "status": "gone", "age": 52},
+		{"name": "dee", "status": "active", "age": 33, "tags": ["x"]}
+	]`).([]interface{})
+
+	tests := []struct {
+		predicate string
+		want      []string
+	}{
+		{`status == "active" && age > 30`, []string{"ann", "dee"}},
+		{`.status == "gone" or (tags | length) > 0`, []string{"cid", "dee"}},
+		{`missing`, nil},
+		{`not`, nil},
+		{`age`, []string{"ann", "bob", "cid", "dee"}},
+	}
+
+	for _, tt := range tests {
+		q, err := ParsePredicate(tt.predicate)
+		if err != nil {
+			t.Fatalf("ParsePredicate(%q) error = %v", tt.predicate, err)
+		}
+		kept, err := q.Filter(items)
+		if err != nil {
+			t.Fatalf("Filter(%q) error = %v", tt.predicate, err)
+		}
+		var got []string
+		for _, item := range kept {
+			got = append(got, item.(map[string]interface{})["name"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%q) kept %v, want %v", tt.predicate, got, tt.want)
+		}
+	}
+
+	q, _ := ParsePredicate("name[0]")
+	if _, err := q.Filter(items); err == nil {
+		t.Errorf("Filter() expected an error indexing a string")
+	}
+	if _, err := Parse("status"); err == nil {
+		t.Errorf("Parse() accepted a bare field name")
+	}
+}
+
+func TestGrep(t *testing.T) {
+	doc := decode(t, testDoc)
+
+	var got []string
+	for _, m := range Grep(doc, regexp.MustCompile(`(?i)^o|shop`), nil) {
+		kind := "value"
+		if m.Key {
+			kind = "key"
+		}
+		got = append(got, jsonpath.String(m.Path)+" "+kind)
+	}
+	want := []string{"meta.odd key key", "meta.owner key", "name value"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Grep() = %q, want %q", got, want)
+	}
+
+	matches := Grep(doc, regexp.MustCompile(`^shop$`), nil)
+	if len(matches) != 1 || matches[0].Parent == nil || matches[0].Parent.(map[string]interface{})["price"] != -3.0 {
+		t.Errorf("Grep() did not return the parent of the match: %v", matches)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ordered := []interface{}{
+		nil,
+		false,
+		true,
+		json.Number("-12345678901234567891"),
+		-1.0,
+		2.0,
+		json.Number("9007199254740993"),
+		json.Number("9007199254740993.5"),
+		"a",
+		"b",
+		[]interface{}{1.0},
+		[]interface{}{1.0, 2.0},
+		map[string]interface{}{"a": 1.0},
+		map[string]interface{}{"a": 2.0},
+		map[string]interface{}{"b": 0.0},
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			got := Compare(ordered[i], ordered[j])
+			switch {
+			case i < j && got >= 0, i > j && got <= 0, i == j && got != 0:
+				t.Errorf("Compare(%v, %v) = %d", ordered[i], ordered[j], got)
+			}
+		}
+	}
+}
+
+func TestRunBigNumbers(t *testing.T) {
+	doc := map[string]interface{}{"id": json.Number("9007199254740993")}
+
+	for query, want := range map[string]interface{}{
+		".id == 9007199254740993": true,
+		".id == 9007199254740992": false,
+		"-.id":                    json.Number("-9007199254740993"),
+		".id | length":            json.Number("9007199254740993"),
+	} {
+		q, err := Parse(query)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", query, err)
+		}
+		results, err := q.Run(doc)
+		if err != nil || len(results) != 1 || results[0].Value != want {
+			t.Errorf("Run(%q) = %v, %v, want %v", query, results, err, want)
+		}
+	}
+}
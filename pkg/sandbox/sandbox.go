@@ -0,0 +1,95 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside is returned for paths that lead out of the sandbox directory
+var ErrOutside = errors.New("path escapes the sandbox directory")
+
+// Check verifies that path, once made absolute and with every symbolic link
+// resolved, lies within root or is root itself. Parts of path that do not
+// exist yet are allowed, as they will be created inside their parent.
+func Check(root, path string) error {
+	realRoot, err := realPath(root)
+	if err != nil {
+		return fmt.Errorf("invalid sandbox directory: %v", err)
+	}
+	info, err := os.Stat(realRoot)
+	if err != nil {
+		return fmt.Errorf("invalid sandbox directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid sandbox directory: %s is not a directory", root)
+	}
+
+	target, err := realPath(path)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(realRoot, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s: %w (%s)", path, ErrOutside, root)
+	}
+	return nil
+}
+
+// maxLinks bounds the dangling symbolic links followed by realPath, so that
+// a cycle of links cannot loop forever
+const maxLinks = 40
+
+// realPath returns the absolute form of path with symbolic links resolved.
+// When path does not exist, its closest existing ancestor is resolved and the
+// missing components are appended to it. A dangling symbolic link does not
+// count as missing: it leads to where its target would be created, so that
+// target is resolved instead.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	links := 0
+	for {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			// Missing components were collected from the leaf upwards
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		if info, lerr := os.Lstat(abs); lerr == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if links++; links > maxLinks {
+				return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+			}
+			target, err := os.Readlink(abs)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(abs), target)
+			}
+			abs = filepath.Clean(target)
+			continue
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(abs))
+		abs = parent
+	}
+}
@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "out")
+	outside := filepath.Join(base, "elsewhere")
+	for _, dir := range []string{root, outside, filepath.Join(root, "sub")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "sub"), filepath.Join(base, "inside")); err != nil {
+		t.Fatal(err)
+	}
+	// Dangling links, whose targets do not exist yet
+	links := map[string]string{
+		"dangling-out.ndjson": filepath.Join(outside, "x.ndjson"),
+		"dangling-rel.ndjson": filepath.Join("..", "elsewhere", "y.ndjson"),
+		"dangling-in.ndjson":  filepath.Join("sub", "new.ndjson"),
+		"dangling-dir":        filepath.Join(outside, "missing"),
+		"loop-a":              "loop-b",
+		"loop-b":              "loop-a",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		outside bool
+	}{
+		{name: "File in root", path: filepath.Join(root, "a.json")},
+		{name: "Root itself", path: root},
+		{name: "New nested directories", path: filepath.Join(root, "x", "y", "a.json")},
+		{name: "Dot-dot that stays inside", path: filepath.Join(root, "sub", "..", "a.json")},
+		{name: "Link into the root", path: filepath.Join(base, "inside", "a.json")},
+		{name: "Dot-dot traversal", path: filepath.Join(root, "..", "a.json"), outside: true},
+		{name: "Absolute path elsewhere", path: filepath.Join(outside, "a.json"), outside: true},
+		{name: "Sibling with a common prefix", path: root + "2/a.json", outside: true},
+		{name: "Link out of the root", path: filepath.Join(root, "escape", "a.json"), outside: true},
+		{name: "Missing file behind a link out", path: filepath.Join(root, "escape", "new", "a.json"), outside: true},
+		{name: "Dangling link inside", path: filepath.Join(root, "dangling-in.ndjson")},
+		{name: "Dangling link out", path: filepath.Join(root, "dangling-out.ndjson"), outside: true},
+		{name: "Relative dangling link out", path: filepath.Join(root, "dangling-rel.ndjson"), outside: true},
+		{name: "Missing file behind a dangling link out", path: filepath.Join(root, "dangling-dir", "a.json"), outside: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(root, tt.path)
+			if tt.outside != errors.Is(err, ErrOutside) {
+				t.Errorf("Check(%q) = %v, want outside = %v", tt.path, err, tt.outside)
+			}
+			if !tt.outside && err != nil {
+				t.Errorf("Check(%q) unexpected error: %v", tt.path, err)
+			}
+		})
+	}
+
+	if err := Check(root, filepath.Join(root, "loop-a")); err == nil {
+		t.Error("Check() expected an error for a cycle of links")
+	}
+	if err := Check(filepath.Join(base, "missing"), filepath.Join(root, "a.json")); err == nil {
+		t.Error("Check() expected an error for a missing sandbox directory")
+	}
+}
@@ -0,0 +1,276 @@
+package schema
+
+import (
+	"encoding/json"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// Draft202012 is the $schema URI of the schemas Infer writes
+const Draft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+// InferOptions holds the settings of Infer
+type InferOptions struct {
+	// Keys returns the keys of an object in document order; nil sorts them
+	Keys func(map[string]interface{}) []string
+	// Order, when set, records the key order of the schema's objects, so that
+	// keywords come in their usual order and properties in document order
+	Order *formatter.KeyOrder
+	// MaxEnum is the most distinct values a string may take to be written as
+	// an enum; 0 writes no enums
+	MaxEnum int
+}
+
+// uuidPattern matches the textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// stringFormats are the formats Infer recognizes, in the order they are tried
+var stringFormats = []struct {
+	name  string
+	match func(string) bool
+}{
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	}},
+	{"date", func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	}},
+	{"uuid", uuidPattern.MatchString},
+	{"email", func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	}},
+	{"uri", func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}},
+}
+
+// observed merges every value found at the same place in the examples, such
+// as one property across all the objects of an array
+type observed struct {
+	seen  int
+	kinds map[string]int
+	// keys lists the properties of the object values in the order they were
+	// first seen, and properties holds what was observed for each
+	keys       []string
+	properties map[string]*observed
+	// items merges the elements of the array values
+	items *observed
+	// strings lists the distinct string values in the order they were first
+	// seen, up to one more than the enum limit, and counts how often each was
+	strings []string
+	counts  map[string]int
+	// formats holds the formats every string value so far matches
+	formats []string
+}
+
+// newObserved returns an empty observation
+func newObserved() *observed {
+	return &observed{kinds: map[string]int{}}
+}
+
+// Infer writes a draft 2020-12 schema that every one of docs conforms to.
+// Types seen at the same place are merged, properties present in every
+// object are required, and strings that repeat a few distinct values are
+// written as enums. Strings that all have a common format, such as
+// date-time or email, declare it.
+func Infer(docs []interface{}, opts InferOptions) map[string]interface{} {
+	if opts.Keys == nil {
+		opts.Keys = sortedKeys
+	}
+	root := newObserved()
+	for _, doc := range docs {
+		root.add(doc, opts)
+	}
+
+	schema := root.schema(opts)
+	keys := append([]string{"$schema"}, opts.Order.Keys(schema)...)
+	schema["$schema"] = Draft202012
+	if opts.Order != nil {
+		opts.Order.Record(schema, keys)
+	}
+	return schema
+}
+
+// add merges v into the observation
+func (o *observed) add(v interface{}, opts InferOptions) {
+	o.seen++
+	switch val := v.(type) {
+	case map[string]interface{}:
+		o.kinds["object"]++
+		if o.properties == nil {
+			o.properties = map[string]*observed{}
+		}
+		for _, k := range opts.Keys(val) {
+			if o.properties[k] == nil {
+				o.properties[k] = newObserved()
+				o.keys = append(o.keys, k)
+			}
+			o.properties[k].add(val[k], opts)
+		}
+	case []interface{}:
+		o.kinds["array"]++
+		if o.items == nil {
+			o.items = newObserved()
+		}
+		for _, item := range val {
+			o.items.add(item, opts)
+		}
+	case string:
+		o.addString(val, opts.MaxEnum)
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			o.kinds["integer"]++
+		} else {
+			o.kinds["number"]++
+		}
+	case json.Number:
+		if _, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			o.kinds["integer"]++
+		} else {
+			o.kinds["number"]++
+		}
+	case bool:
+		o.kinds["boolean"]++
+	case nil:
+		o.kinds["null"]++
+	}
+}
+
+// addString merges a string, keeping the enum candidates and the formats
+// that every string so far matches
+func (o *observed) addString(s string, maxEnum int) {
+	if o.kinds["string"] == 0 {
+		for _, f := range stringFormats {
+			o.formats = append(o.formats, f.name)
+		}
+	}
+	o.kinds["string"]++
+
+	kept := o.formats[:0]
+	for _, name := range o.formats {
+		for _, f := range stringFormats {
+			if f.name == name && f.match(s) {
+				kept = append(kept, name)
+			}
+		}
+	}
+	o.formats = kept
+
+	if o.counts == nil {
+		o.counts = map[string]int{}
+	}
+	if _, ok := o.counts[s]; !ok {
+		if len(o.strings) > maxEnum {
+			return
+		}
+		o.strings = append(o.strings, s)
+	}
+	o.counts[s]++
+}
+
+// schema writes the schema of the values merged into o
+func (o *observed) schema(opts InferOptions) map[string]interface{} {
+	schema := map[string]interface{}{}
+	var keys []string
+	set := func(k string, v interface{}) {
+		schema[k] = v
+		keys = append(keys, k)
+	}
+
+	if names := o.types(); len(names) == 1 {
+		set("type", names[0])
+	} else if len(names) > 1 {
+		list := make([]interface{}, len(names))
+		for i, name := range names {
+			list[i] = name
+		}
+		set("type", list)
+	}
+
+	if o.kinds["string"] > 0 {
+		switch {
+		case len(o.formats) > 0:
+			set("format", o.formats[0])
+		case o.enum(opts.MaxEnum):
+			values := make([]interface{}, 0, len(o.strings)+1)
+			for _, s := range o.strings {
+				values = append(values, s)
+			}
+			if o.kinds["null"] > 0 {
+				// An enum is checked whatever the type, so null must be listed too
+				values = append(values, nil)
+			}
+			set("enum", values)
+		}
+	}
+
+	if o.kinds["object"] > 0 {
+		properties := make(map[string]interface{}, len(o.keys))
+		var required []interface{}
+		for _, k := range o.keys {
+			prop := o.properties[k]
+			properties[k] = prop.schema(opts)
+			if prop.seen == o.kinds["object"] {
+				required = append(required, k)
+			}
+		}
+		if opts.Order != nil {
+			opts.Order.Record(properties, o.keys)
+		}
+		set("properties", properties)
+		if len(required) > 0 {
+			set("required", required)
+		}
+	}
+
+	if o.kinds["array"] > 0 && o.items.seen > 0 {
+		set("items", o.items.schema(opts))
+	}
+
+	if opts.Order != nil {
+		opts.Order.Record(schema, keys)
+	}
+	return schema
+}
+
+// types returns the JSON Schema types seen, in a fixed order; integers are
+// folded into numbers when both were seen
+func (o *observed) types() []string {
+	var names []string
+	for _, kind := range []string{"object", "array", "string", "integer", "number", "boolean", "null"} {
+		if o.kinds[kind] == 0 || (kind == "integer" && o.kinds["number"] > 0) {
+			continue
+		}
+		names = append(names, kind)
+	}
+	return names
+}
+
+// enum reports whether the strings look like values from a fixed set: no
+// more than maxEnum distinct values, each seen twice on average
+func (o *observed) enum(maxEnum int) bool {
+	distinct := len(o.strings)
+	return maxEnum > 0 && distinct > 0 && distinct <= maxEnum &&
+		o.kinds["string"] >= 2*distinct
+}
+
+// sortedKeys returns the keys of obj in sorted order
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []string
+		want string
+	}{
+		{
+			name: "scalar",
+			docs: []string{`42`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"integer"}`,
+		},
+		{
+			name: "required keys are those in every example",
+			docs: []string{`{"id": 1, "name": "a"}`, `{"id": 2}`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+				`"properties":{"id":{"type":"integer"},"name":{"type":"string"}},"required":["id"]}`,
+		},
+		{
+			name: "integers and numbers merge into number",
+			docs: []string{`[1, 2.5]`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"number"}}`,
+		},
+		{
+			name: "several types",
+			docs: []string{`{"v": "x"}`, `{"v": null}`, `{"v": true}`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+				`"properties":{"v":{"type":["string","boolean","null"]}},"required":["v"]}`,
+		},
+		{
+			name: "repeated strings become an enum",
+			docs: []string{`[{"s": "on"}, {"s": "off"}, {"s": "on"}, {"s": "off"}, {"s": null}]`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"object",` +
+				`"properties":{"s":{"type":["string","null"],"enum":["on","off",null]}},"required":["s"]}}`,
+		},
+		{
+			name: "strings seen once are not an enum",
+			docs: []string{`["a", "b", "c"]`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"string"}}`,
+		},
+		{
+			name: "too many distinct strings",
+			docs: []string{`["a", "b", "c", "a", "b", "c"]`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"string"}}`,
+		},
+		{
+			name: "formats",
+			docs: []string{`{"at": "2024-05-01T10:00:00Z", "on": "2024-05-01", "mail": "ada@example.com",` +
+				` "id": "123e4567-e89b-12d3-a456-426614174000", "site": "https://example.com", "mixed": "2024-05-01"}`,
+				`{"at": "2024-06-01T10:00:00.5+02:00", "on": "2024-06-01", "mail": "grace@example.org",` +
+					` "id": "123E4567-E89B-12D3-A456-426614174000", "site": "http://example.org/x", "mixed": "soon"}`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+				`"at":{"type":"string","format":"date-time"},"on":{"type":"string","format":"date"},` +
+				`"mail":{"type":"string","format":"email"},"id":{"type":"string","format":"uuid"},` +
+				`"site":{"type":"string","format":"uri"},"mixed":{"type":"string"}},` +
+				`"required":["at","on","mail","id","site","mixed"]}`,
+		},
+		{
+			name: "empty arrays have no items",
+			docs: []string{`{"tags": []}`},
+			want: `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+				`"properties":{"tags":{"type":"array"}},"required":["tags"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := formatter.Options{KeyOrder: formatter.NewKeyOrder(), Compact: true}
+			var docs []interface{}
+			for _, doc := range tt.docs {
+				v, err := formatter.Decode([]byte(doc), opts)
+				if err != nil {
+					t.Fatalf("Decode(%s) error = %v", doc, err)
+				}
+				docs = append(docs, v)
+			}
+
+			got := Infer(docs, InferOptions{Keys: opts.KeyOrder.Keys, Order: opts.KeyOrder, MaxEnum: 2})
+			out, err := formatter.FormatValue(got, opts)
+			if err != nil {
+				t.Fatalf("FormatValue() error = %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Infer() =\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferSortsKeysWithoutOrder(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{"b": 1, "a": 2}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got := Infer([]interface{}{doc}, InferOptions{})
+	required, _ := got["required"].([]interface{})
+	if len(required) != 2 || required[0] != "a" || required[1] != "b" {
+		t.Errorf("required = %v, want [a b]", required)
+	}
+}
@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPatternRepeat bounds the repetitions drawn for *, + and open-ended {n,},
+// beyond the ones minLength may call for
+const maxPatternRepeat = 3
+
+// patternAttempts is how many strings are drawn from a pattern before giving
+// up on finding one that also fits the length limits
+const patternAttempts = 50
+
+// fromPattern generates a string matching pattern within the schema's length
+// limits, by walking the regular expression and drawing a path through it.
+// Patterns it cannot satisfy, such as ones with word boundaries that no
+// drawn string happens to meet, are an error rather than an invalid sample.
+func (s *Sampler) fromPattern(schema interface{}, pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	parsed = parsed.Simplify()
+
+	minLen, _ := number(schema, "minLength")
+	maxLen, hasMax := number(schema, "maxLength")
+	spread := maxPatternRepeat + int(minLen)
+	for attempt := 0; attempt < patternAttempts; attempt++ {
+		var sb strings.Builder
+		if !s.generate(&sb, parsed, spread) {
+			break
+		}
+		v := sb.String()
+		length := utf8.RuneCountInString(v)
+		if re.MatchString(v) && length >= int(minLen) && (!hasMax || length <= int(maxLen)) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("cannot generate a string matching pattern %q", pattern)
+}
+
+// generate appends a string matched by re to sb, returning false when re
+// matches nothing. Open-ended repetitions draw up to spread more than their minimum.
+func (s *Sampler) generate(sb *strings.Builder, re *syntax.Regexp, spread int) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		r, ok := s.classRune(re.Rune)
+		if !ok {
+			return false
+		}
+		sb.WriteRune(r)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte(byte('a' + s.rand.Intn(26)))
+	case syntax.OpCapture:
+		return s.generate(sb, re.Sub[0], spread)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !s.generate(sb, sub, spread) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return s.generate(sb, re.Sub[s.rand.Intn(len(re.Sub))], spread)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			lo, hi = 0, -1
+		case syntax.OpPlus:
+			lo, hi = 1, -1
+		case syntax.OpQuest:
+			lo, hi = 0, 1
+		}
+		if hi < 0 {
+			hi = lo + spread
+		}
+		for n := lo + s.rand.Intn(hi-lo+1); n > 0; n-- {
+			if !s.generate(sb, re.Sub[0], spread) {
+				return false
+			}
+		}
+	}
+	// Anchors, boundaries and empty matches add nothing; the final match
+	// check rejects strings that break a boundary
+	return true
+}
+
+// classRune draws a rune from a character class given as ranges of pairs,
+// preferring printable ASCII so that negated classes give readable text
+func (s *Sampler) classRune(ranges []rune) (rune, bool) {
+	if len(ranges) == 0 {
+		return 0, false
+	}
+	var printable []rune
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := max(ranges[i], ' '), min(ranges[i+1], '~')
+		for r := lo; r <= hi; r++ {
+			printable = append(printable, r)
+		}
+	}
+	if len(printable) > 0 {
+		return printable[s.rand.Intn(len(printable))], true
+	}
+	i := 2 * s.rand.Intn(len(ranges)/2)
+	return ranges[i] + rune(s.rand.Intn(int(ranges[i+1]-ranges[i])+1)), true
+}
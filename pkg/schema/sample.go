@@ -0,0 +1,491 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// maxSampleDepth stops recursive schemas from producing endless documents
+const maxSampleDepth = 12
+
+// words is the vocabulary for free-form strings
+var words = []string{
+	"alpha", "amber", "harbor", "maple", "orbit", "pixel", "quartz", "river",
+	"signal", "summit", "timber", "velvet", "willow", "zephyr", "copper", "delta",
+}
+
+// nameHints map words found in property names to realistic values
+var nameHints = []struct {
+	contains string
+	values   []string
+}{
+	{"email", []string{"ada@example.com", "grace@example.org", "linus@example.net"}},
+	{"first", []string{"Ada", "Grace", "Linus", "Margaret"}},
+	{"last", []string{"Lovelace", "Hopper", "Torvalds", "Hamilton"}},
+	{"name", []string{"Ada Lovelace", "Grace Hopper", "Linus Torvalds", "Margaret Hamilton"}},
+	{"city", []string{"Lisbon", "Oslo", "Turin", "Kyoto"}},
+	{"country", []string{"PT", "NO", "IT", "JP"}},
+	{"phone", []string{"+1-202-555-0143", "+44 20 7946 0958", "+39 011 555 0199"}},
+	{"url", []string{"https://example.com", "https://example.org/docs"}},
+	{"currency", []string{"EUR", "USD", "JPY"}},
+	{"status", []string{"active", "pending", "archived"}},
+}
+
+// Sampler generates example documents that conform to a schema
+type Sampler struct {
+	rand     *rand.Rand
+	resolver resolver
+	order    *formatter.KeyOrder
+}
+
+// NewSampler returns a sampler for root. The same seed always produces the
+// same documents. order, when set, holds the key order of root, and the
+// samples record theirs in it, so that properties come in the order the
+// schema declares them; a nil order sorts them.
+func NewSampler(root interface{}, seed int64, order *formatter.KeyOrder) *Sampler {
+	return &Sampler{
+		rand:     rand.New(rand.NewSource(seed)),
+		resolver: resolver{root: root},
+		order:    order,
+	}
+}
+
+// Sample generates one document. Values are drawn from const, enum, examples
+// and default when the schema has them; otherwise they respect type, format,
+// length, pattern, range and item count constraints. A pattern no generated
+// string can match is an error.
+func (s *Sampler) Sample() (interface{}, error) {
+	return s.value(s.resolver.root, "", 0)
+}
+
+// value generates a value for schema; name is the property it will be stored under
+func (s *Sampler) value(schema interface{}, name string, depth int) (interface{}, error) {
+	schema, err := s.resolver.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b := schema.(type) {
+	case bool:
+		if !b {
+			return nil, fmt.Errorf("schema false accepts no value")
+		}
+		return s.word(), nil
+	case map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("invalid schema %v", schema)
+	}
+
+	if v, ok := keyword(schema, "const"); ok {
+		return v, nil
+	}
+	if enum, ok := mustKeyword(schema, "enum").([]interface{}); ok && len(enum) > 0 {
+		return enum[s.rand.Intn(len(enum))], nil
+	}
+	if examples, ok := mustKeyword(schema, "examples").([]interface{}); ok && len(examples) > 0 {
+		return examples[s.rand.Intn(len(examples))], nil
+	}
+	if v, ok := keyword(schema, "default"); ok {
+		return v, nil
+	}
+
+	if all, ok := mustKeyword(schema, "allOf").([]interface{}); ok && len(all) > 0 {
+		merged, err := s.mergeAll(schema, all)
+		if err != nil {
+			return nil, err
+		}
+		return s.value(merged, name, depth)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := mustKeyword(schema, key).([]interface{}); ok && len(options) > 0 {
+			return s.value(options[s.rand.Intn(len(options))], name, depth)
+		}
+	}
+
+	switch s.pickType(schema) {
+	case "object":
+		return s.object(schema, depth)
+	case "array":
+		return s.array(schema, name, depth)
+	case "string":
+		return s.str(schema, name)
+	case "integer":
+		return s.num(schema, true)
+	case "number":
+		return s.num(schema, false)
+	case "boolean":
+		return s.rand.Intn(2) == 1, nil
+	case "null":
+		return nil, nil
+	}
+
+	// An empty schema accepts anything
+	return s.word(), nil
+}
+
+// pickType chooses one of the allowed types, preferring anything over null
+func (s *Sampler) pickType(schema interface{}) string {
+	allowed := types(schema)
+	for _, t := range allowed {
+		if t != "null" {
+			return t
+		}
+	}
+	if len(allowed) > 0 {
+		return allowed[0]
+	}
+	return ""
+}
+
+// mergeAll combines the subschemas of allOf into one object schema, merging
+// properties and required lists; other keywords are taken from the first
+// subschema that has them
+func (s *Sampler) mergeAll(schema interface{}, all []interface{}) (interface{}, error) {
+	merged := make(map[string]interface{})
+	properties := make(map[string]interface{})
+	var names []string
+	var required []interface{}
+
+	parts := append([]interface{}{schema}, all...)
+	for i, part := range parts {
+		resolved, err := s.resolver.resolve(part)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := resolved.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range obj {
+			switch {
+			case i == 0 && k == "allOf":
+				// Already being expanded
+			case k == "properties":
+				if props, ok := v.(map[string]interface{}); ok {
+					for _, name := range s.order.Keys(props) {
+						if _, ok := properties[name]; !ok {
+							names = append(names, name)
+						}
+						properties[name] = props[name]
+					}
+				}
+			case k == "required":
+				if list, ok := v.([]interface{}); ok {
+					required = append(required, list...)
+				}
+			default:
+				if _, exists := merged[k]; !exists {
+					merged[k] = v
+				}
+			}
+		}
+	}
+
+	if len(properties) > 0 {
+		s.order.Record(properties, names)
+		merged["properties"] = properties
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged, nil
+}
+
+// object generates every declared property, adding filler properties when
+// minProperties asks for more
+func (s *Sampler) object(schema interface{}, depth int) (interface{}, error) {
+	obj := make(map[string]interface{})
+	props, _ := mustKeyword(schema, "properties").(map[string]interface{})
+
+	required := make(map[string]bool)
+	if list, ok := mustKeyword(schema, "required").([]interface{}); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	var names []string
+	for _, name := range s.order.Keys(props) {
+		// Past the depth limit only required properties are filled in
+		if depth >= maxSampleDepth && !required[name] {
+			continue
+		}
+		v, err := s.value(props[name], name, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		obj[name] = v
+		names = append(names, name)
+	}
+
+	// Required properties without a declaration accept anything
+	undeclared := make([]string, 0, len(required))
+	for name := range required {
+		if _, ok := obj[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		obj[name] = s.word()
+		names = append(names, name)
+	}
+
+	if minProps, ok := number(schema, "minProperties"); ok {
+		extra, _ := keyword(schema, "additionalProperties")
+		for i := 1; len(obj) < int(minProps); i++ {
+			name := fmt.Sprintf("extra%d", i)
+			if _, taken := obj[name]; taken {
+				continue
+			}
+			v, err := s.value(orTrue(extra), name, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+			names = append(names, name)
+		}
+	}
+
+	s.order.Record(obj, names)
+	return obj, nil
+}
+
+// array generates between minItems and maxItems elements, honoring tuple
+// schemas (prefixItems, or items given as a list) and uniqueItems
+func (s *Sampler) array(schema interface{}, name string, depth int) (interface{}, error) {
+	minItems, _ := number(schema, "minItems")
+	maxItems, hasMax := number(schema, "maxItems")
+	if !hasMax {
+		maxItems = minItems + 3
+	}
+	if depth >= maxSampleDepth {
+		maxItems = minItems
+	}
+	// Prefer at least one element, since an empty array shows nothing
+	lowest := int(minItems)
+	if lowest == 0 && maxItems >= 1 {
+		lowest = 1
+	}
+	count := lowest
+	if span := int(maxItems) - lowest; span > 0 {
+		count += s.rand.Intn(span + 1)
+	}
+
+	prefix, _ := mustKeyword(schema, "prefixItems").([]interface{})
+	items := mustKeyword(schema, "items")
+	if tuple, ok := items.([]interface{}); ok {
+		// Draft 4-7 tuple form
+		prefix = tuple
+		items = mustKeyword(schema, "additionalItems")
+	}
+	unique := mustKeyword(schema, "uniqueItems") == true
+
+	arr := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		itemSchema := orTrue(items)
+		if i < len(prefix) {
+			itemSchema = prefix[i]
+		}
+
+		var v interface{}
+		var err error
+		for attempt := 0; attempt < 10; attempt++ {
+			if v, err = s.value(itemSchema, singular(name), depth+1); err != nil {
+				return nil, fmt.Errorf("[%d]: %v", i, err)
+			}
+			if !unique || !containsValue(arr, v) {
+				break
+			}
+		}
+		if unique && containsValue(arr, v) {
+			// The item schema cannot produce more distinct values
+			break
+		}
+		arr = append(arr, v)
+	}
+
+	return arr, nil
+}
+
+// str generates a string honoring format, then property name hints, then
+// length limits. When the result does not match the schema's pattern, a
+// string is generated from the pattern instead.
+func (s *Sampler) str(schema interface{}, name string) (string, error) {
+	v := s.plainStr(schema, name)
+	pattern, ok := mustKeyword(schema, "pattern").(string)
+	if !ok {
+		return v, nil
+	}
+	if re, err := regexp.Compile(pattern); err == nil && re.MatchString(v) {
+		return v, nil
+	}
+	return s.fromPattern(schema, pattern)
+}
+
+// plainStr generates a string from format, property name hints and length limits
+func (s *Sampler) plainStr(schema interface{}, name string) string {
+	if format, ok := mustKeyword(schema, "format").(string); ok {
+		if v, ok := s.formatted(format); ok {
+			return v
+		}
+	}
+
+	minLen, _ := number(schema, "minLength")
+	maxLen, hasMax := number(schema, "maxLength")
+
+	var v string
+	lower := strings.ToLower(name)
+	for _, hint := range nameHints {
+		if strings.Contains(lower, hint.contains) {
+			v = hint.values[s.rand.Intn(len(hint.values))]
+			break
+		}
+	}
+	if v == "" || len(v) < int(minLen) || (hasMax && len(v) > int(maxLen)) {
+		v = s.word()
+	}
+
+	for len(v) < int(minLen) {
+		v += " " + s.word()
+	}
+	if hasMax && len(v) > int(maxLen) {
+		v = v[:int(maxLen)]
+	}
+	return v
+}
+
+// formatted generates a value for a known string format
+func (s *Sampler) formatted(format string) (string, bool) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t := base.Add(time.Duration(s.rand.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+
+	switch format {
+	case "date-time":
+		return t.Format(time.RFC3339), true
+	case "date":
+		return t.Format("2006-01-02"), true
+	case "time":
+		return t.Format("15:04:05Z"), true
+	case "email", "idn-email":
+		return fmt.Sprintf("%s%d@example.com", s.word(), s.rand.Intn(100)), true
+	case "hostname", "idn-hostname":
+		return s.word() + ".example.com", true
+	case "uri", "iri", "url":
+		return "https://example.com/" + s.word(), true
+	case "uri-reference", "iri-reference":
+		return "/" + s.word(), true
+	case "uuid":
+		b := make([]byte, 16)
+		s.rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), true
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", 1+s.rand.Intn(254)), true
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", 1+s.rand.Intn(0xfffe)), true
+	case "duration":
+		return fmt.Sprintf("PT%dM", 1+s.rand.Intn(59)), true
+	}
+	return "", false
+}
+
+// num generates a number within the schema's bounds, as a multiple of
+// multipleOf when set
+func (s *Sampler) num(schema interface{}, integer bool) (interface{}, error) {
+	lo, hasLo := number(schema, "minimum")
+	hi, hasHi := number(schema, "maximum")
+
+	// Draft 6+ uses numeric exclusive bounds, draft 4 booleans
+	if v, ok := number(schema, "exclusiveMinimum"); ok && (!hasLo || v >= lo) {
+		lo, hasLo = math.Nextafter(v, math.Inf(1)), true
+		if integer {
+			lo = math.Floor(v) + 1
+		}
+	} else if mustKeyword(schema, "exclusiveMinimum") == true && hasLo {
+		lo = math.Nextafter(lo, math.Inf(1))
+		if integer {
+			lo = math.Floor(lo) + 1
+		}
+	}
+	if v, ok := number(schema, "exclusiveMaximum"); ok && (!hasHi || v <= hi) {
+		hi, hasHi = math.Nextafter(v, math.Inf(-1)), true
+		if integer {
+			hi = math.Ceil(v) - 1
+		}
+	} else if mustKeyword(schema, "exclusiveMaximum") == true && hasHi {
+		hi = math.Nextafter(hi, math.Inf(-1))
+		if integer {
+			hi = math.Ceil(hi) - 1
+		}
+	}
+
+	switch {
+	case !hasLo && !hasHi:
+		lo, hi = 0, 100
+	case !hasLo:
+		lo = hi - 100
+	case !hasHi:
+		hi = lo + 100
+	}
+
+	step, _ := number(schema, "multipleOf")
+	if integer && step == 0 {
+		step = 1
+	}
+	if step > 0 {
+		first, last := math.Ceil(lo/step), math.Floor(hi/step)
+		if first > last {
+			return nil, fmt.Errorf("no multiple of %v between %v and %v", step, lo, hi)
+		}
+		v := (first + float64(s.rand.Int63n(int64(last-first)+1))) * step
+		// Drop the noise of fractional steps, e.g. 0.30000000000000004
+		return math.Round(v*1e9) / 1e9, nil
+	}
+
+	if lo > hi {
+		return nil, fmt.Errorf("minimum %v is greater than maximum %v", lo, hi)
+	}
+	// Two decimals look more like real data than full float precision
+	v := math.Round((lo+s.rand.Float64()*(hi-lo))*100) / 100
+	return math.Min(math.Max(v, lo), hi), nil
+}
+
+// word returns a random vocabulary word
+func (s *Sampler) word() string {
+	return words[s.rand.Intn(len(words))]
+}
+
+// orTrue returns schema, or the accept-anything schema when it is nil
+func orTrue(schema interface{}) interface{} {
+	if schema == nil {
+		return true
+	}
+	return schema
+}
+
+// singular turns a plural property name into a hint for its items, e.g. emails -> email
+func singular(name string) string {
+	return strings.TrimSuffix(name, "s")
+}
+
+// containsValue reports whether arr already holds a value equal to v
+func containsValue(arr []interface{}, v interface{}) bool {
+	for _, item := range arr {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Schemas are handled as decoded JSON: an object of keywords, or a boolean
+// where true accepts anything and false accepts nothing.
+
+// keyword returns the value of a keyword, if the schema is an object that has it
+func keyword(schema interface{}, name string) (interface{}, bool) {
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok := obj[name]
+	return v, ok
+}
+
+// number returns a numeric keyword
+func number(schema interface{}, name string) (float64, bool) {
+	v, ok := keyword(schema, name)
+	if !ok {
+		return 0, false
+	}
+	return toFloat(v)
+}
+
+// types returns the types a schema allows, inferring them from its keywords
+// when "type" is absent
+func types(schema interface{}) []string {
+	switch t := mustKeyword(schema, "type").(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var names []string
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+
+	for _, hint := range []struct {
+		name     string
+		keywords []string
+	}{
+		{"object", []string{"properties", "required", "additionalProperties", "patternProperties", "minProperties", "maxProperties"}},
+		{"array", []string{"items", "prefixItems", "minItems", "maxItems", "uniqueItems", "contains"}},
+		{"string", []string{"minLength", "maxLength", "pattern", "format"}},
+		{"number", []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}},
+	} {
+		for _, name := range hint.keywords {
+			if _, ok := keyword(schema, name); ok {
+				return []string{hint.name}
+			}
+		}
+	}
+	return nil
+}
+
+// mustKeyword returns a keyword or nil
+func mustKeyword(schema interface{}, name string) interface{} {
+	v, _ := keyword(schema, name)
+	return v
+}
+
+// resolver looks up local "$ref" references inside a root schema
+type resolver struct {
+	root interface{}
+}
+
+// resolve follows $ref until it reaches a schema without one. Only references
+// into the same document ("#", "#/$defs/name", "#/definitions/name") are supported.
+func (r *resolver) resolve(schema interface{}) (interface{}, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := keyword(schema, "$ref")
+		if !ok {
+			return schema, nil
+		}
+		s, ok := ref.(string)
+		if !ok || !strings.HasPrefix(s, "#") {
+			return nil, fmt.Errorf("unsupported $ref %v: only local references such as #/$defs/name are supported", ref)
+		}
+
+		target, err := jsonpath.ResolvePointer(r.root, s[1:])
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve $ref %q: %v", s, err)
+		}
+		schema = target
+	}
+	return nil, fmt.Errorf("too many nested $ref indirections")
+}
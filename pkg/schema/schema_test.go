@@ -0,0 +1,192 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// decode parses a test schema
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", s, err)
+	}
+	return v
+}
+
+func TestSample(t *testing.T) {
+	root := decode(t, `{
+		"type": "object",
+		"required": ["id", "email", "role", "tags"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 10, "maximum": 20},
+			"email": {"type": "string", "format": "email"},
+			"role": {"enum": ["admin", "viewer"]},
+			"score": {"type": "number", "exclusiveMinimum": 0, "maximum": 1},
+			"price": {"type": "number", "minimum": 0, "maximum": 1, "multipleOf": 0.1},
+			"code": {"type": "string", "minLength": 12, "maxLength": 15},
+			"created": {"type": "string", "format": "date-time"},
+			"uid": {"type": "string", "format": "uuid"},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 2, "maxItems": 4, "uniqueItems": true},
+			"owner": {"$ref": "#/$defs/person"},
+			"both": {"allOf": [{"properties": {"a": {"const": 1}}}, {"properties": {"b": {"const": 2}}}]},
+			"maybe": {"type": ["null", "boolean"]}
+		},
+		"$defs": {
+			"person": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+		}
+	}`)
+
+	for seed := int64(1); seed <= 20; seed++ {
+		v, err := NewSampler(root, seed, nil).Sample()
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		doc := v.(map[string]interface{})
+
+		if id := doc["id"].(float64); id < 10 || id > 20 || id != float64(int(id)) {
+			t.Errorf("id = %v, want an integer in [10, 20]", id)
+		}
+		if email := doc["email"].(string); !regexp.MustCompile(`^\w+@example\.com$`).MatchString(email) {
+			t.Errorf("email = %q", email)
+		}
+		if role := doc["role"]; role != "admin" && role != "viewer" {
+			t.Errorf("role = %v, want an enum value", role)
+		}
+		if score := doc["score"].(float64); score <= 0 || score > 1 {
+			t.Errorf("score = %v, want (0, 1]", score)
+		}
+		if price := doc["price"].(float64); price < 0 || price > 1 || price*10 != float64(int(price*10+0.5)) {
+			t.Errorf("price = %v, want a multiple of 0.1 in [0, 1]", price)
+		}
+		if code := doc["code"].(string); len(code) < 12 || len(code) > 15 {
+			t.Errorf("code = %q, want 12-15 characters", code)
+		}
+		if created := doc["created"].(string); !regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`).MatchString(created) {
+			t.Errorf("created = %q", created)
+		}
+		if uid := doc["uid"].(string); !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uid) {
+			t.Errorf("uid = %q", uid)
+		}
+		tags := doc["tags"].([]interface{})
+		if len(tags) < 2 || len(tags) > 4 {
+			t.Errorf("tags = %v, want 2-4 items", tags)
+		}
+		for i := range tags {
+			for j := i + 1; j < len(tags); j++ {
+				if tags[i] == tags[j] {
+					t.Errorf("tags = %v, want unique items", tags)
+				}
+			}
+		}
+		if owner := doc["owner"].(map[string]interface{}); owner["name"] == nil {
+			t.Errorf("owner = %v, want a name", owner)
+		}
+		if both := doc["both"]; !reflect.DeepEqual(both, map[string]interface{}{"a": 1.0, "b": 2.0}) {
+			t.Errorf("both = %v, want the merged allOf", both)
+		}
+		if _, ok := doc["maybe"].(bool); !ok {
+			t.Errorf("maybe = %v, want a boolean", doc["maybe"])
+		}
+	}
+}
+
+func TestSampleDeterministic(t *testing.T) {
+	root := decode(t, `{"type":"array","items":{"type":"object","properties":{"n":{"type":"number"},"s":{"type":"string"}}}}`)
+
+	a, _ := NewSampler(root, 7, nil).Sample()
+	b, _ := NewSampler(root, 7, nil).Sample()
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Sample() with the same seed differs: %v vs %v", a, b)
+	}
+}
+
+func TestSampleDeclarationOrder(t *testing.T) {
+	order := formatter.NewKeyOrder()
+	root, err := formatter.Decode([]byte(`{
+		"type": "object",
+		"required": ["zip"],
+		"properties": {"name": {"const": "a"}, "id": {"const": 1}},
+		"allOf": [{"properties": {"email": {"const": "b"}, "age": {"const": 2}}}]
+	}`), formatter.Options{KeyOrder: order})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewSampler(root, 1, order).Sample()
+	if err != nil {
+		t.Fatalf("Sample() error = %v", err)
+	}
+	out, err := formatter.FormatValue(v, formatter.Options{KeyOrder: order, Compact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Declared properties keep their order; undeclared required ones follow
+	if want := `{"name":"a","id":1,"email":"b","age":2,"zip":`; !strings.HasPrefix(string(out), want) {
+		t.Errorf("Sample() = %s, want it to start with %s", out, want)
+	}
+}
+
+func TestSampleRecursive(t *testing.T) {
+	root := decode(t, `{"$ref":"#/$defs/node","$defs":{"node":{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/$defs/node"}}}}}}`)
+
+	if _, err := NewSampler(root, 1, nil).Sample(); err != nil {
+		t.Errorf("Sample() error = %v", err)
+	}
+}
+
+func TestSamplePattern(t *testing.T) {
+	root := decode(t, `{
+		"type": "object",
+		"required": ["sku", "country", "zip", "slug", "version", "quoted"],
+		"properties": {
+			"sku": {"type": "string", "pattern": "^[A-Z]{3}-\\d{4}$"},
+			"country": {"type": "string", "pattern": "^[A-Z]{2}$"},
+			"zip": {"type": "string", "pattern": "^(\\d{5}|\\d{5}-\\d{4})$"},
+			"slug": {"type": "string", "pattern": "^[a-z0-9]+(-[a-z0-9]+)*$", "minLength": 12, "maxLength": 20},
+			"version": {"type": "string", "pattern": "v\\d+\\.\\d+"},
+			"quoted": {"type": "string", "pattern": "^\"[^\"]*\"$"}
+		}
+	}`)
+	validator, err := NewValidator(root, ValidatorOptions{})
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		v, err := NewSampler(root, seed, nil).Sample()
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		violations, err := validator.Validate(v)
+		if err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		if len(violations) > 0 {
+			t.Errorf("Sample() = %v, violations %v", v, violations)
+		}
+	}
+}
+
+func TestSampleErrors(t *testing.T) {
+	for _, src := range []string{
+		`false`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"$ref":"https://example.com/schema.json"}`,
+		`{"type":"integer","minimum":5,"maximum":4}`,
+		`{"type":"object","properties":{"x":false},"required":["x"]}`,
+		`{"type":"string","pattern":"a\\bb"}`,
+		`{"type":"string","pattern":"^[a-z]{2}$","minLength":3}`,
+		`{"type":"string","pattern":"("}`,
+	} {
+		if _, err := NewSampler(decode(t, src), 1, nil).Sample(); err == nil {
+			t.Errorf("Sample(%s) expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,836 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// maxValidateDepth stops schemas that refer to themselves without consuming
+// any of the document, such as {"$ref": "#"}
+const maxValidateDepth = 10000
+
+// Violation is one way a document fails a schema
+type Violation struct {
+	// InstancePath is a JSON Pointer to the value that fails, "" for the
+	// whole document
+	InstancePath string `json:"instancePath"`
+	// SchemaPath is a JSON Pointer to the keyword that fails, through every
+	// $ref followed on the way, such as #/properties/age/$ref/minimum
+	SchemaPath string `json:"schemaPath"`
+	Message    string `json:"message"`
+}
+
+// String formats the violation as path: message (schema path)
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (%s)", displayPointer(v.InstancePath), v.Message, v.SchemaPath)
+}
+
+// displayPointer names the document root explicitly
+func displayPointer(ptr string) string {
+	if ptr == "" {
+		return "(root)"
+	}
+	return ptr
+}
+
+// Validator checks documents against a JSON Schema. Drafts 7 and 2020-12
+// are supported; the draft is taken from "$schema" and is 2020-12 when it
+// is absent. Formats are checked for date-time, date, uuid, email and uri
+// and ignored otherwise. References to other documents are read with the
+// Loader given in ValidatorOptions.
+type Validator struct {
+	root interface{}
+	// legacy selects the rules of draft 7 and earlier, where $ref replaces
+	// the keywords next to it
+	legacy bool
+	// resources holds the schemas with a $id by their absolute URI, and the
+	// schemas with an anchor by URI#anchor; the root is held under its $id
+	// or ""
+	resources map[string]interface{}
+	patterns  map[string]*regexp.Regexp
+	base      string
+	load      Loader
+}
+
+// Loader returns the schema at an absolute URI without a fragment, for
+// references to schemas outside the one validated against
+type Loader func(uri string) (interface{}, error)
+
+// ValidatorOptions holds the settings of NewValidator
+type ValidatorOptions struct {
+	// Base is the URI the schema was read from, which relative references
+	// resolve against unless the schema has a $id
+	Base string
+	// Load, when set, reads the schemas that references name outside the
+	// schema; each is read once
+	Load Loader
+}
+
+// NewValidator returns a validator for root
+func NewValidator(root interface{}, opts ValidatorOptions) (*Validator, error) {
+	switch root.(type) {
+	case bool, map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("a schema must be an object or a boolean")
+	}
+	v := &Validator{
+		root:      root,
+		resources: map[string]interface{}{},
+		patterns:  map[string]*regexp.Regexp{},
+		load:      opts.Load,
+	}
+	if draft, ok := mustKeyword(root, "$schema").(string); ok {
+		v.legacy = strings.Contains(draft, "draft-04") || strings.Contains(draft, "draft-06") ||
+			strings.Contains(draft, "draft-07")
+	}
+	v.base = v.scopeOf(root, opts.Base)
+	v.resources[opts.Base] = root
+	v.resources[v.base] = root
+	if err := v.index(root, v.base); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// scopeOf returns the base URI inside schema: its $id resolved against
+// base, or base when it has none. A remote schema cannot give itself a
+// local URI, which would let its references reach local files.
+func (v *Validator) scopeOf(schema interface{}, base string) string {
+	id, ok := mustKeyword(schema, "$id").(string)
+	if !ok || (v.legacy && strings.HasPrefix(id, "#")) {
+		return base
+	}
+	abs, err := resolveURI(base, id)
+	if err != nil || (isRemote(base) && !isRemote(abs)) {
+		return base
+	}
+	return strings.SplitN(abs, "#", 2)[0]
+}
+
+// index records the schemas that have a $id or an anchor, so that $ref can
+// find them. Values that are data rather than schemas are skipped.
+func (v *Validator) index(schema interface{}, base string) error {
+	switch s := schema.(type) {
+	case []interface{}:
+		for _, item := range s {
+			if err := v.index(item, base); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		scope := v.scopeOf(s, base)
+		if scope != base {
+			v.resources[scope] = s
+		}
+		if anchor, ok := s["$anchor"].(string); ok {
+			v.resources[scope+"#"+anchor] = s
+		}
+		if id, ok := s["$id"].(string); ok && v.legacy && strings.HasPrefix(id, "#") {
+			v.resources[scope+id] = s
+		}
+		for k, child := range s {
+			switch k {
+			case "enum", "const", "default", "examples":
+				continue
+			}
+			if err := v.index(child, scope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isRemote reports whether uri is an http or https URI
+func isRemote(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// resolveURI resolves ref against base
+func resolveURI(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+// lookup finds the schema a $ref names, returning it and its base URI
+func (v *Validator) lookup(base, ref string) (interface{}, string, error) {
+	abs, err := resolveURI(base, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid $ref %q: %v", ref, err)
+	}
+	doc, fragment, _ := strings.Cut(abs, "#")
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	if _, ok := v.resources[doc]; !ok && v.load != nil {
+		// Schemas read over the network only refer to other remote schemas
+		if isRemote(base) && !isRemote(doc) {
+			return nil, "", fmt.Errorf("cannot resolve $ref %q: a remote schema cannot refer to %s", ref, doc)
+		}
+		loaded, err := v.load(doc)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot resolve $ref %q: %v", ref, err)
+		}
+		switch loaded.(type) {
+		case bool, map[string]interface{}:
+		default:
+			return nil, "", fmt.Errorf("cannot resolve $ref %q: %s is not a schema", ref, doc)
+		}
+		v.resources[doc] = loaded
+		if err := v.index(loaded, doc); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if target, ok := v.resources[doc+"#"+fragment]; ok {
+			return target, doc, nil
+		}
+		return nil, "", fmt.Errorf("cannot resolve $ref %q: no anchor %q", ref, fragment)
+	}
+	resource, ok := v.resources[doc]
+	if !ok {
+		return nil, "", fmt.Errorf("cannot resolve $ref %q: %s is not part of the schema", ref, doc)
+	}
+	target, err := jsonpath.ResolvePointer(resource, fragment)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot resolve $ref %q: %v", ref, err)
+	}
+	return target, doc, nil
+}
+
+// Validate checks doc and returns its violations. The error reports a
+// problem with the schema itself, such as a $ref that cannot be resolved or
+// a pattern that is not a valid regular expression.
+func (v *Validator) Validate(doc interface{}) ([]Violation, error) {
+	c := &check{validator: v}
+	violations, _ := c.validate(v.root, doc, "", "#", v.base, 0)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return violations, nil
+}
+
+// check holds the state of one Validate call
+type check struct {
+	validator *Validator
+	err       error
+}
+
+// evaluated records the properties and items of an instance that a schema
+// and the subschemas it passed looked at, for unevaluatedProperties and
+// unevaluatedItems
+type evaluated struct {
+	props    map[string]bool
+	items    map[int]bool
+	allItems bool
+}
+
+// merge adds the properties and items other evaluated
+func (e *evaluated) merge(other *evaluated) {
+	if other == nil {
+		return
+	}
+	for k := range other.props {
+		e.props[k] = true
+	}
+	for i := range other.items {
+		e.items[i] = true
+	}
+	e.allItems = e.allItems || other.allItems
+}
+
+// fail records a problem with the schema, keeping the first
+func (c *check) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// validate checks inst against schema. ip and sp are the paths of the
+// instance and of the schema; base is the URI relative references resolve
+// against.
+func (c *check) validate(schema, inst interface{}, ip, sp, base string, depth int) ([]Violation, *evaluated) {
+	seen := &evaluated{props: map[string]bool{}, items: map[int]bool{}}
+	if c.err != nil {
+		return nil, seen
+	}
+	if depth > maxValidateDepth {
+		c.fail(fmt.Errorf("%s: schema nests too deeply; does a $ref refer to itself?", sp))
+		return nil, seen
+	}
+
+	var out []Violation
+	report := func(keyword, format string, args ...interface{}) {
+		out = append(out, Violation{InstancePath: ip, SchemaPath: sp + "/" + keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		if b, isBool := schema.(bool); isBool {
+			if !b {
+				out = append(out, Violation{InstancePath: ip, SchemaPath: sp, Message: "no value is allowed here"})
+			}
+			return out, seen
+		}
+		c.fail(fmt.Errorf("%s: a schema must be an object or a boolean", sp))
+		return nil, seen
+	}
+	base = c.validator.scopeOf(obj, base)
+
+	// sub checks inst, or one of its members, against a subschema
+	sub := func(child interface{}, value interface{}, childIP, childSP string) ([]Violation, *evaluated) {
+		return c.validate(child, value, childIP, childSP, base, depth+1)
+	}
+
+	if ref, ok := obj["$ref"].(string); ok {
+		target, targetBase, err := c.validator.lookup(base, ref)
+		if err != nil {
+			c.fail(fmt.Errorf("%s/$ref: %v", sp, err))
+			return nil, seen
+		}
+		found, refSeen := c.validate(target, inst, ip, sp+"/$ref", targetBase, depth+1)
+		out = append(out, found...)
+		if len(found) == 0 {
+			seen.merge(refSeen)
+		}
+		if c.validator.legacy {
+			return out, seen
+		}
+	}
+	if ref, ok := obj["$dynamicRef"].(string); ok {
+		// Resolved statically, like $ref
+		target, targetBase, err := c.validator.lookup(base, ref)
+		if err != nil {
+			c.fail(fmt.Errorf("%s/$dynamicRef: %v", sp, err))
+			return nil, seen
+		}
+		found, refSeen := c.validate(target, inst, ip, sp+"/$dynamicRef", targetBase, depth+1)
+		out = append(out, found...)
+		if len(found) == 0 {
+			seen.merge(refSeen)
+		}
+	}
+
+	if t, ok := obj["type"]; ok {
+		var allowed []string
+		switch names := t.(type) {
+		case string:
+			allowed = []string{names}
+		case []interface{}:
+			for _, name := range names {
+				if s, ok := name.(string); ok {
+					allowed = append(allowed, s)
+				}
+			}
+		}
+		matched := false
+		for _, name := range allowed {
+			if hasType(inst, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("type", "expected %s, got %s", strings.Join(allowed, " or "), typeName(inst))
+		}
+	}
+	if enum, ok := obj["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(inst, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("enum", "must be one of %s", listValues(enum))
+		}
+	}
+	if want, ok := obj["const"]; ok && !equal(inst, want) {
+		report("const", "must be %s", jsonText(want))
+	}
+
+	if n, ok := toFloat(inst); ok {
+		c.numeric(obj, n, report)
+	}
+	if s, ok := inst.(string); ok {
+		c.text(obj, s, sp, report)
+	}
+
+	if items, ok := inst.([]interface{}); ok {
+		out = append(out, c.array(obj, items, ip, sp, seen, report, sub)...)
+	}
+	if members, ok := inst.(map[string]interface{}); ok {
+		out = append(out, c.object(obj, members, ip, sp, seen, report, sub)...)
+	}
+
+	// In-place applicators: their annotations count only when they pass
+	if all, ok := obj["allOf"].([]interface{}); ok {
+		for i, child := range all {
+			found, childSeen := sub(child, inst, ip, sp+"/allOf/"+strconv.Itoa(i))
+			out = append(out, found...)
+			if len(found) == 0 {
+				seen.merge(childSeen)
+			}
+		}
+	}
+	if anyOf, ok := obj["anyOf"].([]interface{}); ok {
+		matched := 0
+		for i, child := range anyOf {
+			found, childSeen := sub(child, inst, ip, sp+"/anyOf/"+strconv.Itoa(i))
+			if len(found) == 0 {
+				matched++
+				seen.merge(childSeen)
+			}
+		}
+		if matched == 0 {
+			report("anyOf", "must match at least one schema in anyOf")
+		}
+	}
+	if oneOf, ok := obj["oneOf"].([]interface{}); ok {
+		var matches []string
+		for i, child := range oneOf {
+			found, childSeen := sub(child, inst, ip, sp+"/oneOf/"+strconv.Itoa(i))
+			if len(found) == 0 {
+				matches = append(matches, strconv.Itoa(i))
+				seen.merge(childSeen)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			report("oneOf", "must match exactly one schema in oneOf, but matches none")
+		case len(matches) > 1:
+			report("oneOf", "must match exactly one schema in oneOf, but matches %s", strings.Join(matches, ", "))
+		}
+	}
+	if not, ok := obj["not"]; ok {
+		if found, _ := sub(not, inst, ip, sp+"/not"); len(found) == 0 {
+			report("not", "must not match the schema in not")
+		}
+	}
+	if cond, ok := obj["if"]; ok {
+		found, condSeen := sub(cond, inst, ip, sp+"/if")
+		branch := "then"
+		if len(found) == 0 {
+			seen.merge(condSeen)
+		} else {
+			branch = "else"
+		}
+		if child, ok := obj[branch]; ok {
+			found, childSeen := sub(child, inst, ip, sp+"/"+branch)
+			out = append(out, found...)
+			if len(found) == 0 {
+				seen.merge(childSeen)
+			}
+		}
+	}
+
+	// unevaluated* look at what every other keyword left over
+	if items, ok := inst.([]interface{}); ok {
+		if child, ok := obj["unevaluatedItems"]; ok && !seen.allItems {
+			for i, item := range items {
+				if seen.items[i] {
+					continue
+				}
+				found, _ := sub(child, item, ip+"/"+strconv.Itoa(i), sp+"/unevaluatedItems")
+				out = append(out, found...)
+			}
+			seen.allItems = true
+		}
+	}
+	if members, ok := inst.(map[string]interface{}); ok {
+		if child, ok := obj["unevaluatedProperties"]; ok {
+			for _, k := range sortedKeys(members) {
+				if seen.props[k] {
+					continue
+				}
+				if b, isBool := child.(bool); isBool && !b {
+					out = append(out, Violation{InstancePath: ip + "/" + pointerToken(k),
+						SchemaPath: sp + "/unevaluatedProperties", Message: fmt.Sprintf("property %q is not allowed", k)})
+				} else {
+					found, _ := sub(child, members[k], ip+"/"+pointerToken(k), sp+"/unevaluatedProperties")
+					out = append(out, found...)
+				}
+				seen.props[k] = true
+			}
+		}
+	}
+
+	return out, seen
+}
+
+// numeric checks the keywords about numbers
+func (c *check) numeric(obj map[string]interface{}, n float64, report func(string, string, ...interface{})) {
+	if limit, ok := number(obj, "minimum"); ok {
+		if excl, _ := obj["exclusiveMinimum"].(bool); excl && n <= limit {
+			report("minimum", "must be > %s", formatNumber(limit))
+		} else if n < limit {
+			report("minimum", "must be >= %s", formatNumber(limit))
+		}
+	}
+	if limit, ok := number(obj, "maximum"); ok {
+		if excl, _ := obj["exclusiveMaximum"].(bool); excl && n >= limit {
+			report("maximum", "must be < %s", formatNumber(limit))
+		} else if n > limit {
+			report("maximum", "must be <= %s", formatNumber(limit))
+		}
+	}
+	if limit, ok := number(obj, "exclusiveMinimum"); ok && n <= limit {
+		report("exclusiveMinimum", "must be > %s", formatNumber(limit))
+	}
+	if limit, ok := number(obj, "exclusiveMaximum"); ok && n >= limit {
+		report("exclusiveMaximum", "must be < %s", formatNumber(limit))
+	}
+	if step, ok := number(obj, "multipleOf"); ok && step > 0 {
+		q := n / step
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {
+			report("multipleOf", "must be a multiple of %s", formatNumber(step))
+		}
+	}
+}
+
+// text checks the keywords about strings
+func (c *check) text(obj map[string]interface{}, s, sp string, report func(string, string, ...interface{})) {
+	length := utf8.RuneCountInString(s)
+	if limit, ok := number(obj, "minLength"); ok && float64(length) < limit {
+		report("minLength", "must be at least %s characters long", formatNumber(limit))
+	}
+	if limit, ok := number(obj, "maxLength"); ok && float64(length) > limit {
+		report("maxLength", "must be at most %s characters long", formatNumber(limit))
+	}
+	if pattern, ok := obj["pattern"].(string); ok {
+		re, err := c.regexp(pattern)
+		if err != nil {
+			c.fail(fmt.Errorf("%s/pattern: %v", sp, err))
+		} else if !re.MatchString(s) {
+			report("pattern", "must match pattern %q", pattern)
+		}
+	}
+	if format, ok := obj["format"].(string); ok {
+		for _, f := range stringFormats {
+			if f.name == format && !f.match(s) {
+				report("format", "must be a valid %s", format)
+			}
+		}
+	}
+}
+
+// array checks the keywords about arrays
+func (c *check) array(obj map[string]interface{}, items []interface{}, ip, sp string, seen *evaluated,
+	report func(string, string, ...interface{}),
+	sub func(interface{}, interface{}, string, string) ([]Violation, *evaluated)) []Violation {
+	var out []Violation
+	itemIP := func(i int) string { return ip + "/" + strconv.Itoa(i) }
+
+	if limit, ok := number(obj, "minItems"); ok && float64(len(items)) < limit {
+		report("minItems", "must have at least %s items", formatNumber(limit))
+	}
+	if limit, ok := number(obj, "maxItems"); ok && float64(len(items)) > limit {
+		report("maxItems", "must have at most %s items", formatNumber(limit))
+	}
+	if unique, _ := obj["uniqueItems"].(bool); unique {
+	outer:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if equal(items[i], items[j]) {
+					report("uniqueItems", "items %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	// The tuple is prefixItems, or items given as a list in draft 7; the
+	// rest of the items are checked against items, or additionalItems in
+	// draft 7
+	tuple, _ := obj["prefixItems"].([]interface{})
+	tupleKey, restKey := "prefixItems", "items"
+	if list, ok := obj["items"].([]interface{}); ok {
+		tuple, tupleKey, restKey = list, "items", "additionalItems"
+	}
+	for i, child := range tuple {
+		if i >= len(items) {
+			break
+		}
+		found, _ := sub(child, items[i], itemIP(i), sp+"/"+tupleKey+"/"+strconv.Itoa(i))
+		out = append(out, found...)
+		seen.items[i] = true
+	}
+	if rest, ok := obj[restKey]; ok {
+		if _, isList := rest.([]interface{}); !isList {
+			for i := len(tuple); i < len(items); i++ {
+				found, _ := sub(rest, items[i], itemIP(i), sp+"/"+restKey)
+				out = append(out, found...)
+			}
+			seen.allItems = true
+		}
+	}
+
+	if contains, ok := obj["contains"]; ok {
+		matched := 0
+		for i, item := range items {
+			if found, _ := sub(contains, item, itemIP(i), sp+"/contains"); len(found) == 0 {
+				matched++
+				seen.items[i] = true
+			}
+		}
+		least := 1.0
+		if limit, ok := number(obj, "minContains"); ok {
+			least = limit
+		}
+		if float64(matched) < least {
+			report("contains", "must contain at least %s matching items, but contains %d", formatNumber(least), matched)
+		}
+		if limit, ok := number(obj, "maxContains"); ok && float64(matched) > limit {
+			report("maxContains", "must contain at most %s matching items, but contains %d", formatNumber(limit), matched)
+		}
+	}
+	return out
+}
+
+// object checks the keywords about objects
+func (c *check) object(obj map[string]interface{}, members map[string]interface{}, ip, sp string, seen *evaluated,
+	report func(string, string, ...interface{}),
+	sub func(interface{}, interface{}, string, string) ([]Violation, *evaluated)) []Violation {
+	var out []Violation
+	memberIP := func(k string) string { return ip + "/" + pointerToken(k) }
+	keys := sortedKeys(members)
+
+	if limit, ok := number(obj, "minProperties"); ok && float64(len(members)) < limit {
+		report("minProperties", "must have at least %s properties", formatNumber(limit))
+	}
+	if limit, ok := number(obj, "maxProperties"); ok && float64(len(members)) > limit {
+		report("maxProperties", "must have at most %s properties", formatNumber(limit))
+	}
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				if _, present := members[s]; !present {
+					report("required", "missing required property %q", s)
+				}
+			}
+		}
+	}
+
+	// dependencies is the draft 7 form of dependentRequired and
+	// dependentSchemas
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas", "dependencies"} {
+		deps, _ := obj[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(deps) {
+			if _, present := members[name]; !present {
+				continue
+			}
+			if list, ok := deps[name].([]interface{}); ok {
+				for _, other := range list {
+					if s, ok := other.(string); ok {
+						if _, present := members[s]; !present {
+							report(keyword, "property %q requires property %q", name, s)
+						}
+					}
+				}
+				continue
+			}
+			found, childSeen := sub(deps[name], members, ip, sp+"/"+keyword+"/"+pointerToken(name))
+			out = append(out, found...)
+			if len(found) == 0 {
+				seen.merge(childSeen)
+			}
+		}
+	}
+
+	if names, ok := obj["propertyNames"]; ok {
+		for _, k := range keys {
+			found, _ := sub(names, k, ip, sp+"/propertyNames")
+			for _, v := range found {
+				v.Message = fmt.Sprintf("property name %q: %s", k, v.Message)
+				out = append(out, v)
+			}
+		}
+	}
+
+	props, _ := obj["properties"].(map[string]interface{})
+	patterns, _ := obj["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := obj["additionalProperties"]
+	for _, k := range keys {
+		matched := false
+		if child, ok := props[k]; ok {
+			found, _ := sub(child, members[k], memberIP(k), sp+"/properties/"+pointerToken(k))
+			out = append(out, found...)
+			matched = true
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			re, err := c.regexp(pattern)
+			if err != nil {
+				c.fail(fmt.Errorf("%s/patternProperties: %v", sp, err))
+				return out
+			}
+			if re.MatchString(k) {
+				found, _ := sub(patterns[pattern], members[k], memberIP(k), sp+"/patternProperties/"+pointerToken(pattern))
+				out = append(out, found...)
+				matched = true
+			}
+		}
+		if !matched && hasAdditional {
+			if b, isBool := additional.(bool); isBool && !b {
+				// Located at the property rather than at the object
+				out = append(out, Violation{InstancePath: memberIP(k), SchemaPath: sp + "/additionalProperties",
+					Message: fmt.Sprintf("property %q is not allowed", k)})
+			} else {
+				found, _ := sub(additional, members[k], memberIP(k), sp+"/additionalProperties")
+				out = append(out, found...)
+			}
+			matched = true
+		}
+		if matched {
+			seen.props[k] = true
+		}
+	}
+	return out
+}
+
+// regexp compiles a pattern once. Patterns are read with Go's syntax, which
+// lacks the lookarounds and backreferences of ECMA-262.
+func (c *check) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c.validator.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	c.validator.patterns[pattern] = re
+	return re, nil
+}
+
+// hasType reports whether v has the JSON Schema type name
+func hasType(v interface{}, name string) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		n, ok := toFloat(v)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return false
+}
+
+// typeName names the JSON type of v for messages
+func typeName(v interface{}) string {
+	for _, name := range []string{"object", "array", "string", "integer", "number", "boolean", "null"} {
+		if hasType(v, name) {
+			return name
+		}
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toFloat returns the value of a number
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil || math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// equal compares two values as JSON Schema does, numbers by their value
+func equal(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !equal(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// listValues writes the values of an enum for messages, eliding long lists
+func listValues(values []interface{}) string {
+	const shown = 5
+	texts := make([]string, 0, shown+1)
+	for i, v := range values {
+		if i == shown {
+			texts = append(texts, fmt.Sprintf("... (%d in total)", len(values)))
+			break
+		}
+		texts = append(texts, jsonText(v))
+	}
+	return strings.Join(texts, ", ")
+}
+
+// jsonText writes a value as compact JSON for messages
+func jsonText(v interface{}) string {
+	text, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(text)
+}
+
+// formatNumber writes a keyword's number as briefly as possible
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// pointerToken escapes a key for a JSON Pointer
+func pointerToken(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
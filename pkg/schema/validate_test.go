@@ -0,0 +1,282 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer"}}}`,
+			doc:    `{"a": 1}`,
+		},
+		{
+			name:   "type",
+			schema: `{"type": ["string", "null"]}`,
+			doc:    `1.5`,
+			want:   []string{"(root): expected string or null, got number (#/type)"},
+		},
+		{
+			name:   "integer",
+			schema: `{"type": "integer"}`,
+			doc:    `2.0`,
+		},
+		{
+			name:   "enum and const",
+			schema: `{"properties": {"a": {"enum": ["x", 1]}, "b": {"const": {"k": [1]}}}}`,
+			doc:    `{"a": 1.0, "b": {"k": [2]}}`,
+			want:   []string{`/b: must be {"k":[1]} (#/properties/b/const)`},
+		},
+		{
+			name:   "numbers",
+			schema: `{"items": {"minimum": 0, "exclusiveMaximum": 10, "multipleOf": 0.5}}`,
+			doc:    `[-1, 10, 0.3, 9.5]`,
+			want: []string{
+				"/0: must be >= 0 (#/items/minimum)",
+				"/1: must be < 10 (#/items/exclusiveMaximum)",
+				"/2: must be a multiple of 0.5 (#/items/multipleOf)",
+			},
+		},
+		{
+			name:   "strings",
+			schema: `{"items": {"minLength": 2, "maxLength": 3, "pattern": "^[a-z]", "format": "date"}}`,
+			doc:    `["é", "2024-01-01", "ab"]`,
+			want: []string{
+				"/0: must be at least 2 characters long (#/items/minLength)",
+				`/0: must match pattern "^[a-z]" (#/items/pattern)`,
+				"/0: must be a valid date (#/items/format)",
+				"/1: must be at most 3 characters long (#/items/maxLength)",
+				`/1: must match pattern "^[a-z]" (#/items/pattern)`,
+				"/2: must be a valid date (#/items/format)",
+			},
+		},
+		{
+			name:   "objects",
+			schema: `{"required": ["id"], "properties": {"id": true}, "patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false, "maxProperties": 2}`,
+			doc:    `{"x-a": 1, "b": 2, "x-c": "ok"}`,
+			want: []string{
+				"(root): must have at most 2 properties (#/maxProperties)",
+				`(root): missing required property "id" (#/required)`,
+				`/b: property "b" is not allowed (#/additionalProperties)`,
+				"/x-a: expected string, got integer (#/patternProperties/^x-/type)",
+			},
+		},
+		{
+			name:   "dependencies and property names",
+			schema: `{"dependentRequired": {"card": ["cvv"]}, "propertyNames": {"maxLength": 4}}`,
+			doc:    `{"card": 1, "number": 2}`,
+			want: []string{
+				`(root): property "card" requires property "cvv" (#/dependentRequired)`,
+				`(root): property name "number": must be at most 4 characters long (#/propertyNames/maxLength)`,
+			},
+		},
+		{
+			name:   "arrays",
+			schema: `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}, "uniqueItems": true, "contains": {"const": 5}, "maxItems": 3}`,
+			doc:    `["a", 1, 1, "b"]`,
+			want: []string{
+				"(root): must have at most 3 items (#/maxItems)",
+				"(root): items 1 and 2 are equal (#/uniqueItems)",
+				"(root): must contain at least 1 matching items, but contains 0 (#/contains)",
+				"/3: expected integer, got string (#/items/type)",
+			},
+		},
+		{
+			name:   "draft 7 tuples",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{"type": "string"}], "additionalItems": false}`,
+			doc:    `["a", 2]`,
+			want:   []string{"/1: no value is allowed here (#/additionalItems)"},
+		},
+		{
+			name:   "combinators",
+			schema: `{"allOf": [{"minimum": 6}], "anyOf": [{"type": "string"}, {"maximum": 0}], "oneOf": [{"type": "number"}, {"type": "integer"}], "not": {"const": 5}}`,
+			doc:    `5`,
+			want: []string{
+				"(root): must be >= 6 (#/allOf/0/minimum)",
+				"(root): must match at least one schema in anyOf (#/anyOf)",
+				"(root): must match exactly one schema in oneOf, but matches 0, 1 (#/oneOf)",
+				"(root): must not match the schema in not (#/not)",
+			},
+		},
+		{
+			name:   "if then else",
+			schema: `{"items": {"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"type": "integer"}}}`,
+			doc:    `["a", 1.5, "ab", 3]`,
+			want: []string{
+				"/0: must be at least 2 characters long (#/items/then/minLength)",
+				"/1: expected integer, got number (#/items/else/type)",
+			},
+		},
+		{
+			name: "references",
+			schema: `{"$defs": {"pos": {"$anchor": "pos", "minimum": 1}, "node": {"properties": {"next": {"$ref": "#/$defs/node"}, "n": {"$ref": "#pos"}}}},
+				"$ref": "#/$defs/node", "maxProperties": 2}`,
+			doc: `{"n": 1, "next": {"n": 0, "next": {"n": -1}}}`,
+			want: []string{
+				"/next/n: must be >= 1 (#/$ref/properties/next/$ref/properties/n/$ref/minimum)",
+				"/next/next/n: must be >= 1 (#/$ref/properties/next/$ref/properties/next/$ref/properties/n/$ref/minimum)",
+			},
+		},
+		{
+			name:   "draft 7 $ref ignores the keywords next to it",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "definitions": {"s": {"type": "string"}}, "$ref": "#/definitions/s", "minLength": 5}`,
+			doc:    `"ab"`,
+		},
+		{
+			name:   "embedded resources",
+			schema: `{"$id": "https://example.com/root.json", "items": {"$ref": "item.json"}, "$defs": {"item": {"$id": "item.json", "type": "boolean"}}}`,
+			doc:    `[true, 1]`,
+			want:   []string{"/1: expected boolean, got integer (#/items/$ref/type)"},
+		},
+		{
+			name:   "unevaluated properties",
+			schema: `{"allOf": [{"properties": {"a": true}}], "properties": {"b": true}, "unevaluatedProperties": false}`,
+			doc:    `{"a": 1, "b": 2, "c": 3}`,
+			want:   []string{`/c: property "c" is not allowed (#/unevaluatedProperties)`},
+		},
+		{
+			name:   "unevaluated items",
+			schema: `{"prefixItems": [true], "contains": {"type": "string"}, "unevaluatedItems": {"type": "null"}}`,
+			doc:    `[1, "s", 2]`,
+			want:   []string{"/2: expected null, got integer (#/unevaluatedItems/type)"},
+		},
+		{
+			name:   "false schema",
+			schema: `false`,
+			doc:    `{}`,
+			want:   []string{"(root): no value is allowed here (#)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewValidator(decode(t, tt.schema), ValidatorOptions{})
+			if err != nil {
+				t.Fatalf("NewValidator() error = %v", err)
+			}
+			violations, err := v.Validate(decode(t, tt.doc))
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			var got []string
+			for _, violation := range violations {
+				got = append(got, violation.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"missing pointer", `{"$ref": "#/$defs/missing"}`, `cannot resolve $ref "#/$defs/missing"`},
+		{"missing anchor", `{"$ref": "#nowhere"}`, `no anchor "nowhere"`},
+		{"external", `{"$ref": "https://example.com/other.json"}`, "is not part of the schema"},
+		{"bad pattern", `{"pattern": "(?<=a)b"}`, "#/pattern: invalid pattern"},
+		{"loop", `{"$ref": "#"}`, "does a $ref refer to itself?"},
+		{"not a schema", `{"not": 3}`, "#/not: a schema must be an object or a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewValidator(decode(t, tt.schema), ValidatorOptions{})
+			if err != nil {
+				t.Fatalf("NewValidator() error = %v", err)
+			}
+			_, err = v.Validate("b")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewValidator([]interface{}{}, ValidatorOptions{}); err == nil {
+		t.Error("NewValidator([]) error = nil")
+	}
+}
+
+func TestValidateLoader(t *testing.T) {
+	schemas := map[string]string{
+		"https://example.com/schemas/address.json": `{"$defs": {"zip": {"$anchor": "zip", "pattern": "^[0-9]{5}$"}},
+			"properties": {"zip": {"$ref": "#zip"}, "country": {"$ref": "country.json"}}}`,
+		"https://example.com/schemas/country.json": `{"enum": ["IT", "PT"]}`,
+	}
+	var loaded []string
+	load := func(uri string) (interface{}, error) {
+		loaded = append(loaded, uri)
+		s, ok := schemas[uri]
+		if !ok {
+			return nil, fmt.Errorf("not found")
+		}
+		return decode(t, s), nil
+	}
+
+	root := decode(t, `{"items": {"$ref": "address.json"}, "properties": {"x": {"$ref": "missing.json"}}}`)
+	v, err := NewValidator(root, ValidatorOptions{Base: "https://example.com/schemas/order.json", Load: load})
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	violations, err := v.Validate(decode(t, `[{"zip": "1234", "country": "IT"}, {"zip": "12345", "country": "FR"}]`))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	var got []string
+	for _, violation := range violations {
+		got = append(got, violation.String())
+	}
+	want := []string{
+		`/0/zip: must match pattern "^[0-9]{5}$" (#/items/$ref/properties/zip/$ref/pattern)`,
+		`/1/country: must be one of "IT", "PT" (#/items/$ref/properties/country/$ref/enum)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(loaded) != 2 {
+		t.Errorf("loaded %v, want each schema read once", loaded)
+	}
+
+	_, err = v.Validate(decode(t, `{"x": 1}`))
+	if err == nil || !strings.Contains(err.Error(), `cannot resolve $ref "missing.json": not found`) {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidateRemoteSchemaCannotReadFiles(t *testing.T) {
+	load := func(uri string) (interface{}, error) {
+		if strings.HasPrefix(uri, "file:") {
+			t.Errorf("loaded %s", uri)
+		}
+		return true, nil
+	}
+
+	v, err := NewValidator(decode(t, `{"$ref": "file:///etc/passwd"}`), ValidatorOptions{Base: "https://example.com/schema.json", Load: load})
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	if _, err := v.Validate(1); err == nil || !strings.Contains(err.Error(), "a remote schema cannot refer to file:///etc/passwd") {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// A $id cannot move a remote schema to a local URI either
+	v, err = NewValidator(decode(t, `{"$id": "file:///etc/", "$ref": "passwd"}`), ValidatorOptions{Base: "https://example.com/schema.json", Load: load})
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	if _, err := v.Validate(1); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/transform"
+)
+
+// Render writes the scalar fields of an object as KEY=value lines that a
+// POSIX shell can eval. Nested fields are joined with underscores, so
+// {"db":{"host":"x"}} becomes DB_HOST='x'. With export, every line starts
+// with "export ". Empty objects and arrays have no value and are skipped.
+func Render(v interface{}, export bool) ([]byte, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("shell output needs an object, got %s", kind(v))
+	}
+
+	flat := transform.Flatten(v, ".", nil).(map[string]interface{})
+	vars := make(map[string]string, len(flat))
+	paths := make(map[string]string, len(flat))
+	for path, value := range flat {
+		text, ok := scalar(value)
+		if !ok {
+			continue
+		}
+		name := Name(path)
+		if other, ok := paths[name]; ok {
+			if other > path {
+				other, path = path, other
+			}
+			return nil, fmt.Errorf("fields %q and %q both become %s", other, path, name)
+		}
+		paths[name] = path
+		vars[name] = text
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		if export {
+			buf.WriteString("export ")
+		}
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(Quote(vars[name]))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// Name turns a field path into a shell variable name: upper case, with every
+// run of other characters replaced by one underscore
+func Name(path string) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range path {
+		switch {
+		case r >= 'a' && r <= 'z':
+			r -= 'a' - 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		default:
+			pending = b.Len() > 0
+			continue
+		}
+		if pending {
+			b.WriteByte('_')
+			pending = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// Quote wraps s in single quotes, inside which a shell expands nothing. A
+// single quote in s closes the quoting, adds an escaped quote and reopens it.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// scalar returns the text of a scalar value; null is the empty string
+func scalar(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return val, true
+	case bool:
+		return strconv.FormatBool(val), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case json.Number:
+		return string(val), true
+	}
+	return "", false
+}
+
+// kind names the JSON type of a value for error messages
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	}
+	return "a number"
+}
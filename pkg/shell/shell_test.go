@@ -0,0 +1,64 @@
+package shell
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var v interface{}
+	doc := `{"db":{"host":"db.local","port":5432,"ssl":true,"password":"it's $x"},"tags":["a","b"],"empty":{},"nothing":null}`
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Render(v, false)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := strings.Join([]string{
+		`DB_HOST='db.local'`,
+		`DB_PASSWORD='it'\''s $x'`,
+		`DB_PORT='5432'`,
+		`DB_SSL='true'`,
+		`NOTHING=''`,
+		`TAGS_0='a'`,
+		`TAGS_1='b'`,
+	}, "\n") + "\n"
+	if string(got) != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	exported, err := Render(map[string]interface{}{"a": "b"}, true)
+	if err != nil || string(exported) != "export A='b'\n" {
+		t.Errorf("Render(export) = %q, %v", exported, err)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Render([]interface{}{1.0}, false); err == nil || !strings.Contains(err.Error(), "needs an object") {
+		t.Errorf("Render(array) error = %v", err)
+	}
+
+	collide := map[string]interface{}{"a-b": "1", "a_b": "2"}
+	if _, err := Render(collide, false); err == nil || !strings.Contains(err.Error(), `"a-b" and "a_b" both become A_B`) {
+		t.Errorf("Render(collision) error = %v", err)
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"db_host":      "DB_HOST",
+		"apiKey":       "APIKEY",
+		"items[0]_id":  "ITEMS_0_ID",
+		"x-forwarded.": "X_FORWARDED",
+		"1st":          "_1ST",
+		"é":            "_",
+	}
+	for path, want := range tests {
+		if got := Name(path); got != want {
+			t.Errorf("Name(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// Format names a way of laying out documents in a file
+type Format string
+
+const (
+	// JSON is a single document
+	JSON Format = "json"
+	// NDJSON is one compact document per line (JSON Lines)
+	NDJSON Format = "ndjson"
+	// JSONSeq is an RFC 7464 JSON text sequence: each document is preceded
+	// by a record separator and followed by a line feed
+	JSONSeq Format = "json-seq"
+	// Concat is documents written back to back, such as {"a":1}{"b":2}, with
+	// or without whitespace between them, as some loggers write them
+	Concat Format = "concat"
+)
+
+// RS is the record separator that starts every JSON text sequence record
+const RS = 0x1E
+
+// ParseFormat validates a format name; empty means JSON
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case "":
+		return JSON, nil
+	case JSON, NDJSON, JSONSeq, Concat:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (use json, ndjson, json-seq or concat)", name)
+}
+
+// Extension returns the file extension for output in this format
+func (f Format) Extension() string {
+	switch f {
+	case NDJSON:
+		return ".ndjson"
+	case JSONSeq:
+		return ".json-seq"
+	}
+	return ".json"
+}
+
+// IsSeq reports whether data looks like a JSON text sequence, which starts
+// with a record separator, possibly after whitespace
+func IsSeq(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == RS
+}
+
+// IsConcat reports whether data holds more than one document written back
+// to back. Only the first two documents are checked.
+func IsConcat(data []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for i := 0; i < 2; i++ {
+		var raw json.RawMessage
+		if !dec.More() || dec.Decode(&raw) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode parses a stream of records, decoding each with the formatter options.
+// For a JSON text sequence with bad records, the error is a *SkippedRecords
+// and the records that could be read are returned with it.
+func Decode(data []byte, format Format, opts formatter.Options) ([]interface{}, error) {
+	switch format {
+	case NDJSON:
+		return decodeLines(data, opts)
+	case JSONSeq:
+		return decodeSeq(data, opts)
+	case Concat:
+		return decodeConcat(data, opts)
+	}
+	return nil, fmt.Errorf("%s is not a record stream format", format)
+}
+
+// decodeLines parses one document per line, skipping blank lines
+func decodeLines(data []byte, opts formatter.Options) ([]interface{}, error) {
+	records := make([]interface{}, 0)
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		v, err := formatter.Decode(line, opts)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		records = append(records, v)
+	}
+	return records, nil
+}
+
+// SkippedRecords is returned by Decode along with the records of a JSON text
+// sequence that could be read, when others were malformed or truncated. As
+// RFC 7464 asks, such records are skipped and parsing goes on at the next
+// record separator.
+type SkippedRecords struct {
+	// Errors holds one error per skipped record, naming it
+	Errors []error
+}
+
+func (e *SkippedRecords) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "skipped " + strings.Join(msgs, "; ")
+}
+
+// decodeSeq parses an RFC 7464 sequence. Records that are empty are skipped.
+// A record holding a number, true, false or null that is not followed by
+// whitespace may have been cut short, and is skipped as truncated, as are
+// malformed records and data before the first record separator.
+func decodeSeq(data []byte, opts formatter.Options) ([]interface{}, error) {
+	parts := bytes.Split(data, []byte{RS})
+	skipped := &SkippedRecords{}
+	if len(bytes.TrimSpace(parts[0])) > 0 {
+		skipped.Errors = append(skipped.Errors, fmt.Errorf("data before the first record separator"))
+	}
+
+	records := make([]interface{}, 0)
+	for i, part := range parts[1:] {
+		text := bytes.TrimSpace(part)
+		if len(text) == 0 {
+			continue
+		}
+		if len(bytes.TrimRight(part, " \t\r\n")) == len(part) && isBareScalar(text) {
+			skipped.Errors = append(skipped.Errors, fmt.Errorf("record %d is truncated", i+1))
+			continue
+		}
+
+		v, err := formatter.Decode(text, opts)
+		if err != nil {
+			skipped.Errors = append(skipped.Errors, fmt.Errorf("record %d: %v", i+1, err))
+			continue
+		}
+		records = append(records, v)
+	}
+
+	if len(skipped.Errors) > 0 {
+		return records, skipped
+	}
+	return records, nil
+}
+
+// decodeConcat parses documents written back to back. Each is split off with
+// a json.Decoder and then decoded with the formatter options, so that key
+// order and duplicate keys are handled as in a single document.
+func decodeConcat(data []byte, opts formatter.Options) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	records := make([]interface{}, 0)
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("record %d: %v", len(records)+1, err)
+		}
+		v, err := formatter.Decode(raw, opts)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", len(records)+1, err)
+		}
+		records = append(records, v)
+	}
+
+	// More stops at a stray closing bracket
+	if rest := bytes.TrimSpace(data[dec.InputOffset():]); len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected %q after record %d", rest[:1], len(records))
+	}
+	return records, nil
+}
+
+// isBareScalar reports whether a JSON text is a number or a literal, whose
+// end cannot be told from its content alone
+func isBareScalar(text []byte) bool {
+	switch text[0] {
+	case '{', '[', '"':
+		return false
+	}
+	return true
+}
+
+// Encode writes each record in the given stream format: compactly, except
+// for Concat which formats each record with the options
+func Encode(records []interface{}, format Format, opts formatter.Options) ([]byte, error) {
+	if format != NDJSON && format != JSONSeq && format != Concat {
+		return nil, fmt.Errorf("%s is not a record stream format", format)
+	}
+
+	if format != Concat {
+		opts.Compact = true
+	}
+	var buf bytes.Buffer
+	for _, record := range records {
+		formatted, err := formatter.FormatValue(record, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if format == JSONSeq {
+			buf.WriteByte(RS)
+		}
+		buf.Write(formatted)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// Records returns the records a value stands for in a stream: the elements
+// of an array, or the value itself
+func Records(v interface{}) []interface{} {
+	if arr, ok := v.([]interface{}); ok {
+		return arr
+	}
+	return []interface{}{v}
+}
+
+// Names lists the formats for help messages
+func Names() string {
+	return strings.Join([]string{string(JSON), string(NDJSON), string(JSONSeq), string(Concat)}, ", ")
+}
@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "NDJSON", format: NDJSON, input: "{\"a\":1}\n\n[2]\r\n\"x\"\n", want: `[{"a":1},[2],"x"]`},
+		{name: "NDJSON error names the line", format: NDJSON, input: "1\n{\n", wantErr: "line 2"},
+		{name: "Sequence", format: JSONSeq, input: "\x1e{\"a\":1}\n\x1e[1,\n2]\n\x1e\n\x1e42\n", want: `[{"a":1},[1,2],42]`},
+		{name: "Empty sequence", format: JSONSeq, input: "", want: `[]`},
+		{name: "Truncated number", format: JSONSeq, input: "\x1e1\n\x1e42", wantErr: "record 2 is truncated"},
+		{name: "Truncated object", format: JSONSeq, input: "\x1e{\"a\":", wantErr: "record 1: invalid JSON"},
+		{name: "Concatenated", format: Concat, input: "{\"a\":1}{\"b\":2}\n[3] \"x\"4", want: `[{"a":1},{"b":2},[3],"x",4]`},
+		{name: "Concatenated error names the record", format: Concat, input: `{"a":1}{"b":}`, wantErr: "record 2"},
+		{name: "Stray bracket after records", format: Concat, input: `{"a":1}}`, wantErr: `unexpected "}" after record 1`},
+		{name: "Data before the first separator", format: JSONSeq, input: "1\n\x1e2\n", wantErr: "before the first record separator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.input), tt.format, formatter.Options{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Decode() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if b, _ := json.Marshal(got); string(b) != tt.want {
+				t.Errorf("Decode() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSeqSkipsBadRecords(t *testing.T) {
+	input := "junk\n\x1e{\"a\":1}\n\x1e{\"b\":\n\x1e42\x1e[3]\n\x1e\"x\"\n"
+	got, err := Decode([]byte(input), JSONSeq, formatter.Options{})
+
+	var skipped *SkippedRecords
+	if !errors.As(err, &skipped) {
+		t.Fatalf("Decode() error = %v, want a SkippedRecords", err)
+	}
+	want := []string{"data before the first record separator", "record 2: invalid JSON", "record 3 is truncated"}
+	if len(skipped.Errors) != len(want) {
+		t.Fatalf("Decode() skipped %v, want %d records", skipped.Errors, len(want))
+	}
+	for i, e := range skipped.Errors {
+		if !strings.Contains(e.Error(), want[i]) {
+			t.Errorf("skipped[%d] = %v, want it to contain %q", i, e, want[i])
+		}
+	}
+	if b, _ := json.Marshal(got); string(b) != `[{"a":1},[3],"x"]` {
+		t.Errorf("Decode() = %s, want the records around the bad ones", b)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(`[{"b":1,"a":[1,2]},"x",1e21]`), &v); err != nil {
+		t.Fatal(err)
+	}
+	opts := formatter.Options{IndentSpaces: 2, Numbers: formatter.NumbersPlain}
+
+	lines, err := Encode(Records(v), NDJSON, opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if want := "{\"a\":[1,2],\"b\":1}\n\"x\"\n1000000000000000000000\n"; string(lines) != want {
+		t.Errorf("Encode(NDJSON) = %q, want %q", lines, want)
+	}
+
+	seq, err := Encode(Records(v), JSONSeq, opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if want := "\x1e{\"a\":[1,2],\"b\":1}\n\x1e\"x\"\n\x1e1000000000000000000000\n"; string(seq) != want {
+		t.Errorf("Encode(JSONSeq) = %q, want %q", seq, want)
+	}
+
+	concat, err := Encode(Records(v), Concat, opts)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if want := "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": 1\n}\n\"x\"\n1000000000000000000000\n"; string(concat) != want {
+		t.Errorf("Encode(Concat) = %q, want %q", concat, want)
+	}
+
+	// Round trip
+	back, err := Decode(seq, JSONSeq, formatter.Options{})
+	if err != nil || len(back) != 3 {
+		t.Errorf("Decode(Encode()) = %v, %v", back, err)
+	}
+}
+
+func TestIsSeq(t *testing.T) {
+	if !IsSeq([]byte("\n\x1e{}\n")) {
+		t.Error("IsSeq() = false for a sequence")
+	}
+	if IsSeq([]byte(`{"a":"\u001e"}`)) || IsSeq(nil) {
+		t.Error("IsSeq() = true for a plain document")
+	}
+}
+
+func TestIsConcat(t *testing.T) {
+	for _, input := range []string{`{"a":1}{"b":2}`, "1 2", "{}\n[]\n{"} {
+		if !IsConcat([]byte(input)) {
+			t.Errorf("IsConcat(%q) = false", input)
+		}
+	}
+	for _, input := range []string{`{"a":1}`, " [1, 2]\n", `{"a":1}}`, `{"a":}{}`, ""} {
+		if IsConcat([]byte(input)) {
+			t.Errorf("IsConcat(%q) = true", input)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": JSON, "json": JSON, "ndjson": NDJSON, "json-seq": JSONSeq, "concat": Concat} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(yaml) succeeded, want an error")
+	}
+	if got := JSONSeq.Extension(); got != ".json-seq" {
+		t.Errorf("Extension() = %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// funcs are the helpers available to templates in addition to the
+// text/template built-ins
+var funcs = template.FuncMap{
+	"json":    toJSON,
+	"pretty":  toPrettyJSON,
+	"num":     formatNumber,
+	"join":    join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"default": defaultValue,
+}
+
+// Parse compiles a template. name is used in error messages.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+// Render executes t with a decoded document as its context
+func Render(t *template.Template, doc interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// toJSON writes a value as compact JSON
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// toPrettyJSON writes a value as JSON indented with two spaces
+func toPrettyJSON(v interface{}) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+// formatNumber writes a number without exponent notation, so that 1000000
+// is not printed as 1e+06
+func formatNumber(v interface{}) (string, error) {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case json.Number:
+		return string(n), nil
+	}
+	return "", fmt.Errorf("num: expected a number, got %T", v)
+}
+
+// join concatenates the elements of an array, formatting numbers like num
+func join(sep string, v interface{}) (string, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("join: expected an array, got %T", v)
+	}
+
+	parts := make([]string, len(items))
+	for i, item := range items {
+		switch val := item.(type) {
+		case string:
+			parts[i] = val
+		case float64:
+			parts[i] = strconv.FormatFloat(val, 'f', -1, 64)
+		case json.Number:
+			parts[i] = string(val)
+		case nil:
+			parts[i] = "null"
+		default:
+			parts[i] = fmt.Sprint(val)
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// defaultValue returns v, or def when v is missing, null or empty
+func defaultValue(def, v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return def
+	case string:
+		if val == "" {
+			return def
+		}
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package tmpl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	doc := `{"title":"Orders","total":1500000,"tags":["a",2,null],"items":[{"id":1,"name":"pen"},{"id":2}],"owner":{"name":"ann"}}`
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Fields and range",
+			text: "# {{.title}}\n{{range .items}}- {{.id}}: {{default \"unnamed\" .name}}\n{{end}}",
+			want: "# Orders\n- 1: pen\n- 2: unnamed\n",
+		},
+		{
+			name: "Numbers",
+			text: "{{num .total}} {{len .items}}",
+			want: "1500000 2",
+		},
+		{
+			name: "JSON helpers",
+			text: "{{json .owner}}|{{pretty .owner}}",
+			want: "{\"name\":\"ann\"}|{\n  \"name\": \"ann\"\n}",
+		},
+		{
+			name: "Strings",
+			text: "{{upper .title}} {{join \", \" .tags}} [{{trim \"  x \"}}]",
+			want: "ORDERS a, 2, null [x]",
+		},
+		{
+			name: "Missing key",
+			text: "{{.nope}}|{{default \"-\" .nope}}",
+			want: "<no value>|-",
+		},
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := Parse("test", tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := Render(tpl, v)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := Parse("bad", "{{.a"); err == nil {
+		t.Error("Parse() expected an error for an unterminated action")
+	}
+
+	tpl, err := Parse("t", "{{num .a}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Render(tpl, map[string]interface{}{"a": "x"})
+	if err == nil || !strings.Contains(err.Error(), "expected a number") {
+		t.Errorf("Render() error = %v, want a num error", err)
+	}
+}
@@ -0,0 +1,155 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// KeyCase is a naming convention for object keys
+type KeyCase string
+
+const (
+	// CaseCamel writes keys like "userId", as in JavaScript
+	CaseCamel KeyCase = "camel"
+	// CasePascal writes keys like "UserId", as in Go
+	CasePascal KeyCase = "pascal"
+	// CaseSnake writes keys like "user_id"
+	CaseSnake KeyCase = "snake"
+	// CaseKebab writes keys like "user-id"
+	CaseKebab KeyCase = "kebab"
+)
+
+// ParseKeyCase validates the name of a naming convention
+func ParseKeyCase(name string) (KeyCase, error) {
+	switch c := KeyCase(name); c {
+	case CaseCamel, CasePascal, CaseSnake, CaseKebab:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown key case %q (use camel, pascal, snake or kebab)", name)
+}
+
+// Convert writes a key in the naming convention. Words are found at
+// underscores, hyphens, spaces and dots, and where the case changes, so
+// "userID", "user_id" and "UserId" all become "user_id" in snake case.
+func (c KeyCase) Convert(key string) string {
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+
+	switch c {
+	case CaseSnake, CaseKebab:
+		sep := "_"
+		if c == CaseKebab {
+			sep = "-"
+		}
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, sep)
+	}
+
+	var sb strings.Builder
+	for i, w := range words {
+		if i == 0 && c == CaseCamel {
+			sb.WriteString(strings.ToLower(w))
+			continue
+		}
+		sb.WriteString(capitalize(w))
+	}
+	return sb.String()
+}
+
+// splitWords splits a key into words at separators and case changes. A run
+// of capitals is one word, except for its last letter when a lower-case
+// letter follows, so "HTTPServer" is "HTTP" and "Server".
+func splitWords(key string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// capitalize upper-cases the first letter of a word and lower-cases the rest
+func capitalize(w string) string {
+	runes := []rune(strings.ToLower(w))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// ConvertKeys rewrites every object key in the document in the naming
+// convention. Two keys of one object that would become the same key are an
+// error, since one of the values would be lost. Converted keys keep the
+// position the old ones had in order.
+func ConvertKeys(v interface{}, c KeyCase, order *formatter.KeyOrder) (interface{}, error) {
+	return convertKeys(nil, v, c, order)
+}
+
+// convertKeys walks v, located at path, converting keys
+func convertKeys(path []jsonpath.Segment, v interface{}, c KeyCase, order *formatter.KeyOrder) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		// Keys are visited in document order, which also reports a collision
+		// the same way on every run
+		keys := order.Keys(val)
+		out := make(map[string]interface{}, len(val))
+		names := make([]string, 0, len(keys))
+		from := make(map[string]string, len(val))
+		for _, k := range keys {
+			childPath := append(path, jsonpath.Key(k))
+			converted, err := convertKeys(childPath, val[k], c, order)
+			if err != nil {
+				return nil, err
+			}
+
+			name := c.Convert(k)
+			if prev, ok := from[name]; ok {
+				return nil, fmt.Errorf("keys %s and %s would both become %q",
+					jsonpath.String(append(path, jsonpath.Key(prev))), jsonpath.String(childPath), name)
+			}
+			from[name] = k
+			out[name] = converted
+			names = append(names, name)
+		}
+		order.Record(out, names)
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			converted, err := convertKeys(append(path, jsonpath.Index(i)), child, c, order)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = converted
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestKeyCaseConvert(t *testing.T) {
+	tests := []struct {
+		key                         string
+		camel, pascal, snake, kebab string
+	}{
+		{"user_id", "userId", "UserId", "user_id", "user-id"},
+		{"userID", "userId", "UserId", "user_id", "user-id"},
+		{"UserName", "userName", "UserName", "user_name", "user-name"},
+		{"HTTPServer", "httpServer", "HttpServer", "http_server", "http-server"},
+		{"created-at", "createdAt", "CreatedAt", "created_at", "created-at"},
+		{"item2Name", "item2Name", "Item2Name", "item2_name", "item2-name"},
+		{"id", "id", "Id", "id", "id"},
+		{"__", "__", "__", "__", "__"},
+	}
+
+	for _, tt := range tests {
+		for c, want := range map[KeyCase]string{CaseCamel: tt.camel, CasePascal: tt.pascal, CaseSnake: tt.snake, CaseKebab: tt.kebab} {
+			if got := c.Convert(tt.key); got != want {
+				t.Errorf("%s.Convert(%q) = %q, want %q", c, tt.key, got, want)
+			}
+		}
+	}
+}
+
+func TestConvertKeys(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(`{"UserName":"a","Orders":[{"OrderID":1,"line_items":[]}]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ConvertKeys(v, CaseCamel, nil)
+	if err != nil {
+		t.Fatalf("ConvertKeys() error = %v", err)
+	}
+	b, _ := json.Marshal(got)
+	if want := `{"orders":[{"lineItems":[],"orderId":1}],"userName":"a"}`; string(b) != want {
+		t.Errorf("ConvertKeys() = %s, want %s", b, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"user_id":1,"userId":2}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConvertKeys(v, CaseSnake, nil); err == nil {
+		t.Errorf("ConvertKeys() accepted two keys that become the same")
+	}
+
+	if _, err := ParseKeyCase("title"); err == nil {
+		t.Errorf("ParseKeyCase() accepted an unknown case")
+	}
+}
+
+func TestConvertKeysKeepsKeyOrder(t *testing.T) {
+	v, order := decodeOrdered(t, `{"Zeta":1,"UserName":{"LastName":"b","FirstName":"a"}}`)
+	got, err := ConvertKeys(v, CaseSnake, order)
+	if err != nil {
+		t.Fatalf("ConvertKeys() error = %v", err)
+	}
+	want := `{"zeta":1,"user_name":{"last_name":"b","first_name":"a"}}`
+	if s := formatOrdered(t, got, order); s != want {
+		t.Errorf("ConvertKeys() = %s, want %s", s, want)
+	}
+}
@@ -0,0 +1,70 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// Dedupe removes repeated elements from every array in the document, keeping
+// the first of each. Elements are equal when their RFC 8785 canonical forms
+// are, so key order and number notation do not matter: {"a":1,"b":2} and
+// {"b":2.0,"a":1} are duplicates. Objects keep the key order order recorded
+// for them.
+func Dedupe(v interface{}, order *formatter.KeyOrder) interface{} {
+	return dedupe(v, order, func(item interface{}) (string, bool) {
+		return canonicalKey(item), true
+	})
+}
+
+// DedupeBy removes array elements that repeat the value of field in an
+// earlier element, keeping the first. Only objects that have the field take
+// part; other elements are always kept.
+func DedupeBy(v interface{}, field string, order *formatter.KeyOrder) interface{} {
+	return dedupe(v, order, func(item interface{}) (string, bool) {
+		f, ok := fieldOf(item, field)
+		if !ok {
+			return "", false
+		}
+		return canonicalKey(f), true
+	})
+}
+
+// dedupe walks v, dropping array elements whose key was already seen in the
+// same array. Elements are deduplicated after their own arrays are.
+func dedupe(v interface{}, order *formatter.KeyOrder, key func(item interface{}) (string, bool)) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		keys := order.Keys(val)
+		for _, k := range keys {
+			out[k] = dedupe(val[k], order, key)
+		}
+		order.Record(out, keys)
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		seen := make(map[string]bool, len(val))
+		for _, child := range val {
+			child = dedupe(child, order, key)
+			if k, ok := key(child); ok {
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+			}
+			out = append(out, child)
+		}
+		return out
+	}
+	return v
+}
+
+// canonicalKey serializes a value so that equal values give equal keys
+func canonicalKey(v interface{}) string {
+	out, err := formatter.Canonical(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(out)
+}
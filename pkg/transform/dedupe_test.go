@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	doc := decodeJSON(t, `{
+		"tags": ["a", "b", "a", 1, 1.0, null, null],
+		"points": [{"x": 1, "y": 2}, {"y": 2, "x": 1}, {"x": 2}],
+		"nested": [[1, 1], [1]]
+	}`)
+	want := decodeJSON(t, `{
+		"tags": ["a", "b", 1, null],
+		"points": [{"x": 1, "y": 2}, {"x": 2}],
+		"nested": [[1]]
+	}`)
+
+	if got := Dedupe(doc, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dedupe() = %v, want %v", got, want)
+	}
+}
+
+func TestDedupeBy(t *testing.T) {
+	doc := decodeJSON(t, `[
+		{"id": 1, "v": "first"},
+		{"id": 2},
+		{"id": 1, "v": "second"},
+		{"v": "no id"},
+		{"v": "no id"},
+		"scalar",
+		{"id": 1.0}
+	]`)
+	want := decodeJSON(t, `[
+		{"id": 1, "v": "first"},
+		{"id": 2},
+		{"v": "no id"},
+		{"v": "no id"},
+		"scalar"
+	]`)
+
+	if got := DedupeBy(doc, "id", nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("DedupeBy() = %v, want %v", got, want)
+	}
+}
+
+func TestDedupeKeepsKeyOrder(t *testing.T) {
+	input := `{"z":[{"z":1,"a":2},{"a":2,"z":1}],"a":[{"id":1,"y":1,"b":2},{"id":1}]}`
+	v, order := decodeOrdered(t, input)
+	if got, want := formatOrdered(t, Dedupe(v, order), order), `{"z":[{"z":1,"a":2}],"a":[{"id":1,"y":1,"b":2},{"id":1}]}`; got != want {
+		t.Errorf("Dedupe() = %s, want %s", got, want)
+	}
+	if got, want := formatOrdered(t, DedupeBy(v, "id", order), order), `{"z":[{"z":1,"a":2},{"a":2,"z":1}],"a":[{"id":1,"y":1,"b":2}]}`; got != want {
+		t.Errorf("DedupeBy() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package transform
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Set puts value at path and returns the updated document. The objects and
+// arrays leading to it are created where missing or null: an object for a
+// key and an array for an index. An index past the end of an array pads it
+// with nulls. Objects are changed in place, so they keep their key order.
+func Set(doc interface{}, path []jsonpath.Segment, value interface{}) (interface{}, error) {
+	return update(doc, path, 0, func(interface{}) (interface{}, error) {
+		return value, nil
+	})
+}
+
+// Insert adds values to the array at path before the element at index, or at
+// the end when index is negative, and returns the updated document. A missing
+// or null array is created, along with the objects and arrays leading to it
+// as Set does.
+func Insert(doc interface{}, path []jsonpath.Segment, index int, values ...interface{}) (interface{}, error) {
+	return update(doc, path, 0, func(v interface{}) (interface{}, error) {
+		if v == nil {
+			v = []interface{}{}
+		}
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is not an array", location(path))
+		}
+		if index < 0 {
+			index = len(arr)
+		}
+		if index > len(arr) {
+			return nil, fmt.Errorf("index %d is past the end of %s, of length %d", index, location(path), len(arr))
+		}
+		out := make([]interface{}, 0, len(arr)+len(values))
+		out = append(out, arr[:index]...)
+		out = append(out, values...)
+		return append(out, arr[index:]...), nil
+	})
+}
+
+// update replaces the value at path[i:] below v, which is located at
+// path[:i], with what fn returns for it. fn gets nil for a missing value.
+func update(v interface{}, path []jsonpath.Segment, i int, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	if i == len(path) {
+		return fn(v)
+	}
+	seg := path[i]
+
+	if v == nil {
+		if seg.IsIndex {
+			v = []interface{}{}
+		} else {
+			v = map[string]interface{}{}
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if seg.IsIndex {
+			return nil, fmt.Errorf("%s is an object, not an array", location(path[:i]))
+		}
+		child, err := update(val[seg.Key], path, i+1, fn)
+		if err != nil {
+			return nil, err
+		}
+		val[seg.Key] = child
+		return val, nil
+	case []interface{}:
+		idx, ok := elementIndex(seg, len(val))
+		if !ok {
+			return nil, fmt.Errorf("%s is an array, not an object", location(path[:i]))
+		}
+		for len(val) <= idx {
+			val = append(val, nil)
+		}
+		child, err := update(val[idx], path, i+1, fn)
+		if err != nil {
+			return nil, err
+		}
+		val[idx] = child
+		return val, nil
+	}
+
+	return nil, fmt.Errorf("%s is a %s, which has no children", location(path[:i]), kindName(v))
+}
+
+// Delete removes every entry whose path matches one of the patterns and
+// returns the updated document with the number of entries removed. Array
+// elements are matched by their index before any removal, so "items[1]" and
+// "items[2]" remove two neighbours. Objects are changed in place.
+func Delete(doc interface{}, patterns []*jsonpath.Pattern) (interface{}, int) {
+	removed := 0
+	return remove(nil, doc, patterns, &removed), removed
+}
+
+// remove walks v, located at path, dropping matching entries
+func remove(path []jsonpath.Segment, v interface{}, patterns []*jsonpath.Pattern, removed *int) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			childPath := append(path, jsonpath.Key(k))
+			if jsonpath.MatchAny(patterns, childPath) {
+				delete(val, k)
+				*removed++
+				continue
+			}
+			val[k] = remove(childPath, child, patterns, removed)
+		}
+		return val
+	case []interface{}:
+		out := val[:0]
+		for i, child := range val {
+			childPath := append(path, jsonpath.Index(i))
+			if jsonpath.MatchAny(patterns, childPath) {
+				*removed++
+				continue
+			}
+			out = append(out, remove(childPath, child, patterns, removed))
+		}
+		return out
+	}
+
+	return v
+}
+
+// elementIndex reads a segment as an array index. Keys made of digits count,
+// as JSON Pointers name elements that way, and "-" is the end of the array.
+func elementIndex(seg jsonpath.Segment, length int) (int, bool) {
+	if seg.IsIndex {
+		return seg.Index, true
+	}
+	if seg.Key == "-" {
+		return length, true
+	}
+	n, err := strconv.Atoi(seg.Key)
+	if err != nil || n < 0 || strconv.Itoa(n) != seg.Key {
+		return 0, false
+	}
+	return n, true
+}
+
+// location names a path in messages, "the document" for the root
+func location(path []jsonpath.Segment) string {
+	if len(path) == 0 {
+		return "the document"
+	}
+	return jsonpath.String(path)
+}
+
+// kindName names the JSON type of a scalar for messages
+func kindName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	return "number"
+}
@@ -0,0 +1,124 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		doc, path, value, want string
+	}{
+		{`{"user": {"name": "ann"}}`, "user.settings.theme", `"dark"`, `{"user": {"name": "ann", "settings": {"theme": "dark"}}}`},
+		{`{"user": {"name": "ann"}}`, "user.name", `{"first": "Ann"}`, `{"user": {"name": {"first": "Ann"}}}`},
+		{`{}`, "items[2].id", `7`, `{"items": [null, null, {"id": 7}]}`},
+		{`{"items": [1, 2]}`, "items[0]", `0`, `{"items": [0, 2]}`},
+		{`{"items": [1, 2]}`, "/items/-", `3`, `{"items": [1, 2, 3]}`},
+		{`{"items": [1, 2]}`, "/items/1", `null`, `{"items": [1, null]}`},
+		{`{"a": null}`, "a.b", `true`, `{"a": {"b": true}}`},
+		{`{"a": 1}`, "$", `[1]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		path, err := jsonpath.ParsePath(tt.path)
+		if err != nil {
+			t.Fatalf("ParsePath(%q) error = %v", tt.path, err)
+		}
+		got, err := Set(decodeJSON(t, tt.doc), path, decodeJSON(t, tt.value))
+		if err != nil {
+			t.Errorf("Set(%s, %s) error = %v", tt.doc, tt.path, err)
+			continue
+		}
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("Set(%s, %s) = %v, want %s", tt.doc, tt.path, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ doc, path string }{
+		{`{"a": "text"}`, "a.b"},
+		{`{"a": [1]}`, "a.b"},
+		{`{"a": {}}`, "a[0]"},
+	} {
+		path, _ := jsonpath.ParsePath(tt.path)
+		if _, err := Set(decodeJSON(t, tt.doc), path, 1.0); err == nil {
+			t.Errorf("Set(%s, %s) expected an error", tt.doc, tt.path)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		doc     string
+		exprs   []string
+		want    string
+		removed int
+	}{
+		{`{"items": [0, 1, 2, 3, 4], "metadata": {"debug": true, "v": 1}}`, []string{"items[3]", "metadata.debug"}, `{"items": [0, 1, 2, 4], "metadata": {"v": 1}}`, 2},
+		{`{"items": [0, 1, 2, 3]}`, []string{"items[1]", "items[2]"}, `{"items": [0, 3]}`, 2},
+		{`{"items": [{"id": 1, "debug": 1}, {"id": 2, "debug": 2}]}`, []string{"items[*].debug"}, `{"items": [{"id": 1}, {"id": 2}]}`, 2},
+		{`{"debug": 1, "a": {"debug": 2}}`, []string{"debug"}, `{"a": {"debug": 2}}`, 1},
+		{`{"debug": 1, "a": {"debug": 2}}`, []string{"$..debug"}, `{"a": {}}`, 2},
+		{`{"a": 1}`, []string{"b.c"}, `{"a": 1}`, 0},
+	}
+
+	for _, tt := range tests {
+		var patterns []*jsonpath.Pattern
+		for _, expr := range tt.exprs {
+			p, err := jsonpath.CompileRooted(expr)
+			if err != nil {
+				t.Fatalf("CompileRooted(%q) error = %v", expr, err)
+			}
+			patterns = append(patterns, p)
+		}
+		got, removed := Delete(decodeJSON(t, tt.doc), patterns)
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) || removed != tt.removed {
+			t.Errorf("Delete(%s, %v) = %v, %d, want %s, %d", tt.doc, tt.exprs, got, removed, tt.want, tt.removed)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		doc, path string
+		index     int
+		values    []interface{}
+		want      string
+	}{
+		{`{"tags": ["a"]}`, "tags", -1, []interface{}{"b"}, `{"tags": ["a", "b"]}`},
+		{`{"tags": ["a", "c"]}`, "tags", 1, []interface{}{"b"}, `{"tags": ["a", "b", "c"]}`},
+		{`{"tags": ["c"]}`, "tags", 0, []interface{}{"a", "b"}, `{"tags": ["a", "b", "c"]}`},
+		{`{"tags": ["a"]}`, "tags", 1, []interface{}{"b"}, `{"tags": ["a", "b"]}`},
+		{`{}`, "user.tags", -1, []interface{}{"a"}, `{"user": {"tags": ["a"]}}`},
+		{`{"rows": [[1], [2]]}`, "/rows/1", -1, []interface{}{3.0}, `{"rows": [[1], [2, 3]]}`},
+	}
+
+	for _, tt := range tests {
+		path, err := jsonpath.ParsePath(tt.path)
+		if err != nil {
+			t.Fatalf("ParsePath(%q) error = %v", tt.path, err)
+		}
+		got, err := Insert(decodeJSON(t, tt.doc), path, tt.index, tt.values...)
+		if err != nil {
+			t.Errorf("Insert(%s, %s, %d) error = %v", tt.doc, tt.path, tt.index, err)
+			continue
+		}
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("Insert(%s, %s, %d) = %v, want %s", tt.doc, tt.path, tt.index, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		doc, path string
+		index     int
+	}{
+		{`{"tags": "a"}`, "tags", -1},
+		{`{"tags": ["a"]}`, "tags", 2},
+	} {
+		path, _ := jsonpath.ParsePath(tt.path)
+		if _, err := Insert(decodeJSON(t, tt.doc), path, tt.index, "x"); err == nil {
+			t.Errorf("Insert(%s, %s, %d) expected an error", tt.doc, tt.path, tt.index)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// ParseEmbedded replaces string values that hold a JSON object or array,
+// such as "{\"a\":1}" in a log line, with the value they hold. Expanded
+// values are searched for embedded JSON in turn. When patterns are given,
+// only strings at matching paths are expanded. Strings holding scalars, like
+// "42" or "true", are left alone, since they are usually meant as text.
+// Embedded documents are decoded as the document itself was, resolving
+// repeated keys by opts.DuplicateKeys and recording their key order in
+// opts.KeyOrder; one rejected for a duplicate key is an error.
+func ParseEmbedded(v interface{}, patterns []*jsonpath.Pattern, opts formatter.Options) (interface{}, error) {
+	opts = formatter.Options{DuplicateKeys: opts.DuplicateKeys, KeyOrder: opts.KeyOrder}
+	return parseEmbedded(nil, v, patterns, opts)
+}
+
+// parseEmbedded walks v, located at path, expanding embedded documents
+func parseEmbedded(path []jsonpath.Segment, v interface{}, patterns []*jsonpath.Pattern, opts formatter.Options) (interface{}, error) {
+	var err error
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, k := range opts.KeyOrder.Keys(val) {
+			if val[k], err = parseEmbedded(append(path, jsonpath.Key(k)), val[k], patterns, opts); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			if val[i], err = parseEmbedded(append(path, jsonpath.Index(i)), child, patterns, opts); err != nil {
+				return nil, err
+			}
+		}
+	case string:
+		if len(patterns) > 0 && !jsonpath.MatchAny(patterns, path) {
+			return v, nil
+		}
+		expanded, ok, err := decodeEmbedded(val, opts)
+		if err != nil {
+			where := jsonpath.String(path)
+			if where == "" {
+				where = "the document"
+			}
+			return nil, fmt.Errorf("embedded JSON in %s: %v", where, err)
+		}
+		if ok {
+			// Documents nested inside the expanded one are expanded wherever they are
+			return parseEmbedded(path, expanded, nil, opts)
+		}
+	}
+	return v, nil
+}
+
+// decodeEmbedded decodes s if it holds exactly one JSON object or array. Only
+// a rejected duplicate key is an error; other text is simply not expanded.
+func decodeEmbedded(s string, opts formatter.Options) (interface{}, bool, error) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false, nil
+	}
+
+	v, err := formatter.Decode([]byte(trimmed), opts)
+	var dupErr *formatter.DuplicateKeyError
+	if errors.As(err, &dupErr) {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, nil
+	}
+	return v, true, nil
+}
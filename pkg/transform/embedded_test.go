@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+func TestParseEmbedded(t *testing.T) {
+	input := `{
+		"payload": "{\"a\":1,\"inner\":\"[1, 2]\"}",
+		"list": ["[true]", "not json", "{broken"],
+		"count": "42",
+		"meta": {"body": " {\"x\": null} "}
+	}`
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{
+			name: "Everywhere",
+			want: `{"count":"42","list":[[true],"not json","{broken"],"meta":{"body":{"x":null}},"payload":{"a":1,"inner":[1,2]}}`,
+		},
+		{
+			name:  "Only at matching paths",
+			paths: []string{"meta.body"},
+			want:  `{"count":"42","list":["[true]","not json","{broken"],"meta":{"body":{"x":null}},"payload":"{\"a\":1,\"inner\":\"[1, 2]\"}"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(input), &v); err != nil {
+				t.Fatal(err)
+			}
+			patterns, err := jsonpath.CompileAll(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expanded, err := ParseEmbedded(v, patterns, formatter.Options{})
+			if err != nil {
+				t.Fatalf("ParseEmbedded() error = %v", err)
+			}
+			got, _ := json.Marshal(expanded)
+			if string(got) != tt.want {
+				t.Errorf("ParseEmbedded() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEmbeddedDecodeOptions(t *testing.T) {
+	input := `{"z":1,"body":"{\"y\":1,\"b\":2,\"y\":3}"}`
+
+	v, order := decodeOrdered(t, input)
+	expanded, err := ParseEmbedded(v, nil, formatter.Options{KeyOrder: order, DuplicateKeys: formatter.DuplicateFirstWins})
+	if err != nil {
+		t.Fatalf("ParseEmbedded() error = %v", err)
+	}
+	if got, want := formatOrdered(t, expanded, order), `{"z":1,"body":{"y":1,"b":2}}`; got != want {
+		t.Errorf("ParseEmbedded() = %s, want %s", got, want)
+	}
+
+	v, order = decodeOrdered(t, input)
+	_, err = ParseEmbedded(v, nil, formatter.Options{KeyOrder: order, DuplicateKeys: formatter.DuplicateError})
+	if err == nil || !strings.Contains(err.Error(), `embedded JSON in body`) || !strings.Contains(err.Error(), `duplicate key "y"`) {
+		t.Errorf("ParseEmbedded() error = %v, want a duplicate key error in body", err)
+	}
+}
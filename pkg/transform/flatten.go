@@ -0,0 +1,305 @@
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Flatten converts nested objects and arrays into a single object whose keys
+// are the paths of the scalar values, e.g. "a.b[0].c". Object keys are joined
+// with sep; array indexes are always written in brackets. A backslash escapes
+// sep, "[" and "\\" within a key, so that {"a.b":1} flattens to "a\\.b" and
+// Unflatten restores it. Empty objects and arrays are kept as values so that
+// nothing is lost. The keys follow the document, visiting objects in the
+// order order recorded for them, and that order is recorded for the result.
+// A scalar document is returned unchanged.
+func Flatten(v interface{}, sep string, order *formatter.KeyOrder) interface{} {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return v
+	}
+
+	f := &flattener{out: make(map[string]interface{}), sep: sep, order: order}
+	f.flatten("", true, v)
+	order.Record(f.out, f.keys)
+	return f.out
+}
+
+// flattener collects the scalars of a document under their flattened keys
+type flattener struct {
+	out   map[string]interface{}
+	keys  []string
+	sep   string
+	order *formatter.KeyOrder
+}
+
+// flatten adds the scalars of v, found at prefix, to the result. top is set
+// for the document itself, whose prefix is empty.
+func (f *flattener) flatten(prefix string, top bool, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && !top {
+			f.add(prefix, val)
+			return
+		}
+		for _, k := range f.order.Keys(val) {
+			key := escapeFlatKey(k, f.sep)
+			if !top {
+				key = prefix + f.sep + key
+			}
+			f.flatten(key, false, val[k])
+		}
+	case []interface{}:
+		if len(val) == 0 && !top {
+			f.add(prefix, val)
+			return
+		}
+		for i, child := range val {
+			f.flatten(prefix+"["+strconv.Itoa(i)+"]", false, child)
+		}
+	default:
+		f.add(prefix, v)
+	}
+}
+
+// add sets one flattened key. Escaping keeps the keys of different paths
+// apart, except for an empty key holding an array, "[0]", and a document
+// that is itself an array, which cannot meet in the same document.
+func (f *flattener) add(key string, v interface{}) {
+	f.keys = append(f.keys, key)
+	f.out[key] = v
+}
+
+// escapeFlatKey escapes sep, "[" and backslashes in an object key
+func escapeFlatKey(key, sep string) string {
+	if !strings.ContainsAny(key, `\[`) && !strings.Contains(key, sep) {
+		return key
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		switch {
+		case key[i] == '\\' || key[i] == '[':
+			b.WriteByte('\\')
+			b.WriteByte(key[i])
+			i++
+		case strings.HasPrefix(key[i:], sep):
+			b.WriteByte('\\')
+			b.WriteString(sep)
+			i += len(sep)
+		default:
+			b.WriteByte(key[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// missing marks a place in an unflattened document that no key has filled yet
+var missing = &struct{}{}
+
+// flatObject is an object being rebuilt by Unflatten, with its keys in the
+// order they were first filled
+type flatObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// Unflatten rebuilds nested objects and arrays from an object whose keys are
+// paths, such as "a.b[0].c", reversing Flatten. Object keys are split at sep
+// and bracketed numbers are array indexes, unless escaped with a backslash;
+// array elements that no key fills are null. Two keys that need the same
+// place to be both a value and a container are an error. Keys are visited in
+// the order order recorded for the object, and each rebuilt object records
+// its keys in the order they first appear. A document that is not an object
+// is returned unchanged.
+func Unflatten(v interface{}, sep string, order *formatter.KeyOrder) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v, nil
+	}
+
+	var root interface{} = missing
+	for _, k := range order.Keys(obj) {
+		path, err := splitFlatKey(k, sep, len(obj))
+		if err != nil {
+			return nil, err
+		}
+		if root, err = insertAt(root, path, obj[k]); err != nil {
+			return nil, fmt.Errorf("key %q: %v", k, err)
+		}
+	}
+	if root == missing {
+		return map[string]interface{}{}, nil
+	}
+	return finishUnflatten(root, order), nil
+}
+
+// splitFlatKey parses a flattened key into a path. Indexes are bounded by the
+// number of keys, since every element of a flattened array has at least one.
+func splitFlatKey(key, sep string, count int) ([]jsonpath.Segment, error) {
+	var path []jsonpath.Segment
+	for i, part := range splitUnescaped(key, sep) {
+		// Peel the unescaped [n] suffixes off the key
+		var indexes []int
+		for strings.HasSuffix(part, "]") {
+			open := strings.LastIndexByte(part, '[')
+			if open < 0 || escapedAt(part, open) {
+				break
+			}
+			n, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil || n < 0 {
+				break
+			}
+			if n >= count {
+				return nil, fmt.Errorf("key %q: array index %d is too large: a document with %d key(s) cannot fill that many elements", key, n, count)
+			}
+			indexes = append([]int{n}, indexes...)
+			part = part[:open]
+		}
+
+		// Only the first part may be empty before an index, for a document
+		// that is an array
+		if i > 0 || part != "" || len(indexes) == 0 {
+			path = append(path, jsonpath.Key(unescapeFlatKey(part, sep)))
+		}
+		for _, n := range indexes {
+			path = append(path, jsonpath.Index(n))
+		}
+	}
+	return path, nil
+}
+
+// splitUnescaped splits key at every sep not preceded by an escaping
+// backslash, leaving the escapes in place
+func splitUnescaped(key, sep string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(key); {
+		switch {
+		case key[i] == '\\' && i+1 < len(key) && isFlatEscape(key[i+1:], sep):
+			if key[i+1] == '\\' || key[i+1] == '[' {
+				i += 2
+			} else {
+				i += 1 + len(sep)
+			}
+		case strings.HasPrefix(key[i:], sep):
+			parts = append(parts, key[start:i])
+			i += len(sep)
+			start = i
+		default:
+			i++
+		}
+	}
+	return append(parts, key[start:])
+}
+
+// isFlatEscape reports whether a backslash followed by rest escapes
+// something. Other backslashes are kept as they are.
+func isFlatEscape(rest, sep string) bool {
+	return rest[0] == '\\' || rest[0] == '[' || strings.HasPrefix(rest, sep)
+}
+
+// escapedAt reports whether the byte at i of part is escaped by a backslash
+func escapedAt(part string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && part[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// unescapeFlatKey removes the escapes added by escapeFlatKey
+func unescapeFlatKey(part, sep string) string {
+	if !strings.Contains(part, `\`) {
+		return part
+	}
+	var b strings.Builder
+	for i := 0; i < len(part); {
+		switch {
+		case part[i] == '\\' && i+1 < len(part) && (part[i+1] == '\\' || part[i+1] == '['):
+			b.WriteByte(part[i+1])
+			i += 2
+		case part[i] == '\\' && strings.HasPrefix(part[i+1:], sep):
+			b.WriteString(sep)
+			i += 1 + len(sep)
+		default:
+			b.WriteByte(part[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// insertAt places value at path below node, creating objects and arrays as needed
+func insertAt(node interface{}, path []jsonpath.Segment, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		if node != missing {
+			return nil, fmt.Errorf("another key already holds a value here")
+		}
+		return value, nil
+	}
+
+	seg := path[0]
+	if seg.IsIndex {
+		arr, ok := node.([]interface{})
+		if node != missing && !ok {
+			return nil, fmt.Errorf("[%d] is used as an array index where there is an object or a value", seg.Index)
+		}
+		for len(arr) <= seg.Index {
+			arr = append(arr, missing)
+		}
+		child, err := insertAt(arr[seg.Index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		arr[seg.Index] = child
+		return arr, nil
+	}
+
+	obj, ok := node.(*flatObject)
+	if node == missing {
+		obj, ok = &flatObject{values: make(map[string]interface{})}, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("%q is used as an object key where there is an array or a value", seg.Key)
+	}
+	existing, found := obj.values[seg.Key]
+	if !found {
+		existing = missing
+		obj.keys = append(obj.keys, seg.Key)
+	}
+	child, err := insertAt(existing, path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	obj.values[seg.Key] = child
+	return obj, nil
+}
+
+// finishUnflatten turns the rebuilt objects into maps, recording their key
+// order, and replaces the array elements that no key filled with null
+func finishUnflatten(v interface{}, order *formatter.KeyOrder) interface{} {
+	switch val := v.(type) {
+	case *flatObject:
+		out := make(map[string]interface{}, len(val.values))
+		for _, k := range val.keys {
+			out[k] = finishUnflatten(val.values[k], order)
+		}
+		order.Record(out, val.keys)
+		return out
+	case []interface{}:
+		for i, child := range val {
+			val[i] = finishUnflatten(child, order)
+		}
+	default:
+		if v == missing {
+			return nil
+		}
+	}
+	return v
+}
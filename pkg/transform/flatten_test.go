@@ -0,0 +1,189 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		sep   string
+		want  string
+	}{
+		{
+			name:  "Nested objects and arrays",
+			input: `{"a":{"b":[{"c":1},2]},"d":"x"}`,
+			sep:   ".",
+			want:  `{"a.b[0].c":1,"a.b[1]":2,"d":"x"}`,
+		},
+		{
+			name:  "Custom separator",
+			input: `{"a":{"b":{"c":null}}}`,
+			sep:   "/",
+			want:  `{"a/b/c":null}`,
+		},
+		{
+			name:  "Empty containers are kept",
+			input: `{"a":{},"b":[]}`,
+			sep:   ".",
+			want:  `{"a":{},"b":[]}`,
+		},
+		{
+			name:  "Top-level array",
+			input: `[{"a":1}]`,
+			sep:   ".",
+			want:  `{"[0].a":1}`,
+		},
+		{
+			name:  "Separators and brackets in keys are escaped",
+			input: `{"a.b":1,"a":{"b":2},"x[0]":3,"c\\d":4}`,
+			sep:   ".",
+			want:  `{"a.b":2,"a\\.b":1,"c\\\\d":4,"x\\[0]":3}`,
+		},
+		{
+			name:  "Scalar",
+			input: `3`,
+			sep:   ".",
+			want:  `3`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(Flatten(decodeJSON(t, tt.input), tt.sep, nil))
+			if string(got) != tt.want {
+				t.Errorf("Flatten() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		sep     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Nested objects and arrays",
+			input: `{"a.b[0].c":1,"a.b[1]":2,"d":"x"}`,
+			sep:   ".",
+			want:  `{"a":{"b":[{"c":1},2]},"d":"x"}`,
+		},
+		{
+			name:  "Custom separator",
+			input: `{"a/b/c":null,"a/e":[]}`,
+			sep:   "/",
+			want:  `{"a":{"b":{"c":null},"e":[]}}`,
+		},
+		{
+			name:  "Root array and nested indexes",
+			input: `{"[0][1]":"x","[1].k":true}`,
+			sep:   ".",
+			want:  `[[null,"x"],{"k":true}]`,
+		},
+		{
+			name:    "Value and container at the same place",
+			input:   `{"a":1,"a.b":2}`,
+			sep:     ".",
+			wantErr: true,
+		},
+		{
+			name:    "Object and array at the same place",
+			input:   `{"a[0]":1,"a.b":2}`,
+			sep:     ".",
+			wantErr: true,
+		},
+		{
+			name:  "Escaped separators and brackets",
+			input: `{"a\\.b":1,"a.b":2,"x\\[0]":3,"c\\\\d":4,"C:\\x":5}`,
+			sep:   ".",
+			want:  `{"C:\\x":5,"a":{"b":2},"a.b":1,"c\\d":4,"x[0]":3}`,
+		},
+		{
+			name:    "Index out of range",
+			input:   `{"a[999999999]":1}`,
+			sep:     ".",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+
+			got, err := Unflatten(v, tt.sep, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unflatten() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			b, _ := json.Marshal(got)
+			if string(b) != tt.want {
+				t.Errorf("Unflatten() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	input := `{"a":{"b":[{"c":1},[2,3],{}],"d":"x"},"e":null}`
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unflatten(Flatten(v, ".", nil), ".", nil)
+	if err != nil {
+		t.Fatalf("Unflatten() error = %v", err)
+	}
+	if b, _ := json.Marshal(got); string(b) != input {
+		t.Errorf("Unflatten(Flatten()) = %s, want %s", b, input)
+	}
+}
+
+func TestFlattenKeepsKeyOrder(t *testing.T) {
+	v, order := decodeOrdered(t, `{"zeta":{"y":1,"b":[true,{"q":null,"a":2}]},"alpha":"x","mid":{}}`)
+	got := formatOrdered(t, Flatten(v, ".", order), order)
+	want := `{"zeta.y":1,"zeta.b[0]":true,"zeta.b[1].q":null,"zeta.b[1].a":2,"alpha":"x","mid":{}}`
+	if got != want {
+		t.Errorf("Flatten() = %s, want %s", got, want)
+	}
+}
+
+func TestFlattenRoundTripKeepsKeysAndOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		sep   string
+	}{
+		{"Key order", `{"zeta":{"y":1,"b":[true,{"q":null,"a":2}]},"alpha":"x","mid":{}}`, "."},
+		{"Keys that collide once flattened", `{"a.b":1,"a":{"b":2},"x[0]":3,"x":[4]}`, "."},
+		{"Backslashes and empty keys", `{"c\\d":{"":1,"e\\":2},"":{"f":3}}`, "."},
+		{"Multi-character separator", `{"a__b":{"c":1},"a":{"b__c":2}}`, "__"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, order := decodeOrdered(t, tt.input)
+			flat := Flatten(v, tt.sep, order)
+			if n := len(flat.(map[string]interface{})); n < 2 {
+				t.Fatalf("Flatten() = %v, keys were merged", flat)
+			}
+			got, err := Unflatten(flat, tt.sep, order)
+			if err != nil {
+				t.Fatalf("Unflatten() error = %v", err)
+			}
+			if out := formatOrdered(t, got, order); out != tt.input {
+				t.Errorf("Unflatten(Flatten()) = %s, want %s", out, tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Aggregate is a statistic computed over the elements of a group
+type Aggregate struct {
+	// Func is count, sum, avg, min or max
+	Func string
+	// Field is the field the statistic reads; count has none
+	Field string
+}
+
+// Name is how the aggregate is written, e.g. "sum(amount)", which is also the
+// key of its value in the output
+func (a Aggregate) Name() string {
+	if a.Field == "" {
+		return a.Func
+	}
+	return a.Func + "(" + a.Field + ")"
+}
+
+// ParseAggregates reads a comma-separated list such as "count,sum(amount)"
+func ParseAggregates(spec string) ([]Aggregate, error) {
+	var aggs []Aggregate
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var a Aggregate
+		if open := strings.IndexByte(item, '('); open >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("aggregate %q is missing a closing parenthesis", item)
+			}
+			a.Func, a.Field = item[:open], strings.TrimSpace(item[open+1:len(item)-1])
+		} else {
+			a.Func = item
+		}
+
+		switch a.Func {
+		case "count":
+			if a.Field != "" {
+				return nil, fmt.Errorf("count takes no field, found %q", item)
+			}
+		case "sum", "avg", "min", "max":
+			if a.Field == "" {
+				return nil, fmt.Errorf("%s needs a field, as in %s(amount)", a.Func, a.Func)
+			}
+		default:
+			return nil, fmt.Errorf("unknown aggregate %q (use count, sum, avg, min or max)", a.Func)
+		}
+		aggs = append(aggs, a)
+	}
+	return aggs, nil
+}
+
+// GroupBy buckets the elements of items by the value of field and returns one
+// object per group, in order of first appearance, holding the field's value and
+// the aggregates, e.g. {"status": "active", "count": 3, "sum(amount)": 120}.
+// Elements without the field form a group of their own with a null value. sum
+// and avg only count numbers; min and max compare values like SortArraysBy and
+// skip nulls. An aggregate with nothing to read is null.
+func GroupBy(items []interface{}, field string, aggs []Aggregate) []interface{} {
+	type group struct {
+		value interface{}
+		items []interface{}
+	}
+
+	var groups []*group
+	index := make(map[string]*group)
+	for _, item := range items {
+		value, _ := fieldOf(item, field)
+		key := canonicalKey(value)
+		g, ok := index[key]
+		if !ok {
+			g = &group{value: value}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.items = append(g.items, item)
+	}
+
+	out := make([]interface{}, len(groups))
+	for i, g := range groups {
+		row := map[string]interface{}{field: g.value}
+		for _, a := range aggs {
+			row[a.Name()] = aggregate(g.items, a)
+		}
+		out[i] = row
+	}
+	return out
+}
+
+// aggregate computes one statistic over the elements of a group
+func aggregate(items []interface{}, a Aggregate) interface{} {
+	if a.Func == "count" {
+		return float64(len(items))
+	}
+
+	var sum float64
+	var numbers int
+	var best interface{}
+	for _, item := range items {
+		v, ok := fieldOf(item, a.Field)
+		if !ok || v == nil {
+			continue
+		}
+		if n, ok := toFloat(v); ok {
+			sum += n
+			numbers++
+		}
+		switch {
+		case best == nil,
+			a.Func == "min" && Compare(v, best) < 0,
+			a.Func == "max" && Compare(v, best) > 0:
+			best = v
+		}
+	}
+
+	switch a.Func {
+	case "sum":
+		return sum
+	case "avg":
+		if numbers == 0 {
+			return nil
+		}
+		return sum / float64(numbers)
+	}
+	return best
+}
+
+// toFloat reads a decoded number
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,38 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	items := decodeJSON(t, `[
+		{"status": "active", "amount": 10, "region": "eu"},
+		{"status": "gone", "amount": 5, "region": "us"},
+		{"status": "active", "amount": 30, "region": "us"},
+		{"amount": 1},
+		{"status": "active", "amount": "n/a", "region": null}
+	]`).([]interface{})
+
+	aggs, err := ParseAggregates("count, sum(amount), avg(amount), min(region), max(amount)")
+	if err != nil {
+		t.Fatalf("ParseAggregates() error = %v", err)
+	}
+
+	want := decodeJSON(t, `[
+		{"status": "active", "count": 3, "sum(amount)": 40, "avg(amount)": 20, "min(region)": "eu", "max(amount)": "n/a"},
+		{"status": "gone", "count": 1, "sum(amount)": 5, "avg(amount)": 5, "min(region)": "us", "max(amount)": 5},
+		{"status": null, "count": 1, "sum(amount)": 1, "avg(amount)": 1, "min(region)": null, "max(amount)": 1}
+	]`)
+	if got := GroupBy(items, "status", aggs); !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy() = %v, want %v", got, want)
+	}
+}
+
+func TestParseAggregates(t *testing.T) {
+	for _, spec := range []string{"median(x)", "sum", "count(x)", "sum(x", "avg()"} {
+		if _, err := ParseAggregates(spec); err == nil {
+			t.Errorf("ParseAggregates(%q) expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// ArrayMerge selects how DeepMerge combines two arrays found at the same place
+type ArrayMerge string
+
+const (
+	// ArrayReplace keeps the later array and drops the earlier one
+	ArrayReplace ArrayMerge = "replace"
+	// ArrayConcat appends the elements of the later array to the earlier one
+	ArrayConcat ArrayMerge = "concat"
+	// ArrayMergeByKey merges objects whose key field has the same value, and
+	// appends the other elements of the later array
+	ArrayMergeByKey ArrayMerge = "merge-by-key"
+)
+
+// ParseArrayMerge validates the name of an array strategy
+func ParseArrayMerge(name string) (ArrayMerge, error) {
+	switch a := ArrayMerge(name); a {
+	case ArrayReplace, ArrayConcat, ArrayMergeByKey:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown array strategy %q (use replace, concat or merge-by-key)", name)
+}
+
+// MergeOptions controls DeepMerge
+type MergeOptions struct {
+	Arrays ArrayMerge
+	// Key is the field that identifies objects with ArrayMergeByKey
+	Key string
+	// Order, when set, holds the key order of both documents and receives
+	// the order of the merged objects
+	Order *formatter.KeyOrder
+}
+
+// DeepMerge overlays one document on another. Objects are merged key by key,
+// arrays as opts.Arrays selects, and any other value of overlay, null
+// included, replaces the one in base. Merged objects keep the keys of base
+// in their order, followed by the keys only overlay has. Neither document is
+// modified.
+func DeepMerge(base, overlay interface{}, opts MergeOptions) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return overlay
+		}
+		out := make(map[string]interface{}, len(b)+len(o))
+		keys := opts.Order.Keys(b)
+		for _, k := range keys {
+			out[k] = b[k]
+		}
+		for _, k := range opts.Order.Keys(o) {
+			v := o[k]
+			if prev, ok := out[k]; ok {
+				v = DeepMerge(prev, v, opts)
+			} else {
+				keys = append(keys, k)
+			}
+			out[k] = v
+		}
+		opts.Order.Record(out, keys)
+		return out
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return overlay
+		}
+		switch opts.Arrays {
+		case ArrayConcat:
+			out := make([]interface{}, 0, len(b)+len(o))
+			return append(append(out, b...), o...)
+		case ArrayMergeByKey:
+			return mergeByKey(b, o, opts)
+		}
+	}
+	return overlay
+}
+
+// mergeByKey merges each object of overlay into the element of base with the
+// same value in the key field, and appends the elements that match none
+func mergeByKey(base, overlay []interface{}, opts MergeOptions) []interface{} {
+	out := make([]interface{}, len(base), len(base)+len(overlay))
+	copy(out, base)
+
+	for _, item := range overlay {
+		match := -1
+		if obj, ok := item.(map[string]interface{}); ok {
+			if id, ok := obj[opts.Key]; ok {
+				match = findByKey(out, opts.Key, id)
+			}
+		}
+		if match < 0 {
+			out = append(out, item)
+			continue
+		}
+		out[match] = DeepMerge(out[match], item, opts)
+	}
+	return out
+}
+
+// findByKey returns the index of the first object in items whose key field
+// equals id, or -1
+func findByKey(items []interface{}, key string, id interface{}) int {
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := obj[key]; ok && reflect.DeepEqual(v, id) {
+			return i
+		}
+	}
+	return -1
+}
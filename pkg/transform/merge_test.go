@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestDeepMerge(t *testing.T) {
+	base := `{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["a"],
+		"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "dev"}]}`
+
+	tests := []struct {
+		name    string
+		overlay string
+		arrays  ArrayMerge
+		want    string
+	}{
+		{
+			name:    "objects merged, scalars replaced",
+			overlay: `{"db": {"host": "db.internal"}, "debug": true}`,
+			arrays:  ArrayReplace,
+			want: `{"name": "app", "db": {"host": "db.internal", "port": 5432}, "tags": ["a"], "debug": true,
+				"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "dev"}]}`,
+		},
+		{
+			name:    "null replaces",
+			overlay: `{"db": null}`,
+			arrays:  ArrayReplace,
+			want: `{"name": "app", "db": null, "tags": ["a"],
+				"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "dev"}]}`,
+		},
+		{
+			name:    "arrays replaced",
+			overlay: `{"tags": ["b"], "users": [{"id": 2, "active": false}]}`,
+			arrays:  ArrayReplace,
+			want: `{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["b"],
+				"users": [{"id": 2, "active": false}]}`,
+		},
+		{
+			name:    "arrays concatenated",
+			overlay: `{"tags": ["b"]}`,
+			arrays:  ArrayConcat,
+			want: `{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"],
+				"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "dev"}]}`,
+		},
+		{
+			name:    "arrays merged by key",
+			overlay: `{"tags": ["b"], "users": [{"id": 2, "active": false}, {"id": 3, "role": "ops"}, {"role": "guest"}]}`,
+			arrays:  ArrayMergeByKey,
+			want: `{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"],
+				"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "dev", "active": false}, {"id": 3, "role": "ops"}, {"role": "guest"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := decodeJSON(t, base)
+			got := DeepMerge(b, decodeJSON(t, tt.overlay), MergeOptions{Arrays: tt.arrays, Key: "id"})
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("DeepMerge() = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(b, decodeJSON(t, base)) {
+				t.Errorf("DeepMerge() modified the base document")
+			}
+		})
+	}
+
+	if _, err := ParseArrayMerge("zip"); err == nil {
+		t.Errorf("ParseArrayMerge() accepted an unknown strategy")
+	}
+}
+
+func TestDeepMergeKeepsKeyOrder(t *testing.T) {
+	base, order := decodeOrdered(t, `{"zeta":1,"db":{"port":1,"host":"a"},"alpha":2}`)
+	overlay, err := formatter.Decode([]byte(`{"new":3,"db":{"user":"u","host":"b"},"zeta":4}`), formatter.Options{KeyOrder: order})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := formatOrdered(t, DeepMerge(base, overlay, MergeOptions{Arrays: ArrayReplace, Order: order}), order)
+	want := `{"zeta":4,"db":{"port":1,"host":"b","user":"u"},"alpha":2,"new":3}`
+	if got != want {
+		t.Errorf("DeepMerge() = %s, want %s", got, want)
+	}
+}
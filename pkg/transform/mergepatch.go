@@ -0,0 +1,37 @@
+package transform
+
+import "github.com/nicolasalberti00/fj/pkg/formatter"
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to target. An object patch
+// is merged key by key: null removes the key, an object is merged into the
+// value already there, and anything else replaces it. A patch that is not an
+// object replaces the target outright. Merged objects keep the keys of
+// target in the order order recorded for them, followed by the keys the
+// patch adds. target is not modified.
+func MergePatch(target, patch interface{}, order *formatter.KeyOrder) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, _ := target.(map[string]interface{})
+	out := make(map[string]interface{}, len(t)+len(p))
+	keys := order.Keys(t)
+	for _, k := range keys {
+		out[k] = t[k]
+	}
+
+	for _, k := range order.Keys(p) {
+		v := p[k]
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		if _, ok := out[k]; !ok {
+			keys = append(keys, k)
+		}
+		out[k] = MergePatch(out[k], v, order)
+	}
+	order.Record(out, keys)
+	return out
+}
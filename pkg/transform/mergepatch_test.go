@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Test cases from RFC 7386, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		target, patch, want := decodeJSON(t, tt.target), decodeJSON(t, tt.patch), decodeJSON(t, tt.want)
+		if got := MergePatch(target, patch, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+		if !reflect.DeepEqual(target, decodeJSON(t, tt.target)) {
+			t.Errorf("MergePatch(%s, %s) modified the target", tt.target, tt.patch)
+		}
+	}
+}
+
+func TestMergePatchKeepsKeyOrder(t *testing.T) {
+	target, order := decodeOrdered(t, `{"zeta":1,"db":{"port":1,"host":"a"},"alpha":2,"gone":0}`)
+	patch, err := formatter.Decode([]byte(`{"new":{"y":1,"x":2},"db":{"user":"u","host":"b"},"gone":null}`), formatter.Options{KeyOrder: order})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := formatOrdered(t, MergePatch(target, patch, order), order)
+	want := `{"zeta":1,"db":{"port":1,"host":"b","user":"u"},"alpha":2,"new":{"y":1,"x":2}}`
+	if got != want {
+		t.Errorf("MergePatch() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Pick keeps only the entries whose path matches one of the patterns, along
+// with the objects and arrays that lead to them. A matching container is kept
+// whole. Elements of an array that keep nothing are dropped, so the remaining
+// elements are renumbered. Kept keys stay in the order order recorded for
+// their object.
+func Pick(v interface{}, patterns []*jsonpath.Pattern, order *formatter.KeyOrder) interface{} {
+	picked, ok := pick(nil, v, patterns, order)
+	if !ok {
+		// Nothing matched: an empty container of the same kind is the most
+		// faithful answer, and a scalar root has nothing to pick from
+		switch v.(type) {
+		case map[string]interface{}:
+			return map[string]interface{}{}
+		case []interface{}:
+			return []interface{}{}
+		}
+		return nil
+	}
+	return picked
+}
+
+// pick returns the part of v, located at path, that should be kept, and
+// whether anything was kept at all
+func pick(path []jsonpath.Segment, v interface{}, patterns []*jsonpath.Pattern, order *formatter.KeyOrder) (interface{}, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		var keys []string
+		for _, k := range order.Keys(val) {
+			child := val[k]
+			childPath := append(path, jsonpath.Key(k))
+			if jsonpath.MatchAny(patterns, childPath) {
+				out[k] = child
+				keys = append(keys, k)
+				continue
+			}
+			if kept, ok := pick(childPath, child, patterns, order); ok {
+				out[k] = kept
+				keys = append(keys, k)
+			}
+		}
+		order.Record(out, keys)
+		return out, len(out) > 0
+	case []interface{}:
+		out := make([]interface{}, 0)
+		for i, child := range val {
+			childPath := append(path, jsonpath.Index(i))
+			if jsonpath.MatchAny(patterns, childPath) {
+				out = append(out, child)
+				continue
+			}
+			if kept, ok := pick(childPath, child, patterns, order); ok {
+				out = append(out, kept)
+			}
+		}
+		return out, len(out) > 0
+	}
+
+	return nil, false
+}
+
+// Omit removes every entry whose path matches one of the patterns. The other
+// keys stay in the order order recorded for their object.
+func Omit(v interface{}, patterns []*jsonpath.Pattern, order *formatter.KeyOrder) interface{} {
+	if len(patterns) == 0 {
+		return v
+	}
+	return omit(nil, v, patterns, order)
+}
+
+// omit walks v, located at path, dropping matching entries
+func omit(path []jsonpath.Segment, v interface{}, patterns []*jsonpath.Pattern, order *formatter.KeyOrder) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		keys := make([]string, 0, len(val))
+		for _, k := range order.Keys(val) {
+			childPath := append(path, jsonpath.Key(k))
+			if jsonpath.MatchAny(patterns, childPath) {
+				continue
+			}
+			out[k] = omit(childPath, val[k], patterns, order)
+			keys = append(keys, k)
+		}
+		order.Record(out, keys)
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for i, child := range val {
+			childPath := append(path, jsonpath.Index(i))
+			if jsonpath.MatchAny(patterns, childPath) {
+				continue
+			}
+			out = append(out, omit(childPath, child, patterns, order))
+		}
+		return out
+	}
+
+	return v
+}
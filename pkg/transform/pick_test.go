@@ -0,0 +1,91 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+func TestPickAndOmit(t *testing.T) {
+	input := `{"id":1,"user":{"name":"ann","email":"a@x"},"items":[{"id":2,"debug":true},{"debug":false}],"debug":{"trace":"t"}}`
+
+	tests := []struct {
+		name     string
+		omit     bool
+		patterns []string
+		want     string
+	}{
+		{
+			name:     "Pick anchored path",
+			patterns: []string{"user.name"},
+			want:     `{"user":{"name":"ann"}}`,
+		},
+		{
+			name:     "Pick key at any depth drops empty elements",
+			patterns: []string{"id"},
+			want:     `{"id":1,"items":[{"id":2}]}`,
+		},
+		{
+			name:     "Pick whole container",
+			patterns: []string{"user", "id"},
+			want:     `{"id":1,"items":[{"id":2}],"user":{"email":"a@x","name":"ann"}}`,
+		},
+		{
+			name:     "Pick nothing",
+			patterns: []string{"missing"},
+			want:     `{}`,
+		},
+		{
+			name:     "Omit key at any depth",
+			omit:     true,
+			patterns: []string{"debug"},
+			want:     `{"id":1,"items":[{"id":2},{}],"user":{"email":"a@x","name":"ann"}}`,
+		},
+		{
+			name:     "Omit wildcard and array element",
+			omit:     true,
+			patterns: []string{"user.*", "items[0]", "debug.trace"},
+			want:     `{"debug":{},"id":1,"items":[{"debug":false}],"user":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := jsonpath.CompileAll(tt.patterns)
+			if err != nil {
+				t.Fatalf("CompileAll() error = %v", err)
+			}
+
+			v := decodeJSON(t, input)
+			var result interface{}
+			if tt.omit {
+				result = Omit(v, patterns, nil)
+			} else {
+				result = Pick(v, patterns, nil)
+			}
+
+			got, _ := json.Marshal(result)
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickAndOmitKeepKeyOrder(t *testing.T) {
+	patterns, err := jsonpath.CompileAll([]string{"zeta", "mid.y", "mid.b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"zeta":1,"alpha":2,"mid":{"y":1,"x":2,"b":3}}`
+
+	v, order := decodeOrdered(t, input)
+	if got, want := formatOrdered(t, Pick(v, patterns, order), order), `{"zeta":1,"mid":{"y":1,"b":3}}`; got != want {
+		t.Errorf("Pick() = %s, want %s", got, want)
+	}
+	v, order = decodeOrdered(t, input)
+	if got, want := formatOrdered(t, Omit(v, patterns, order), order), `{"alpha":2,"mid":{"x":2}}`; got != want {
+		t.Errorf("Omit() = %s, want %s", got, want)
+	}
+}
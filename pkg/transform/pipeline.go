@@ -0,0 +1,242 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Step transforms a whole document, recording the key order of the objects
+// it builds in order
+type Step func(v interface{}, order *formatter.KeyOrder) (interface{}, error)
+
+// stepBuilders maps the name of each pipeline step to a function that builds
+// the step from its argument in the pipeline file
+var stepBuilders = map[string]func(arg json.RawMessage) (Step, error){
+	"pick":           buildPick,
+	"omit":           buildOmit,
+	"rename":         buildRename,
+	"redact":         buildRedact,
+	"sort-array-by":  buildSortArrayBy,
+	"flatten":        buildFlatten,
+	"unflatten":      buildUnflatten,
+	"rename-keys":    buildRenameKeys,
+	"prune":          buildPrune,
+	"parse-embedded": buildParseEmbedded,
+}
+
+// Pipeline is an ordered list of steps read from a pipeline file
+type Pipeline struct {
+	names []string
+	steps []Step
+}
+
+// ParsePipeline reads a pipeline file, a JSON object listing its steps in order.
+// Each step is an object with a single key naming the step:
+//
+//	{"steps": [
+//	  {"pick": ["id", "name", "address.city"]},
+//	  {"rename": {"name": "fullName"}},
+//	  {"redact": ["email"]},
+//	  {"sort-array-by": "id"},
+//	  {"flatten": "."}
+//	]}
+func ParsePipeline(data []byte) (*Pipeline, error) {
+	var file struct {
+		Steps []map[string]json.RawMessage `json:"steps"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %v", err)
+	}
+	if len(file.Steps) == 0 {
+		return nil, fmt.Errorf("invalid pipeline: no steps")
+	}
+
+	p := &Pipeline{}
+	for i, entry := range file.Steps {
+		if len(entry) != 1 {
+			return nil, fmt.Errorf("invalid pipeline step %d: expected a single key naming the step, got %d", i+1, len(entry))
+		}
+		for name, arg := range entry {
+			build, ok := stepBuilders[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid pipeline step %d: unknown step %q (expected one of %s)", i+1, name, stepNames())
+			}
+			step, err := build(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pipeline step %d (%s): %v", i+1, name, err)
+			}
+			p.names = append(p.names, name)
+			p.steps = append(p.steps, step)
+		}
+	}
+
+	return p, nil
+}
+
+// Apply runs every step in order. The objects the steps rebuild keep the key
+// order recorded in order, which may be nil.
+func (p *Pipeline) Apply(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+	for i, step := range p.steps {
+		var err error
+		if v, err = step(v, order); err != nil {
+			return nil, fmt.Errorf("pipeline step %d (%s): %v", i+1, p.names[i], err)
+		}
+	}
+	return v, nil
+}
+
+// stepNames lists the known steps for error messages
+func stepNames() string {
+	names := make([]string, 0, len(stepBuilders))
+	for name := range stepBuilders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// patternsArg decodes a list of path expressions
+func patternsArg(arg json.RawMessage) ([]*jsonpath.Pattern, error) {
+	var exprs []string
+	if err := json.Unmarshal(arg, &exprs); err != nil {
+		return nil, fmt.Errorf("expected a list of paths")
+	}
+	return jsonpath.CompileAll(exprs)
+}
+
+func buildPick(arg json.RawMessage) (Step, error) {
+	patterns, err := patternsArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Pick(v, patterns, order), nil
+	}, nil
+}
+
+func buildOmit(arg json.RawMessage) (Step, error) {
+	patterns, err := patternsArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Omit(v, patterns, order), nil
+	}, nil
+}
+
+func buildRedact(arg json.RawMessage) (Step, error) {
+	patterns, err := patternsArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Redact(v, patterns), nil
+	}, nil
+}
+
+func buildRename(arg json.RawMessage) (Step, error) {
+	var mapping map[string]string
+	if err := json.Unmarshal(arg, &mapping); err != nil {
+		return nil, fmt.Errorf("expected an object mapping paths to new key names")
+	}
+	renames, err := RenamesFromMap(mapping)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return RenameKeys(v, renames, order)
+	}, nil
+}
+
+func buildSortArrayBy(arg json.RawMessage) (Step, error) {
+	var field string
+	if err := json.Unmarshal(arg, &field); err != nil || field == "" {
+		return nil, fmt.Errorf("expected the name of the field to sort by")
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return SortArraysBy(v, field), nil
+	}, nil
+}
+
+func buildFlatten(arg json.RawMessage) (Step, error) {
+	sep := "."
+	if string(arg) != "true" {
+		if err := json.Unmarshal(arg, &sep); err != nil || sep == "" {
+			return nil, fmt.Errorf("expected true or a key separator such as \".\"")
+		}
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Flatten(v, sep, order), nil
+	}, nil
+}
+
+func buildUnflatten(arg json.RawMessage) (Step, error) {
+	sep := "."
+	if string(arg) != "true" {
+		if err := json.Unmarshal(arg, &sep); err != nil || sep == "" {
+			return nil, fmt.Errorf("expected true or a key separator such as \".\"")
+		}
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Unflatten(v, sep, order)
+	}, nil
+}
+
+func buildRenameKeys(arg json.RawMessage) (Step, error) {
+	var name string
+	if err := json.Unmarshal(arg, &name); err != nil {
+		return nil, fmt.Errorf("expected a key case: camel, pascal, snake or kebab")
+	}
+	c, err := ParseKeyCase(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return ConvertKeys(v, c, order)
+	}, nil
+}
+
+func buildPrune(arg json.RawMessage) (Step, error) {
+	var kinds []string
+	if err := json.Unmarshal(arg, &kinds); err != nil || len(kinds) == 0 {
+		return nil, fmt.Errorf("expected a list of values to remove: null, empty-strings or empty-containers")
+	}
+
+	var opts PruneOptions
+	for _, kind := range kinds {
+		switch kind {
+		case "null":
+			opts.Null = true
+		case "empty-strings":
+			opts.EmptyStrings = true
+		case "empty-containers":
+			opts.EmptyContainers = true
+		default:
+			return nil, fmt.Errorf("unknown value to remove %q (use null, empty-strings or empty-containers)", kind)
+		}
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return Prune(v, opts, order), nil
+	}, nil
+}
+
+func buildParseEmbedded(arg json.RawMessage) (Step, error) {
+	var patterns []*jsonpath.Pattern
+	if string(arg) != "true" {
+		var err error
+		if patterns, err = patternsArg(arg); err != nil {
+			return nil, fmt.Errorf("expected true or a list of paths")
+		}
+	}
+	return func(v interface{}, order *formatter.KeyOrder) (interface{}, error) {
+		return ParseEmbedded(v, patterns, formatter.Options{KeyOrder: order})
+	}, nil
+}
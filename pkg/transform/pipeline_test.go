@@ -0,0 +1,124 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+)
+
+// decodeJSON decodes a test document
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid test input: %v", err)
+	}
+	return v
+}
+
+// decodeOrdered decodes a test document, recording the order of its keys
+func decodeOrdered(t *testing.T, s string) (interface{}, *formatter.KeyOrder) {
+	t.Helper()
+	order := formatter.NewKeyOrder()
+	v, err := formatter.Decode([]byte(s), formatter.Options{KeyOrder: order})
+	if err != nil {
+		t.Fatalf("invalid test input: %v", err)
+	}
+	return v, order
+}
+
+// formatOrdered writes v on one line with the keys in the order recorded
+func formatOrdered(t *testing.T, v interface{}, order *formatter.KeyOrder) string {
+	t.Helper()
+	out, err := formatter.FormatValue(v, formatter.Options{KeyOrder: order, Compact: true})
+	if err != nil {
+		t.Fatalf("FormatValue() error = %v", err)
+	}
+	return string(out)
+}
+
+func TestPipeline(t *testing.T) {
+	spec := `{"steps": [
+		{"omit": ["debug"]},
+		{"rename": {"users[*].fullName": "name"}},
+		{"sort-array-by": "name"},
+		{"redact": ["email"]},
+		{"flatten": true}
+	]}`
+	input := `{"users":[{"fullName":"zoe","email":"z@x"},{"fullName":"ann","debug":1},{"id":3}]}`
+	want := `{"users[0].name":"ann","users[1].email":"***","users[1].name":"zoe","users[2].id":3}`
+
+	p, err := ParsePipeline([]byte(spec))
+	if err != nil {
+		t.Fatalf("ParsePipeline() error = %v", err)
+	}
+	result, err := p.Apply(decodeJSON(t, input), nil)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	got, _ := json.Marshal(result)
+	if string(got) != want {
+		t.Errorf("Apply() = %s, want %s", got, want)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		input   string
+		wantErr string
+	}{
+		{name: "No steps", spec: `{"steps": []}`, wantErr: "no steps"},
+		{name: "Unknown field", spec: `{"stages": []}`, wantErr: "unknown field"},
+		{name: "Unknown step", spec: `{"steps": [{"explode": true}]}`, wantErr: `unknown step "explode"`},
+		{name: "Two keys", spec: `{"steps": [{"pick": ["a"], "omit": ["b"]}]}`, wantErr: "step 1: expected a single key"},
+		{name: "Bad argument", spec: `{"steps": [{"pick": "a"}]}`, wantErr: "step 1 (pick): expected a list of paths"},
+		{name: "Bad path", spec: `{"steps": [{"omit": ["a[x]"]}]}`, wantErr: "invalid path expression"},
+		{
+			name:    "Rename collision",
+			spec:    `{"steps": [{"flatten": "_"}, {"rename": {"a_b": "c"}}]}`,
+			input:   `{"a":{"b":1},"c":2}`,
+			wantErr: `pipeline step 2 (rename): renaming a_b and c would both produce key "c"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePipeline([]byte(tt.spec))
+			if err == nil {
+				_, err = p.Apply(decodeJSON(t, tt.input), nil)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSortArraysBy(t *testing.T) {
+	input := `{"rows":[{"n":"b"},3,{"n":10},{"n":"a"},{"x":1},{"n":null},{"n":2}]}`
+	want := `{"rows":[{"n":null},{"n":2},{"n":10},{"n":"a"},{"n":"b"},3,{"x":1}]}`
+
+	got, _ := json.Marshal(SortArraysBy(decodeJSON(t, input), "n"))
+	if string(got) != want {
+		t.Errorf("SortArraysBy() = %s, want %s", got, want)
+	}
+}
+
+func TestSortArraysByBigNumbers(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"n": json.Number("9007199254740993")},
+		map[string]interface{}{"n": 9007199254740992.0},
+		map[string]interface{}{"n": json.Number("1e400")},
+	}
+	want := `[{"n":9007199254740992},{"n":9007199254740993},{"n":1e400}]`
+
+	got, _ := json.Marshal(SortArraysBy(rows, "n"))
+	if string(got) != want {
+		t.Errorf("SortArraysBy() = %s, want %s", got, want)
+	}
+}
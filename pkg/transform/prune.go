@@ -0,0 +1,55 @@
+package transform
+
+import "github.com/nicolasalberti00/fj/pkg/formatter"
+
+// PruneOptions selects the values Prune removes
+type PruneOptions struct {
+	Null            bool
+	EmptyStrings    bool
+	EmptyContainers bool
+}
+
+// Prune removes the object entries whose value is null, an empty string or
+// an empty object or array, as selected. Objects and arrays that become empty
+// once their own entries are removed count as empty too. Array elements are
+// kept, since removing them would shift the position of the others, and the
+// document itself is never removed. The kept keys stay in the order order
+// recorded for their object.
+func Prune(v interface{}, opts PruneOptions, order *formatter.KeyOrder) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		keys := make([]string, 0, len(val))
+		for _, k := range order.Keys(val) {
+			child := Prune(val[k], opts, order)
+			if !opts.removes(child) {
+				out[k] = child
+				keys = append(keys, k)
+			}
+		}
+		order.Record(out, keys)
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = Prune(child, opts, order)
+		}
+		return out
+	}
+	return v
+}
+
+// removes reports whether an object entry with value v is dropped
+func (opts PruneOptions) removes(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return opts.Null
+	case string:
+		return opts.EmptyStrings && val == ""
+	case map[string]interface{}:
+		return opts.EmptyContainers && len(val) == 0
+	case []interface{}:
+		return opts.EmptyContainers && len(val) == 0
+	}
+	return false
+}
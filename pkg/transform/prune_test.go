@@ -0,0 +1,72 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	input := `{"a":null,"b":"","c":{},"d":[],"e":{"f":null,"g":""},"h":[null,"",{}],"i":0,"j":false}`
+
+	tests := []struct {
+		name string
+		opts PruneOptions
+		want string
+	}{
+		{
+			name: "Null",
+			opts: PruneOptions{Null: true},
+			want: `{"b":"","c":{},"d":[],"e":{"g":""},"h":[null,"",{}],"i":0,"j":false}`,
+		},
+		{
+			name: "Empty strings",
+			opts: PruneOptions{EmptyStrings: true},
+			want: `{"a":null,"c":{},"d":[],"e":{"f":null},"h":[null,"",{}],"i":0,"j":false}`,
+		},
+		{
+			name: "Empty containers",
+			opts: PruneOptions{EmptyContainers: true},
+			want: `{"a":null,"b":"","e":{"f":null,"g":""},"h":[null,"",{}],"i":0,"j":false}`,
+		},
+		{
+			name: "Everything",
+			opts: PruneOptions{Null: true, EmptyStrings: true, EmptyContainers: true},
+			want: `{"h":[null,"",{}],"i":0,"j":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(input), &v); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(Prune(v, tt.opts, nil))
+			if string(got) != tt.want {
+				t.Errorf("Prune() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneKeepsKeyOrder(t *testing.T) {
+	input := `{"zeta":1,"Alpha_b":{"y":1,"x":null,"w":""},"mid":"","b":[]}`
+	tests := []struct {
+		name string
+		opts PruneOptions
+		want string
+	}{
+		{"Null", PruneOptions{Null: true}, `{"zeta":1,"Alpha_b":{"y":1,"w":""},"mid":"","b":[]}`},
+		{"Empty strings", PruneOptions{EmptyStrings: true}, `{"zeta":1,"Alpha_b":{"y":1,"x":null},"b":[]}`},
+		{"Empty containers", PruneOptions{EmptyContainers: true}, `{"zeta":1,"Alpha_b":{"y":1,"x":null,"w":""},"mid":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, order := decodeOrdered(t, input)
+			got := formatOrdered(t, Prune(v, tt.opts, order), order)
+			if got != tt.want {
+				t.Errorf("Prune() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
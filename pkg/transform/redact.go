@@ -0,0 +1,43 @@
+package transform
+
+import (
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Redacted is the placeholder written in place of redacted values
+const Redacted = "***"
+
+// Redact replaces every value whose path matches one of the patterns with Redacted.
+// Matching containers are replaced as a whole, so nothing below them is printed.
+func Redact(v interface{}, patterns []*jsonpath.Pattern) interface{} {
+	if len(patterns) == 0 {
+		return v
+	}
+	return redact(nil, v, patterns)
+}
+
+// redact walks v, located at path, replacing matching entries
+func redact(path []jsonpath.Segment, v interface{}, patterns []*jsonpath.Pattern) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			childPath := append(path, jsonpath.Key(k))
+			if jsonpath.MatchAny(patterns, childPath) {
+				val[k] = Redacted
+				continue
+			}
+			val[k] = redact(childPath, child, patterns)
+		}
+	case []interface{}:
+		for i, child := range val {
+			childPath := append(path, jsonpath.Index(i))
+			if jsonpath.MatchAny(patterns, childPath) {
+				val[i] = Redacted
+				continue
+			}
+			val[i] = redact(childPath, child, patterns)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		patterns []string
+		want     string
+	}{
+		{
+			name:     "No patterns",
+			input:    `{"password":"hunter2"}`,
+			patterns: nil,
+			want:     `{"password":"hunter2"}`,
+		},
+		{
+			name:     "Key at any depth",
+			input:    `{"user":{"name":"ann","password":"hunter2"},"accounts":[{"password":"x"}]}`,
+			patterns: []string{"password"},
+			want:     `{"accounts":[{"password":"***"}],"user":{"name":"ann","password":"***"}}`,
+		},
+		{
+			name:     "Wildcard key",
+			input:    `{"client_secret":"a","secretary":"b","apiSecretKey":"c"}`,
+			patterns: []string{"*secret*"},
+			want:     `{"apiSecretKey":"c","client_secret":"***","secretary":"***"}`,
+		},
+		{
+			name:     "Anchored path replaces containers",
+			input:    `{"auth":{"token":{"value":"t","expires":1}},"token":"keep"}`,
+			patterns: []string{"auth.token"},
+			want:     `{"auth":{"token":"***"},"token":"keep"}`,
+		},
+		{
+			name:     "Array elements",
+			input:    `{"keys":["a","b"]}`,
+			patterns: []string{"keys[*]"},
+			want:     `{"keys":["***","***"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := jsonpath.CompileAll(tt.patterns)
+			if err != nil {
+				t.Fatalf("CompileAll() error = %v", err)
+			}
+
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+
+			got, _ := json.Marshal(Redact(v, patterns))
+			if string(got) != tt.want {
+				t.Errorf("Redact() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
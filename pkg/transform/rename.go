@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/jsonpath"
+)
+
+// Rename renames the object keys whose path matches Pattern to To
+type Rename struct {
+	Pattern *jsonpath.Pattern
+	To      string
+}
+
+// ParseRename parses a rename written as "path=newName", such as
+// "user.name=fullName" or "*_id=id". The path is a pattern as accepted by
+// jsonpath.Compile, so a bare key is renamed wherever it appears.
+func ParseRename(spec string) (Rename, error) {
+	i := strings.LastIndex(spec, "=")
+	if i < 0 {
+		return Rename{}, fmt.Errorf("expected path=newName, got %q", spec)
+	}
+	expr, to := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+	if to == "" {
+		return Rename{}, fmt.Errorf("missing the new name in %q", spec)
+	}
+	pattern, err := jsonpath.Compile(expr)
+	if err != nil {
+		return Rename{}, err
+	}
+	return Rename{Pattern: pattern, To: to}, nil
+}
+
+// RenamesFromMap builds the renames of a mapping from paths to new key names.
+// They are ordered by path, so overlapping patterns behave the same on every
+// run.
+func RenamesFromMap(mapping map[string]string) ([]Rename, error) {
+	exprs := make([]string, 0, len(mapping))
+	for expr := range mapping {
+		exprs = append(exprs, expr)
+	}
+	sort.Strings(exprs)
+
+	renames := make([]Rename, 0, len(exprs))
+	for _, expr := range exprs {
+		pattern, err := jsonpath.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		renames = append(renames, Rename{Pattern: pattern, To: mapping[expr]})
+	}
+	return renames, nil
+}
+
+// RenameKeys applies the renames throughout the document. The first rename
+// matching a key wins. A rename onto a key that already exists in the same
+// object is an error, since one of the values would be lost. A renamed key
+// keeps the position its old name had in order.
+func RenameKeys(v interface{}, renames []Rename, order *formatter.KeyOrder) (interface{}, error) {
+	if len(renames) == 0 {
+		return v, nil
+	}
+	return renameKeys(nil, v, renames, order)
+}
+
+// renameKeys walks v, located at path, renaming matching keys
+func renameKeys(path []jsonpath.Segment, v interface{}, renames []Rename, order *formatter.KeyOrder) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		// Keys are visited in document order, which also reports a collision
+		// the same way on every run
+		keys := order.Keys(val)
+		out := make(map[string]interface{}, len(val))
+		names := make([]string, 0, len(keys))
+		from := make(map[string]string, len(val))
+		for _, k := range keys {
+			child := val[k]
+			childPath := append(path, jsonpath.Key(k))
+			renamed, err := renameKeys(childPath, child, renames, order)
+			if err != nil {
+				return nil, err
+			}
+
+			name := k
+			for _, r := range renames {
+				if r.Pattern.Match(childPath) {
+					name = r.To
+					break
+				}
+			}
+			if prev, ok := from[name]; ok {
+				return nil, fmt.Errorf("renaming %s and %s would both produce key %q",
+					jsonpath.String(append(path, jsonpath.Key(prev))), jsonpath.String(childPath), name)
+			}
+			from[name] = k
+			out[name] = renamed
+			names = append(names, name)
+		}
+		order.Record(out, names)
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			renamed, err := renameKeys(append(path, jsonpath.Index(i)), child, renames, order)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = renamed
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
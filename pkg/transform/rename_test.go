@@ -0,0 +1,75 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRename(t *testing.T) {
+	r, err := ParseRename("user.name=fullName")
+	if err != nil {
+		t.Fatalf("ParseRename() error = %v", err)
+	}
+	if r.Pattern.String() != "user.name" || r.To != "fullName" {
+		t.Errorf("ParseRename() = %s=%s, want user.name=fullName", r.Pattern, r.To)
+	}
+
+	for _, spec := range []string{"user.name", "user.name=", "=x", "items[=x"} {
+		if _, err := ParseRename(spec); err == nil {
+			t.Errorf("ParseRename(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	tests := []struct {
+		doc   string
+		specs []string
+		want  string
+	}{
+		{`{"user": {"name": "ann", "id": 1}}`, []string{"user.name=fullName"}, `{"user": {"fullName": "ann", "id": 1}}`},
+		{`{"id": 1, "items": [{"id": 2}, {"id": 3}]}`, []string{"id=ID"}, `{"ID": 1, "items": [{"ID": 2}, {"ID": 3}]}`},
+		{`{"items": [{"id": 2}], "id": 1}`, []string{"items[*].id=sku"}, `{"items": [{"sku": 2}], "id": 1}`},
+		{`{"user_id": 1, "order_id": 2}`, []string{"user_id=user", "*_id=ref"}, `{"user": 1, "ref": 2}`},
+	}
+
+	for _, tt := range tests {
+		var renames []Rename
+		for _, spec := range tt.specs {
+			r, err := ParseRename(spec)
+			if err != nil {
+				t.Fatalf("ParseRename(%q) error = %v", spec, err)
+			}
+			renames = append(renames, r)
+		}
+		got, err := RenameKeys(decodeJSON(t, tt.doc), renames, nil)
+		if err != nil {
+			t.Errorf("RenameKeys(%s, %v) error = %v", tt.doc, tt.specs, err)
+			continue
+		}
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("RenameKeys(%s, %v) = %v, want %s", tt.doc, tt.specs, got, tt.want)
+		}
+	}
+
+	r, _ := ParseRename("*_id=id")
+	if _, err := RenameKeys(decodeJSON(t, `{"a_id": 1, "b_id": 2}`), []Rename{r}, nil); err == nil {
+		t.Error("RenameKeys() expected an error when two keys become the same key")
+	}
+}
+
+func TestRenameKeysKeepsKeyOrder(t *testing.T) {
+	r, err := ParseRename("name=fullName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, order := decodeOrdered(t, `{"zip":1,"name":"a","users":[{"name":"b","age":2}]}`)
+	got, err := RenameKeys(v, []Rename{r}, order)
+	if err != nil {
+		t.Fatalf("RenameKeys() error = %v", err)
+	}
+	want := `{"zip":1,"fullName":"a","users":[{"fullName":"b","age":2}]}`
+	if s := formatOrdered(t, got, order); s != want {
+		t.Errorf("RenameKeys() = %s, want %s", s, want)
+	}
+}
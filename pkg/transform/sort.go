@@ -0,0 +1,115 @@
+package transform
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// SortArraysBy orders every array of objects in the document by the value of
+// the given field. Elements without the field, and elements that are not
+// objects, keep their relative order after the others.
+func SortArraysBy(v interface{}, field string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = SortArraysBy(child, field)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = SortArraysBy(child, field)
+		}
+		sort.SliceStable(val, func(i, j int) bool {
+			a, aok := fieldOf(val[i], field)
+			b, bok := fieldOf(val[j], field)
+			if !aok || !bok {
+				return aok && !bok
+			}
+			return Compare(a, b) < 0
+		})
+	}
+
+	return v
+}
+
+// fieldOf returns a field of v, if v is an object that has it
+func fieldOf(v interface{}, field string) (interface{}, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	f, ok := obj[field]
+	return f, ok
+}
+
+// Compare orders two decoded JSON values: null, then booleans (false first),
+// numbers, strings, arrays and objects. Numbers and strings compare by value;
+// arrays and objects of the same kind compare equal.
+func Compare(a, b interface{}) int {
+	ra, rb := kindRank(a), kindRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch x := a.(type) {
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case float64, json.Number:
+		return compareNumbers(x, b)
+	case string:
+		y := b.(string)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+// kindRank gives the position of a value's type in the order used by Compare
+func kindRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64, json.Number:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	case map[string]interface{}:
+		return 5
+	}
+	return 6
+}
+
+// compareNumbers orders two numbers exactly, including json.Number values
+// too large or too precise for float64
+func compareNumbers(a, b interface{}) int {
+	return bigNumber(a).Cmp(bigNumber(b))
+}
+
+// bigNumber converts a decoded number to a big.Float
+func bigNumber(v interface{}) *big.Float {
+	switch n := v.(type) {
+	case float64:
+		return big.NewFloat(n)
+	case json.Number:
+		if f, _, err := big.ParseFloat(string(n), 10, 256, big.ToNearestEven); err == nil {
+			return f
+		}
+	}
+	return new(big.Float)
+}
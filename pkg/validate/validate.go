@@ -0,0 +1,242 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/schema"
+)
+
+// Diagnostic codes reported by Validate
+const (
+	CodeSyntax       = "syntax"
+	CodeDuplicateKey = "duplicate-key"
+	CodeIJSON        = "ijson"
+	CodeSchema       = "schema"
+)
+
+// Options selects the checks made beyond syntax
+type Options struct {
+	// DuplicateKeys is the strategy used when formatting; repeated keys are
+	// errors under formatter.DuplicateError and warnings otherwise
+	DuplicateKeys formatter.DuplicateKeys
+	// StrictIJSON reports I-JSON (RFC 7493) violations as errors
+	StrictIJSON bool
+	// RejectScalars also requires a top-level object or array in strict mode
+	RejectScalars bool
+	// Schema, when set, reports every way the document fails a JSON Schema
+	// as an error
+	Schema *schema.Validator
+}
+
+// Validate checks a document and returns its diagnostics in document order.
+// A syntax error stops the check, since nothing after it can be trusted.
+func Validate(file string, data []byte, opts Options) []diag.Diagnostic {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		offset := len(data)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = int(syntaxErr.Offset)
+			// The offset is just past the offending byte
+			if offset > 0 && offset <= len(data) && syntaxErr.Error() != "unexpected end of JSON input" {
+				offset--
+			}
+		}
+		message := strings.TrimPrefix(err.Error(), "json: ")
+		return []diag.Diagnostic{diag.New(file, data, offset, diag.SeverityError, CodeSyntax, message)}
+	}
+
+	severity := diag.SeverityWarning
+	if opts.DuplicateKeys == formatter.DuplicateError || opts.StrictIJSON {
+		severity = diag.SeverityError
+	}
+
+	var diagnostics []diag.Diagnostic
+	for _, dup := range duplicateKeys(data) {
+		diagnostics = append(diagnostics, diag.New(file, data, dup.offset, severity, CodeDuplicateKey,
+			fmt.Sprintf("duplicate key %q", dup.key)))
+	}
+
+	// Repeated keys were reported above; the I-JSON check would stop at the first one
+	if opts.StrictIJSON && len(diagnostics) == 0 {
+		if err := formatter.ValidateIJSON(data, opts.RejectScalars); err != nil {
+			offset := 0
+			message := err.Error()
+			var ijsonErr *formatter.IJSONError
+			if errors.As(err, &ijsonErr) {
+				offset, message = ijsonErr.Offset, ijsonErr.Reason
+			}
+			diagnostics = append(diagnostics, diag.New(file, data, offset, diag.SeverityError, CodeIJSON, message))
+		}
+	}
+
+	if opts.Schema != nil {
+		diagnostics = append(diagnostics, schemaDiagnostics(file, data, v, opts.Schema)...)
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			return diagnostics[i].Offset < diagnostics[j].Offset
+		})
+	}
+
+	return diagnostics
+}
+
+// schemaDiagnostics checks a document against a schema, locating each
+// violation at the value that fails
+func schemaDiagnostics(file string, data []byte, v interface{}, s *schema.Validator) []diag.Diagnostic {
+	violations, err := s.Validate(v)
+	if err != nil {
+		return []diag.Diagnostic{diag.New(file, data, 0, diag.SeverityError, CodeSchema, "invalid schema: "+err.Error())}
+	}
+
+	want := make(map[string]bool, len(violations))
+	for _, violation := range violations {
+		want[violation.InstancePath] = true
+	}
+	offsets := valueOffsets(data, want)
+
+	diagnostics := make([]diag.Diagnostic, 0, len(violations))
+	for _, violation := range violations {
+		d := diag.New(file, data, offsets[violation.InstancePath], diag.SeverityError, CodeSchema, violation.Message)
+		d.InstancePath, d.SchemaPath = violation.InstancePath, violation.SchemaPath
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// valueOffsets finds where the values at the wanted JSON Pointers start in a
+// syntactically valid document
+func valueOffsets(data []byte, want map[string]bool) map[string]int {
+	l := &locator{dec: json.NewDecoder(bytes.NewReader(data)), data: data, want: want, found: map[string]int{}}
+	_ = l.value("")
+	return l.found
+}
+
+// locator walks the token stream, tracking the JSON Pointer of each value
+type locator struct {
+	dec   *json.Decoder
+	data  []byte
+	want  map[string]bool
+	found map[string]int
+}
+
+// value reads one complete value whose pointer is ptr
+func (l *locator) value(ptr string) error {
+	if l.want[ptr] {
+		l.found[ptr] = skipSeparators(l.data, int(l.dec.InputOffset()))
+	}
+	tok, err := l.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for l.dec.More() {
+			tok, err := l.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := strings.ReplaceAll(strings.ReplaceAll(tok.(string), "~", "~0"), "/", "~1")
+			if err := l.value(ptr + "/" + key); err != nil {
+				return err
+			}
+		}
+		_, err = l.dec.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; l.dec.More(); i++ {
+			if err := l.value(fmt.Sprintf("%s/%d", ptr, i)); err != nil {
+				return err
+			}
+		}
+		_, err = l.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// duplicate is a key repeated within one object
+type duplicate struct {
+	key    string
+	offset int
+}
+
+// duplicateKeys finds every repeated key of a syntactically valid document,
+// located at the opening quote of the repetition
+func duplicateKeys(data []byte) []duplicate {
+	f := &dupFinder{dec: json.NewDecoder(bytes.NewReader(data)), data: data}
+	_ = f.value()
+	return f.dups
+}
+
+// dupFinder walks the token stream, remembering the keys of each object
+type dupFinder struct {
+	dec  *json.Decoder
+	data []byte
+	dups []duplicate
+}
+
+// value reads one complete value
+func (f *dupFinder) value() error {
+	tok, err := f.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return f.object()
+	case json.Delim('['):
+		for f.dec.More() {
+			if err := f.value(); err != nil {
+				return err
+			}
+		}
+		_, err = f.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// object reads the members of an object whose opening brace was consumed
+func (f *dupFinder) object() error {
+	seen := make(map[string]bool)
+	for f.dec.More() {
+		before := int(f.dec.InputOffset())
+		tok, err := f.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key := tok.(string)
+		if seen[key] {
+			f.dups = append(f.dups, duplicate{key: key, offset: skipSeparators(f.data, before)})
+		}
+		seen[key] = true
+
+		if err := f.value(); err != nil {
+			return err
+		}
+	}
+
+	_, err := f.dec.Token()
+	return err
+}
+
+// skipSeparators returns the offset of the first byte at or after offset
+// that is neither whitespace, a comma nor a colon. The decoder consumes the
+// separator before a token together with the token, so the offset it
+// reports before reading one still points at the separator.
+func skipSeparators(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
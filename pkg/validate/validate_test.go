@@ -0,0 +1,128 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nicolasalberti00/fj/pkg/diag"
+	"github.com/nicolasalberti00/fj/pkg/formatter"
+	"github.com/nicolasalberti00/fj/pkg/schema"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  []diag.Diagnostic
+	}{
+		{
+			name:  "Valid",
+			input: `{"a": [1, 2]}`,
+		},
+		{
+			name:  "Syntax error",
+			input: "{\n  \"a\": 1,\n  \"b\" 2\n}",
+			want: []diag.Diagnostic{
+				{File: "f.json", Line: 3, Column: 7, Offset: 18, Code: CodeSyntax, Message: "invalid character '2' after object key", Severity: diag.SeverityError},
+			},
+		},
+		{
+			name:  "Truncated",
+			input: `{"a": [1`,
+			want: []diag.Diagnostic{
+				{File: "f.json", Line: 1, Column: 9, Offset: 8, Code: CodeSyntax, Message: "unexpected end of JSON input", Severity: diag.SeverityError},
+			},
+		},
+		{
+			name:  "Duplicate keys are warnings",
+			input: "{\"a\": 1, \"b\": {\"c\": 1,\n \"c\": 2}, \"a\": 3}",
+			want: []diag.Diagnostic{
+				{File: "f.json", Line: 2, Column: 2, Offset: 24, Code: CodeDuplicateKey, Message: `duplicate key "c"`, Severity: diag.SeverityWarning},
+				{File: "f.json", Line: 2, Column: 11, Offset: 33, Code: CodeDuplicateKey, Message: `duplicate key "a"`, Severity: diag.SeverityWarning},
+			},
+		},
+		{
+			name:  "Duplicate keys are errors under the error strategy",
+			input: `[{"a": 1}, {"a": 1, "a": 2}]`,
+			opts:  Options{DuplicateKeys: formatter.DuplicateError},
+			want: []diag.Diagnostic{
+				{File: "f.json", Line: 1, Column: 21, Offset: 20, Code: CodeDuplicateKey, Message: `duplicate key "a"`, Severity: diag.SeverityError},
+			},
+		},
+		{
+			name:  "I-JSON violation",
+			input: `{"n": 9007199254740993}`,
+			opts:  Options{StrictIJSON: true},
+			want: []diag.Diagnostic{
+				{File: "f.json", Line: 1, Column: 7, Offset: 6, Code: CodeIJSON, Message: "integer 9007199254740993 is outside the exact IEEE 754 range of ±(2^53-1)", Severity: diag.SeverityError},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate("f.json", []byte(tt.input), tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(`{"required": ["id"], "properties": {"tags": {"items": {"type": "string"}}}}`), &root); err != nil {
+		t.Fatal(err)
+	}
+	v, err := schema.NewValidator(root, schema.ValidatorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "{\n  \"tags\": [\"a\",\n    2],\n  \"tags\": []\n}"
+	got := Validate("f.json", []byte(input), Options{Schema: v})
+	want := []diag.Diagnostic{
+		{File: "f.json", Line: 1, Column: 1, Offset: 0, Code: CodeSchema, Message: `missing required property "id"`,
+			Severity: diag.SeverityError, SchemaPath: "#/required"},
+		{File: "f.json", Line: 4, Column: 3, Offset: 28, Code: CodeDuplicateKey, Message: `duplicate key "tags"`,
+			Severity: diag.SeverityWarning},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() =\n%v\nwant\n%v", got, want)
+	}
+
+	input = "{\"id\": 1, \"tags\": [\"a\",\n    2]}"
+	got = Validate("f.json", []byte(input), Options{Schema: v})
+	want = []diag.Diagnostic{
+		{File: "f.json", Line: 2, Column: 5, Offset: 28, Code: CodeSchema, Message: "expected string, got integer",
+			Severity: diag.SeverityError, InstancePath: "/tags/1", SchemaPath: "#/properties/tags/items/type"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() =\n%v\nwant\n%v", got, want)
+	}
+	if s := got[0].String(); s != "f.json:2:5: error: /tags/1: expected string, got integer (#/properties/tags/items/type)" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestValidateLocatesSyntaxErrorsLikeFormat(t *testing.T) {
+	for _, input := range []string{`[1,2,]`, `{"a":1,}`, "{\n  \"a\": 1,\n}", `{"a" 1}`, `[1 2]`} {
+		diagnostics := Validate("f.json", []byte(input), Options{})
+		if len(diagnostics) != 1 {
+			t.Fatalf("Validate(%q) = %v, want one syntax error", input, diagnostics)
+		}
+		_, err := formatter.Decode([]byte(input), formatter.Options{})
+		var syntaxErr *formatter.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("Decode(%q) error = %v, want a SyntaxError", input, err)
+		}
+		got, want := diagnostics[0], syntaxErr
+		if got.Line != want.Line || got.Column != want.Column || got.Message != want.Msg {
+			t.Errorf("Validate(%q) = %d:%d %s, format reports %d:%d %s",
+				input, got.Line, got.Column, got.Message, want.Line, want.Column, want.Msg)
+		}
+	}
+}